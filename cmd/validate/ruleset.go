@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 
@@ -68,7 +69,8 @@ func validateRuleSet(cmd *cobra.Command, args []string) error {
 
 	defer close(queue)
 
-	provider, err := filesystem.NewProvider(conf, rules.NewRuleSetProcessor(queue, rFactory, logger), logger)
+	provider, err := filesystem.NewProvider(conf,
+		rules.NewRuleSetProcessor(queue, rFactory, conf, logger), prometheus.NewRegistry(), logger)
 	if err != nil {
 		return err
 	}
@@ -23,8 +23,9 @@ import (
 type ctxKey struct{}
 
 type accessContext struct {
-	err     error
-	subject string
+	err      error
+	subject  string
+	upstream string
 }
 
 func New(ctx context.Context) context.Context {
@@ -58,3 +59,17 @@ func SetSubject(ctx context.Context, subject string) {
 		c.subject = subject
 	}
 }
+
+func Upstream(ctx context.Context) string {
+	if c, ok := ctx.Value(ctxKey{}).(*accessContext); ok {
+		return c.upstream
+	}
+
+	return ""
+}
+
+func SetUpstream(ctx context.Context, upstream string) {
+	if c, ok := ctx.Value(ctxKey{}).(*accessContext); ok {
+		c.upstream = upstream
+	}
+}
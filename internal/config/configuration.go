@@ -45,8 +45,8 @@ func NewConfiguration(envPrefix EnvVarPrefix, configFile ConfigurationPath) (*Co
 		parser.WithDecodeHookFunc(mapstructure.StringToSliceHookFunc(",")),
 		parser.WithDecodeHookFunc(logLevelDecodeHookFunc),
 		parser.WithDecodeHookFunc(logFormatDecodeHookFunc),
-		parser.WithDecodeHookFunc(decodeTLSCipherSuiteHookFunc),
-		parser.WithDecodeHookFunc(decodeTLSMinVersionHookFunc),
+		parser.WithDecodeHookFunc(DecodeTLSCipherSuiteHookFunc),
+		parser.WithDecodeHookFunc(DecodeTLSMinVersionHookFunc),
 		parser.WithEnvPrefix(string(envPrefix)),
 		parser.WithDefaultConfigFilename("heimdall.yaml"),
 		parser.WithConfigFile(string(configFile)),
@@ -1,6 +1,6 @@
 package config
 
 type KeyStore struct {
-	Path     string `koanf:"path"`
-	Password string `koanf:"password"`
+	Path     string `koanf:"path"     mapstructure:"path"`
+	Password string `koanf:"password" mapstructure:"password"`
 }
@@ -70,8 +70,10 @@ func logFormatDecodeHookFunc(from reflect.Type, to reflect.Type, val any) (any,
 	return val, nil
 }
 
+// DecodeTLSCipherSuiteHookFunc decodes TLS cipher suite names into TLSCipherSuites.
+//
 //nolint:cyclop
-func decodeTLSCipherSuiteHookFunc(from reflect.Type, to reflect.Type, data any) (any, error) {
+func DecodeTLSCipherSuiteHookFunc(from reflect.Type, to reflect.Type, data any) (any, error) {
 	var suites TLSCipherSuites
 
 	if from.Kind() != reflect.Slice || to != reflect.TypeOf(TLSCipherSuites{}) {
@@ -111,7 +113,8 @@ func decodeTLSCipherSuiteHookFunc(from reflect.Type, to reflect.Type, data any)
 	return suites, nil
 }
 
-func decodeTLSMinVersionHookFunc(from reflect.Type, to reflect.Type, data any) (any, error) {
+// DecodeTLSMinVersionHookFunc decodes TLS version names into TLSMinVersion.
+func DecodeTLSMinVersionHookFunc(from reflect.Type, to reflect.Type, data any) (any, error) {
 	if from.Kind() != reflect.String || to != reflect.TypeOf(TLSMinVersion(0)) {
 		return data, nil
 	}
@@ -280,7 +280,7 @@ cipher_suites:
 			var typ Type
 
 			dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-				DecodeHook: decodeTLSCipherSuiteHookFunc,
+				DecodeHook: DecodeTLSCipherSuiteHookFunc,
 				Result:     &typ,
 			})
 			require.NoError(t, err)
@@ -345,7 +345,7 @@ func TestDecodeTLSMinVersion(t *testing.T) {
 			var typ Type
 
 			dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-				DecodeHook: decodeTLSMinVersionHookFunc,
+				DecodeHook: DecodeTLSMinVersionHookFunc,
 				Result:     &typ,
 			})
 			require.NoError(t, err)
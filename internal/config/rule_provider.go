@@ -21,4 +21,7 @@ type RuleProviders struct {
 	HTTPEndpoint map[string]any `koanf:"http_endpoint,omitempty"`
 	CloudBlob    map[string]any `koanf:"cloud_blob,omitempty"`
 	Kubernetes   map[string]any `koanf:"kubernetes,omitempty"`
+	Git          map[string]any `koanf:"git,omitempty"`
+	OCI          map[string]any `koanf:"oci,omitempty"`
+	InMemory     map[string]any `koanf:"in_memory,omitempty"`
 }
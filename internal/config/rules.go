@@ -17,7 +17,8 @@
 package config
 
 type Rules struct {
-	Prototypes *MechanismPrototypes `koanf:"mechanisms,omitempty"`
-	Default    *DefaultRule         `koanf:"default,omitempty"`
-	Providers  RuleProviders        `koanf:"providers,omitempty"`
+	Prototypes    *MechanismPrototypes `koanf:"mechanisms,omitempty"`
+	Default       *DefaultRule         `koanf:"default,omitempty"`
+	Providers     RuleProviders        `koanf:"providers,omitempty"`
+	FailOnOverlap bool                 `koanf:"fail_on_overlap,omitempty"`
 }
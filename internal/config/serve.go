@@ -65,10 +65,11 @@ func (v TLSMinVersion) OrDefault() uint16 {
 }
 
 type TLS struct {
-	KeyStore     KeyStore        `koanf:"key_store"`
-	KeyID        string          `koanf:"key_id"`
-	CipherSuites TLSCipherSuites `koanf:"cipher_suites"`
-	MinVersion   TLSMinVersion   `koanf:"min_version"`
+	KeyStore                 KeyStore        `koanf:"key_store"                  mapstructure:"key_store"`
+	KeyID                    string          `koanf:"key_id"                     mapstructure:"key_id"`
+	CipherSuites             TLSCipherSuites `koanf:"cipher_suites"              mapstructure:"cipher_suites"`
+	MinVersion               TLSMinVersion   `koanf:"min_version"                mapstructure:"min_version"`
+	RequestClientCertificate bool            `koanf:"request_client_certificate" mapstructure:"request_client_certificate"`
 }
 
 type ServiceConfig struct {
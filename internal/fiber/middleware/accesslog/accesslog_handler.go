@@ -89,6 +89,10 @@ func logAccessStatus(ctx context.Context, event *zerolog.Event, err error) *zero
 		event.Str("_subject", subject).Bool("_access_granted", true)
 	}
 
+	if upstream := accesscontext.Upstream(ctx); len(upstream) != 0 {
+		event.Str("_upstream", upstream)
+	}
+
 	return event
 }
 
@@ -225,6 +225,32 @@ func TestLoggerHandler(t *testing.T) {
 				assert.Equal(t, "TX finished", logEvent2["message"])
 			},
 		},
+		{
+			uc:        "without tracing and x-* header, but with subject and upstream set on context",
+			setHeader: func(t *testing.T, req *http.Request) { t.Helper() },
+			configureHandler: func(t *testing.T, ctx *fiber.Ctx) error {
+				t.Helper()
+
+				accesscontext.SetSubject(ctx.UserContext(), "bar")
+				accesscontext.SetUpstream(ctx.UserContext(), "http://foo.local")
+
+				return nil
+			},
+			assert: func(t *testing.T, logEvent1, logEvent2 map[string]any) {
+				t.Helper()
+
+				require.Len(t, logEvent1, 11)
+				assert.Equal(t, "TX started", logEvent1["message"])
+				assert.NotContains(t, logEvent1, "_upstream")
+
+				require.Len(t, logEvent2, 17)
+				assert.Equal(t, float64(200), logEvent2["_http_status_code"])
+				assert.Equal(t, true, logEvent2["_access_granted"])
+				assert.Equal(t, "bar", logEvent2["_subject"])
+				assert.Equal(t, "http://foo.local", logEvent2["_upstream"])
+				assert.Equal(t, "TX finished", logEvent2["message"])
+			},
+		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -73,7 +73,9 @@ func (h *Handler) decisions(c *fiber.Ctx) error {
 	reqURL := fiberxforwarded.RequestURL(c.UserContext())
 	method := fiberxforwarded.RequestMethod(c.UserContext())
 
-	rul, err := h.r.FindRule(reqURL)
+	reqCtx := requestcontext.New(c, method, reqURL, h.s)
+
+	rul, err := h.r.FindRule(reqCtx.Request())
 	if err != nil {
 		return err
 	}
@@ -83,8 +85,6 @@ func (h *Handler) decisions(c *fiber.Ctx) error {
 			"rule doesn't match %s method", method)
 	}
 
-	reqCtx := requestcontext.New(c, method, reqURL, h.s)
-
 	_, err = rul.Execute(reqCtx)
 	if err != nil {
 		return err
@@ -20,7 +20,6 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -176,10 +175,10 @@ func TestHandleDecisionEndpointRequest(t *testing.T) {
 					ctx.AddCookieForUpstream("X-Bar-Foo", "zab")
 
 					return true
-				})).Return(&url.URL{Scheme: "http", Host: "heimdall.test.local", Path: "/foobar"}, nil)
+				})).Return(nil, nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.Scheme == "http" && reqURL.Host == "heimdall.test.local" && reqURL.Path == "/foobar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.Scheme == "http" && req.URL.Host == "heimdall.test.local" && req.URL.Path == "/foobar"
 				})).Return(rule, nil)
 			},
 			assertResponse: func(t *testing.T, err error, response *http.Response) {
@@ -228,10 +227,10 @@ func TestHandleDecisionEndpointRequest(t *testing.T) {
 					ctx.AddCookieForUpstream("X-Bar-Foo", "zab")
 
 					return true
-				})).Return(&url.URL{Scheme: "https", Host: "test.com", Path: "/bar"}, nil)
+				})).Return(nil, nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.Scheme == "http" && reqURL.Host == "heimdall.test.local" && reqURL.Path == "/foobar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.Scheme == "http" && req.URL.Host == "heimdall.test.local" && req.URL.Path == "/foobar"
 				})).Return(rule, nil)
 			},
 			assertResponse: func(t *testing.T, err error, response *http.Response) {
@@ -281,10 +280,10 @@ func TestHandleDecisionEndpointRequest(t *testing.T) {
 					ctx.AddCookieForUpstream("X-Bar-Foo", "zab")
 
 					return true
-				})).Return(&url.URL{Scheme: "http", Host: "heimdall.test.local", Path: "/foobar"}, nil)
+				})).Return(nil, nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.Scheme == "http" && reqURL.Host == "heimdall.test.local" && reqURL.Path == "/foobar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.Scheme == "http" && req.URL.Host == "heimdall.test.local" && req.URL.Path == "/foobar"
 				})).Return(rule, nil)
 			},
 			assertResponse: func(t *testing.T, err error, response *http.Response) {
@@ -327,10 +326,10 @@ func TestHandleDecisionEndpointRequest(t *testing.T) {
 
 				rule.EXPECT().MatchesMethod(http.MethodGet).Return(true)
 				rule.EXPECT().Execute(mock.Anything).
-					Return(&url.URL{Scheme: "http", Host: "heimdall.test.local", Path: "/foobar"}, nil)
+					Return(nil, nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.Scheme == "http" && reqURL.Host == "heimdall.test.local" && reqURL.Path == "/foobar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.Scheme == "http" && req.URL.Host == "heimdall.test.local" && req.URL.Path == "/foobar"
 				})).Return(rule, nil)
 			},
 			assertResponse: func(t *testing.T, err error, response *http.Response) {
@@ -361,10 +360,10 @@ func TestHandleDecisionEndpointRequest(t *testing.T) {
 
 				rule.EXPECT().MatchesMethod(http.MethodPost).Return(true)
 				rule.EXPECT().Execute(mock.Anything).
-					Return(&url.URL{Scheme: "http", Host: "test.com", Path: "/foobar"}, nil)
+					Return(nil, nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.Scheme == "http" && reqURL.Host == "test.com" && reqURL.Path == "/foobar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.Scheme == "http" && req.URL.Host == "test.com" && req.URL.Path == "/foobar"
 				})).Return(rule, nil)
 			},
 			assertResponse: func(t *testing.T, err error, response *http.Response) {
@@ -395,10 +394,10 @@ func TestHandleDecisionEndpointRequest(t *testing.T) {
 
 				rule.EXPECT().MatchesMethod(http.MethodPost).Return(true)
 				rule.EXPECT().Execute(mock.Anything).
-					Return(&url.URL{Scheme: "http", Host: "heimdall.test.local", Path: "/bar"}, nil)
+					Return(nil, nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.Scheme == "http" && reqURL.Host == "heimdall.test.local" && reqURL.Path == "bar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.Scheme == "http" && req.URL.Host == "heimdall.test.local" && req.URL.Path == "bar"
 				})).Return(rule, nil)
 			},
 			assertResponse: func(t *testing.T, err error, response *http.Response) {
@@ -429,10 +428,10 @@ func TestHandleDecisionEndpointRequest(t *testing.T) {
 
 				rule.EXPECT().MatchesMethod(http.MethodPost).Return(true)
 				rule.EXPECT().Execute(mock.Anything).
-					Return(&url.URL{Scheme: "https", Host: "heimdall.test.local", Path: "/foobar"}, nil)
+					Return(nil, nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.Scheme == "https" && reqURL.Host == "heimdall.test.local" && reqURL.Path == "/foobar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.Scheme == "https" && req.URL.Host == "heimdall.test.local" && req.URL.Path == "/foobar"
 				})).Return(rule, nil)
 			},
 			assertResponse: func(t *testing.T, err error, response *http.Response) {
@@ -466,10 +465,10 @@ func TestHandleDecisionEndpointRequest(t *testing.T) {
 
 				rule.EXPECT().MatchesMethod(http.MethodPatch).Return(true)
 				rule.EXPECT().Execute(mock.Anything).
-					Return(&url.URL{Scheme: "https", Host: "test.com", Path: "/bar"}, nil)
+					Return(nil, nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.Scheme == "https" && reqURL.Host == "test.com" && reqURL.Path == "bar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.Scheme == "https" && req.URL.Host == "test.com" && req.URL.Path == "bar"
 				})).Return(rule, nil)
 			},
 			assertResponse: func(t *testing.T, err error, response *http.Response) {
@@ -39,7 +39,7 @@ func (h *Handler) Check(ctx context.Context, creq *envoy_auth.CheckRequest) (*en
 	reqCtx := NewRequestContext(ctx, creq, h.s)
 	req := reqCtx.Request()
 
-	rul, err := h.r.FindRule(req.URL)
+	rul, err := h.r.FindRule(req)
 	if err != nil {
 		return nil, err
 	}
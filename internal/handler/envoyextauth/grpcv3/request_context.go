@@ -18,7 +18,9 @@ package grpcv3
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -28,8 +30,10 @@ import (
 	"google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/truststore"
 	"github.com/dadrus/heimdall/internal/x"
 )
 
@@ -38,9 +42,11 @@ type RequestContext struct {
 	ips             []string
 	reqMethod       string
 	reqHeaders      map[string]string
-	reqURL          *url.URL
+	reqURL          *heimdall.URL
 	reqBody         string
 	reqRawBody      []byte
+	peerAddress     string
+	clientCerts     []*x509.Certificate
 	upstreamHeaders http.Header
 	upstreamCookies map[string]string
 	jwtSigner       heimdall.JWTSigner
@@ -62,21 +68,58 @@ func NewRequestContext(ctx context.Context, req *envoy_auth.CheckRequest, signer
 		ips:        clientIPs,
 		reqMethod:  req.Attributes.Request.Http.Method,
 		reqHeaders: canonicalizeHeaders(req.Attributes.Request.Http.Headers),
-		reqURL: &url.URL{
-			Scheme:   req.Attributes.Request.Http.Scheme,
-			Host:     req.Attributes.Request.Http.Host,
-			Path:     req.Attributes.Request.Http.Path,
-			RawQuery: req.Attributes.Request.Http.Query,
-			Fragment: req.Attributes.Request.Http.Fragment,
+		reqURL: &heimdall.URL{
+			URL: url.URL{
+				Scheme:   req.Attributes.Request.Http.Scheme,
+				Host:     req.Attributes.Request.Http.Host,
+				Path:     req.Attributes.Request.Http.Path,
+				RawQuery: req.Attributes.Request.Http.Query,
+				Fragment: req.Attributes.Request.Http.Fragment,
+			},
 		},
 		reqBody:         req.Attributes.Request.Http.Body,
 		reqRawBody:      req.Attributes.Request.Http.RawBody,
+		peerAddress:     peerAddress(ctx),
+		clientCerts:     clientCertificates(req.Attributes.Source),
 		jwtSigner:       signer,
 		upstreamHeaders: make(http.Header),
 		upstreamCookies: make(map[string]string),
 	}
 }
 
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+func clientCertificates(source *envoy_auth.AttributeContext_Peer) []*x509.Certificate {
+	// envoy forwards the certificate presented by the downstream client url encoded in PEM format
+	if source == nil || len(source.Certificate) == 0 {
+		return nil
+	}
+
+	pemData, err := url.PathUnescape(source.Certificate)
+	if err != nil {
+		return nil
+	}
+
+	certs, err := truststore.NewTrustStoreFromPEMBytes([]byte(pemData), true)
+	if err != nil {
+		return nil
+	}
+
+	return certs
+}
+
 func canonicalizeHeaders(headers map[string]string) map[string]string {
 	result := make(map[string]string, len(headers))
 
@@ -115,6 +158,8 @@ func (s *RequestContext) Cookie(name string) string {
 }
 
 func (s *RequestContext) Body() []byte                            { return s.reqRawBody }
+func (s *RequestContext) PeerAddress() string                     { return s.peerAddress }
+func (s *RequestContext) ClientCertificates() []*x509.Certificate { return s.clientCerts }
 func (s *RequestContext) AppContext() context.Context             { return s.ctx }
 func (s *RequestContext) SetPipelineError(err error)              { s.err = err }
 func (s *RequestContext) AddHeaderForUpstream(name, value string) { s.upstreamHeaders.Add(name, value) }
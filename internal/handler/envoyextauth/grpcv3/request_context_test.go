@@ -3,9 +3,12 @@ package grpcv3
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
@@ -13,15 +16,24 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/x/pkix/pemx"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
 )
 
 func TestNewRequestContext(t *testing.T) {
 	t.Parallel()
 
 	// GIVEN
+	ca, err := testsupport.NewRootCA("Test Root CA", 24*time.Hour)
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(pemx.WithX509Certificate(ca.Certificate))
+	require.NoError(t, err)
+
 	httpReq := &envoy_auth.AttributeContext_HttpRequest{
 		Method:   http.MethodPatch,
 		Scheme:   "https",
@@ -42,15 +54,21 @@ func TestNewRequestContext(t *testing.T) {
 			Request: &envoy_auth.AttributeContext_Request{
 				Http: httpReq,
 			},
+			Source: &envoy_auth.AttributeContext_Peer{
+				Certificate: url.PathEscape(string(pemBytes)),
+			},
 		},
 	}
 	md := metadata.New(nil)
 	md.Set("x-forwarded-for", "127.0.0.1", "192.168.1.1")
 
 	ctx := NewRequestContext(
-		metadata.NewIncomingContext(
-			context.Background(),
-			md,
+		peer.NewContext(
+			metadata.NewIncomingContext(
+				context.Background(),
+				md,
+			),
+			&peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.10.10.10"), Port: 12345}},
 		),
 		checkReq,
 		mocks.NewJWTSignerMock(t),
@@ -73,6 +91,9 @@ func TestNewRequestContext(t *testing.T) {
 	require.NotNil(t, ctx.AppContext())
 	require.NotNil(t, ctx.Signer())
 	assert.Equal(t, ctx.Request().ClientIP, []string{"127.0.0.1", "192.168.1.1"})
+	assert.Equal(t, "10.10.10.10", ctx.Request().PeerAddress())
+	require.Len(t, ctx.Request().ClientCertificates(), 1)
+	assert.Equal(t, ca.Certificate, ctx.Request().ClientCertificates()[0])
 }
 
 func TestFinalizeRequestContext(t *testing.T) {
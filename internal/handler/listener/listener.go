@@ -83,5 +83,11 @@ func newTLSListener(tlsConf *config.TLS, listener net.Listener) (net.Listener, e
 		cfg.CipherSuites = tlsConf.CipherSuites.OrDefault()
 	}
 
+	if tlsConf.RequestClientCertificate {
+		// the verification of the presented certificate is done by the authenticators
+		// making use of it, as only these know which trust anchors to use
+		cfg.ClientAuth = tls.RequestClientCert
+	}
+
 	return tls.NewListener(listener, cfg), nil
 }
@@ -206,6 +206,25 @@ func TestNewListener(t *testing.T) {
 			assert: func(t *testing.T, err error, ln net.Listener, port string) {
 				t.Helper()
 
+				require.NoError(t, err)
+				require.NotNil(t, ln)
+				assert.Equal(t, "tcp", ln.Addr().Network())
+				assert.Contains(t, ln.Addr().String(), port)
+			},
+		},
+		{
+			uc:      "successful with client certificate requested",
+			network: "tcp",
+			serviceConf: config.ServiceConfig{
+				TLS: &config.TLS{
+					KeyStore:                 config.KeyStore{Path: pemFile.Name()},
+					KeyID:                    "key1",
+					RequestClientCertificate: true,
+				},
+			},
+			assert: func(t *testing.T, err error, ln net.Listener, port string) {
+				t.Helper()
+
 				require.NoError(t, err)
 				require.NotNil(t, ln)
 				assert.Equal(t, "tcp", ln.Addr().Network())
@@ -72,7 +72,9 @@ func (h *Handler) proxy(c *fiber.Ctx) error {
 	reqURL := fiberxforwarded.RequestURL(c.UserContext())
 	method := fiberxforwarded.RequestMethod(c.UserContext())
 
-	rul, err := h.r.FindRule(reqURL)
+	reqCtx := requestcontext.New(c, method, reqURL, h.s)
+
+	rul, err := h.r.FindRule(reqCtx.Request())
 	if err != nil {
 		return err
 	}
@@ -82,14 +84,12 @@ func (h *Handler) proxy(c *fiber.Ctx) error {
 			"rule (id=%s, src=%s) doesn't match %s method", rul.ID(), rul.SrcID(), method)
 	}
 
-	reqCtx := requestcontext.New(c, method, reqURL, h.s)
-
-	upstreamURL, err := rul.Execute(reqCtx)
+	upstream, err := rul.Execute(reqCtx)
 	if err != nil {
 		return err
 	}
 
 	logger.Debug().Msg("Finalizing request")
 
-	return reqCtx.FinalizeAndForward(upstreamURL, h.t)
+	return reqCtx.FinalizeAndForward(upstream, h.t)
 }
@@ -205,7 +205,7 @@ func TestHandleProxyEndpointRequest(t *testing.T) {
 					ctx.SetPipelineError(heimdall.ErrAuthorization)
 
 					return true
-				})).Return(upstreamURL, nil)
+				})).Return(newForwardingBackend(t, upstreamURL), nil)
 
 				repository.EXPECT().FindRule(mock.Anything).Return(rule, nil)
 			},
@@ -249,10 +249,10 @@ func TestHandleProxyEndpointRequest(t *testing.T) {
 					ctx.AddCookieForUpstream("X-Bar-Foo", "zab")
 
 					return true
-				})).Return(upstreamURL, nil)
+				})).Return(newForwardingBackend(t, upstreamURL), nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.String() == "http://heimdall.test.local/foobar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.String() == "http://heimdall.test.local/foobar"
 				})).Return(rule, nil)
 			},
 			instructUpstream: func(t *testing.T) {
@@ -323,10 +323,10 @@ func TestHandleProxyEndpointRequest(t *testing.T) {
 					ctx.AddCookieForUpstream("X-Bar-Foo", "zab")
 
 					return true
-				})).Return(upstreamURL, nil)
+				})).Return(newForwardingBackend(t, upstreamURL), nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.String() == "http://heimdall.test.local/foobar"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.String() == "http://heimdall.test.local/foobar"
 				})).Return(rule, nil)
 			},
 			instructUpstream: func(t *testing.T) {
@@ -396,10 +396,10 @@ func TestHandleProxyEndpointRequest(t *testing.T) {
 					ctx.AddCookieForUpstream("X-Bar-Foo", "zab")
 
 					return true
-				})).Return(upstreamURL, nil)
+				})).Return(newForwardingBackend(t, upstreamURL), nil)
 
-				repository.EXPECT().FindRule(mock.MatchedBy(func(reqURL *url.URL) bool {
-					return reqURL.String() == "http://heimdall.test.local/barfoo"
+				repository.EXPECT().FindRule(mock.MatchedBy(func(req *heimdall.Request) bool {
+					return req.URL.String() == "http://heimdall.test.local/barfoo"
 				})).Return(rule, nil)
 			},
 			instructUpstream: func(t *testing.T) {
@@ -440,6 +440,117 @@ func TestHandleProxyEndpointRequest(t *testing.T) {
 				assert.JSONEq(t, `{ "foo": "bar" }`, string(data))
 			},
 		},
+		{
+			uc:          "successful rule execution - request is forwarded to the url created by the upstream",
+			serviceConf: config.ServiceConfig{Timeout: config.Timeout{Read: 10 * time.Second}},
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return httptest.NewRequest(http.MethodGet, "http://heimdall.test.local/api/v1/foobar?foo=bar", nil)
+			},
+			configureMocks: func(t *testing.T, repository *mocks4.RepositoryMock, rule *mocks4.RuleMock) {
+				t.Helper()
+
+				backend := mocks4.NewBackendMock(t)
+				backend.EXPECT().CreateURL(mock.MatchedBy(func(value *heimdall.URL) bool {
+					return value.Path == "/api/v1/foobar"
+				})).Return(&url.URL{
+					Scheme:   upstreamURL.Scheme,
+					Host:     upstreamURL.Host,
+					Path:     "/internal/foobar",
+					RawQuery: "foo=bar",
+				}, nil)
+				backend.EXPECT().Done(mock.MatchedBy(func(target *url.URL) bool {
+					return target.Path == "/internal/foobar"
+				}), true)
+
+				rule.EXPECT().MatchesMethod(http.MethodGet).Return(true)
+				rule.EXPECT().Execute(mock.Anything).Return(backend, nil)
+
+				repository.EXPECT().FindRule(mock.Anything).Return(rule, nil)
+			},
+			instructUpstream: func(t *testing.T) {
+				t.Helper()
+
+				upstreamCheckRequest = func(req *http.Request) {
+					assert.Equal(t, http.MethodGet, req.Method)
+					assert.Equal(t, "/internal/foobar", req.URL.Path)
+					assert.Equal(t, "bar", req.URL.Query().Get("foo"))
+				}
+
+				upstreamResponseCode = http.StatusOK
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.True(t, upstreamCalled)
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, response.StatusCode)
+			},
+		},
+		{
+			uc:          "unavailable upstream is reported as failure",
+			serviceConf: config.ServiceConfig{Timeout: config.Timeout{Read: 10 * time.Second}},
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return httptest.NewRequest(http.MethodGet, "http://heimdall.test.local/foobar", nil)
+			},
+			configureMocks: func(t *testing.T, repository *mocks4.RepositoryMock, rule *mocks4.RuleMock) {
+				t.Helper()
+
+				backend := mocks4.NewBackendMock(t)
+				backend.EXPECT().CreateURL(mock.Anything).
+					Return(&url.URL{Scheme: upstreamURL.Scheme, Host: upstreamURL.Host, Path: "/foobar"}, nil)
+				backend.EXPECT().Done(mock.Anything, false)
+
+				rule.EXPECT().MatchesMethod(http.MethodGet).Return(true)
+				rule.EXPECT().Execute(mock.Anything).Return(backend, nil)
+
+				repository.EXPECT().FindRule(mock.Anything).Return(rule, nil)
+			},
+			instructUpstream: func(t *testing.T) {
+				t.Helper()
+
+				upstreamResponseCode = http.StatusServiceUnavailable
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.True(t, upstreamCalled)
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+			},
+		},
+		{
+			uc: "rule execution fails due to error while creating the upstream url",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return httptest.NewRequest(http.MethodGet, "http://heimdall.test.local/foobar", nil)
+			},
+			configureMocks: func(t *testing.T, repository *mocks4.RepositoryMock, rule *mocks4.RuleMock) {
+				t.Helper()
+
+				backend := mocks4.NewBackendMock(t)
+				backend.EXPECT().CreateURL(mock.Anything).Return(nil, heimdall.ErrInternal)
+
+				rule.EXPECT().MatchesMethod(http.MethodGet).Return(true)
+				rule.EXPECT().Execute(mock.Anything).Return(backend, nil)
+
+				repository.EXPECT().FindRule(mock.Anything).Return(rule, nil)
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.False(t, upstreamCalled)
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -490,3 +601,20 @@ func TestHandleProxyEndpointRequest(t *testing.T) {
 		})
 	}
 }
+
+func newForwardingBackend(t *testing.T, upstreamURL *url.URL) *mocks4.BackendMock {
+	t.Helper()
+
+	backend := mocks4.NewBackendMock(t)
+	backend.EXPECT().CreateURL(mock.Anything).RunAndReturn(func(value *heimdall.URL) (*url.URL, error) {
+		return &url.URL{
+			Scheme:   upstreamURL.Scheme,
+			Host:     upstreamURL.Host,
+			Path:     value.Path,
+			RawQuery: value.RawQuery,
+		}, nil
+	}).Maybe()
+	backend.EXPECT().Done(mock.Anything, mock.Anything).Maybe()
+
+	return backend
+}
@@ -18,15 +18,20 @@ package requestcontext
 
 import (
 	"context"
+	"crypto/x509"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/valyala/fasthttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/dadrus/heimdall/internal/accesscontext"
 	"github.com/dadrus/heimdall/internal/fasthttp/opentelemetry"
 	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/rule"
 	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
@@ -34,7 +39,7 @@ import (
 type RequestContext struct {
 	c               *fiber.Ctx
 	reqMethod       string
-	reqURL          *url.URL
+	reqURL          *heimdall.URL
 	upstreamHeaders http.Header
 	upstreamCookies map[string]string
 	jwtSigner       heimdall.JWTSigner
@@ -46,7 +51,7 @@ func New(c *fiber.Ctx, method string, reqURL *url.URL, signer heimdall.JWTSigner
 		c:               c,
 		jwtSigner:       signer,
 		reqMethod:       method,
-		reqURL:          reqURL,
+		reqURL:          &heimdall.URL{URL: *reqURL},
 		upstreamHeaders: make(http.Header),
 		upstreamCookies: make(map[string]string),
 	}
@@ -70,12 +75,21 @@ func (s *RequestContext) SetPipelineError(err error)              { s.err = err
 func (s *RequestContext) AddHeaderForUpstream(name, value string) { s.upstreamHeaders.Add(name, value) }
 func (s *RequestContext) AddCookieForUpstream(name, value string) { s.upstreamCookies[name] = value }
 func (s *RequestContext) Signer() heimdall.JWTSigner              { return s.jwtSigner }
+func (s *RequestContext) PeerAddress() string                     { return s.c.Context().RemoteIP().String() }
 func (s *RequestContext) RequestClientIPs() []string {
 	ips := s.c.IPs()
 
 	return x.IfThenElse(len(ips) != 0, ips, []string{s.c.IP()})
 }
 
+func (s *RequestContext) ClientCertificates() []*x509.Certificate {
+	if state := s.c.Context().TLSConnectionState(); state != nil {
+		return state.PeerCertificates
+	}
+
+	return nil
+}
+
 func (s *RequestContext) Finalize(statusCode int) error {
 	if s.err != nil {
 		return s.err
@@ -94,12 +108,12 @@ func (s *RequestContext) Finalize(statusCode int) error {
 	return nil
 }
 
-func (s *RequestContext) FinalizeAndForward(upstreamURL *url.URL, timeout time.Duration) error {
+func (s *RequestContext) FinalizeAndForward(upstream rule.Backend, timeout time.Duration) error {
 	if s.err != nil {
 		return s.err
 	}
 
-	if upstreamURL == nil {
+	if upstream == nil {
 		// happens only if default rule has been applied or if the rule does not have an upstream defined
 		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
 			"cannot forward request due to missing upstream URL")
@@ -120,16 +134,31 @@ func (s *RequestContext) FinalizeAndForward(upstreamURL *url.URL, timeout time.D
 		s.c.Request().Header.Del(name)
 	}
 
-	URL := &url.URL{
-		Scheme:   upstreamURL.Scheme,
-		Host:     upstreamURL.Host,
-		Path:     s.reqURL.Path,
-		RawQuery: s.reqURL.RawQuery,
+	// the upstream rewrites the path of the request if configured to do so
+	URL, err := upstream.CreateURL(s.reqURL)
+	if err != nil {
+		return err
 	}
 
+	target := (&url.URL{Scheme: URL.Scheme, Host: URL.Host}).String()
+
+	accesscontext.SetUpstream(s.c.UserContext(), target)
+	trace.SpanFromContext(s.c.UserContext()).SetAttributes(attribute.String("heimdall.upstream.target", target))
+
 	s.c.Request().Header.SetMethod(s.reqMethod)
 	s.c.Request().SetRequestURI(URL.String())
 
-	return opentelemetry.NewClient(&fasthttp.Client{}).
+	err = opentelemetry.NewClient(&fasthttp.Client{}).
 		DoTimeout(s.c.UserContext(), s.c.Request(), s.c.Response(), timeout)
+
+	// let the upstream know about the outcome, e.g. to eject failing targets
+	upstream.Done(URL, err == nil && !isUnavailable(s.c.Response().StatusCode()))
+
+	return err
+}
+
+func isUnavailable(statusCode int) bool {
+	return statusCode == http.StatusBadGateway ||
+		statusCode == http.StatusServiceUnavailable ||
+		statusCode == http.StatusGatewayTimeout
 }
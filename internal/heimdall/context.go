@@ -18,6 +18,7 @@ package heimdall
 
 import (
 	"context"
+	"crypto/x509"
 	"net/url"
 )
 
@@ -43,12 +44,25 @@ type RequestFunctions interface {
 	Cookie(name string) string
 	Headers() map[string]string
 	Body() []byte
+	// PeerAddress returns the ip address of the peer, heimdall is directly communicating with.
+	PeerAddress() string
+	// ClientCertificates returns the certificates presented by the client during
+	// the TLS handshake. The first certificate is the leaf certificate.
+	ClientCertificates() []*x509.Certificate
 }
 
 type Request struct {
 	RequestFunctions
 
 	Method   string
-	URL      *url.URL
+	URL      *URL
 	ClientIP []string
 }
+
+type URL struct {
+	url.URL
+
+	// Captures holds the values of the named parameters captured while matching
+	// the url against the pattern of the rule.
+	Captures map[string]string
+}
@@ -0,0 +1,226 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	defaultMaxFailures         = 3
+	defaultEjectionTime        = 30 * time.Second
+	defaultHealthCheckPath     = "/"
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultHealthCheckTimeout  = 2 * time.Second
+)
+
+type backend struct {
+	targets  []*upstreamTarget
+	lb       loadBalancer
+	passive  *config.PassiveHealthCheck
+	prober   *healthProber
+	rewriter *pathRewriter
+	logger   zerolog.Logger
+}
+
+func newBackend(
+	upstream *config.Upstream, rewrite *config.UpstreamRewrite, logger zerolog.Logger,
+) (*backend, error) {
+	if len(upstream.Targets) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "no upstream targets defined")
+	}
+
+	targets, err := newUpstreamTargets(upstream.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	lb, err := newLoadBalancer(upstream.LoadBalancing)
+	if err != nil {
+		return nil, err
+	}
+
+	bknd := &backend{targets: targets, lb: lb, logger: logger}
+
+	if upstream.HealthCheck != nil {
+		bknd.passive = newPassiveHealthCheck(upstream.HealthCheck.Passive)
+		bknd.prober = newHealthProber(targets, upstream.HealthCheck.Active, logger)
+	}
+
+	if rewrite != nil {
+		if bknd.rewriter, err = newPathRewriter(rewrite); err != nil {
+			return nil, err
+		}
+	}
+
+	return bknd, nil
+}
+
+func newUpstreamTargets(conf []config.UpstreamTarget) ([]*upstreamTarget, error) {
+	targets := make([]*upstreamTarget, len(conf))
+
+	for idx, tgt := range conf {
+		targetURL, err := url.Parse(tgt.URL)
+		if err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "bad upstream URL").CausedBy(err)
+		}
+
+		if tgt.Weight < 0 {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"negative weight defined for upstream target %s", tgt.URL)
+		}
+
+		targets[idx] = &upstreamTarget{url: targetURL, weight: x.IfThenElse(tgt.Weight > 0, tgt.Weight, 1)}
+	}
+
+	return targets, nil
+}
+
+func newLoadBalancer(strategy string) (loadBalancer, error) {
+	switch strategy {
+	case "", config.LoadBalancingRoundRobin:
+		return &roundRobinBalancer{}, nil
+	case config.LoadBalancingWeighted:
+		return &weightedBalancer{}, nil
+	case config.LoadBalancingLeastConnections:
+		return &leastConnectionsBalancer{}, nil
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported load balancing strategy '%s'", strategy)
+	}
+}
+
+func newPassiveHealthCheck(conf *config.PassiveHealthCheck) *config.PassiveHealthCheck {
+	if conf == nil {
+		return nil
+	}
+
+	return &config.PassiveHealthCheck{
+		MaxFailures:  x.IfThenElse(conf.MaxFailures > 0, conf.MaxFailures, defaultMaxFailures),
+		EjectionTime: x.IfThenElse(conf.EjectionTime > 0, conf.EjectionTime, defaultEjectionTime),
+	}
+}
+
+func newHealthProber(
+	targets []*upstreamTarget, conf *config.ActiveHealthCheck, logger zerolog.Logger,
+) *healthProber {
+	if conf == nil {
+		return nil
+	}
+
+	return &healthProber{
+		targets: targets,
+		conf: config.ActiveHealthCheck{
+			Path:     x.IfThenElse(len(conf.Path) != 0, conf.Path, defaultHealthCheckPath),
+			Interval: x.IfThenElse(conf.Interval > 0, conf.Interval, defaultHealthCheckInterval),
+			Timeout:  x.IfThenElse(conf.Timeout > 0, conf.Timeout, defaultHealthCheckTimeout),
+		},
+		client: &http.Client{},
+		logger: logger,
+	}
+}
+
+func (b *backend) CreateURL(value *heimdall.URL) (*url.URL, error) {
+	path := value.Path
+
+	if b.rewriter != nil {
+		var err error
+
+		if path, err = b.rewriter.rewrite(value); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(path) != 0 && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	tgt := b.selectTarget()
+	tgt.inFlight.Add(1)
+
+	return &url.URL{
+		Scheme:   tgt.url.Scheme,
+		Host:     tgt.url.Host,
+		Path:     path,
+		RawQuery: value.RawQuery,
+	}, nil
+}
+
+func (b *backend) Done(target *url.URL, success bool) {
+	for _, tgt := range b.targets {
+		if !tgt.is(target) {
+			continue
+		}
+
+		tgt.inFlight.Add(-1)
+
+		if b.passive != nil &&
+			tgt.recordOutcome(success, int64(b.passive.MaxFailures), b.passive.EjectionTime) {
+			b.logger.Warn().
+				Str("_url", tgt.url.String()).
+				Dur("_ejection_time", b.passive.EjectionTime).
+				Msg("Upstream target ejected due to consecutive failures")
+		}
+
+		return
+	}
+}
+
+// selectTarget returns the target to forward the request to. If all targets are considered
+// unhealthy, all of them are used, as refusing all requests would not be any better.
+func (b *backend) selectTarget() *upstreamTarget {
+	if len(b.targets) == 1 {
+		return b.targets[0]
+	}
+
+	now := time.Now()
+	candidates := make([]*upstreamTarget, 0, len(b.targets))
+
+	for _, tgt := range b.targets {
+		if tgt.isAvailable(now) {
+			candidates = append(candidates, tgt)
+		}
+	}
+
+	if len(candidates) == 0 {
+		candidates = b.targets
+	}
+
+	return b.lb.next(candidates)
+}
+
+func (b *backend) startHealthChecks() {
+	if b.prober != nil {
+		b.prober.start()
+	}
+}
+
+func (b *backend) stopHealthChecks() {
+	if b.prober != nil {
+		b.prober.stop()
+	}
+}
@@ -0,0 +1,507 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+)
+
+func newTestUpstream(urls ...string) *config.Upstream {
+	upstream := &config.Upstream{}
+
+	for _, u := range urls {
+		upstream.Targets = append(upstream.Targets, config.UpstreamTarget{URL: u})
+	}
+
+	return upstream
+}
+
+func TestNewBackend(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		upstream *config.Upstream
+		rewrite  *config.UpstreamRewrite
+		assert   func(t *testing.T, err error, upstream *backend)
+	}{
+		{
+			uc:       "without targets",
+			upstream: &config.Upstream{},
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no upstream targets")
+			},
+		},
+		{
+			uc:       "bad upstream url",
+			upstream: newTestUpstream("http://foo:bar"),
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "bad upstream URL")
+			},
+		},
+		{
+			uc: "negative weight",
+			upstream: &config.Upstream{
+				Targets: []config.UpstreamTarget{{URL: "http://foo.bar", Weight: -1}},
+			},
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "negative weight")
+			},
+		},
+		{
+			uc: "unsupported load balancing strategy",
+			upstream: &config.Upstream{
+				Targets:       []config.UpstreamTarget{{URL: "http://foo.bar"}},
+				LoadBalancing: "foo",
+			},
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported load balancing strategy")
+			},
+		},
+		{
+			uc:       "bad path template",
+			upstream: newTestUpstream("http://foo.bar"),
+			rewrite:  &config.UpstreamRewrite{PathTemplate: "{{ .URL.Path "},
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "bad path template")
+			},
+		},
+		{
+			uc:       "bad path regex",
+			upstream: newTestUpstream("http://foo.bar"),
+			rewrite:  &config.UpstreamRewrite{PathRegex: &config.PathRegex{Pattern: "(foo"}},
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "bad path regex")
+			},
+		},
+		{
+			uc:       "single target without rewrite and health checks",
+			upstream: newTestUpstream("http://foo.bar"),
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, upstream.targets, 1)
+				assert.Equal(t, "http://foo.bar", upstream.targets[0].url.String())
+				assert.Equal(t, 1, upstream.targets[0].weight)
+				assert.IsType(t, &roundRobinBalancer{}, upstream.lb)
+				assert.Nil(t, upstream.rewriter)
+				assert.Nil(t, upstream.passive)
+				assert.Nil(t, upstream.prober)
+			},
+		},
+		{
+			uc: "multiple targets with health checks using defaults",
+			upstream: &config.Upstream{
+				Targets: []config.UpstreamTarget{
+					{URL: "http://foo.bar", Weight: 3},
+					{URL: "http://bar.foo"},
+				},
+				LoadBalancing: config.LoadBalancingWeighted,
+				HealthCheck: &config.HealthCheck{
+					Passive: &config.PassiveHealthCheck{},
+					Active:  &config.ActiveHealthCheck{},
+				},
+			},
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, upstream.targets, 2)
+				assert.Equal(t, 3, upstream.targets[0].weight)
+				assert.Equal(t, 1, upstream.targets[1].weight)
+				assert.IsType(t, &weightedBalancer{}, upstream.lb)
+
+				require.NotNil(t, upstream.passive)
+				assert.Equal(t, defaultMaxFailures, upstream.passive.MaxFailures)
+				assert.Equal(t, defaultEjectionTime, upstream.passive.EjectionTime)
+
+				require.NotNil(t, upstream.prober)
+				assert.Equal(t, defaultHealthCheckPath, upstream.prober.conf.Path)
+				assert.Equal(t, defaultHealthCheckInterval, upstream.prober.conf.Interval)
+				assert.Equal(t, defaultHealthCheckTimeout, upstream.prober.conf.Timeout)
+				assert.Equal(t, upstream.targets, upstream.prober.targets)
+			},
+		},
+		{
+			uc: "with configured health checks",
+			upstream: &config.Upstream{
+				Targets:       []config.UpstreamTarget{{URL: "http://foo.bar"}},
+				LoadBalancing: config.LoadBalancingLeastConnections,
+				HealthCheck: &config.HealthCheck{
+					Passive: &config.PassiveHealthCheck{MaxFailures: 5, EjectionTime: time.Minute},
+					Active:  &config.ActiveHealthCheck{Path: "/health", Interval: time.Second, Timeout: time.Second},
+				},
+			},
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.IsType(t, &leastConnectionsBalancer{}, upstream.lb)
+
+				require.NotNil(t, upstream.passive)
+				assert.Equal(t, 5, upstream.passive.MaxFailures)
+				assert.Equal(t, time.Minute, upstream.passive.EjectionTime)
+
+				require.NotNil(t, upstream.prober)
+				assert.Equal(t, "/health", upstream.prober.conf.Path)
+				assert.Equal(t, time.Second, upstream.prober.conf.Interval)
+				assert.Equal(t, time.Second, upstream.prober.conf.Timeout)
+			},
+		},
+		{
+			uc:       "with rewrite",
+			upstream: newTestUpstream("http://foo.bar"),
+			rewrite: &config.UpstreamRewrite{
+				StripPathPrefix: "/foo",
+				AddPathPrefix:   "/bar",
+				PathRegex:       &config.PathRegex{Pattern: "^/(.*)$", Replacement: "/$1"},
+			},
+			assert: func(t *testing.T, err error, upstream *backend) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, upstream.rewriter)
+				assert.Nil(t, upstream.rewriter.tpl)
+				assert.NotNil(t, upstream.rewriter.regex)
+				assert.Equal(t, "/$1", upstream.rewriter.replacement)
+				assert.Equal(t, "/foo", upstream.rewriter.stripPrefix)
+				assert.Equal(t, "/bar", upstream.rewriter.addPrefix)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			upstream, err := newBackend(tc.upstream, tc.rewrite, log.Logger)
+
+			// THEN
+			tc.assert(t, err, upstream)
+		})
+	}
+}
+
+func TestBackendCreateURL(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		rewrite  *config.UpstreamRewrite
+		captures map[string]string
+		expected string
+	}{
+		{
+			uc:       "without rewrite",
+			expected: "http://orders.local/api/v1/orders/123?foo=bar",
+		},
+		{
+			uc:       "strip path prefix",
+			rewrite:  &config.UpstreamRewrite{StripPathPrefix: "/api/v1"},
+			expected: "http://orders.local/orders/123?foo=bar",
+		},
+		{
+			uc:       "strip path prefix not present in the path",
+			rewrite:  &config.UpstreamRewrite{StripPathPrefix: "/api/v2"},
+			expected: "http://orders.local/api/v1/orders/123?foo=bar",
+		},
+		{
+			uc:       "strip path prefix resulting in a relative path",
+			rewrite:  &config.UpstreamRewrite{StripPathPrefix: "/api/v1/"},
+			expected: "http://orders.local/orders/123?foo=bar",
+		},
+		{
+			uc:       "strip and add path prefix",
+			rewrite:  &config.UpstreamRewrite{StripPathPrefix: "/api/v1/orders", AddPathPrefix: "/internal"},
+			expected: "http://orders.local/internal/123?foo=bar",
+		},
+		{
+			uc: "path regex",
+			rewrite: &config.UpstreamRewrite{
+				PathRegex: &config.PathRegex{Pattern: "^/api/v(\\d+)/orders/(.*)$", Replacement: "/orders/$2/v$1"},
+			},
+			expected: "http://orders.local/orders/123/v1?foo=bar",
+		},
+		{
+			uc:       "path template using captures",
+			rewrite:  &config.UpstreamRewrite{PathTemplate: "/internal/{{ .URL.Captures.id }}"},
+			captures: map[string]string{"id": "123"},
+			expected: "http://orders.local/internal/123?foo=bar",
+		},
+		{
+			uc: "all options combined",
+			rewrite: &config.UpstreamRewrite{
+				PathTemplate:    "/tenants/{{ .URL.Captures.tenant }}{{ .URL.Path }}",
+				StripPathPrefix: "/tenants",
+				PathRegex:       &config.PathRegex{Pattern: "/api/v1", Replacement: ""},
+				AddPathPrefix:   "/internal",
+			},
+			captures: map[string]string{"tenant": "acme"},
+			expected: "http://orders.local/internal/acme/orders/123?foo=bar",
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			upstream, err := newBackend(newTestUpstream("http://orders.local/ignored"), tc.rewrite, log.Logger)
+			require.NoError(t, err)
+
+			reqURL := &heimdall.URL{
+				URL:      url.URL{Scheme: "https", Host: "heimdall.local", Path: "/api/v1/orders/123", RawQuery: "foo=bar"},
+				Captures: tc.captures,
+			}
+
+			// WHEN
+			result, err := upstream.CreateURL(reqURL)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, result.String())
+		})
+	}
+}
+
+func TestBackendCreateURLFailsOnTemplateRenderingError(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	upstream, err := newBackend(newTestUpstream("http://orders.local"),
+		&config.UpstreamRewrite{PathTemplate: `{{ fail "boom" }}`}, log.Logger)
+	require.NoError(t, err)
+
+	// WHEN
+	_, err = upstream.CreateURL(&heimdall.URL{URL: url.URL{Path: "/foo"}})
+
+	// THEN
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrInternal)
+}
+
+func TestBackendDistributesRequestsOverTargets(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		upstream *config.Upstream
+		expected map[string]int
+	}{
+		{
+			uc:       "round robin",
+			upstream: newTestUpstream("http://foo.local", "http://bar.local"),
+			expected: map[string]int{"foo.local": 3, "bar.local": 3},
+		},
+		{
+			uc: "round robin ignores weights",
+			upstream: &config.Upstream{
+				Targets: []config.UpstreamTarget{{URL: "http://foo.local", Weight: 5}, {URL: "http://bar.local"}},
+			},
+			expected: map[string]int{"foo.local": 3, "bar.local": 3},
+		},
+		{
+			uc: "weighted",
+			upstream: &config.Upstream{
+				Targets:       []config.UpstreamTarget{{URL: "http://foo.local", Weight: 2}, {URL: "http://bar.local"}},
+				LoadBalancing: config.LoadBalancingWeighted,
+			},
+			expected: map[string]int{"foo.local": 4, "bar.local": 2},
+		},
+		{
+			uc: "least connections",
+			upstream: &config.Upstream{
+				Targets:       []config.UpstreamTarget{{URL: "http://foo.local"}, {URL: "http://bar.local"}},
+				LoadBalancing: config.LoadBalancingLeastConnections,
+			},
+			expected: map[string]int{"foo.local": 3, "bar.local": 3},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			upstream, err := newBackend(tc.upstream, nil, log.Logger)
+			require.NoError(t, err)
+
+			counts := make(map[string]int)
+
+			// WHEN
+			for i := 0; i < 6; i++ {
+				target, err := upstream.CreateURL(&heimdall.URL{URL: url.URL{Path: "/foo"}})
+				require.NoError(t, err)
+
+				counts[target.Host]++
+
+				upstream.Done(target, true)
+			}
+
+			// THEN
+			assert.Equal(t, tc.expected, counts)
+		})
+	}
+}
+
+func TestBackendLeastConnectionsPrefersTargetsWithFewerRequestsInFlight(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	upstream, err := newBackend(&config.Upstream{
+		Targets:       []config.UpstreamTarget{{URL: "http://foo.local"}, {URL: "http://bar.local"}},
+		LoadBalancing: config.LoadBalancingLeastConnections,
+	}, nil, log.Logger)
+	require.NoError(t, err)
+
+	reqURL := &heimdall.URL{URL: url.URL{Path: "/foo"}}
+
+	first, err := upstream.CreateURL(reqURL)
+	require.NoError(t, err)
+
+	second, err := upstream.CreateURL(reqURL)
+	require.NoError(t, err)
+
+	upstream.Done(second, true)
+
+	// WHEN
+	third, err := upstream.CreateURL(reqURL)
+	require.NoError(t, err)
+
+	// THEN
+	assert.NotEqual(t, first.Host, second.Host)
+	assert.Equal(t, second.Host, third.Host)
+}
+
+func TestBackendPassiveHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	upstream, err := newBackend(&config.Upstream{
+		Targets: []config.UpstreamTarget{{URL: "http://foo.local"}, {URL: "http://bar.local"}},
+		HealthCheck: &config.HealthCheck{
+			Passive: &config.PassiveHealthCheck{MaxFailures: 2, EjectionTime: time.Hour},
+		},
+	}, nil, log.Logger)
+	require.NoError(t, err)
+
+	foo := &url.URL{Scheme: "http", Host: "foo.local"}
+	reqURL := &heimdall.URL{URL: url.URL{Path: "/foo"}}
+
+	// WHEN
+	upstream.Done(foo, false)
+	upstream.Done(foo, true)
+	upstream.Done(foo, false)
+
+	// THEN
+	// failures are counted only if consecutive
+	assert.True(t, upstream.targets[0].isAvailable(time.Now()))
+
+	// WHEN
+	upstream.Done(foo, false)
+
+	// THEN
+	assert.False(t, upstream.targets[0].isAvailable(time.Now()))
+	assert.True(t, upstream.targets[0].isAvailable(time.Now().Add(2*time.Hour)))
+
+	for i := 0; i < 4; i++ {
+		target, err := upstream.CreateURL(reqURL)
+		require.NoError(t, err)
+		assert.Equal(t, "bar.local", target.Host)
+	}
+
+	// WHEN
+	upstream.Done(&url.URL{Scheme: "http", Host: "bar.local"}, false)
+	upstream.Done(&url.URL{Scheme: "http", Host: "bar.local"}, false)
+
+	// THEN
+	// all targets are ejected, so all of them are used
+	counts := make(map[string]int)
+
+	for i := 0; i < 4; i++ {
+		target, err := upstream.CreateURL(reqURL)
+		require.NoError(t, err)
+
+		counts[target.Host]++
+	}
+
+	assert.Equal(t, map[string]int{"foo.local": 2, "bar.local": 2}, counts)
+}
+
+func TestBackendActiveHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/health", r.URL.Path)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	upstream, err := newBackend(&config.Upstream{
+		Targets: []config.UpstreamTarget{{URL: healthy.URL}, {URL: unhealthy.URL}},
+		HealthCheck: &config.HealthCheck{
+			Active: &config.ActiveHealthCheck{Path: "/health", Interval: 10 * time.Millisecond},
+		},
+	}, nil, log.Logger)
+	require.NoError(t, err)
+
+	// WHEN
+	upstream.startHealthChecks()
+	defer upstream.stopHealthChecks()
+
+	// THEN
+	assert.Eventually(t, func() bool {
+		return !upstream.targets[1].isAvailable(time.Now())
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, upstream.targets[0].isAvailable(time.Now()))
+
+	target, err := upstream.CreateURL(&heimdall.URL{URL: url.URL{Path: "/foo"}})
+	require.NoError(t, err)
+	assert.Equal(t, healthy.URL, (&url.URL{Scheme: target.Scheme, Host: target.Host}).String())
+}
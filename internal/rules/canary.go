@@ -0,0 +1,113 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"hash/fnv"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const percentageBuckets = 100
+
+type canary struct {
+	name       string
+	backend    *backend
+	percentage int
+	condition  executionCondition
+}
+
+func newCanary(conf config.Canary, backend *backend) (*canary, error) {
+	if len(conf.Name) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "canary without name defined")
+	}
+
+	if conf.Percentage < 0 || conf.Percentage > percentageBuckets {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"percentage of canary %s must be between 0 and 100", conf.Name)
+	}
+
+	if conf.Percentage == 0 && len(conf.If) == 0 {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"neither percentage, nor if condition defined for canary %s", conf.Name)
+	}
+
+	cnr := &canary{
+		name:       conf.Name,
+		backend:    backend,
+		percentage: conf.Percentage,
+	}
+
+	if len(conf.If) != 0 {
+		condition, err := newCelExecutionCondition(conf.If)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed creating if condition of canary %s", conf.Name).CausedBy(err)
+		}
+
+		cnr.condition = condition
+	}
+
+	return cnr, nil
+}
+
+// applies returns true if the request of the given subject should be forwarded to the canary.
+// The decision based on the percentage is sticky, as it depends on the subject id only. Since the
+// bucket of a subject does not depend on the percentage, increasing it does not move any subject
+// already routed to the canary back to the regular upstream. Anonymous subjects share the same id,
+// so their requests are distributed by the address of the client instead.
+func (c *canary) applies(ctx heimdall.Context, ruleID string, sub *subject.Subject) (bool, error) {
+	if c.condition != nil {
+		matches, err := c.condition.CanExecute(ctx, sub)
+		if err != nil || !matches {
+			return false, err
+		}
+	}
+
+	if c.percentage == 0 || c.percentage == percentageBuckets {
+		return true, nil
+	}
+
+	return bucketOf(ruleID, c.name, bucketKey(ctx, sub)) < c.percentage, nil
+}
+
+func bucketKey(ctx heimdall.Context, sub *subject.Subject) string {
+	if sub != nil && len(sub.ID) != 0 && !sub.IsAnonymous() {
+		return sub.ID
+	}
+
+	// the first address is the one of the client, the others are the ones of the proxies in between
+	if clientIPs := ctx.Request().ClientIP; len(clientIPs) != 0 {
+		return clientIPs[0]
+	}
+
+	return ""
+}
+
+func bucketOf(values ...string) int {
+	hash := fnv.New32a()
+
+	for _, value := range values {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+
+	return int(hash.Sum32() % percentageBuckets)
+}
@@ -0,0 +1,258 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+)
+
+func TestNewCanary(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   config.Canary
+		assert func(t *testing.T, err error, cnr *canary)
+	}{
+		{
+			uc:   "without name",
+			conf: config.Canary{Percentage: 10},
+			assert: func(t *testing.T, err error, _ *canary) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "without name")
+			},
+		},
+		{
+			uc:   "with negative percentage",
+			conf: config.Canary{Name: "foo", Percentage: -1},
+			assert: func(t *testing.T, err error, _ *canary) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "between 0 and 100")
+			},
+		},
+		{
+			uc:   "with percentage exceeding 100",
+			conf: config.Canary{Name: "foo", Percentage: 101},
+			assert: func(t *testing.T, err error, _ *canary) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "between 0 and 100")
+			},
+		},
+		{
+			uc:   "without percentage and if condition",
+			conf: config.Canary{Name: "foo"},
+			assert: func(t *testing.T, err error, _ *canary) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "neither percentage, nor if condition")
+			},
+		},
+		{
+			uc:   "with malformed if condition",
+			conf: config.Canary{Name: "foo", If: "foo"},
+			assert: func(t *testing.T, err error, _ *canary) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "if condition of canary foo")
+			},
+		},
+		{
+			uc:   "with percentage only",
+			conf: config.Canary{Name: "foo", Percentage: 10},
+			assert: func(t *testing.T, err error, cnr *canary) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, cnr)
+				assert.Equal(t, "foo", cnr.name)
+				assert.Equal(t, 10, cnr.percentage)
+				assert.Nil(t, cnr.condition)
+			},
+		},
+		{
+			uc:   "with percentage and if condition",
+			conf: config.Canary{Name: "foo", Percentage: 10, If: "Request.Method == 'GET'"},
+			assert: func(t *testing.T, err error, cnr *canary) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, cnr)
+				assert.Equal(t, "foo", cnr.name)
+				assert.Equal(t, 10, cnr.percentage)
+				assert.NotNil(t, cnr.condition)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			cnr, err := newCanary(tc.conf, &backend{})
+
+			// THEN
+			tc.assert(t, err, cnr)
+		})
+	}
+}
+
+func TestCanaryAppliesUsingCondition(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		method   string
+		expected bool
+	}{
+		{uc: "condition matches", method: http.MethodGet, expected: true},
+		{uc: "condition does not match", method: http.MethodPost, expected: false},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().Request().Return(&heimdall.Request{
+				Method: tc.method,
+				URL:    &heimdall.URL{URL: url.URL{Scheme: "http", Host: "localhost", Path: "/test"}},
+			})
+
+			cnr, err := newCanary(config.Canary{Name: "foo", If: "Request.Method == 'GET'"}, &backend{})
+			require.NoError(t, err)
+
+			// WHEN
+			applies, err := cnr.applies(ctx, "rule", &subject.Subject{ID: "bar"})
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, applies)
+		})
+	}
+}
+
+func TestCanaryAppliesUsingPercentage(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ctx := mocks.NewContextMock(t)
+	small := &canary{name: "foo", percentage: 20}
+	large := &canary{name: "foo", percentage: 60}
+	all := &canary{name: "foo", percentage: 100}
+
+	var routed int
+
+	for idx := 0; idx < 1000; idx++ {
+		sub := &subject.Subject{ID: fmt.Sprintf("subject-%d", idx)}
+
+		// WHEN
+		smallApplies, err := small.applies(ctx, "rule", sub)
+		require.NoError(t, err)
+
+		smallAppliesAgain, err := small.applies(ctx, "rule", sub)
+		require.NoError(t, err)
+
+		largeApplies, err := large.applies(ctx, "rule", sub)
+		require.NoError(t, err)
+
+		allApplies, err := all.applies(ctx, "rule", sub)
+		require.NoError(t, err)
+
+		// THEN
+		// the decision is sticky for a subject
+		assert.Equal(t, smallApplies, smallAppliesAgain)
+		// increasing the percentage does not route subjects back to the regular upstream
+		if smallApplies {
+			assert.True(t, largeApplies)
+		}
+
+		assert.True(t, allApplies)
+
+		if smallApplies {
+			routed++
+		}
+	}
+
+	assert.InDelta(t, 200, routed, 60)
+}
+
+func TestCanaryAppliesUsingPercentageForAnonymousSubjects(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc  string
+		sub *subject.Subject
+	}{
+		{uc: "anonymous subject", sub: subject.NewAnonymous("anonymous")},
+		{uc: "subject without id", sub: &subject.Subject{}},
+		{uc: "no subject"},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			cnr := &canary{name: "foo", percentage: 20}
+
+			var routed int
+
+			for idx := 0; idx < 1000; idx++ {
+				clientIP := fmt.Sprintf("10.0.%d.%d", idx/256, idx%256)
+
+				ctx := mocks.NewContextMock(t)
+				ctx.EXPECT().Request().Return(&heimdall.Request{
+					Method:   http.MethodGet,
+					URL:      &heimdall.URL{URL: url.URL{Scheme: "http", Host: "localhost", Path: "/test"}},
+					ClientIP: []string{clientIP, "192.168.1.1"},
+				})
+
+				// WHEN
+				applies, err := cnr.applies(ctx, "rule", tc.sub)
+				require.NoError(t, err)
+
+				appliesAgain, err := cnr.applies(ctx, "rule", tc.sub)
+				require.NoError(t, err)
+
+				// THEN
+				// the decision is sticky for a client
+				assert.Equal(t, applies, appliesAgain)
+
+				if applies {
+					routed++
+				}
+			}
+
+			// requests of anonymous subjects are distributed and not routed to the same upstream
+			assert.InDelta(t, 200, routed, 60)
+		})
+	}
+}
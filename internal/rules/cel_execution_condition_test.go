@@ -76,6 +76,11 @@ func TestCelExecutionConditionCanExecute(t *testing.T) {
 			expression: `Subject.ID == "foobar" && Request.Method == "GET"`,
 			expected:   true,
 		},
+		{
+			uc:         "expression using captured url parameters",
+			expression: `Request.URL.Captures.tenant == "acme" && Request.URL.Path == "/test"`,
+			expected:   true,
+		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -83,12 +88,12 @@ func TestCelExecutionConditionCanExecute(t *testing.T) {
 
 			ctx.EXPECT().Request().Return(&heimdall.Request{
 				Method: http.MethodGet,
-				URL: &url.URL{
+				URL: &heimdall.URL{URL: url.URL{
 					Scheme:   "http",
 					Host:     "localhost",
 					Path:     "/test",
 					RawQuery: "foo=bar&baz=zab",
-				},
+				}, Captures: map[string]string{"tenant": "acme"}},
 				ClientIP: []string{"127.0.0.1", "10.10.10.10"},
 			})
 
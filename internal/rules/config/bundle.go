@@ -0,0 +1,327 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	ContentTypeJSON  = "application/json"
+	ContentTypeYAML  = "application/yaml"
+	ContentTypeTarGz = "application/gzip"
+	ContentTypeZip   = "application/zip"
+
+	// maxBundleSize limits the amount of uncompressed data read from a rule set bundle.
+	maxBundleSize = 64 << 20
+
+	// idSeparator separates the source of a bundle from the ID of a rule set within it.
+	idSeparator = "#"
+)
+
+var ErrBundleTooLarge = errors.New("rule set bundle too large")
+
+type bundledRuleSet struct {
+	id      string
+	ruleSet *RuleSet
+}
+
+// ContentTypeByExtension returns the content type of a rule set file or of a rule set bundle
+// based on the extension of the given file name. An empty string is returned if the
+// extension is not known.
+func ContentTypeByExtension(fileName string) string {
+	name := strings.ToLower(fileName)
+
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ContentTypeTarGz
+	case strings.HasSuffix(name, ".zip"):
+		return ContentTypeZip
+	case strings.HasSuffix(name, ".yaml"), strings.HasSuffix(name, ".yml"):
+		return ContentTypeYAML
+	case strings.HasSuffix(name, ".json"):
+		return ContentTypeJSON
+	default:
+		return ""
+	}
+}
+
+// ParseRuleSets parses the given contents, which can be a single rule set, a multi-document
+// YAML stream or a tar.gz or zip archive bundling rule set files, and returns all rule sets
+// found, with their Hash and Source set. The source of each rule set is the provided source
+// extended by the ID of the rule set within the bundle, which is the path of the archive entry
+// followed by the name of the rule set, if set. E.g. "<source>#<entry path>#<name>". That way,
+// the source of a rule set does not change if other rule sets in the same bundle are updated or
+// added. A document without a name is only accepted if it is the only one in its stream and is
+// given the provided source, respectively the source extended by the path of the archive entry.
+func ParseRuleSets(contentType, source string, contents []byte) ([]*RuleSet, error) {
+	var (
+		bundled []bundledRuleSet
+		err     error
+	)
+
+	// nothing needs to be decoded, regardless of the content type
+	if len(contents) == 0 {
+		return nil, ErrEmptyRuleSet
+	}
+
+	switch contentType {
+	case ContentTypeJSON, ContentTypeYAML:
+		bundled, err = parseDocuments(bytes.NewReader(contents))
+	case ContentTypeTarGz, "application/x-gzip", "application/x-tgz":
+		bundled, err = parseTarGzBundle(contents)
+	case ContentTypeZip, "application/x-zip-compressed":
+		bundled, err = parseZipBundle(contents)
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"unsupported '%s' content type", contentType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bundled) == 0 {
+		return nil, ErrEmptyRuleSet
+	}
+
+	ruleSets := make([]*RuleSet, len(bundled))
+	known := make(map[string]bool, len(bundled))
+
+	for idx, entry := range bundled {
+		if known[entry.id] {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"rule set %s is defined multiple times in the bundle", entry.id)
+		}
+
+		known[entry.id] = true
+
+		entry.ruleSet.Source = source
+		if len(entry.id) != 0 {
+			entry.ruleSet.Source = source + idSeparator + entry.id
+		}
+
+		ruleSets[idx] = entry.ruleSet
+	}
+
+	return ruleSets, nil
+}
+
+func parseDocuments(reader io.Reader) ([]bundledRuleSet, error) {
+	var ruleSets []bundledRuleSet
+
+	dec := yaml.NewDecoder(reader)
+
+	for {
+		var rawConfig map[string]any
+
+		if err := dec.Decode(&rawConfig); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, err
+		}
+
+		if len(rawConfig) == 0 {
+			continue
+		}
+
+		var ruleSet RuleSet
+
+		if err := DecodeConfig(rawConfig, &ruleSet); err != nil {
+			return nil, err
+		}
+
+		// the hash is calculated over the canonical representation of the document,
+		// so that changes to other documents in the same stream do not affect it
+		canonical, err := json.Marshal(rawConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		hash := sha256.Sum256(canonical)
+		ruleSet.Hash = hash[:]
+
+		ruleSets = append(ruleSets, bundledRuleSet{id: ruleSet.Name, ruleSet: &ruleSet})
+	}
+
+	// the name is the only discriminator of documents in a stream, which is stable across changes
+	// to other documents
+	if len(ruleSets) > 1 {
+		for _, entry := range ruleSets {
+			if len(entry.id) == 0 {
+				return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+					"rule sets in a multi-document stream must have a name")
+			}
+		}
+	}
+
+	return ruleSets, nil
+}
+
+func parseTarGzBundle(contents []byte) ([]bundledRuleSet, error) {
+	gzr, err := gzip.NewReader(bytes.NewReader(contents))
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to read rule set bundle").
+			CausedBy(err)
+	}
+
+	defer gzr.Close()
+
+	var ruleSets []bundledRuleSet
+
+	reader := &limitedReader{n: maxBundleSize}
+	tr := tar.NewReader(gzr)
+
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to read rule set bundle").
+				CausedBy(err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || !isRuleSetEntry(hdr.Name) {
+			continue
+		}
+
+		reader.r = tr
+
+		entryRuleSets, err := parseBundleEntry(hdr.Name, reader)
+		if err != nil {
+			return nil, err
+		}
+
+		ruleSets = append(ruleSets, entryRuleSets...)
+	}
+
+	return ruleSets, nil
+}
+
+func parseZipBundle(contents []byte) ([]bundledRuleSet, error) {
+	zr, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to read rule set bundle").
+			CausedBy(err)
+	}
+
+	var ruleSets []bundledRuleSet
+
+	reader := &limitedReader{n: maxBundleSize}
+
+	for _, file := range zr.File {
+		if !file.Mode().IsRegular() || !isRuleSetEntry(file.Name) {
+			continue
+		}
+
+		entryRuleSets, err := parseZipEntry(file, reader)
+		if err != nil {
+			return nil, err
+		}
+
+		ruleSets = append(ruleSets, entryRuleSets...)
+	}
+
+	return ruleSets, nil
+}
+
+func parseZipEntry(file *zip.File, reader *limitedReader) ([]bundledRuleSet, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal, "failed to read bundle entry %s", file.Name).
+			CausedBy(err)
+	}
+
+	defer rc.Close()
+
+	reader.r = rc
+
+	return parseBundleEntry(file.Name, reader)
+}
+
+func parseBundleEntry(name string, reader io.Reader) ([]bundledRuleSet, error) {
+	entryName := path.Clean(strings.TrimPrefix(name, "./"))
+
+	contents, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal, "failed to read bundle entry %s", entryName).
+			CausedBy(err)
+	}
+
+	ruleSets, err := parseDocuments(bytes.NewReader(contents))
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal, "failed to parse bundle entry %s", entryName).
+			CausedBy(err)
+	}
+
+	for idx := range ruleSets {
+		ruleSets[idx].id = x.IfThenElse(len(ruleSets[idx].id) != 0,
+			entryName+idSeparator+ruleSets[idx].id, entryName)
+	}
+
+	return ruleSets, nil
+}
+
+// isRuleSetEntry returns true for YAML and JSON files, but not for hidden ones, like
+// the metadata files created by some archivers.
+func isRuleSetEntry(name string) bool {
+	contentType := ContentTypeByExtension(name)
+
+	return (contentType == ContentTypeYAML || contentType == ContentTypeJSON) &&
+		!strings.HasPrefix(path.Base(name), ".")
+}
+
+// limitedReader reads from r until n bytes have been read in total and fails with
+// ErrBundleTooLarge afterwards. Used to protect against decompression bombs.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, ErrBundleTooLarge
+	}
+
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+
+	return n, err
+}
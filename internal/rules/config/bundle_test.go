@@ -0,0 +1,358 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func TestContentTypeByExtension(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		fileName    string
+		contentType string
+	}{
+		{fileName: "rules.yaml", contentType: ContentTypeYAML},
+		{fileName: "rules.YML", contentType: ContentTypeYAML},
+		{fileName: "rules.json", contentType: ContentTypeJSON},
+		{fileName: "bundle.tar.gz", contentType: ContentTypeTarGz},
+		{fileName: "bundle.tgz", contentType: ContentTypeTarGz},
+		{fileName: "bundle.zip", contentType: ContentTypeZip},
+		{fileName: "rules.txt", contentType: ""},
+		{fileName: "rules", contentType: ""},
+	} {
+		t.Run(tc.fileName, func(t *testing.T) {
+			assert.Equal(t, tc.contentType, ContentTypeByExtension(tc.fileName))
+		})
+	}
+}
+
+func TestParseRuleSets(t *testing.T) {
+	t.Parallel()
+
+	ruleSet := func(name, ruleID string) string {
+		return "version: \"1\"\nname: " + name + "\nrules:\n- id: " + ruleID + "\n"
+	}
+
+	for _, tc := range []struct {
+		uc          string
+		contentType string
+		content     []byte
+		assert      func(t *testing.T, err error, ruleSets []*RuleSet)
+	}{
+		{
+			uc:          "unsupported content type and not empty contents",
+			contentType: "foobar",
+			content:     []byte(`foo: bar`),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "unsupported 'foobar'")
+			},
+		},
+		{
+			uc:          "unsupported content type and empty contents",
+			contentType: "foobar",
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrEmptyRuleSet)
+			},
+		},
+		{
+			uc:          "tar.gz content type and empty contents",
+			contentType: ContentTypeTarGz,
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrEmptyRuleSet)
+			},
+		},
+		{
+			uc:          "YAML content type and empty contents",
+			contentType: ContentTypeYAML,
+			content:     []byte("---\n---\n"),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrEmptyRuleSet)
+			},
+		},
+		{
+			uc:          "JSON content with a single rule set",
+			contentType: ContentTypeJSON,
+			content:     []byte(`{"version": "1", "name": "foo", "rules": [{"id": "bar"}]}`),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, ruleSets, 1)
+				assert.Equal(t, "test#foo", ruleSets[0].Source)
+				assert.Equal(t, "foo", ruleSets[0].Name)
+				assert.NotEmpty(t, ruleSets[0].Hash)
+				assert.Len(t, ruleSets[0].Rules, 1)
+			},
+		},
+		{
+			uc:          "YAML content with a single rule set in a stream",
+			contentType: ContentTypeYAML,
+			content:     []byte("---\n---\n" + ruleSet("foo", "bar")),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, ruleSets, 1)
+				assert.Equal(t, "test#foo", ruleSets[0].Source)
+				assert.Equal(t, "foo", ruleSets[0].Name)
+				assert.NotEmpty(t, ruleSets[0].Hash)
+			},
+		},
+		{
+			uc:          "YAML content with a single unnamed rule set",
+			contentType: ContentTypeYAML,
+			content:     []byte("version: \"1\"\nrules:\n- id: bar\n"),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, ruleSets, 1)
+				assert.Equal(t, "test", ruleSets[0].Source)
+				assert.Empty(t, ruleSets[0].Name)
+			},
+		},
+		{
+			uc:          "YAML content with multiple rule sets",
+			contentType: ContentTypeYAML,
+			content:     []byte(ruleSet("foo", "bar") + "---\n" + ruleSet("bar", "baz")),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, ruleSets, 2)
+				assert.Equal(t, "test#foo", ruleSets[0].Source)
+				assert.Equal(t, "bar", ruleSets[0].Rules[0].ID)
+				assert.Equal(t, "test#bar", ruleSets[1].Source)
+				assert.Equal(t, "baz", ruleSets[1].Rules[0].ID)
+				assert.NotEqual(t, ruleSets[0].Hash, ruleSets[1].Hash)
+			},
+		},
+		{
+			uc:          "YAML content with multiple rule sets, one without a name",
+			contentType: ContentTypeYAML,
+			content: []byte(ruleSet("foo", "bar") + "---\n" +
+				"version: \"1\"\nrules:\n- id: zab\n"),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "must have a name")
+			},
+		},
+		{
+			uc:          "YAML content with multiple rule sets having the same name",
+			contentType: ContentTypeYAML,
+			content:     []byte(ruleSet("foo", "bar") + "---\n" + ruleSet("foo", "baz")),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "foo is defined multiple times")
+			},
+		},
+		{
+			uc:          "YAML content with an invalid rule set",
+			contentType: ContentTypeYAML,
+			content:     []byte(ruleSet("foo", "bar") + "---\nfoo: bar\n"),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+			},
+		},
+		{
+			uc:          "tar.gz bundle with multiple rule set files",
+			contentType: ContentTypeTarGz,
+			content: testsupport.CreateTarGzBundle(t, map[string]string{
+				"a/foo.yaml":     ruleSet("foo", "bar"),
+				"b/bar.yml":      ruleSet("bar", "baz") + "---\n" + ruleSet("baz", "zab"),
+				"c/baz.json":     `{"version": "1", "name": "baz", "rules": [{"id": "foo"}]}`,
+				"README.md":      "# Rule Sets",
+				"a/.hidden.yaml": "foo: bar",
+			}),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, ruleSets, 4)
+				assert.Equal(t, "test#a/foo.yaml#foo", ruleSets[0].Source)
+				assert.Equal(t, "test#b/bar.yml#bar", ruleSets[1].Source)
+				assert.Equal(t, "test#b/bar.yml#baz", ruleSets[2].Source)
+				assert.Equal(t, "test#c/baz.json#baz", ruleSets[3].Source)
+				assert.Equal(t, "foo", ruleSets[3].Rules[0].ID)
+			},
+		},
+		{
+			uc:          "zip bundle with multiple rule set files",
+			contentType: ContentTypeZip,
+			content: testsupport.CreateZipBundle(t, map[string]string{
+				"./foo.yaml": ruleSet("foo", "bar"),
+				"bar.yaml":   ruleSet("bar", "baz"),
+				"LICENSE":    "foo",
+			}),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, ruleSets, 2)
+				assert.Equal(t, "test#foo.yaml#foo", ruleSets[0].Source)
+				assert.Equal(t, "bar", ruleSets[0].Rules[0].ID)
+				assert.Equal(t, "test#bar.yaml#bar", ruleSets[1].Source)
+				assert.Equal(t, "baz", ruleSets[1].Rules[0].ID)
+			},
+		},
+		{
+			uc:          "bundle without rule set files",
+			contentType: ContentTypeZip,
+			content:     testsupport.CreateZipBundle(t, map[string]string{"README.md": "# Rule Sets"}),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrEmptyRuleSet)
+			},
+		},
+		{
+			uc:          "bundle with an invalid rule set file",
+			contentType: ContentTypeTarGz,
+			content: testsupport.CreateTarGzBundle(t, map[string]string{
+				"foo.yaml": ruleSet("foo", "bar"),
+				"bar.yaml": "foo: bar",
+			}),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "bar.yaml")
+			},
+		},
+		{
+			uc:          "invalid tar.gz bundle",
+			contentType: ContentTypeTarGz,
+			content:     []byte(ruleSet("foo", "bar")),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to read rule set bundle")
+			},
+		},
+		{
+			uc:          "invalid zip bundle",
+			contentType: ContentTypeZip,
+			content:     []byte(ruleSet("foo", "bar")),
+			assert: func(t *testing.T, err error, ruleSets []*RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to read rule set bundle")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			ruleSets, err := ParseRuleSets(tc.contentType, "test", tc.content)
+
+			// THEN
+			tc.assert(t, err, ruleSets)
+		})
+	}
+}
+
+func TestParseRuleSetsKeepsHashesOfUnchangedRuleSets(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	original := testsupport.CreateTarGzBundle(t, map[string]string{
+		"foo.yaml": "version: \"1\"\nname: foo\nrules:\n- id: foo\n",
+		"bar.yaml": "version: \"1\"\nname: bar\nrules:\n- id: bar\n",
+	})
+	updated := testsupport.CreateTarGzBundle(t, map[string]string{
+		"foo.yaml": "# some comment\nversion: \"1\"\nname: foo\nrules:\n- id: foo\n",
+		"bar.yaml": "version: \"1\"\nname: bar\nrules:\n- id: baz\n",
+	})
+
+	// WHEN
+	originalRuleSets, err := ParseRuleSets(ContentTypeTarGz, "test", original)
+	require.NoError(t, err)
+
+	updatedRuleSets, err := ParseRuleSets(ContentTypeTarGz, "test", updated)
+	require.NoError(t, err)
+
+	// THEN
+	require.Len(t, originalRuleSets, 2)
+	require.Len(t, updatedRuleSets, 2)
+
+	for idx := range originalRuleSets {
+		assert.Equal(t, originalRuleSets[idx].Source, updatedRuleSets[idx].Source)
+	}
+
+	assert.Equal(t, "test#bar.yaml#bar", updatedRuleSets[0].Source)
+	assert.NotEqual(t, originalRuleSets[0].Hash, updatedRuleSets[0].Hash)
+	assert.Equal(t, "test#foo.yaml#foo", updatedRuleSets[1].Source)
+	assert.Equal(t, originalRuleSets[1].Hash, updatedRuleSets[1].Hash)
+}
+
+func TestLimitedReader(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	reader := &limitedReader{r: strings.NewReader("foobar"), n: 4}
+
+	// WHEN
+	data, err := io.ReadAll(reader)
+
+	// THEN
+	require.ErrorIs(t, err, ErrBundleTooLarge)
+	assert.Equal(t, "foob", string(data))
+}
+
+func TestParseBundleEntryExceedingSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	// WHEN
+	_, err := parseBundleEntry("foo.yaml",
+		&limitedReader{r: strings.NewReader("version: \"1\"\nrules:\n- id: foo\n"), n: 10})
+
+	// THEN
+	require.ErrorIs(t, err, ErrBundleTooLarge)
+	assert.Contains(t, err.Error(), "foo.yaml")
+}
@@ -0,0 +1,32 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+// Canary defines an alternate upstream, a part of the requests matched by a rule is forwarded to.
+// Which requests are affected is defined by the percentage of subjects, by a CEL expression, or by
+// both.
+type Canary struct {
+	Name       string    `json:"name" yaml:"name"`
+	Upstream   *Upstream `json:"upstream" yaml:"upstream"`
+	Percentage int       `json:"percentage,omitempty" yaml:"percentage,omitempty"`
+	If         string    `json:"if,omitempty" yaml:"if,omitempty"`
+}
+
+func (in *Canary) DeepCopyInto(out *Canary) {
+	*out = *in
+	out.Upstream = in.Upstream.DeepCopy()
+}
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/mitchellh/mapstructure"
+
 	"github.com/dadrus/heimdall/internal/x"
 )
 
@@ -57,7 +59,7 @@ func matcherDecodeHookFunc(from reflect.Type, to reflect.Type, data any) (any, e
 		return nil, ErrURLMissing
 	}
 
-	urlValue, ok := URL.(string)
+	_, ok := URL.(string)
 	if !ok {
 		return nil, ErrURLType
 	}
@@ -74,8 +76,60 @@ func matcherDecodeHookFunc(from reflect.Type, to reflect.Type, data any) (any, e
 		}
 	}
 
-	return Matcher{
-		URL:      urlValue,
-		Strategy: x.IfThenElse(strategyPresent, strategyValue, "glob"),
-	}, nil
+	var matcher Matcher
+
+	// url and strategy are already verified above. so only the additional conditions
+	// can cause errors here
+	dec, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			Result:      &matcher,
+			ErrorUnused: true,
+			TagName:     "json",
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = dec.Decode(values); err != nil {
+		return nil, err
+	}
+
+	matcher.Strategy = x.IfThenElse(strategyPresent, strategyValue, "glob")
+
+	return matcher, nil
+}
+
+func upstreamDecodeHookFunc(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if to != reflect.TypeOf(Upstream{}) {
+		return data, nil
+	}
+
+	if from.Kind() == reflect.String {
+		// nolint: forcetypeassert
+		// already checked above
+		return Upstream{Targets: []UpstreamTarget{{URL: data.(string)}}}, nil
+	}
+
+	if from.Kind() != reflect.Map {
+		return data, nil
+	}
+
+	var upstream Upstream
+
+	dec, err := mapstructure.NewDecoder(
+		&mapstructure.DecoderConfig{
+			DecodeHook:  mapstructure.StringToTimeDurationHookFunc(),
+			Result:      &upstream,
+			ErrorUnused: true,
+			TagName:     "json",
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = dec.Decode(data); err != nil {
+		return nil, err
+	}
+
+	return upstream, nil
 }
@@ -18,6 +18,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -146,6 +147,45 @@ match:
 				assert.Equal(t, "regex", matcher.Strategy)
 			},
 		},
+		{
+			uc: "specified as structured type with additional conditions",
+			config: []byte(`
+match: 
+  url: foo.bar
+  host: <*>.example.com
+  headers:
+    Accept: [ "application/grpc<*>" ]
+  query_params:
+    version: [ "v1", "v2" ]
+  client_cidrs: [ "10.0.0.0/8" ]
+`),
+			assert: func(t *testing.T, err error, matcher *Matcher) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo.bar", matcher.URL)
+				assert.Equal(t, "glob", matcher.Strategy)
+				assert.Equal(t, "<*>.example.com", matcher.Host)
+				assert.Equal(t, map[string][]string{"Accept": {"application/grpc<*>"}}, matcher.Headers)
+				assert.Equal(t, map[string][]string{"version": {"v1", "v2"}}, matcher.QueryParams)
+				assert.Equal(t, []string{"10.0.0.0/8"}, matcher.ClientCIDRs)
+			},
+		},
+		{
+			uc: "specified as structured type with unsupported condition",
+			config: []byte(`
+match: 
+  url: foo.bar
+  cookies:
+    foo: [ "bar" ]
+`),
+			assert: func(t *testing.T, err error, matcher *Matcher) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "cookies")
+			},
+		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -162,3 +202,89 @@ match:
 		})
 	}
 }
+
+func TestUpstreamDecodeHookFunc(t *testing.T) {
+	t.Parallel()
+
+	type Typ struct {
+		Upstream *Upstream `json:"upstream"`
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, upstream *Upstream)
+	}{
+		{
+			uc:     "specified as string",
+			config: []byte(`upstream: http://foo.bar`),
+			assert: func(t *testing.T, err error, upstream *Upstream) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, &Upstream{Targets: []UpstreamTarget{{URL: "http://foo.bar"}}}, upstream)
+			},
+		},
+		{
+			uc: "specified as structured type",
+			config: []byte(`
+upstream:
+  targets:
+    - url: http://foo.bar
+      weight: 2
+    - url: http://bar.foo
+  load_balancing: weighted
+  health_check:
+    passive:
+      max_failures: 5
+      ejection_time: 1m
+    active:
+      path: /health
+      interval: 5s
+      timeout: 1s
+`),
+			assert: func(t *testing.T, err error, upstream *Upstream) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, &Upstream{
+					Targets:       []UpstreamTarget{{URL: "http://foo.bar", Weight: 2}, {URL: "http://bar.foo"}},
+					LoadBalancing: LoadBalancingWeighted,
+					HealthCheck: &HealthCheck{
+						Passive: &PassiveHealthCheck{MaxFailures: 5, EjectionTime: time.Minute},
+						Active:  &ActiveHealthCheck{Path: "/health", Interval: 5 * time.Second, Timeout: time.Second},
+					},
+				}, upstream)
+			},
+		},
+		{
+			uc: "specified as structured type with unsupported property",
+			config: []byte(`
+upstream:
+  targets:
+    - url: http://foo.bar
+  retries: 3
+`),
+			assert: func(t *testing.T, err error, upstream *Upstream) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "retries")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			raw, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			var typ Typ
+
+			// WHEN
+			err = DecodeConfig(raw, &typ)
+
+			// THEN
+			tc.assert(t, err, typ.Upstream)
+		})
+	}
+}
@@ -23,8 +23,12 @@ import (
 )
 
 type Matcher struct {
-	URL      string `json:"url" yaml:"url"`
-	Strategy string `json:"strategy" yaml:"strategy"`
+	URL         string              `json:"url" yaml:"url"`
+	Strategy    string              `json:"strategy" yaml:"strategy"`
+	Host        string              `json:"host,omitempty" yaml:"host,omitempty"`
+	Headers     map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	QueryParams map[string][]string `json:"query_params,omitempty" yaml:"query_params,omitempty"`
+	ClientCIDRs []string            `json:"client_cidrs,omitempty" yaml:"client_cidrs,omitempty"`
 }
 
 func (m *Matcher) UnmarshalJSON(data []byte) error {
@@ -44,3 +48,37 @@ func (m *Matcher) UnmarshalJSON(data []byte) error {
 
 	return DecodeConfig(rawData, m)
 }
+
+func (m *Matcher) DeepCopyInto(out *Matcher) {
+	*out = *m
+
+	if m.Headers != nil {
+		out.Headers = deepCopyValues(m.Headers)
+	}
+
+	if m.QueryParams != nil {
+		out.QueryParams = deepCopyValues(m.QueryParams)
+	}
+
+	if m.ClientCIDRs != nil {
+		out.ClientCIDRs = make([]string, len(m.ClientCIDRs))
+		copy(out.ClientCIDRs, m.ClientCIDRs)
+	}
+}
+
+func deepCopyValues(in map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(in))
+
+	for key, values := range in {
+		if values == nil {
+			out[key] = nil
+
+			continue
+		}
+
+		out[key] = make([]string, len(values))
+		copy(out[key], values)
+	}
+
+	return out
+}
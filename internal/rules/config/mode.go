@@ -0,0 +1,25 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+const (
+	// RuleModeEnforce is the default mode of a rule. The decision of such a rule is enforced.
+	RuleModeEnforce = "enforce"
+	// RuleModeShadow makes a rule only record the decision it would have made. The request is
+	// handled by the rule, which would have been used if the shadow rule did not exist.
+	RuleModeShadow = "shadow"
+)
@@ -104,6 +104,39 @@ rules:
 				assert.Len(t, ruleSet.Rules, 1)
 			},
 		},
+		{
+			uc:          "YAML content with defaults and templates",
+			contentType: "application/yaml",
+			content: []byte(`
+version: "1"
+name: foo
+defaults:
+  upstream: http://foo.bar
+templates:
+- id: base
+  methods: [ GET ]
+  execute:
+  - authenticator: foo
+rules:
+- id: bar
+  extends: base
+  match: http://foo.bar/<**>
+`),
+			assert: func(t *testing.T, err error, ruleSet *RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, ruleSet)
+				require.NotNil(t, ruleSet.Defaults)
+				assert.Equal(t, "http://foo.bar", ruleSet.Defaults.Upstream.Targets[0].URL)
+				require.Len(t, ruleSet.Templates, 1)
+				assert.Equal(t, "base", ruleSet.Templates[0].ID)
+				assert.Equal(t, []string{"GET"}, ruleSet.Templates[0].Methods)
+				assert.Len(t, ruleSet.Templates[0].Execute, 1)
+				require.Len(t, ruleSet.Rules, 1)
+				assert.Equal(t, "base", ruleSet.Rules[0].Extends)
+			},
+		},
 		{
 			uc:          "YAML content and empty contents",
 			contentType: "application/yaml",
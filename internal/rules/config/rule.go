@@ -21,17 +21,33 @@ import (
 )
 
 type Rule struct {
-	ID           string                   `json:"id" yaml:"id"`
-	RuleMatcher  Matcher                  `json:"match" yaml:"match"`
-	Upstream     string                   `json:"upstream" yaml:"upstream"`
-	Methods      []string                 `json:"methods" yaml:"methods"`
-	Execute      []config.MechanismConfig `json:"execute" yaml:"execute"`
-	ErrorHandler []config.MechanismConfig `json:"on_error" yaml:"on_error"`
+	ID              string                   `json:"id" yaml:"id"`
+	Extends         string                   `json:"extends,omitempty" yaml:"extends,omitempty"`
+	RuleMatcher     Matcher                  `json:"match" yaml:"match"`
+	Priority        int                      `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Mode            string                   `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Upstream        *Upstream                `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+	UpstreamRewrite *UpstreamRewrite         `json:"upstream_rewrite,omitempty" yaml:"upstream_rewrite,omitempty"`
+	Canaries        []Canary                 `json:"canaries,omitempty" yaml:"canaries,omitempty"`
+	Methods         []string                 `json:"methods" yaml:"methods"`
+	Execute         []config.MechanismConfig `json:"execute" yaml:"execute"`
+	ErrorHandler    []config.MechanismConfig `json:"on_error" yaml:"on_error"`
 }
 
 func (in *Rule) DeepCopyInto(out *Rule) {
 	*out = *in
-	out.RuleMatcher = in.RuleMatcher
+	in.RuleMatcher.DeepCopyInto(&out.RuleMatcher)
+	out.Upstream = in.Upstream.DeepCopy()
+	out.UpstreamRewrite = in.UpstreamRewrite.DeepCopy()
+
+	if in.Canaries != nil {
+		in, out := &in.Canaries, &out.Canaries
+
+		*out = make([]Canary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 
 	if in.Methods != nil {
 		in, out := &in.Methods, &out.Methods
@@ -12,14 +12,23 @@ type MetaData struct {
 	Hash    []byte    `json:"-" yaml:"-"`
 	Source  string    `json:"-" yaml:"-"`
 	ModTime time.Time `json:"-" yaml:"-"`
+	// Revision identifies the version of the source, the rule set has been loaded from, like
+	// the hash of a git commit. It is empty if the source is not versioned.
+	Revision string `json:"-" yaml:"-"`
+	// Obsoletes lists the sources of rule sets, which are deleted right after this rule set has
+	// been applied. Rules from these sources are not considered while checking for overlaps.
+	Obsoletes []string `json:"-" yaml:"-"`
 }
 
 type RuleSet struct {
 	MetaData
 
-	Version string `json:"version" yaml:"version"`
-	Name    string `json:"name" yaml:"name"`
-	Rules   []Rule `json:"rules" yaml:"rules"`
+	Version   string           `json:"version" yaml:"version"`
+	Name      string           `json:"name" yaml:"name"`
+	Mode      string           `json:"mode,omitempty" yaml:"mode,omitempty"`
+	Defaults  *RuleSetDefaults `json:"defaults,omitempty" yaml:"defaults,omitempty"`
+	Templates []RuleTemplate   `json:"templates,omitempty" yaml:"templates,omitempty"`
+	Rules     []Rule           `json:"rules" yaml:"rules"`
 }
 
 func (rs RuleSet) VerifyPathPrefix(prefix string) error {
@@ -37,3 +46,38 @@ func (rs RuleSet) VerifyPathPrefix(prefix string) error {
 
 	return nil
 }
+
+// ExpandRules returns the rules of the rule set with the templates, these extend, and the defaults
+// of the rule set applied. Properties defined by a rule take precedence over the ones defined by
+// the template, which take precedence over the defaults. The resulting rules are thus equal to
+// rules defining the same properties on their own. The rule set itself is not modified.
+func (rs *RuleSet) ExpandRules() ([]Rule, error) {
+	templates, err := rs.resolveTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, len(rs.Rules))
+
+	for idx := range rs.Rules {
+		rul := &rules[idx]
+		rs.Rules[idx].DeepCopyInto(rul)
+
+		if len(rul.Extends) != 0 {
+			tpl, ok := templates[rul.Extends]
+			if !ok {
+				return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+					"rule ID=%s extends unknown template %s", rul.ID, rul.Extends)
+			}
+
+			tpl.applyTo(rul)
+			rul.Extends = ""
+		}
+
+		if rs.Defaults != nil {
+			rs.Defaults.applyTo(rul)
+		}
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"github.com/dadrus/heimdall/internal/config"
+)
+
+// RuleSetDefaults defines properties applied to all rules of a rule set, which define these
+// neither on their own, nor by the template they extend.
+type RuleSetDefaults struct {
+	Upstream        *Upstream                `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+	UpstreamRewrite *UpstreamRewrite         `json:"upstream_rewrite,omitempty" yaml:"upstream_rewrite,omitempty"`
+	Methods         []string                 `json:"methods,omitempty" yaml:"methods,omitempty"`
+	ErrorHandler    []config.MechanismConfig `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+}
+
+func (in *RuleSetDefaults) DeepCopyInto(out *RuleSetDefaults) {
+	*out = *in
+	out.Upstream = in.Upstream.DeepCopy()
+	out.UpstreamRewrite = in.UpstreamRewrite.DeepCopy()
+
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+
+	out.ErrorHandler = deepCopyMechanismConfigs(in.ErrorHandler)
+}
+
+func (in *RuleSetDefaults) DeepCopy() *RuleSetDefaults {
+	if in == nil {
+		return nil
+	}
+
+	out := new(RuleSetDefaults)
+	in.DeepCopyInto(out)
+
+	return out
+}
+
+func (in *RuleSetDefaults) applyTo(rul *Rule) {
+	tpl := RuleTemplate{
+		Upstream:        in.Upstream,
+		UpstreamRewrite: in.UpstreamRewrite,
+		Methods:         in.Methods,
+		ErrorHandler:    in.ErrorHandler,
+	}
+
+	tpl.applyTo(rul)
+}
@@ -3,7 +3,10 @@ package config
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
 )
 
 func TestRuleSetConfigurationVerifyPathPrefixPathPrefixVerify(t *testing.T) {
@@ -37,3 +40,50 @@ func TestRuleSetConfigurationVerifyPathPrefixPathPrefixVerify(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleSetExpandRulesWithDefaults(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	rs := RuleSet{
+		Defaults: &RuleSetDefaults{
+			Upstream:     &Upstream{Targets: []UpstreamTarget{{URL: "http://foo.bar"}}},
+			Methods:      []string{"GET"},
+			ErrorHandler: []config.MechanismConfig{{"error_handler": "default"}},
+		},
+		Templates: []RuleTemplate{{ID: "post", Methods: []string{"POST"}}},
+		Rules: []Rule{
+			{ID: "rule1"},
+			{ID: "rule2", Extends: "post"},
+			{
+				ID:           "rule3",
+				Upstream:     &Upstream{Targets: []UpstreamTarget{{URL: "http://bar.foo"}}},
+				Methods:      []string{"PATCH"},
+				ErrorHandler: []config.MechanismConfig{{"error_handler": "other"}},
+			},
+		},
+	}
+
+	// WHEN
+	rules, err := rs.ExpandRules()
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+
+	assert.Equal(t, "http://foo.bar", rules[0].Upstream.Targets[0].URL)
+	assert.Equal(t, []string{"GET"}, rules[0].Methods)
+	assert.Equal(t, []config.MechanismConfig{{"error_handler": "default"}}, rules[0].ErrorHandler)
+
+	assert.Equal(t, "http://foo.bar", rules[1].Upstream.Targets[0].URL)
+	assert.Equal(t, []string{"POST"}, rules[1].Methods)
+	assert.Equal(t, []config.MechanismConfig{{"error_handler": "default"}}, rules[1].ErrorHandler)
+
+	assert.Equal(t, "http://bar.foo", rules[2].Upstream.Targets[0].URL)
+	assert.Equal(t, []string{"PATCH"}, rules[2].Methods)
+	assert.Equal(t, []config.MechanismConfig{{"error_handler": "other"}}, rules[2].ErrorHandler)
+
+	// the defaults are not shared with the rules
+	rules[0].Methods[0] = "DELETE"
+	assert.Equal(t, []string{"GET"}, rs.Defaults.Methods)
+}
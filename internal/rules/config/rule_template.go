@@ -0,0 +1,177 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// RuleTemplate defines properties shared by multiple rules of a rule set. Rules make use of a
+// template by referencing it in their extends property. A template can extend another one.
+type RuleTemplate struct {
+	ID              string                   `json:"id" yaml:"id"`
+	Extends         string                   `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Upstream        *Upstream                `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+	UpstreamRewrite *UpstreamRewrite         `json:"upstream_rewrite,omitempty" yaml:"upstream_rewrite,omitempty"`
+	Methods         []string                 `json:"methods,omitempty" yaml:"methods,omitempty"`
+	Execute         []config.MechanismConfig `json:"execute,omitempty" yaml:"execute,omitempty"`
+	ErrorHandler    []config.MechanismConfig `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+}
+
+func (in *RuleTemplate) DeepCopyInto(out *RuleTemplate) {
+	*out = *in
+	out.Upstream = in.Upstream.DeepCopy()
+	out.UpstreamRewrite = in.UpstreamRewrite.DeepCopy()
+
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+
+	out.Execute = deepCopyMechanismConfigs(in.Execute)
+	out.ErrorHandler = deepCopyMechanismConfigs(in.ErrorHandler)
+}
+
+// applyTo sets all properties of the given rule, not defined by the rule itself, to the ones
+// defined by the template.
+func (in *RuleTemplate) applyTo(rul *Rule) {
+	if rul.Upstream == nil {
+		rul.Upstream = in.Upstream.DeepCopy()
+	}
+
+	if rul.UpstreamRewrite == nil {
+		rul.UpstreamRewrite = in.UpstreamRewrite.DeepCopy()
+	}
+
+	if len(rul.Methods) == 0 && in.Methods != nil {
+		rul.Methods = make([]string, len(in.Methods))
+		copy(rul.Methods, in.Methods)
+	}
+
+	if len(rul.Execute) == 0 {
+		rul.Execute = deepCopyMechanismConfigs(in.Execute)
+	}
+
+	if len(rul.ErrorHandler) == 0 {
+		rul.ErrorHandler = deepCopyMechanismConfigs(in.ErrorHandler)
+	}
+}
+
+// resolveTemplates returns the templates of the rule set by their ids, with the templates, these
+// extend, applied.
+func (rs *RuleSet) resolveTemplates() (map[string]*RuleTemplate, error) {
+	defined := make(map[string]*RuleTemplate, len(rs.Templates))
+
+	for idx := range rs.Templates {
+		tpl := &rs.Templates[idx]
+
+		if len(tpl.ID) == 0 {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "template without ID defined")
+		}
+
+		if _, ok := defined[tpl.ID]; ok {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"template ID=%s defined multiple times", tpl.ID)
+		}
+
+		defined[tpl.ID] = tpl
+	}
+
+	resolved := make(map[string]*RuleTemplate, len(defined))
+
+	for id := range defined {
+		if _, err := resolveTemplate(id, defined, resolved, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+func resolveTemplate(
+	id string, defined, resolved map[string]*RuleTemplate, visiting map[string]bool,
+) (*RuleTemplate, error) {
+	if tpl, ok := resolved[id]; ok {
+		return tpl, nil
+	}
+
+	if visiting[id] {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"cyclic extends detected for template ID=%s", id)
+	}
+
+	tpl, ok := defined[id]
+	if !ok {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "unknown template %s", id)
+	}
+
+	result := &RuleTemplate{}
+	tpl.DeepCopyInto(result)
+
+	if len(tpl.Extends) != 0 {
+		visiting[id] = true
+
+		parent, err := resolveTemplate(tpl.Extends, defined, resolved, visiting)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed resolving template ID=%s", id).CausedBy(err)
+		}
+
+		if result.Upstream == nil {
+			result.Upstream = parent.Upstream.DeepCopy()
+		}
+
+		if result.UpstreamRewrite == nil {
+			result.UpstreamRewrite = parent.UpstreamRewrite.DeepCopy()
+		}
+
+		if len(result.Methods) == 0 {
+			result.Methods = parent.Methods
+		}
+
+		if len(result.Execute) == 0 {
+			result.Execute = parent.Execute
+		}
+
+		if len(result.ErrorHandler) == 0 {
+			result.ErrorHandler = parent.ErrorHandler
+		}
+
+		result.Extends = ""
+	}
+
+	resolved[id] = result
+
+	return result, nil
+}
+
+func deepCopyMechanismConfigs(in []config.MechanismConfig) []config.MechanismConfig {
+	if in == nil {
+		return nil
+	}
+
+	out := make([]config.MechanismConfig, len(in))
+	for i := range in {
+		in[i].DeepCopyInto(&out[i])
+	}
+
+	return out
+}
@@ -0,0 +1,240 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+func TestRuleSetExpandRulesWithTemplates(t *testing.T) {
+	t.Parallel()
+
+	base := RuleTemplate{
+		ID:              "base",
+		Upstream:        &Upstream{Targets: []UpstreamTarget{{URL: "http://foo.bar"}}},
+		UpstreamRewrite: &UpstreamRewrite{StripPathPrefix: "/api"},
+		Methods:         []string{"GET"},
+		Execute:         []config.MechanismConfig{{"authenticator": "foo"}, {"unifier": "bar"}},
+		ErrorHandler:    []config.MechanismConfig{{"error_handler": "baz"}},
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		rs     RuleSet
+		assert func(t *testing.T, err error, rules []Rule)
+	}{
+		{
+			uc: "without templates",
+			rs: RuleSet{Rules: []Rule{{ID: "foo", Methods: []string{"POST"}}}},
+			assert: func(t *testing.T, err error, rules []Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []Rule{{ID: "foo", Methods: []string{"POST"}}}, rules)
+			},
+		},
+		{
+			uc: "rule extending a template",
+			rs: RuleSet{
+				Templates: []RuleTemplate{base},
+				Rules:     []Rule{{ID: "foo", Extends: "base", RuleMatcher: Matcher{URL: "http://foo.bar/<**>"}}},
+			},
+			assert: func(t *testing.T, err error, rules []Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, rules, 1)
+				assert.Equal(t, Rule{
+					ID:              "foo",
+					RuleMatcher:     Matcher{URL: "http://foo.bar/<**>"},
+					Upstream:        &Upstream{Targets: []UpstreamTarget{{URL: "http://foo.bar"}}},
+					UpstreamRewrite: &UpstreamRewrite{StripPathPrefix: "/api"},
+					Methods:         []string{"GET"},
+					Execute:         []config.MechanismConfig{{"authenticator": "foo"}, {"unifier": "bar"}},
+					ErrorHandler:    []config.MechanismConfig{{"error_handler": "baz"}},
+				}, rules[0])
+				assert.NotSame(t, base.UpstreamRewrite, rules[0].UpstreamRewrite)
+			},
+		},
+		{
+			uc: "rule overriding properties of a template",
+			rs: RuleSet{
+				Templates: []RuleTemplate{base},
+				Rules: []Rule{{
+					ID:       "foo",
+					Extends:  "base",
+					Upstream: &Upstream{Targets: []UpstreamTarget{{URL: "http://bar.foo"}}},
+					Execute:  []config.MechanismConfig{{"authenticator": "bar"}},
+				}},
+			},
+			assert: func(t *testing.T, err error, rules []Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, rules, 1)
+				assert.Equal(t, "http://bar.foo", rules[0].Upstream.Targets[0].URL)
+				assert.Equal(t, []string{"GET"}, rules[0].Methods)
+				assert.Equal(t, []config.MechanismConfig{{"authenticator": "bar"}}, rules[0].Execute)
+				assert.Equal(t, []config.MechanismConfig{{"error_handler": "baz"}}, rules[0].ErrorHandler)
+			},
+		},
+		{
+			uc: "template extending another template",
+			rs: RuleSet{
+				Templates: []RuleTemplate{
+					{ID: "post", Extends: "base", Methods: []string{"POST"}},
+					base,
+				},
+				Rules: []Rule{{ID: "foo", Extends: "post"}},
+			},
+			assert: func(t *testing.T, err error, rules []Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, rules, 1)
+				assert.Equal(t, "http://foo.bar", rules[0].Upstream.Targets[0].URL)
+				assert.Equal(t, base.UpstreamRewrite, rules[0].UpstreamRewrite)
+				assert.Equal(t, []string{"POST"}, rules[0].Methods)
+				assert.Equal(t, base.Execute, rules[0].Execute)
+				assert.Empty(t, rules[0].Extends)
+			},
+		},
+		{
+			uc: "rule extending unknown template",
+			rs: RuleSet{
+				Templates: []RuleTemplate{base},
+				Rules:     []Rule{{ID: "foo", Extends: "bar"}},
+			},
+			assert: func(t *testing.T, err error, rules []Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unknown template bar")
+			},
+		},
+		{
+			uc: "template extending unknown template",
+			rs: RuleSet{
+				Templates: []RuleTemplate{{ID: "foo", Extends: "bar"}},
+			},
+			assert: func(t *testing.T, err error, rules []Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unknown template bar")
+			},
+		},
+		{
+			uc: "cyclic templates",
+			rs: RuleSet{
+				Templates: []RuleTemplate{{ID: "foo", Extends: "bar"}, {ID: "bar", Extends: "foo"}},
+			},
+			assert: func(t *testing.T, err error, rules []Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cyclic")
+			},
+		},
+		{
+			uc: "template without id",
+			rs: RuleSet{Templates: []RuleTemplate{{Upstream: &Upstream{Targets: []UpstreamTarget{{URL: "http://foo.bar"}}}}}},
+			assert: func(t *testing.T, err error, rules []Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "without ID")
+			},
+		},
+		{
+			uc: "template defined multiple times",
+			rs: RuleSet{Templates: []RuleTemplate{base, base}},
+			assert: func(t *testing.T, err error, rules []Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "multiple times")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			rules, err := tc.rs.ExpandRules()
+
+			// THEN
+			tc.assert(t, err, rules)
+		})
+	}
+}
+
+func TestRuleSetExpandRulesIsHashStable(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	inline := RuleSet{Rules: []Rule{{
+		ID:          "foo",
+		RuleMatcher: Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"},
+		Methods:     []string{"GET"},
+		Execute:     []config.MechanismConfig{{"authenticator": "foo", "config": map[string]any{"a": "b"}}},
+	}}}
+
+	templated := RuleSet{
+		Templates: []RuleTemplate{{
+			ID:      "base",
+			Methods: []string{"GET"},
+			Execute: []config.MechanismConfig{{"authenticator": "foo", "config": map[string]any{"a": "b"}}},
+		}},
+		Rules: []Rule{{
+			ID:          "foo",
+			Extends:     "base",
+			RuleMatcher: Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"},
+		}},
+	}
+
+	// WHEN
+	inlineRules, err := inline.ExpandRules()
+	require.NoError(t, err)
+
+	templatedRules, err := templated.ExpandRules()
+	require.NoError(t, err)
+
+	// THEN
+	inlineJSON, err := json.Marshal(inlineRules[0])
+	require.NoError(t, err)
+
+	templatedJSON, err := json.Marshal(templatedRules[0])
+	require.NoError(t, err)
+
+	assert.Equal(t, string(inlineJSON), string(templatedJSON))
+
+	// the rule set itself is not modified, and the expanded rules do not share state with it
+	assert.Equal(t, "base", templated.Rules[0].Extends)
+	templatedRules[0].Execute[0]["authenticator"] = "bar"
+	assert.Equal(t, "foo", templated.Templates[0].Execute[0]["authenticator"])
+}
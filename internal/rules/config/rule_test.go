@@ -37,7 +37,10 @@ func TestRuleConfigDeepCopyInto(t *testing.T) {
 			URL:      "bar",
 			Strategy: "glob",
 		},
-		Upstream:     "baz",
+		Upstream: &Upstream{Targets: []UpstreamTarget{{URL: "baz"}}},
+		Canaries: []Canary{
+			{Name: "canary", Percentage: 10, Upstream: &Upstream{Targets: []UpstreamTarget{{URL: "zab"}}}},
+		},
 		Methods:      []string{"GET", "PATCH"},
 		Execute:      []config.MechanismConfig{{"foo": "bar"}},
 		ErrorHandler: []config.MechanismConfig{{"bar": "foo"}},
@@ -50,6 +53,8 @@ func TestRuleConfigDeepCopyInto(t *testing.T) {
 	assert.Equal(t, in.ID, out.ID)
 	assert.Equal(t, in.RuleMatcher.URL, out.RuleMatcher.URL)
 	assert.Equal(t, in.Upstream, out.Upstream)
+	assert.Equal(t, in.Canaries, out.Canaries)
+	assert.NotSame(t, in.Canaries[0].Upstream, out.Canaries[0].Upstream)
 	assert.Equal(t, in.RuleMatcher.Strategy, out.RuleMatcher.Strategy)
 	assert.Equal(t, in.Methods, out.Methods)
 	assert.Equal(t, in.Execute, out.Execute)
@@ -66,7 +71,7 @@ func TestRuleConfigDeepCopy(t *testing.T) {
 			URL:      "bar",
 			Strategy: "glob",
 		},
-		Upstream:     "baz",
+		Upstream:     &Upstream{Targets: []UpstreamTarget{{URL: "baz"}}},
 		Methods:      []string{"GET", "PATCH"},
 		Execute:      []config.MechanismConfig{{"foo": "bar"}},
 		ErrorHandler: []config.MechanismConfig{{"bar": "foo"}},
@@ -0,0 +1,122 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+const (
+	LoadBalancingRoundRobin       = "round_robin"
+	LoadBalancingWeighted         = "weighted"
+	LoadBalancingLeastConnections = "least_connections"
+)
+
+type Upstream struct {
+	Targets       []UpstreamTarget `json:"targets" yaml:"targets"`
+	LoadBalancing string           `json:"load_balancing,omitempty" yaml:"load_balancing,omitempty"`
+	HealthCheck   *HealthCheck     `json:"health_check,omitempty" yaml:"health_check,omitempty"`
+}
+
+type UpstreamTarget struct {
+	URL    string `json:"url" yaml:"url"`
+	Weight int    `json:"weight,omitempty" yaml:"weight,omitempty"`
+}
+
+type HealthCheck struct {
+	Passive *PassiveHealthCheck `json:"passive,omitempty" yaml:"passive,omitempty"`
+	Active  *ActiveHealthCheck  `json:"active,omitempty" yaml:"active,omitempty"`
+}
+
+type PassiveHealthCheck struct {
+	MaxFailures  int           `json:"max_failures,omitempty" yaml:"max_failures,omitempty"`
+	EjectionTime time.Duration `json:"ejection_time,omitempty" yaml:"ejection_time,omitempty"`
+}
+
+type ActiveHealthCheck struct {
+	Path     string        `json:"path,omitempty" yaml:"path,omitempty"`
+	Interval time.Duration `json:"interval,omitempty" yaml:"interval,omitempty"`
+	Timeout  time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+}
+
+func (u *Upstream) UnmarshalJSON(data []byte) error {
+	if data[0] == '"' {
+		// data contains just the url of the single target
+		u.Targets = []UpstreamTarget{{URL: stringx.ToString(data[1 : len(data)-1])}}
+
+		return nil
+	}
+
+	var rawData map[string]any
+
+	if err := json.Unmarshal(data, &rawData); err != nil {
+		return err
+	}
+
+	return DecodeConfig(rawData, u)
+}
+
+func (in *Upstream) DeepCopyInto(out *Upstream) {
+	*out = *in
+
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+
+		*out = make([]UpstreamTarget, len(*in))
+		copy(*out, *in)
+	}
+
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+
+		*out = new(HealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *Upstream) DeepCopy() *Upstream {
+	if in == nil {
+		return nil
+	}
+
+	out := new(Upstream)
+	in.DeepCopyInto(out)
+
+	return out
+}
+
+func (in *HealthCheck) DeepCopyInto(out *HealthCheck) {
+	*out = *in
+
+	if in.Passive != nil {
+		in, out := &in.Passive, &out.Passive
+
+		*out = new(PassiveHealthCheck)
+		**out = **in
+	}
+
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+
+		*out = new(ActiveHealthCheck)
+		**out = **in
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+// UpstreamRewrite defines how the path of a request is rewritten before the request is forwarded
+// to the upstream service. The options are applied in the order: path template, strip path prefix,
+// path regex and add path prefix.
+type UpstreamRewrite struct {
+	PathTemplate    string     `json:"path_template,omitempty" yaml:"path_template,omitempty"`
+	StripPathPrefix string     `json:"strip_path_prefix,omitempty" yaml:"strip_path_prefix,omitempty"`
+	PathRegex       *PathRegex `json:"path_regex,omitempty" yaml:"path_regex,omitempty"`
+	AddPathPrefix   string     `json:"add_path_prefix,omitempty" yaml:"add_path_prefix,omitempty"`
+}
+
+type PathRegex struct {
+	Pattern     string `json:"pattern" yaml:"pattern"`
+	Replacement string `json:"replacement" yaml:"replacement"`
+}
+
+func (in *UpstreamRewrite) DeepCopyInto(out *UpstreamRewrite) {
+	*out = *in
+
+	if in.PathRegex != nil {
+		in, out := &in.PathRegex, &out.PathRegex
+
+		*out = new(PathRegex)
+		**out = **in
+	}
+}
+
+func (in *UpstreamRewrite) DeepCopy() *UpstreamRewrite {
+	if in == nil {
+		return nil
+	}
+
+	out := new(UpstreamRewrite)
+	in.DeepCopyInto(out)
+
+	return out
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	type Typ struct {
+		Upstream *Upstream `json:"upstream"`
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, upstream *Upstream)
+	}{
+		{
+			uc:     "specified as string",
+			config: []byte(`{ "upstream": "http://foo.bar" }`),
+			assert: func(t *testing.T, err error, upstream *Upstream) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, &Upstream{Targets: []UpstreamTarget{{URL: "http://foo.bar"}}}, upstream)
+			},
+		},
+		{
+			uc: "specified as structured type",
+			config: []byte(`{
+"upstream": {
+  "targets": [{ "url": "http://foo.bar" }, { "url": "http://bar.foo", "weight": 3 }],
+  "load_balancing": "least_connections",
+  "health_check": { "active": { "path": "/health", "interval": "1m" } }
+}
+}`),
+			assert: func(t *testing.T, err error, upstream *Upstream) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, &Upstream{
+					Targets:       []UpstreamTarget{{URL: "http://foo.bar"}, {URL: "http://bar.foo", Weight: 3}},
+					LoadBalancing: LoadBalancingLeastConnections,
+					HealthCheck:   &HealthCheck{Active: &ActiveHealthCheck{Path: "/health", Interval: time.Minute}},
+				}, upstream)
+			},
+		},
+		{
+			uc:     "specified as structured type with invalid json structure",
+			config: []byte(`{ "upstream": { targets: [] } }`),
+			assert: func(t *testing.T, err error, upstream *Upstream) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid character")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			var typ Typ
+
+			// WHEN
+			err := json.Unmarshal(tc.config, &typ)
+
+			// THEN
+			tc.assert(t, err, typ.Upstream)
+		})
+	}
+}
+
+func TestUpstreamDeepCopy(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	in := &Upstream{
+		Targets:       []UpstreamTarget{{URL: "http://foo.bar", Weight: 2}},
+		LoadBalancing: LoadBalancingWeighted,
+		HealthCheck: &HealthCheck{
+			Passive: &PassiveHealthCheck{MaxFailures: 2},
+			Active:  &ActiveHealthCheck{Path: "/health"},
+		},
+	}
+
+	// WHEN
+	out := in.DeepCopy()
+
+	// THEN
+	assert.Equal(t, in, out)
+	assert.NotSame(t, &in.Targets[0], &out.Targets[0])
+	assert.NotSame(t, in.HealthCheck, out.HealthCheck)
+	assert.NotSame(t, in.HealthCheck.Passive, out.HealthCheck.Passive)
+	assert.NotSame(t, in.HealthCheck.Active, out.HealthCheck.Active)
+	assert.Nil(t, (*Upstream)(nil).DeepCopy())
+}
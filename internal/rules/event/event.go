@@ -44,6 +44,7 @@ func (t ChangeType) String() string {
 
 type RuleSetChanged struct {
 	Source     string
+	Revision   string
 	Name       string
 	Rules      []rule.Rule
 	ChangeType ChangeType
@@ -0,0 +1,131 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/rules/config"
+)
+
+// healthProber actively checks the health of upstream targets by periodically sending GET requests
+// to their health endpoints. Targets responding with a status code other than 2xx or 3xx, or not
+// responding at all, are considered unhealthy until a subsequent probe succeeds.
+type healthProber struct {
+	targets []*upstreamTarget
+	conf    config.ActiveHealthCheck
+	client  *http.Client
+	logger  zerolog.Logger
+
+	mutex  sync.Mutex
+	cancel context.CancelFunc
+}
+
+func (p *healthProber) start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	go p.run(ctx)
+}
+
+func (p *healthProber) stop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+}
+
+func (p *healthProber) run(ctx context.Context) {
+	ticker := time.NewTicker(p.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.probeAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *healthProber) probeAll(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, tgt := range p.targets {
+		wg.Add(1)
+
+		go func(tgt *upstreamTarget) {
+			defer wg.Done()
+
+			p.probe(ctx, tgt)
+		}(tgt)
+	}
+
+	wg.Wait()
+}
+
+func (p *healthProber) probe(ctx context.Context, tgt *upstreamTarget) {
+	healthy := p.isHealthy(ctx, &url.URL{Scheme: tgt.url.Scheme, Host: tgt.url.Host, Path: p.conf.Path})
+	if ctx.Err() != nil {
+		// the prober has been stopped
+		return
+	}
+
+	if wasUnhealthy := tgt.unhealthy.Swap(!healthy); wasUnhealthy == healthy {
+		p.logger.Info().
+			Str("_url", tgt.url.String()).
+			Bool("_healthy", healthy).
+			Msg("Health state of upstream target changed")
+	}
+}
+
+func (p *healthProber) isHealthy(ctx context.Context, probeURL *url.URL) bool {
+	ctx, cancel := context.WithTimeout(ctx, p.conf.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL.String(), nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false
+	}
+
+	defer resp.Body.Close()
+
+	return resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusBadRequest
+}
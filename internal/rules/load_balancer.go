@@ -0,0 +1,84 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type loadBalancer interface {
+	next(targets []*upstreamTarget) *upstreamTarget
+}
+
+type roundRobinBalancer struct {
+	counter atomic.Uint64
+}
+
+func (b *roundRobinBalancer) next(targets []*upstreamTarget) *upstreamTarget {
+	return targets[(b.counter.Add(1)-1)%uint64(len(targets))]
+}
+
+// weightedBalancer implements the smooth weighted round-robin algorithm. That way requests are
+// distributed according to the weights of the targets without sending bursts to a single one.
+type weightedBalancer struct {
+	mutex sync.Mutex
+}
+
+func (b *weightedBalancer) next(targets []*upstreamTarget) *upstreamTarget {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var (
+		selected *upstreamTarget
+		total    int
+	)
+
+	for _, tgt := range targets {
+		tgt.currentWeight += tgt.weight
+		total += tgt.weight
+
+		if selected == nil || tgt.currentWeight > selected.currentWeight {
+			selected = tgt
+		}
+	}
+
+	selected.currentWeight -= total
+
+	return selected
+}
+
+// leastConnectionsBalancer selects the target with the fewest requests in flight. Ties are resolved
+// in a round-robin manner.
+type leastConnectionsBalancer struct {
+	counter atomic.Uint64
+}
+
+func (b *leastConnectionsBalancer) next(targets []*upstreamTarget) *upstreamTarget {
+	count := uint64(len(targets))
+	offset := (b.counter.Add(1) - 1) % count
+	selected := targets[offset]
+
+	for i := uint64(1); i < count; i++ {
+		tgt := targets[(offset+i)%count]
+		if tgt.inFlight.Load() < selected.inFlight.Load() {
+			selected = tgt
+		}
+	}
+
+	return selected
+}
@@ -185,6 +185,7 @@ func TestAnonymousAuthenticatorExecute(t *testing.T) {
 	assert.Equal(t, subjectID, sub.ID)
 	assert.Empty(t, sub.Attributes)
 	assert.NotNil(t, sub.Attributes)
+	assert.True(t, sub.IsAnonymous())
 }
 
 func TestAnonymousAuthenticatorIsFallbackOnErrorAllowed(t *testing.T) {
@@ -0,0 +1,160 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"github.com/rs/zerolog"
+	"golang.org/x/exp/maps"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthenticatorTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authenticator, error) {
+			if typ != AuthenticatorAPIKey {
+				return false, nil, nil
+			}
+
+			auth, err := newAPIKeyAuthenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type apiKeyAuthenticator struct {
+	id                   string
+	ads                  extractors.AuthDataExtractStrategy
+	store                *apiKeyStore
+	allowFallbackOnError bool
+}
+
+func newAPIKeyAuthenticator(id string, rawConfig map[string]any) (*apiKeyAuthenticator, error) {
+	type KeyStore struct {
+		Path   string `mapstructure:"path"`
+		Pepper string `mapstructure:"pepper"`
+	}
+
+	type Config struct {
+		KeySource            extractors.CompositeExtractStrategy `mapstructure:"key_source"`
+		KeyStore             KeyStore                            `mapstructure:"key_store"`
+		AllowFallbackOnError bool                                `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode api_key authenticator config").
+			CausedBy(err)
+	}
+
+	if len(conf.KeyStore.Path) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "api_key authenticator requires key_store path to be set")
+	}
+
+	store, err := newAPIKeyStore(conf.KeyStore.Path, conf.KeyStore.Pepper)
+	if err != nil {
+		return nil, err
+	}
+
+	ads := x.IfThenElseExec(conf.KeySource == nil,
+		func() extractors.CompositeExtractStrategy {
+			return extractors.CompositeExtractStrategy{
+				extractors.HeaderValueExtractStrategy{Name: "X-API-Key"},
+			}
+		},
+		func() extractors.CompositeExtractStrategy { return conf.KeySource },
+	)
+
+	return &apiKeyAuthenticator{
+		id:                   id,
+		ads:                  ads,
+		store:                store,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *apiKeyAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authenticating using api_key authenticator")
+
+	apiKey, err := a.ads.GetAuthData(ctx)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "no api key present").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if err = a.store.reload(); err != nil {
+		logger.Warn().Err(err).Str("_id", a.id).
+			Msg("Failed to reload api key store. Using previously loaded keys")
+	}
+
+	entry, err := a.store.lookup(apiKey)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "invalid api key").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	// the attributes are copied, as these can be modified by the subsequent pipeline steps
+	return &subject.Subject{ID: entry.subjectID, Attributes: maps.Clone(entry.attributes)}, nil
+}
+
+func (a *apiKeyAuthenticator) WithConfig(config map[string]any) (Authenticator, error) {
+	// this authenticator allows only the fallback behavior to be redefined on the rule level
+	if len(config) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		AllowFallbackOnError *bool `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(config, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode api_key authenticator config").
+			CausedBy(err)
+	}
+
+	return &apiKeyAuthenticator{
+		id:    a.id,
+		ads:   a.ads,
+		store: a.store,
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *apiKeyAuthenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *apiKeyAuthenticator) HandlerID() string {
+	return a.id
+}
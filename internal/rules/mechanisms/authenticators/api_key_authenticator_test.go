@@ -0,0 +1,372 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func TestCreateAPIKeyAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	keyStorePath := filepath.Join(t.TempDir(), "keys.yaml")
+	writeFileAtomically(t, keyStorePath, `
+keys:
+  - hash: `+sha256HashOf("foo", "pepper")+`
+    subject:
+      id: foo
+`)
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *apiKeyAuthenticator)
+	}{
+		{
+			uc:     "with unsupported fields",
+			config: []byte("foo: bar"),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc:     "without key store",
+			config: []byte("allow_fallback_on_error: true"),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires key_store path")
+			},
+		},
+		{
+			uc: "with not existing key store",
+			config: []byte(`
+key_store:
+  path: /no/such/file.yaml
+`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to read")
+			},
+		},
+		{
+			uc: "with key store requiring a pepper, which is not configured",
+			config: []byte(`
+key_store:
+  path: ` + keyStorePath + `
+`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no pepper is configured")
+			},
+		},
+		{
+			uc: "minimal configuration",
+			id: "auth1",
+			config: []byte(`
+key_store:
+  path: ` + keyStorePath + `
+  pepper: pepper
+`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.HandlerID())
+				assert.Equal(t, extractors.CompositeExtractStrategy{
+					extractors.HeaderValueExtractStrategy{Name: "X-API-Key"},
+				}, auth.ads)
+				assert.NotNil(t, auth.store)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "full configuration",
+			id: "auth2",
+			config: []byte(`
+key_source:
+  - header: Authorization
+    schema: ApiKey
+  - query_parameter: api_key
+key_store:
+  path: ` + keyStorePath + `
+  pepper: pepper
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth2", auth.HandlerID())
+				assert.Equal(t, extractors.CompositeExtractStrategy{
+					&extractors.HeaderValueExtractStrategy{Name: "Authorization", Schema: "ApiKey"},
+					&extractors.QueryParameterExtractStrategy{Name: "api_key"},
+				}, auth.ads)
+				assert.NotNil(t, auth.store)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newAPIKeyAuthenticator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateAPIKeyAuthenticatorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	keyStorePath := filepath.Join(t.TempDir(), "keys.yaml")
+	writeFileAtomically(t, keyStorePath, `
+keys:
+  - id: foo
+    hash: `+argon2idHashOf("foo.secret")+`
+    subject:
+      id: foo
+`)
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator)
+	}{
+		{
+			uc: "no new configuration provided",
+			assert: func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "fallback on error redefined",
+			config: []byte("allow_fallback_on_error: true"),
+			assert: func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.ads, configured.ads)
+				assert.Same(t, prototype.store, configured.store)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "key store cannot be redefined",
+			config: []byte("key_store: { path: foo.yaml }"),
+			assert: func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			pc, err := testsupport.DecodeTestConfig([]byte("key_store: { path: " + keyStorePath + " }"))
+			require.NoError(t, err)
+
+			rc, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newAPIKeyAuthenticator("auth", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(rc)
+
+			// THEN
+			var configured *apiKeyAuthenticator
+			if err == nil {
+				configured, _ = auth.(*apiKeyAuthenticator)
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+func TestAPIKeyAuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	type HandlerIdentifier interface {
+		HandlerID() string
+	}
+
+	// GIVEN
+	keyStorePath := filepath.Join(t.TempDir(), "keys.yaml")
+	writeFileAtomically(t, keyStorePath, `
+keys:
+  - id: a
+    hash: `+argon2idHashOf("a.foo")+`
+    subject:
+      id: service-a
+      attributes:
+        team: a
+  - id: b
+    hash: `+bcryptHashOf(t, "b.bar")+`
+    subject:
+      id: service-b
+    expires_at: 2000-01-01T00:00:00Z
+  - hash: `+sha256HashOf("baz", "pepper")+`
+    subject:
+      id: service-c
+`)
+
+	conf, err := testsupport.DecodeTestConfig([]byte(`
+key_store:
+  path: ` + keyStorePath + `
+  pepper: pepper
+`))
+	require.NoError(t, err)
+
+	auth, err := newAPIKeyAuthenticator("auth", conf)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		apiKey string
+		assert func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc: "no api key present",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "no api key present")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "unknown api key",
+			apiKey: "qux",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+				assert.Contains(t, err.Error(), "invalid api key")
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "expired api key",
+			apiKey: "b.bar",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, ErrAPIKeyExpired)
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "valid api key with argon2id hash",
+			apiKey: "a.foo",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, "service-a", sub.ID)
+				assert.Equal(t, map[string]any{"team": "a"}, sub.Attributes)
+			},
+		},
+		{
+			uc:     "valid api key with sha256 hash",
+			apiKey: "baz",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, "service-c", sub.ID)
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			fnt := mocks.NewRequestFunctionsMock(t)
+			fnt.EXPECT().Header("X-API-Key").Return(tc.apiKey)
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: fnt})
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	hashPrefixSHA256 = "$sha256$"
+
+	// apiKeyIDSeparator separates the identifier of an api key from its secret part.
+	apiKeyIDSeparator = "."
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyExpired  = errors.New("api key expired")
+)
+
+type apiKeyEntry struct {
+	subjectID  string
+	attributes map[string]any
+	expiresAt  *time.Time
+}
+
+type identifiedAPIKey struct {
+	entry *apiKeyEntry
+	hash  secretHash
+}
+
+type apiKeys struct {
+	// keys hashed with a fast hash function are looked up directly by their hash. All others
+	// are selected by their identifier, so that a computationally expensive hash is verified
+	// for a single candidate only.
+	peppered   map[string]*apiKeyEntry
+	identified map[string]identifiedAPIKey
+}
+
+type apiKeyStore struct {
+	path    string
+	pepper  []byte
+	keys    *apiKeys
+	mut     sync.RWMutex
+	tracker *fileChangeTracker
+}
+
+func newAPIKeyStore(path string, pepper string) (*apiKeyStore, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to get the absolute path for the api key store").CausedBy(err)
+	}
+
+	store := &apiKeyStore{path: absPath, pepper: []byte(pepper)}
+
+	if store.keys, err = store.load(); err != nil {
+		return nil, err
+	}
+
+	if store.tracker, err = newFileChangeTracker(absPath); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// reload loads the api keys again if the underlying file has been changed. If the file cannot be
+// loaded, the previously loaded keys are kept and the reload is retried on next usage.
+func (s *apiKeyStore) reload() error {
+	generation, changed := s.tracker.changed()
+	if !changed {
+		return nil
+	}
+
+	keys, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	s.keys = keys
+	s.mut.Unlock()
+
+	s.tracker.ack(generation)
+
+	return nil
+}
+
+func (s *apiKeyStore) lookup(key string) (*apiKeyEntry, error) {
+	s.mut.RLock()
+	keys := s.keys
+	s.mut.RUnlock()
+
+	entry, ok := keys.peppered[s.pepperedHash(key)]
+	if !ok {
+		id, _, found := strings.Cut(key, apiKeyIDSeparator)
+		if candidate, known := keys.identified[id]; found && known && candidate.hash.matches(key) {
+			entry = candidate.entry
+		}
+	}
+
+	if entry == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	if entry.expiresAt != nil && entry.expiresAt.Before(time.Now()) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	return entry, nil
+}
+
+func (s *apiKeyStore) pepperedHash(key string) string {
+	md := hmac.New(sha256.New, s.pepper)
+	md.Write([]byte(key))
+
+	return hex.EncodeToString(md.Sum(nil))
+}
+
+func (s *apiKeyStore) load() (*apiKeys, error) {
+	type Subject struct {
+		ID         string         `yaml:"id"`
+		Attributes map[string]any `yaml:"attributes"`
+	}
+
+	type Key struct {
+		ID        string     `yaml:"id"`
+		Hash      string     `yaml:"hash"`
+		Subject   Subject    `yaml:"subject"`
+		ExpiresAt *time.Time `yaml:"expires_at"`
+	}
+
+	type KeyStore struct {
+		Keys []Key `yaml:"keys"`
+	}
+
+	contents, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"failed to read api key store %s", s.path).CausedBy(err)
+	}
+
+	var ks KeyStore
+	if err = yaml.Unmarshal(contents, &ks); err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"failed to parse api key store %s", s.path).CausedBy(err)
+	}
+
+	keys := &apiKeys{
+		peppered:   make(map[string]*apiKeyEntry),
+		identified: make(map[string]identifiedAPIKey),
+	}
+
+	for idx, key := range ks.Keys {
+		if len(key.Subject.ID) == 0 {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"api key entry %d has no subject id", idx)
+		}
+
+		entry := &apiKeyEntry{
+			subjectID:  key.Subject.ID,
+			attributes: key.Subject.Attributes,
+			expiresAt:  key.ExpiresAt,
+		}
+
+		if entry.attributes == nil {
+			entry.attributes = make(map[string]any)
+		}
+
+		if strings.HasPrefix(key.Hash, hashPrefixSHA256) {
+			if len(s.pepper) == 0 {
+				return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+					"api key entry %d uses a sha256 hash, but no pepper is configured", idx)
+			}
+
+			hash := strings.ToLower(strings.TrimPrefix(key.Hash, hashPrefixSHA256))
+			if _, known := keys.peppered[hash]; known {
+				return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+					"api key entry %d is a duplicate", idx)
+			}
+
+			keys.peppered[hash] = entry
+
+			continue
+		}
+
+		if err = keys.addIdentified(idx, key.ID, key.Hash, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+func (k *apiKeys) addIdentified(idx int, id, value string, entry *apiKeyEntry) error {
+	hash, err := parseAPIKeyHash(value)
+	if err != nil {
+		return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"api key entry %d has an unsupported hash", idx).CausedBy(err)
+	}
+
+	if len(id) == 0 || strings.Contains(id, apiKeyIDSeparator) {
+		return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"api key entry %d requires an id without '%s' for its hash type", idx, apiKeyIDSeparator)
+	}
+
+	if _, known := k.identified[id]; known {
+		return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"api key entry %d reuses the id %s", idx, id)
+	}
+
+	k.identified[id] = identifiedAPIKey{entry: entry, hash: hash}
+
+	return nil
+}
+
+func parseAPIKeyHash(value string) (secretHash, error) {
+	if strings.HasPrefix(value, hashPrefixArgon2id) {
+		return parseArgon2idHash(value)
+	}
+
+	if _, err := bcrypt.Cost([]byte(value)); err == nil {
+		return bcryptHash(value), nil
+	}
+
+	return nil, errorchain.NewWithMessage(heimdall.ErrArgument,
+		"only argon2id, bcrypt and sha256 hashes are supported")
+}
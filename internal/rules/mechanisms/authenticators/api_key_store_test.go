@@ -0,0 +1,356 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+func argon2idHashOf(key string) string {
+	salt := []byte("0123456789abcdef")
+	hash := argon2.IDKey([]byte(key), salt, 1, 1024, 1, 32)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=1024,t=1,p=1$%s$%s", argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash))
+}
+
+func bcryptHashOf(t *testing.T, key string) string {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(key), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	return string(hash)
+}
+
+func sha256HashOf(key, pepper string) string {
+	md := hmac.New(sha256.New, []byte(pepper))
+	md.Write([]byte(key))
+
+	return "$sha256$" + hex.EncodeToString(md.Sum(nil))
+}
+
+func writeFileAtomically(t *testing.T, path, contents string) {
+	t.Helper()
+
+	// atomic replacement, as done by editors and kubelet
+	tmpFile := filepath.Join(filepath.Dir(path), ".tmp-"+filepath.Base(path))
+
+	require.NoError(t, os.WriteFile(tmpFile, []byte(contents), 0o600))
+	require.NoError(t, os.Rename(tmpFile, path))
+}
+
+func TestAPIKeyStoreLoad(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		pepper   string
+		contents string
+		assert   func(t *testing.T, err error, store *apiKeyStore)
+	}{
+		{
+			uc:       "malformed contents",
+			contents: "foo",
+			assert: func(t *testing.T, err error, _ *apiKeyStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to parse")
+			},
+		},
+		{
+			uc: "entry without subject id",
+			contents: `
+keys:
+  - hash: ` + argon2idHashOf("foo"),
+			assert: func(t *testing.T, err error, _ *apiKeyStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "entry 0 has no subject id")
+			},
+		},
+		{
+			uc: "entry with unsupported hash",
+			contents: `
+keys:
+  - hash: foo
+    subject:
+      id: bar
+`,
+			assert: func(t *testing.T, err error, _ *apiKeyStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "entry 0 has an unsupported hash")
+			},
+		},
+		{
+			uc: "argon2id entry without id",
+			contents: `
+keys:
+  - hash: ` + argon2idHashOf("foo") + `
+    subject:
+      id: bar
+`,
+			assert: func(t *testing.T, err error, _ *apiKeyStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "entry 0 requires an id")
+			},
+		},
+		{
+			uc: "bcrypt entry with id containing the separator",
+			contents: `
+keys:
+  - id: foo.bar
+    hash: ` + bcryptHashOf(t, "foo.bar.baz") + `
+    subject:
+      id: bar
+`,
+			assert: func(t *testing.T, err error, _ *apiKeyStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "entry 0 requires an id")
+			},
+		},
+		{
+			uc: "entries with the same id",
+			contents: `
+keys:
+  - id: foo
+    hash: ` + argon2idHashOf("foo.bar") + `
+    subject:
+      id: bar
+  - id: foo
+    hash: ` + argon2idHashOf("foo.baz") + `
+    subject:
+      id: baz
+`,
+			assert: func(t *testing.T, err error, _ *apiKeyStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "entry 1 reuses the id foo")
+			},
+		},
+		{
+			uc: "entry with malformed argon2id hash",
+			contents: `
+keys:
+  - hash: $argon2id$v=19$m=foo$bar$baz
+    subject:
+      id: bar
+`,
+			assert: func(t *testing.T, err error, _ *apiKeyStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "malformed argon2id parameters")
+			},
+		},
+		{
+			uc: "entry with sha256 hash without configured pepper",
+			contents: `
+keys:
+  - hash: ` + sha256HashOf("foo", "bar") + `
+    subject:
+      id: bar
+`,
+			assert: func(t *testing.T, err error, _ *apiKeyStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no pepper is configured")
+			},
+		},
+		{
+			uc:     "duplicate sha256 hashes",
+			pepper: "pepper",
+			contents: `
+keys:
+  - hash: ` + sha256HashOf("foo", "pepper") + `
+    subject:
+      id: bar
+  - hash: ` + sha256HashOf("foo", "pepper") + `
+    subject:
+      id: baz
+`,
+			assert: func(t *testing.T, err error, _ *apiKeyStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "entry 1 is a duplicate")
+			},
+		},
+		{
+			uc:     "all supported hashes",
+			pepper: "pepper",
+			contents: `
+keys:
+  - id: k1
+    hash: ` + argon2idHashOf("k1.foo") + `
+    subject:
+      id: foo
+      attributes:
+        team: a
+  - id: k2
+    hash: ` + bcryptHashOf(t, "k2.bar") + `
+    subject:
+      id: bar
+    expires_at: 2000-01-01T00:00:00Z
+  - hash: ` + sha256HashOf("baz", "pepper") + `
+    subject:
+      id: baz
+`,
+			assert: func(t *testing.T, err error, store *apiKeyStore) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				entry, err := store.lookup("k1.foo")
+				require.NoError(t, err)
+				assert.Equal(t, "foo", entry.subjectID)
+				assert.Equal(t, map[string]any{"team": "a"}, entry.attributes)
+
+				_, err = store.lookup("k2.bar")
+				require.ErrorIs(t, err, ErrAPIKeyExpired)
+
+				// the identifier selects the entry to verify the key against
+				_, err = store.lookup("k2.foo")
+				require.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+				entry, err = store.lookup("baz")
+				require.NoError(t, err)
+				assert.Equal(t, "baz", entry.subjectID)
+				assert.Empty(t, entry.attributes)
+
+				_, err = store.lookup("qux")
+				require.ErrorIs(t, err, ErrAPIKeyNotFound)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			path := filepath.Join(t.TempDir(), "keys.yaml")
+			writeFileAtomically(t, path, tc.contents)
+
+			// WHEN
+			store, err := newAPIKeyStore(path, tc.pepper)
+
+			// THEN
+			tc.assert(t, err, store)
+		})
+	}
+}
+
+func TestAPIKeyStoreReload(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	writeFileAtomically(t, path, `
+keys:
+  - id: k
+    hash: `+argon2idHashOf("k.foo")+`
+    subject:
+      id: foo
+`)
+
+	store, err := newAPIKeyStore(path, "")
+	require.NoError(t, err)
+
+	_, err = store.lookup("k.foo")
+	require.NoError(t, err)
+
+	// WHEN
+	writeFileAtomically(t, path, `
+keys:
+  - id: k
+    hash: `+argon2idHashOf("k.bar")+`
+    subject:
+      id: bar
+`)
+
+	// THEN
+	require.Eventually(t, changeReported(store.tracker), 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, store.reload())
+
+	_, err = store.lookup("k.foo")
+	require.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	entry, err := store.lookup("k.bar")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", entry.subjectID)
+
+	// WHEN
+	writeFileAtomically(t, path, "foo")
+
+	// THEN
+	require.Eventually(t, changeReported(store.tracker), 2*time.Second, 10*time.Millisecond)
+	require.Error(t, store.reload())
+
+	// previously loaded keys are kept
+	_, err = store.lookup("k.bar")
+	require.NoError(t, err)
+
+	// the failed reload is retried
+	assert.True(t, changeReported(store.tracker)())
+
+	// WHEN
+	writeFileAtomically(t, path, `
+keys:
+  - id: k
+    hash: `+argon2idHashOf("k.baz")+`
+    subject:
+      id: baz
+`)
+
+	// THEN
+	require.NoError(t, store.reload())
+
+	entry, err = store.lookup("k.baz")
+	require.NoError(t, err)
+	assert.Equal(t, "baz", entry.subjectID)
+}
@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	"github.com/rs/zerolog"
+	"golang.org/x/exp/maps"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/authenticators/extractors"
@@ -55,14 +56,18 @@ type basicAuthAuthenticator struct {
 	id                   string
 	userID               string
 	password             string
+	htpasswd             *htpasswdStore
+	userAttributes       map[string]map[string]any
 	allowFallbackOnError bool
 }
 
 func newBasicAuthAuthenticator(id string, rawConfig map[string]any) (*basicAuthAuthenticator, error) {
 	type Config struct {
-		UserID               string `mapstructure:"user_id"`
-		Password             string `mapstructure:"password"`
-		AllowFallbackOnError bool   `mapstructure:"allow_fallback_on_error"`
+		UserID               string                    `mapstructure:"user_id"`
+		Password             string                    `mapstructure:"password"`
+		HtpasswdFile         string                    `mapstructure:"htpasswd_file"`
+		UserAttributes       map[string]map[string]any `mapstructure:"user_attributes"`
+		AllowFallbackOnError bool                      `mapstructure:"allow_fallback_on_error"`
 	}
 
 	var conf Config
@@ -73,21 +78,37 @@ func newBasicAuthAuthenticator(id string, rawConfig map[string]any) (*basicAuthA
 			CausedBy(err)
 	}
 
-	if len(conf.UserID) == 0 {
-		return nil, errorchain.
-			NewWithMessagef(heimdall.ErrConfiguration, "basic_auth authenticator requires user_id to be set")
-	}
+	if len(conf.HtpasswdFile) == 0 || len(conf.UserID) != 0 || len(conf.Password) != 0 {
+		if len(conf.UserID) == 0 {
+			return nil, errorchain.
+				NewWithMessagef(heimdall.ErrConfiguration, "basic_auth authenticator requires user_id to be set")
+		}
 
-	if len(conf.Password) == 0 {
-		return nil, errorchain.
-			NewWithMessagef(heimdall.ErrConfiguration, "basic_auth authenticator requires password to be set")
+		if len(conf.Password) == 0 {
+			return nil, errorchain.
+				NewWithMessagef(heimdall.ErrConfiguration, "basic_auth authenticator requires password to be set")
+		}
 	}
 
 	auth := basicAuthAuthenticator{
 		id:                   id,
+		userAttributes:       conf.UserAttributes,
 		allowFallbackOnError: conf.AllowFallbackOnError,
 	}
 
+	if len(conf.HtpasswdFile) != 0 {
+		store, err := newHtpasswdStore(conf.HtpasswdFile)
+		if err != nil {
+			return nil, err
+		}
+
+		auth.htpasswd = store
+	}
+
+	if len(conf.UserID) == 0 {
+		return &auth, nil
+	}
+
 	// rewrite user id and password as hashes to mitigate potential side-channel attacks
 	// during credentials check
 	md := sha256.New()
@@ -140,13 +161,32 @@ func (a *basicAuthAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject
 	userIDOK := userID == a.userID
 	passwordOK := password == a.password
 
-	if !(userIDOK && passwordOK) {
+	if !(userIDOK && passwordOK) && !a.verifyHtpasswd(ctx, userIDAndPassword[0], userIDAndPassword[1]) {
 		return nil, errorchain.
 			NewWithMessage(heimdall.ErrAuthentication, "invalid user credentials").
 			WithErrorContext(a)
 	}
 
-	return &subject.Subject{ID: userIDAndPassword[0], Attributes: make(map[string]any)}, nil
+	attributes, ok := a.userAttributes[userIDAndPassword[0]]
+	if !ok {
+		attributes = make(map[string]any)
+	}
+
+	// the attributes are copied, as these can be modified by the subsequent pipeline steps
+	return &subject.Subject{ID: userIDAndPassword[0], Attributes: maps.Clone(attributes)}, nil
+}
+
+func (a *basicAuthAuthenticator) verifyHtpasswd(ctx heimdall.Context, userID, password string) bool {
+	if a.htpasswd == nil {
+		return false
+	}
+
+	if err := a.htpasswd.reload(); err != nil {
+		zerolog.Ctx(ctx.AppContext()).Warn().Err(err).Str("_id", a.id).
+			Msg("Failed to reload htpasswd file. Using previously loaded entries")
+	}
+
+	return a.htpasswd.verify(userID, password)
 }
 
 func (a *basicAuthAuthenticator) WithConfig(rawConfig map[string]any) (Authenticator, error) {
@@ -169,6 +209,14 @@ func (a *basicAuthAuthenticator) WithConfig(rawConfig map[string]any) (Authentic
 			CausedBy(err)
 	}
 
+	// redefined credentials are the only ones accepted by the rule. The entries of the htpasswd
+	// file are thus not considered and the prototype must not leave any of the credentials undefined
+	redefined := len(conf.UserID) != 0 || len(conf.Password) != 0
+	if redefined && len(a.userID) == 0 && (len(conf.UserID) == 0 || len(conf.Password) == 0) {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"basic_auth authenticator requires both, user_id and password to be set")
+	}
+
 	return &basicAuthAuthenticator{
 		id: a.id,
 		userID: x.IfThenElseExec(len(conf.UserID) != 0,
@@ -189,6 +237,8 @@ func (a *basicAuthAuthenticator) WithConfig(rawConfig map[string]any) (Authentic
 			}, func() string {
 				return a.password
 			}),
+		htpasswd:       x.IfThenElse(redefined, nil, a.htpasswd),
+		userAttributes: a.userAttributes,
 		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
 			func() bool { return *conf.AllowFallbackOnError },
 			func() bool { return a.allowFallbackOnError }),
@@ -22,6 +22,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,6 +37,9 @@ import (
 func TestCreateBasicAuthAuthenticator(t *testing.T) {
 	t.Parallel()
 
+	htpasswdFile := filepath.Join(t.TempDir(), "htpasswd")
+	writeFileAtomically(t, htpasswdFile, "bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=")
+
 	for _, tc := range []struct {
 		uc     string
 		id     string
@@ -120,6 +124,72 @@ user_id: foo`),
 				assert.Nil(t, auth)
 			},
 		},
+		{
+			uc: "without user_id, password and htpasswd_file",
+			config: []byte(`
+allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires user_id")
+
+				assert.Nil(t, auth)
+			},
+		},
+		{
+			uc: "with htpasswd_file and without password",
+			config: []byte(`
+user_id: foo
+htpasswd_file: ` + htpasswdFile),
+			assert: func(t *testing.T, err error, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires password")
+
+				assert.Nil(t, auth)
+			},
+		},
+		{
+			uc: "with not existing htpasswd_file",
+			config: []byte(`
+htpasswd_file: /no/such/file`),
+			assert: func(t *testing.T, err error, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to read htpasswd file")
+
+				assert.Nil(t, auth)
+			},
+		},
+		{
+			uc: "valid configuration with htpasswd_file and user attributes",
+			id: "auth1",
+			config: []byte(`
+htpasswd_file: ` + htpasswdFile + `
+user_attributes:
+  bob:
+    group: admin
+`),
+			assert: func(t *testing.T, err error, auth *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Empty(t, auth.userID)
+				assert.Empty(t, auth.password)
+				require.NotNil(t, auth.htpasswd)
+				assert.True(t, auth.htpasswd.verify("bob", "password"))
+				assert.Equal(t, map[string]map[string]any{"bob": {"group": "admin"}}, auth.userAttributes)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+				assert.Equal(t, "auth1", auth.HandlerID())
+			},
+		},
 		{
 			uc: "with unexpected config attribute",
 			config: []byte(`
@@ -152,6 +222,9 @@ foo: bar`),
 func TestCreateBasicAuthAuthenticatorFromPrototype(t *testing.T) {
 	t.Parallel()
 
+	htpasswdFile := filepath.Join(t.TempDir(), "htpasswd")
+	writeFileAtomically(t, htpasswdFile, "bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=")
+
 	for _, tc := range []struct {
 		uc              string
 		id              string
@@ -303,6 +376,59 @@ password: baz`),
 				assert.Equal(t, value, configured.password)
 			},
 		},
+		{
+			uc: "only fallback on error redefined for prototype with htpasswd_file",
+			id: "auth2",
+			prototypeConfig: []byte(`
+htpasswd_file: ` + htpasswdFile),
+			config: []byte(`
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, prototype *basicAuthAuthenticator, configured *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+				require.NotNil(t, configured.htpasswd)
+				assert.Equal(t, prototype.htpasswd, configured.htpasswd)
+			},
+		},
+		{
+			uc: "user_id and password redefined for prototype with htpasswd_file",
+			id: "auth2",
+			prototypeConfig: []byte(`
+user_id: foo
+password: bar
+htpasswd_file: ` + htpasswdFile),
+			config: []byte(`
+user_id: baz
+password: baz`),
+			assert: func(t *testing.T, err error, prototype *basicAuthAuthenticator, configured *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, prototype.htpasswd)
+				// the redefined credentials are the only accepted ones
+				assert.Nil(t, configured.htpasswd)
+				assert.NotEqual(t, prototype.userID, configured.userID)
+				assert.NotEqual(t, prototype.password, configured.password)
+			},
+		},
+		{
+			uc: "only password redefined for prototype with htpasswd_file only",
+			id: "auth2",
+			prototypeConfig: []byte(`
+htpasswd_file: ` + htpasswdFile),
+			config: []byte(`
+password: baz`),
+			assert: func(t *testing.T, err error, _ *basicAuthAuthenticator, _ *basicAuthAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires both, user_id and password")
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			pc, err := testsupport.DecodeTestConfig(tc.prototypeConfig)
@@ -318,8 +444,14 @@ password: baz`),
 			auth, err := prototype.WithConfig(conf)
 
 			// THEN
-			baa, ok := auth.(*basicAuthAuthenticator)
-			require.True(t, ok)
+			var baa *basicAuthAuthenticator
+
+			if err == nil {
+				var ok bool
+
+				baa, ok = auth.(*basicAuthAuthenticator)
+				require.True(t, ok)
+			}
 
 			tc.assert(t, err, prototype, baa)
 		})
@@ -517,3 +649,102 @@ password: bar`))
 		})
 	}
 }
+
+func TestBasicAuthAuthenticatorExecuteWithHtpasswdFile(t *testing.T) {
+	t.Parallel()
+
+	htpasswdFile := filepath.Join(t.TempDir(), "htpasswd")
+	writeFileAtomically(t, htpasswdFile, `
+bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=
+carol:$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE1
+`)
+
+	conf, err := testsupport.DecodeTestConfig([]byte(`
+user_id: foo
+password: bar
+htpasswd_file: ` + htpasswdFile + `
+user_attributes:
+  bob:
+    group: admin
+`))
+	require.NoError(t, err)
+
+	auth, err := newBasicAuthAuthenticator("auth", conf)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc          string
+		credentials string
+		assert      func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:          "invalid password for user from htpasswd file",
+			credentials: "bob:foo",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "invalid user credentials")
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:          "valid credentials of the configured user",
+			credentials: "foo:bar",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, "foo", sub.ID)
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+		{
+			uc:          "valid credentials of a user with attributes from htpasswd file",
+			credentials: "bob:password",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, "bob", sub.ID)
+				assert.Equal(t, map[string]any{"group": "admin"}, sub.Attributes)
+			},
+		},
+		{
+			uc:          "valid credentials of a user without attributes from htpasswd file",
+			credentials: "carol:password",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, "carol", sub.ID)
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			fnt := mocks.NewRequestFunctionsMock(t)
+			fnt.EXPECT().Header("Authorization").
+				Return("Basic " + base64.StdEncoding.EncodeToString([]byte(tc.credentials)))
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: fnt})
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+		})
+	}
+}
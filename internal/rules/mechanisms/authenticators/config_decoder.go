@@ -17,6 +17,8 @@
 package authenticators
 
 import (
+	"reflect"
+
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/dadrus/heimdall/internal/endpoint"
@@ -29,7 +31,7 @@ import (
 func decodeConfig(input any, output any) error {
 	dec, err := mapstructure.NewDecoder(
 		&mapstructure.DecoderConfig{
-			DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			DecodeHook: keepUntypedValues(mapstructure.ComposeDecodeHookFunc(
 				endpoint.DecodeAuthenticationStrategyHookFunc(),
 				endpoint.DecodeEndpointHookFunc(),
 				mapstructure.StringToTimeDurationHookFunc(),
@@ -37,7 +39,7 @@ func decodeConfig(input any, output any) error {
 				oauth2.DecodeScopesMatcherHookFunc(),
 				truststore.DecodeTrustStoreHookFunc(),
 				template.DecodeTemplateHookFunc(),
-			),
+			)),
 			Result:      output,
 			ErrorUnused: true,
 		})
@@ -47,3 +49,15 @@ func decodeConfig(input any, output any) error {
 
 	return dec.Decode(input)
 }
+
+// keepUntypedValues prevents the given hook from converting values, which are decoded into untyped
+// targets, like the attributes of a subject. Otherwise, e.g. each string would become an endpoint.
+func keepUntypedValues(hook mapstructure.DecodeHookFunc) mapstructure.DecodeHookFuncValue {
+	return func(from reflect.Value, to reflect.Value) (any, error) {
+		if to.Kind() == reflect.Interface && to.Type().NumMethod() == 0 {
+			return from.Interface(), nil
+		}
+
+		return mapstructure.DecodeHookExec(hook, from, to)
+	}
+}
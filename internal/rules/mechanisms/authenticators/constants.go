@@ -24,4 +24,6 @@ const (
 	AuthenticatorOAuth2Introspection = "oauth2_introspection"
 	AuthenticatorJwt                 = "jwt"
 	AuthenticatorGeneric             = "generic"
+	AuthenticatorX509                = "x509"
+	AuthenticatorAPIKey              = "api_key"
 )
@@ -0,0 +1,166 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// kubeletDataDir is the symlink atomically replaced by kubelet on updates of ConfigMap and Secret
+// mounts. The mounted files themselves are symlinks pointing into it and do not receive any events.
+const kubeletDataDir = "..data"
+
+// fileWatcher is shared by all trackers, so that the number of inotify instances and goroutines
+// does not grow with the number of authenticators (and rules) referencing the same files.
+var fileWatcher = &sharedFileWatcher{files: make(map[string]*watchedFile)} // nolint: gochecknoglobals
+
+type watchedFile struct {
+	generation atomic.Uint64
+}
+
+type sharedFileWatcher struct {
+	mut     sync.Mutex
+	watcher *fsnotify.Watcher
+	dirs    map[string]bool
+	files   map[string]*watchedFile
+}
+
+func (w *sharedFileWatcher) watch(path string) (*watchedFile, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	if file, ok := w.files[path]; ok {
+		return file, nil
+	}
+
+	if w.watcher == nil {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+				"failed to instantiate file watcher").CausedBy(err)
+		}
+
+		w.watcher = watcher
+		w.dirs = make(map[string]bool)
+
+		go w.dispatch(watcher)
+	}
+
+	// the file is watched via its directory. Otherwise, atomic replacements of that file, like
+	// done by editors, or by kubelet for ConfigMap and Secret mounts, would end the watch.
+	dir := filepath.Dir(path)
+	if !w.dirs[dir] {
+		if err := w.watcher.Add(dir); err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"failed to watch %s", path).CausedBy(err)
+		}
+
+		w.dirs[dir] = true
+	}
+
+	file := &watchedFile{}
+	w.files[path] = file
+
+	return file, nil
+}
+
+func (w *sharedFileWatcher) dispatch(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			w.changed(filepath.Clean(event.Name))
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func (w *sharedFileWatcher) changed(name string) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	if filepath.Base(name) == kubeletDataDir {
+		// all files in that directory might have been changed
+		dir := filepath.Dir(name)
+
+		for path, file := range w.files {
+			if filepath.Dir(path) == dir {
+				file.generation.Add(1)
+			}
+		}
+
+		return
+	}
+
+	// events for other files in the same directory are not of interest
+	if file, ok := w.files[name]; ok {
+		file.generation.Add(1)
+	}
+}
+
+// fileChangeTracker tracks changes of a file used by an authenticator. It only marks the file as
+// changed. The actual reload is up to the authenticator and happens on next usage, so that
+// failures can be logged in the context of the request. A change is reported until the reload
+// has been acknowledged, so that a failed reload, e.g. of a partially written file, is retried.
+type fileChangeTracker struct {
+	file *watchedFile
+	seen atomic.Uint64
+}
+
+func newFileChangeTracker(path string) (*fileChangeTracker, error) {
+	file, err := fileWatcher.watch(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := &fileChangeTracker{file: file}
+	tracker.seen.Store(file.generation.Load())
+
+	return tracker, nil
+}
+
+// changed reports whether the file has been changed since the last acknowledged reload. If so,
+// it returns the generation of the file, which must be passed to ack after a successful reload.
+func (t *fileChangeTracker) changed() (uint64, bool) {
+	current := t.file.generation.Load()
+
+	return current, t.seen.Load() != current
+}
+
+// ack marks the given generation of the file as loaded. Acknowledging an older generation than
+// the one already acknowledged, e.g. by a concurrent reload, has no effect.
+func (t *fileChangeTracker) ack(generation uint64) {
+	for {
+		seen := t.seen.Load()
+		if seen >= generation || t.seen.CompareAndSwap(seen, generation) {
+			return
+		}
+	}
+}
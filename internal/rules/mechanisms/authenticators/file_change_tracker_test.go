@@ -0,0 +1,151 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changeReported returns a function reporting whether a change is pending.
+func changeReported(tracker *fileChangeTracker) func() bool {
+	return func() bool {
+		_, changed := tracker.changed()
+
+		return changed
+	}
+}
+
+func TestFileChangeTrackerIgnoresOtherFiles(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tracked")
+	require.NoError(t, os.WriteFile(path, []byte("foo"), 0o600))
+
+	tracker, err := newFileChangeTracker(path)
+	require.NoError(t, err)
+
+	// WHEN
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other"), []byte("foo"), 0o600))
+
+	// THEN
+	require.Never(t, changeReported(tracker), 200*time.Millisecond, 10*time.Millisecond)
+
+	// WHEN
+	writeFileAtomically(t, path, "bar")
+
+	// THEN
+	require.Eventually(t, changeReported(tracker), 2*time.Second, 10*time.Millisecond)
+}
+
+func TestFileChangeTrackerReportsChangesUntilAcknowledged(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "tracked")
+	require.NoError(t, os.WriteFile(path, []byte("foo"), 0o600))
+
+	tracker, err := newFileChangeTracker(path)
+	require.NoError(t, err)
+
+	// WHEN
+	writeFileAtomically(t, path, "bar")
+
+	// THEN
+	require.Eventually(t, changeReported(tracker), 2*time.Second, 10*time.Millisecond)
+
+	generation, changed := tracker.changed()
+	assert.True(t, changed)
+
+	// a not acknowledged change, e.g. due to a failed reload, is reported again
+	_, changed = tracker.changed()
+	assert.True(t, changed)
+
+	// WHEN
+	tracker.ack(generation)
+
+	// THEN
+	_, changed = tracker.changed()
+	assert.False(t, changed)
+
+	// acknowledging an older generation does not report the change again
+	tracker.ack(generation - 1)
+
+	_, changed = tracker.changed()
+	assert.False(t, changed)
+}
+
+func TestFileChangeTrackerSharesWatchedFiles(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "tracked")
+	require.NoError(t, os.WriteFile(path, []byte("foo"), 0o600))
+
+	tracker1, err := newFileChangeTracker(path)
+	require.NoError(t, err)
+
+	tracker2, err := newFileChangeTracker(path)
+	require.NoError(t, err)
+
+	// WHEN
+	require.NoError(t, os.WriteFile(path, []byte("bar"), 0o600))
+
+	// THEN
+	assert.Same(t, tracker1.file, tracker2.file)
+	require.Eventually(t, changeReported(tracker1), 2*time.Second, 10*time.Millisecond)
+	require.Eventually(t, changeReported(tracker2), 2*time.Second, 10*time.Millisecond)
+
+	// acknowledging a change by one tracker does not affect the other one
+	generation, _ := tracker1.changed()
+	tracker1.ack(generation)
+
+	assert.False(t, changeReported(tracker1)())
+	assert.True(t, changeReported(tracker2)())
+}
+
+func TestFileChangeTrackerFollowsKubeletUpdates(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tracked")
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "v1"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "v1", "tracked"), []byte("foo"), 0o600))
+	require.NoError(t, os.Symlink("v1", filepath.Join(dir, kubeletDataDir)))
+	require.NoError(t, os.Symlink(filepath.Join(kubeletDataDir, "tracked"), path))
+
+	tracker, err := newFileChangeTracker(path)
+	require.NoError(t, err)
+
+	// WHEN
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "v2"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "v2", "tracked"), []byte("bar"), 0o600))
+	require.NoError(t, os.Symlink("v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, kubeletDataDir)))
+
+	// THEN
+	require.Eventually(t, changeReported(tracker), 2*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,303 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"bufio"
+	"bytes"
+	// used for the apr1 and sha htpasswd entries only, which are supported for compatibility reasons
+	// nolint: gosec
+	"crypto/md5"
+	// nolint: gosec
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	htpasswdPrefixAPR1 = "$apr1$"
+	htpasswdPrefixSHA  = "{SHA}"
+
+	apr1Rounds   = 1000
+	apr1Alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+//nolint:gochecknoglobals
+var (
+	dummyHashOnce sync.Once
+	dummyHash     secretHash
+)
+
+// htpasswdDummyHash returns the hash used to verify the passwords of unknown users, so that response
+// times do not reveal, whether a user exists. It is a bcrypt hash created with the default cost, as
+// the hashes of the entries should be bcrypt ones, and the other supported ones are much faster.
+func htpasswdDummyHash() secretHash {
+	dummyHashOnce.Do(func() {
+		hash, _ := bcrypt.GenerateFromPassword([]byte("heimdall"), bcrypt.DefaultCost)
+		dummyHash = bcryptHash(hash)
+	})
+
+	return dummyHash
+}
+
+type htpasswdUsers struct {
+	hashes map[string]secretHash
+}
+
+type htpasswdStore struct {
+	path    string
+	users   *htpasswdUsers
+	mut     sync.RWMutex
+	tracker *fileChangeTracker
+}
+
+func newHtpasswdStore(path string) (*htpasswdStore, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to get the absolute path for the htpasswd file").CausedBy(err)
+	}
+
+	store := &htpasswdStore{path: absPath}
+
+	// created upfront to not have the first request of an unknown user take longer
+	htpasswdDummyHash()
+
+	if store.users, err = store.load(); err != nil {
+		return nil, err
+	}
+
+	if store.tracker, err = newFileChangeTracker(absPath); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// reload loads the htpasswd file again if it has been changed. If the file cannot be
+// loaded, the previously loaded entries are kept and the reload is retried on next usage.
+func (s *htpasswdStore) reload() error {
+	generation, changed := s.tracker.changed()
+	if !changed {
+		return nil
+	}
+
+	users, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	s.users = users
+	s.mut.Unlock()
+
+	s.tracker.ack(generation)
+
+	return nil
+}
+
+func (s *htpasswdStore) verify(userID, password string) bool {
+	s.mut.RLock()
+	users := s.users
+	s.mut.RUnlock()
+
+	hash, ok := users.hashes[userID]
+	if !ok {
+		hash = htpasswdDummyHash()
+	}
+
+	matches := hash.matches(password)
+
+	return ok && matches
+}
+
+func (s *htpasswdStore) load() (*htpasswdUsers, error) {
+	contents, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"failed to read htpasswd file %s", s.path).CausedBy(err)
+	}
+
+	users := &htpasswdUsers{hashes: make(map[string]secretHash)}
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		userID, value, ok := strings.Cut(line, ":")
+		if !ok || len(userID) == 0 {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"malformed entry in line %d of the htpasswd file", lineNo)
+		}
+
+		hash, err := parseHtpasswdHash(value)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"unsupported hash for user %s in the htpasswd file", userID).CausedBy(err)
+		}
+
+		users.hashes[userID] = hash
+	}
+
+	return users, nil
+}
+
+func parseHtpasswdHash(value string) (secretHash, error) {
+	switch {
+	case strings.HasPrefix(value, htpasswdPrefixSHA):
+		hash, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, htpasswdPrefixSHA))
+		if err != nil || len(hash) != sha1.Size {
+			return nil, errorchain.NewWithMessage(heimdall.ErrArgument, "malformed sha hash")
+		}
+
+		return shaHash(hash), nil
+	case strings.HasPrefix(value, htpasswdPrefixAPR1):
+		salt, _, ok := strings.Cut(strings.TrimPrefix(value, htpasswdPrefixAPR1), "$")
+		if !ok {
+			return nil, errorchain.NewWithMessage(heimdall.ErrArgument, "malformed apr1 hash")
+		}
+
+		return &apr1Hash{salt: salt, hash: value}, nil
+	}
+
+	if _, err := bcrypt.Cost([]byte(value)); err == nil {
+		return bcryptHash(value), nil
+	}
+
+	return nil, errorchain.NewWithMessage(heimdall.ErrArgument,
+		"only bcrypt, sha and apr1 hashes are supported")
+}
+
+type shaHash []byte
+
+func (h shaHash) matches(password string) bool {
+	// nolint: gosec
+	computed := sha1.Sum([]byte(password))
+
+	return subtle.ConstantTimeCompare(computed[:], h) == 1
+}
+
+type apr1Hash struct {
+	salt string
+	hash string
+}
+
+func (h *apr1Hash) matches(password string) bool {
+	return subtle.ConstantTimeCompare([]byte(apr1(password, h.salt)), []byte(h.hash)) == 1
+}
+
+// apr1 implements the Apache specific variant of the MD5 based crypt algorithm.
+func apr1(password, salt string) string { // nolint: funlen, cyclop
+	const (
+		maxSaltLength = 8
+		blockSize     = 16
+	)
+
+	if len(salt) > maxSaltLength {
+		salt = salt[:maxSaltLength]
+	}
+
+	pwd := []byte(password)
+
+	alternate := md5.New() // nolint: gosec
+	alternate.Write(pwd)
+	alternate.Write([]byte(salt))
+	alternate.Write(pwd)
+	mixin := alternate.Sum(nil)
+
+	md := md5.New() // nolint: gosec
+	md.Write(pwd)
+	md.Write([]byte(htpasswdPrefixAPR1))
+	md.Write([]byte(salt))
+
+	for idx := len(pwd); idx > 0; idx -= blockSize {
+		md.Write(mixin[:x.IfThenElse(idx > blockSize, blockSize, idx)])
+	}
+
+	for idx := len(pwd); idx != 0; idx >>= 1 {
+		if idx&1 != 0 {
+			md.Write([]byte{0})
+		} else {
+			md.Write(pwd[:1])
+		}
+	}
+
+	final := md.Sum(nil)
+
+	for round := 0; round < apr1Rounds; round++ {
+		md = md5.New() // nolint: gosec
+
+		if round&1 != 0 {
+			md.Write(pwd)
+		} else {
+			md.Write(final)
+		}
+
+		if round%3 != 0 {
+			md.Write([]byte(salt))
+		}
+
+		if round%7 != 0 {
+			md.Write(pwd)
+		}
+
+		if round&1 != 0 {
+			md.Write(final)
+		} else {
+			md.Write(pwd)
+		}
+
+		final = md.Sum(nil)
+	}
+
+	var result strings.Builder
+
+	result.WriteString(htpasswdPrefixAPR1)
+	result.WriteString(salt)
+	result.WriteString("$")
+
+	for _, group := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		apr1Encode(&result, uint(final[group[0]])<<16|uint(final[group[1]])<<8|uint(final[group[2]]), 4)
+	}
+
+	apr1Encode(&result, uint(final[11]), 2) // nolint: gomnd
+
+	return result.String()
+}
+
+func apr1Encode(result *strings.Builder, value uint, length int) {
+	for idx := 0; idx < length; idx++ {
+		result.WriteByte(apr1Alphabet[value&0x3f])
+		value >>= 6
+	}
+}
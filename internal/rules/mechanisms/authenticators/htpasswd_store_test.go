@@ -0,0 +1,201 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+func TestAPR1(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		password string
+		salt     string
+		expected string
+	}{
+		// created with openssl passwd -apr1 -salt <salt> <password>
+		{password: "password", salt: "abcdefgh", expected: "$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE1"},
+		{password: "", salt: "abcdefgh", expected: "$apr1$abcdefgh$L.PT565ESX4Tp2bqNs7Ie."},
+		{
+			password: "a very long password, which exceeds the md5 block size",
+			salt:     "xyz",
+			expected: "$apr1$xyz$y5eQhDm5Qx3sckvagBqHs0",
+		},
+	} {
+		t.Run("password="+tc.password, func(t *testing.T) {
+			// WHEN
+			hash := apr1(tc.password, tc.salt)
+
+			// THEN
+			assert.Equal(t, tc.expected, hash)
+		})
+	}
+}
+
+func TestHtpasswdStoreLoad(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		contents string
+		assert   func(t *testing.T, err error, store *htpasswdStore)
+	}{
+		{
+			uc:       "malformed entry",
+			contents: "foo",
+			assert: func(t *testing.T, err error, _ *htpasswdStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "malformed entry in line 1")
+			},
+		},
+		{
+			uc:       "entry with unsupported hash",
+			contents: "foo:bar",
+			assert: func(t *testing.T, err error, _ *htpasswdStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported hash for user foo")
+			},
+		},
+		{
+			uc:       "entry with malformed sha hash",
+			contents: "foo:{SHA}Zm9vYmFy",
+			assert: func(t *testing.T, err error, _ *htpasswdStore) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "malformed sha hash")
+			},
+		},
+		{
+			uc: "all supported hashes",
+			contents: `
+# sha
+bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=
+# bcrypt
+alice:` + bcryptHashOf(t, "alice-secret") + `
+# apr1
+carol:$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE1
+`,
+			assert: func(t *testing.T, err error, store *htpasswdStore) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.True(t, store.verify("alice", "alice-secret"))
+				assert.False(t, store.verify("alice", "password"))
+				assert.True(t, store.verify("bob", "password"))
+				assert.False(t, store.verify("bob", "alice-secret"))
+				assert.True(t, store.verify("carol", "password"))
+				assert.False(t, store.verify("carol", "alice-secret"))
+				assert.False(t, store.verify("dave", "password"))
+
+				// unknown users are verified against a bcrypt hash with the default cost, regardless of the
+				// hash of the first entry
+				dummy, ok := htpasswdDummyHash().(bcryptHash)
+				require.True(t, ok)
+				cost, err := bcrypt.Cost(dummy)
+				require.NoError(t, err)
+				assert.Equal(t, bcrypt.DefaultCost, cost)
+				assert.False(t, store.verify("dave", "alice-secret"))
+				assert.False(t, store.verify("dave", "heimdall"))
+			},
+		},
+		{
+			uc:       "file without entries",
+			contents: "# no users yet\n",
+			assert: func(t *testing.T, err error, store *htpasswdStore) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.False(t, store.verify("dave", "password"))
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			path := filepath.Join(t.TempDir(), "htpasswd")
+			writeFileAtomically(t, path, tc.contents)
+
+			// WHEN
+			store, err := newHtpasswdStore(path)
+
+			// THEN
+			tc.assert(t, err, store)
+		})
+	}
+}
+
+func TestHtpasswdStoreReload(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	writeFileAtomically(t, path, "bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=")
+
+	store, err := newHtpasswdStore(path)
+	require.NoError(t, err)
+
+	require.True(t, store.verify("bob", "password"))
+
+	// WHEN
+	writeFileAtomically(t, path, "carol:$apr1$abcdefgh$FBwExRW4dCc8aL.OvjpIE1")
+
+	// THEN
+	require.Eventually(t, changeReported(store.tracker), 2*time.Second, 10*time.Millisecond)
+	require.NoError(t, store.reload())
+
+	assert.False(t, store.verify("bob", "password"))
+	assert.True(t, store.verify("carol", "password"))
+
+	// WHEN
+	writeFileAtomically(t, path, "foo")
+
+	// THEN
+	require.Eventually(t, changeReported(store.tracker), 2*time.Second, 10*time.Millisecond)
+	require.Error(t, store.reload())
+
+	// previously loaded entries are kept
+	assert.True(t, store.verify("carol", "password"))
+
+	// the failed reload is retried
+	assert.True(t, changeReported(store.tracker)())
+
+	// WHEN
+	writeFileAtomically(t, path, "bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=")
+
+	// THEN
+	require.NoError(t, store.reload())
+
+	assert.True(t, store.verify("bob", "password"))
+	assert.False(t, store.verify("carol", "password"))
+}
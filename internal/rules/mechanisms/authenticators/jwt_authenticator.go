@@ -24,6 +24,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/goccy/go-json"
@@ -44,7 +46,11 @@ import (
 	"github.com/dadrus/heimdall/internal/x/stringx"
 )
 
-const defaultJWTAuthenticatorTTL = 10 * time.Minute
+const (
+	defaultJWTAuthenticatorTTL = 10 * time.Minute
+
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+)
 
 // by intention. Used only during application bootstrap
 // nolint
@@ -64,6 +70,8 @@ func init() {
 type jwtAuthenticator struct {
 	id                   string
 	e                    endpoint.Endpoint
+	issuer               string
+	ks                   keySource
 	a                    oauth2.Expectation
 	ttl                  *time.Duration
 	sf                   SubjectFactory
@@ -71,35 +79,135 @@ type jwtAuthenticator struct {
 	allowFallbackOnError bool
 	trustStore           truststore.TrustStore
 	validateJWKCert      bool
+	issuers              map[string]*jwtAuthenticator
+}
+
+type jwtKeyStoreConfig struct {
+	Path string `mapstructure:"path"`
+}
+
+// jwtIssuerConfig holds the settings required to verify JWTs of a particular issuer.
+type jwtIssuerConfig struct {
+	Endpoint    endpoint.Endpoint     `mapstructure:"jwks_endpoint"`
+	Issuer      string                `mapstructure:"issuer"`
+	JWKS        any                   `mapstructure:"jwks"`
+	JWKSFile    string                `mapstructure:"jwks_file"`
+	KeyStore    jwtKeyStoreConfig     `mapstructure:"key_store"`
+	Assertions  oauth2.Expectation    `mapstructure:"assertions"`
+	SubjectInfo SubjectInfo           `mapstructure:"subject"`
+	CacheTTL    *time.Duration        `mapstructure:"cache_ttl"`
+	ValidateJWK *bool                 `mapstructure:"validate_jwk"`
+	TrustStore  truststore.TrustStore `mapstructure:"trust_store"`
 }
 
-func newJwtAuthenticator(id string, rawConfig map[string]any) (*jwtAuthenticator, error) { // nolint: funlen
+func newJwtAuthenticator(id string, rawConfig map[string]any) (*jwtAuthenticator, error) { // nolint: funlen, cyclop
+	type TrustedIssuer struct {
+		Iss          string          `mapstructure:"iss"`
+		IssuerConfig jwtIssuerConfig `mapstructure:",squash"`
+	}
+
 	type Config struct {
-		Endpoint             endpoint.Endpoint                   `mapstructure:"jwks_endpoint"`
+		IssuerConfig         jwtIssuerConfig                     `mapstructure:",squash"`
+		TrustedIssuers       []TrustedIssuer                     `mapstructure:"trusted_issuers"`
 		AuthDataSource       extractors.CompositeExtractStrategy `mapstructure:"jwt_source"`
-		Assertions           oauth2.Expectation                  `mapstructure:"assertions"`
-		SubjectInfo          SubjectInfo                         `mapstructure:"subject"`
-		CacheTTL             *time.Duration                      `mapstructure:"cache_ttl"`
 		AllowFallbackOnError bool                                `mapstructure:"allow_fallback_on_error"`
-		ValidateJWK          *bool                               `mapstructure:"validate_jwk"`
-		TrustStore           truststore.TrustStore               `mapstructure:"trust_store"`
 	}
 
-	var (
-		conf Config
-		err  error
-	)
-
-	if err = decodeConfig(rawConfig, &conf); err != nil {
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
 		return nil, errorchain.
 			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal jwt authenticator config").
 			CausedBy(err)
 	}
 
-	if err = conf.Endpoint.Validate(); err != nil {
+	ads := x.IfThenElseExec(conf.AuthDataSource == nil,
+		func() extractors.CompositeExtractStrategy {
+			return extractors.CompositeExtractStrategy{
+				extractors.HeaderValueExtractStrategy{Name: "Authorization", Schema: "Bearer"},
+				extractors.QueryParameterExtractStrategy{Name: "access_token"},
+				extractors.BodyParameterExtractStrategy{Name: "access_token"},
+			}
+		},
+		func() extractors.CompositeExtractStrategy { return conf.AuthDataSource },
+	)
+
+	if len(conf.TrustedIssuers) == 0 {
+		auth, err := newJwtIssuerAuthenticator(id, conf.IssuerConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		auth.ads = ads
+		auth.allowFallbackOnError = conf.AllowFallbackOnError
+
+		return auth, nil
+	}
+
+	if !reflect.ValueOf(conf.IssuerConfig).IsZero() {
 		return nil, errorchain.
-			NewWithMessage(heimdall.ErrConfiguration, "failed to validate endpoint configuration").
-			CausedBy(err)
+			NewWithMessage(heimdall.ErrConfiguration,
+				"issuer specific settings must be configured per entry if trusted_issuers are used")
+	}
+
+	issuers := make(map[string]*jwtAuthenticator, len(conf.TrustedIssuers))
+
+	for idx, ti := range conf.TrustedIssuers {
+		iss := x.IfThenElse(len(ti.Iss) != 0, ti.Iss, ti.IssuerConfig.Issuer)
+		if len(iss) == 0 {
+			return nil, errorchain.
+				NewWithMessagef(heimdall.ErrConfiguration, "no iss configured for %d trusted_issuers entry", idx+1)
+		}
+
+		if _, ok := issuers[iss]; ok {
+			return nil, errorchain.
+				NewWithMessagef(heimdall.ErrConfiguration, "duplicate trusted_issuers entry for iss=%s", iss)
+		}
+
+		if len(ti.IssuerConfig.Assertions.TrustedIssuers) == 0 {
+			ti.IssuerConfig.Assertions.TrustedIssuers = []string{iss}
+		}
+
+		auth, err := newJwtIssuerAuthenticator(id, ti.IssuerConfig)
+		if err != nil {
+			return nil, errorchain.
+				NewWithMessagef(heimdall.ErrConfiguration, "failed to configure iss=%s", iss).
+				CausedBy(err)
+		}
+
+		issuers[iss] = auth
+	}
+
+	return &jwtAuthenticator{
+		id:                   id,
+		ads:                  ads,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+		issuers:              issuers,
+	}, nil
+}
+
+func newJwtIssuerAuthenticator(id string, conf jwtIssuerConfig) (*jwtAuthenticator, error) { // nolint: funlen, cyclop
+	var (
+		ks  keySource
+		err error
+	)
+
+	keySources := 0
+
+	for _, configured := range []bool{
+		len(conf.Endpoint.URL) != 0, len(conf.Issuer) != 0, conf.JWKS != nil,
+		len(conf.JWKSFile) != 0, len(conf.KeyStore.Path) != 0,
+	} {
+		keySources += x.IfThenElse(configured, 1, 0)
+	}
+
+	if keySources != 1 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration,
+				"exactly one of jwks_endpoint, issuer, jwks, jwks_file or key_store must be configured")
+	}
+
+	if len(conf.Issuer) != 0 && len(conf.Assertions.TrustedIssuers) == 0 {
+		conf.Assertions.TrustedIssuers = []string{conf.Issuer}
 	}
 
 	if len(conf.Assertions.TrustedIssuers) == 0 {
@@ -107,16 +215,40 @@ func newJwtAuthenticator(id string, rawConfig map[string]any) (*jwtAuthenticator
 			NewWithMessage(heimdall.ErrConfiguration, "no trusted issuers configured")
 	}
 
-	if conf.Endpoint.Headers == nil {
-		conf.Endpoint.Headers = make(map[string]string)
+	switch {
+	case len(conf.Issuer) != 0:
+		// the keys are retrieved from the jwks_uri, the OpenID Connect discovery endpoint points to
+		conf.Endpoint = endpoint.Endpoint{URL: strings.TrimSuffix(conf.Issuer, "/") + oidcDiscoveryPath}
+	case conf.JWKS != nil:
+		ks, err = newKeySourceFromJWKS(conf.JWKS)
+	case len(conf.JWKSFile) != 0:
+		ks, err = newKeySourceFromJWKSFile(conf.JWKSFile)
+	case len(conf.KeyStore.Path) != 0:
+		ks, err = newKeySourceFromKeyStore(conf.KeyStore.Path)
 	}
 
-	if _, ok := conf.Endpoint.Headers["Accept-Type"]; !ok {
-		conf.Endpoint.Headers["Accept-Type"] = "application/json"
+	if err != nil {
+		return nil, err
 	}
 
-	if len(conf.Endpoint.Method) == 0 {
-		conf.Endpoint.Method = "GET"
+	if ks == nil {
+		if err = conf.Endpoint.Validate(); err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrConfiguration, "failed to validate endpoint configuration").
+				CausedBy(err)
+		}
+
+		if conf.Endpoint.Headers == nil {
+			conf.Endpoint.Headers = make(map[string]string)
+		}
+
+		if _, ok := conf.Endpoint.Headers["Accept-Type"]; !ok {
+			conf.Endpoint.Headers["Accept-Type"] = "application/json"
+		}
+
+		if len(conf.Endpoint.Method) == 0 {
+			conf.Endpoint.Method = "GET"
+		}
 	}
 
 	if len(conf.Assertions.AllowedAlgorithms) == 0 {
@@ -135,27 +267,16 @@ func newJwtAuthenticator(id string, rawConfig map[string]any) (*jwtAuthenticator
 		func() bool { return *conf.ValidateJWK },
 		func() bool { return true })
 
-	ads := x.IfThenElseExec(conf.AuthDataSource == nil,
-		func() extractors.CompositeExtractStrategy {
-			return extractors.CompositeExtractStrategy{
-				extractors.HeaderValueExtractStrategy{Name: "Authorization", Schema: "Bearer"},
-				extractors.QueryParameterExtractStrategy{Name: "access_token"},
-				extractors.BodyParameterExtractStrategy{Name: "access_token"},
-			}
-		},
-		func() extractors.CompositeExtractStrategy { return conf.AuthDataSource },
-	)
-
 	return &jwtAuthenticator{
-		id:                   id,
-		e:                    conf.Endpoint,
-		a:                    conf.Assertions,
-		ttl:                  conf.CacheTTL,
-		sf:                   &conf.SubjectInfo,
-		ads:                  ads,
-		allowFallbackOnError: conf.AllowFallbackOnError,
-		validateJWKCert:      validateJWKCert,
-		trustStore:           conf.TrustStore,
+		id:              id,
+		e:               conf.Endpoint,
+		issuer:          conf.Issuer,
+		ks:              ks,
+		a:               conf.Assertions,
+		ttl:             conf.CacheTTL,
+		sf:              &conf.SubjectInfo,
+		validateJWKCert: validateJWKCert,
+		trustStore:      conf.TrustStore,
 	}, nil
 }
 
@@ -180,12 +301,17 @@ func (a *jwtAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, erro
 			CausedBy(err)
 	}
 
-	rawClaims, err := a.verifyToken(ctx, token)
+	auth, err := a.issuerAuthenticator(token)
+	if err != nil {
+		return nil, err
+	}
+
+	rawClaims, err := auth.verifyToken(ctx, token)
 	if err != nil {
 		return nil, err
 	}
 
-	sub, err := a.sf.CreateSubject(rawClaims)
+	sub, err := auth.sf.CreateSubject(rawClaims)
 	if err != nil {
 		return nil, errorchain.
 			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from jwt").
@@ -215,13 +341,25 @@ func (a *jwtAuthenticator) WithConfig(config map[string]any) (Authenticator, err
 			CausedBy(err)
 	}
 
+	if len(a.issuers) != 0 {
+		// the issuer is already determined by the trusted_issuers entry used to verify the JWT
+		if conf.Assertions != nil && len(conf.Assertions.TrustedIssuers) != 0 {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"assertions.issuers cannot be redefined if trusted_issuers are configured")
+		}
+
+		return a.withIssuersConfig(config, conf.AllowFallbackOnError)
+	}
+
 	return &jwtAuthenticator{
-		id:  a.id,
-		e:   a.e,
-		a:   conf.Assertions.Merge(&a.a),
-		ttl: x.IfThenElse(conf.CacheTTL != nil, conf.CacheTTL, a.ttl),
-		sf:  a.sf,
-		ads: a.ads,
+		id:     a.id,
+		e:      a.e,
+		issuer: a.issuer,
+		ks:     a.ks,
+		a:      conf.Assertions.Merge(&a.a),
+		ttl:    x.IfThenElse(conf.CacheTTL != nil, conf.CacheTTL, a.ttl),
+		sf:     a.sf,
+		ads:    a.ads,
 		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
 			func() bool { return *conf.AllowFallbackOnError },
 			func() bool { return a.allowFallbackOnError }),
@@ -230,6 +368,30 @@ func (a *jwtAuthenticator) WithConfig(config map[string]any) (Authenticator, err
 	}, nil
 }
 
+// withIssuersConfig applies the given rule level configuration to the configurations of all trusted issuers.
+func (a *jwtAuthenticator) withIssuersConfig(config map[string]any, allowFallbackOnError *bool) (Authenticator, error) {
+	issuers := make(map[string]*jwtAuthenticator, len(a.issuers))
+
+	for iss, auth := range a.issuers {
+		configured, err := auth.WithConfig(config)
+		if err != nil {
+			return nil, err
+		}
+
+		// nolint: forcetypeassert
+		issuers[iss] = configured.(*jwtAuthenticator)
+	}
+
+	return &jwtAuthenticator{
+		id:  a.id,
+		ads: a.ads,
+		allowFallbackOnError: x.IfThenElseExec(allowFallbackOnError != nil,
+			func() bool { return *allowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+		issuers: issuers,
+	}, nil
+}
+
 func (a *jwtAuthenticator) IsFallbackOnErrorAllowed() bool {
 	return a.allowFallbackOnError
 }
@@ -281,6 +443,35 @@ func (a *jwtAuthenticator) getCacheTTL(key *jose.JSONWebKey) time.Duration {
 	}
 }
 
+// issuerAuthenticator selects the configuration to verify the given JWT with based on its iss claim.
+// The claim is read without verification. It is verified together with all other claims later on.
+func (a *jwtAuthenticator) issuerAuthenticator(token *jwt.JSONWebToken) (*jwtAuthenticator, error) {
+	if len(a.issuers) == 0 {
+		return a, nil
+	}
+
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+
+	if err := token.UnsafeClaimsWithoutVerification(&claims); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "failed to read iss claim from JWT").
+			WithErrorContext(a).
+			CausedBy(heimdall.ErrArgument).
+			CausedBy(err)
+	}
+
+	auth, ok := a.issuers[claims.Issuer]
+	if !ok {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "issuer of the JWT is not trusted").
+			WithErrorContext(a)
+	}
+
+	return auth, nil
+}
+
 func (a *jwtAuthenticator) verifyToken(ctx heimdall.Context, token *jwt.JSONWebToken) (json.RawMessage, error) {
 	if len(token.Headers[0].KeyID) == 0 {
 		return a.verifyTokenWithoutKID(ctx, token)
@@ -326,7 +517,7 @@ func (a *jwtAuthenticator) verifyTokenWithoutKID(ctx heimdall.Context, token *jw
 	if len(rawClaims) == 0 {
 		return nil, errorchain.
 			NewWithMessage(heimdall.ErrAuthentication,
-				"None of the keys received from the JWKS endpoint could be used to verify the JWT").
+				"None of the keys could be used to verify the JWT").
 			WithErrorContext(a)
 	}
 
@@ -346,7 +537,10 @@ func (a *jwtAuthenticator) getKey(ctx heimdall.Context, keyID string) (*jose.JSO
 		ok         bool
 	)
 
-	if a.isCacheEnabled() {
+	// keys from static sources are held in memory anyway
+	useCache := a.ks == nil && a.isCacheEnabled()
+
+	if useCache {
 		cacheKey = a.calculateCacheKey(keyID)
 		cacheEntry = cch.Get(cacheKey)
 	}
@@ -385,7 +579,7 @@ func (a *jwtAuthenticator) getKey(ctx heimdall.Context, keyID string) (*jose.JSO
 			CausedBy(err)
 	}
 
-	if cacheTTL := a.getCacheTTL(jwk); cacheTTL > 0 {
+	if cacheTTL := a.getCacheTTL(jwk); useCache && cacheTTL > 0 {
 		cch.Set(cacheKey, jwk, cacheTTL)
 	}
 
@@ -393,11 +587,20 @@ func (a *jwtAuthenticator) getKey(ctx heimdall.Context, keyID string) (*jose.JSO
 }
 
 func (a *jwtAuthenticator) fetchJWKS(ctx heimdall.Context) (*jose.JSONWebKeySet, error) {
+	if a.ks != nil {
+		return a.ks.keys(ctx), nil
+	}
+
+	ept, err := a.jwksEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	logger := zerolog.Ctx(ctx.AppContext())
 
 	logger.Debug().Msg("Retrieving JWKS from configured endpoint")
 
-	req, err := a.e.CreateRequest(ctx.AppContext(), nil, nil)
+	req, err := ept.CreateRequest(ctx.AppContext(), nil, nil)
 	if err != nil {
 		return nil, errorchain.
 			NewWithMessage(heimdall.ErrInternal, "failed creating request").
@@ -405,7 +608,7 @@ func (a *jwtAuthenticator) fetchJWKS(ctx heimdall.Context) (*jose.JSONWebKeySet,
 			CausedBy(err)
 	}
 
-	resp, err := a.e.CreateClient(req.URL.Hostname()).Do(req)
+	resp, err := ept.CreateClient(req.URL.Hostname()).Do(req)
 	if err != nil {
 		var clientErr *url.Error
 		if errors.As(err, &clientErr) && clientErr.Timeout() {
@@ -426,6 +629,98 @@ func (a *jwtAuthenticator) fetchJWKS(ctx heimdall.Context) (*jose.JSONWebKeySet,
 	return a.readJWKS(resp)
 }
 
+// jwksEndpoint returns the endpoint to retrieve the JWKS from. If an issuer is configured, the
+// jwks_uri is resolved using OpenID Connect discovery and cached like the keys themselves.
+func (a *jwtAuthenticator) jwksEndpoint(ctx heimdall.Context) (endpoint.Endpoint, error) {
+	if len(a.issuer) == 0 {
+		return a.e, nil
+	}
+
+	cch := cache.Ctx(ctx.AppContext())
+	logger := zerolog.Ctx(ctx.AppContext())
+
+	var (
+		cacheKey string
+		jwksURI  string
+		err      error
+	)
+
+	if a.isCacheEnabled() {
+		cacheKey = a.calculateCacheKey(oidcDiscoveryPath)
+
+		if entry := cch.Get(cacheKey); entry != nil {
+			var ok bool
+			if jwksURI, ok = entry.(string); !ok {
+				logger.Warn().Msg("Wrong object type from cache")
+				cch.Delete(cacheKey)
+			} else {
+				logger.Debug().Msg("Reusing jwks_uri from cache")
+			}
+		}
+	}
+
+	if len(jwksURI) == 0 {
+		if jwksURI, err = a.discoverJWKSURI(ctx); err != nil {
+			return endpoint.Endpoint{}, err
+		}
+
+		if a.isCacheEnabled() {
+			cch.Set(cacheKey, jwksURI, x.IfThenElseExec(a.ttl != nil,
+				func() time.Duration { return *a.ttl },
+				func() time.Duration { return defaultJWTAuthenticatorTTL }))
+		}
+	}
+
+	// the jwks endpoint is used with the same settings as the discovery endpoint
+	ept := a.e
+	ept.URL = jwksURI
+
+	return ept, nil
+}
+
+func (a *jwtAuthenticator) discoverJWKSURI(ctx heimdall.Context) (string, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+
+	logger.Debug().Msg("Retrieving OpenID Connect provider metadata")
+
+	rawData, err := a.e.SendRequest(ctx.AppContext(), nil, nil)
+	if err != nil {
+		return "", errorchain.
+			NewWithMessage(heimdall.ErrCommunication, "request to OpenID Connect discovery endpoint failed").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	var metadata struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+
+	if err = json.Unmarshal(rawData, &metadata); err != nil {
+		return "", errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to unmarshal OpenID Connect provider metadata").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	// as required by OpenID Connect Discovery 1.0, section 4.3
+	if metadata.Issuer != a.issuer {
+		return "", errorchain.
+			NewWithMessagef(heimdall.ErrInternal,
+				"issuer '%s' in the OpenID Connect provider metadata does not match the configured one",
+				metadata.Issuer).
+			WithErrorContext(a)
+	}
+
+	if len(metadata.JWKSURI) == 0 {
+		return "", errorchain.
+			NewWithMessage(heimdall.ErrInternal, "OpenID Connect provider metadata does not contain a jwks_uri").
+			WithErrorContext(a)
+	}
+
+	return metadata.JWKSURI, nil
+}
+
 func (a *jwtAuthenticator) readJWKS(resp *http.Response) (*jose.JSONWebKeySet, error) {
 	if !(resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices) {
 		return nil, errorchain.
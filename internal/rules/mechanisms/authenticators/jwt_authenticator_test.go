@@ -24,6 +24,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -34,6 +35,7 @@ import (
 
 	"github.com/goccy/go-json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/square/go-jose.v2"
 	"gopkg.in/square/go-jose.v2/jwt"
@@ -81,6 +83,28 @@ func TestJwtAuthenticatorCreate(t *testing.T) {
 
 	trustStorePath := file.Name()
 
+	keyStorePath := createKeyStoreFile(t, createCertificatesPEM(t))
+	defer os.Remove(keyStorePath)
+
+	privateKeyStorePath := createKeyStoreFile(t, createKeyStorePEM(t))
+	defer os.Remove(privateKeyStorePath)
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{KeyID: "foo", Algorithm: string(jose.ES256), Key: signingKey, Use: "sig"},
+	}})
+	require.NoError(t, err)
+
+	jwksFile, err := os.CreateTemp("", "test-create-jwt-authenticator-jwks-*")
+	require.NoError(t, err)
+
+	_, err = jwksFile.Write(jwks)
+	require.NoError(t, err)
+
+	defer os.Remove(jwksFile.Name())
+
 	for _, tc := range []struct {
 		uc     string
 		id     string
@@ -117,7 +141,344 @@ subject:
 
 				require.Error(t, err)
 				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
-				assert.Contains(t, err.Error(), "endpoint configuration")
+				assert.Contains(t, err.Error(), "exactly one of")
+			},
+		},
+		{
+			uc: "with multiple key sources configured",
+			config: []byte(`
+jwks_endpoint:
+  url: http://test.com
+issuer: http://test.com
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "exactly one of")
+			},
+		},
+		{
+			uc: "with invalid inline jwks",
+			config: []byte(`
+jwks: foo
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal jwks")
+			},
+		},
+		{
+			uc: "with inline jwks without keys",
+			config: []byte(`
+jwks:
+  keys: []
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "does not contain any keys")
+			},
+		},
+		{
+			uc: "with not existing jwks file",
+			config: []byte(`
+jwks_file: /does/not/exist.json
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to read jwks file")
+			},
+		},
+		{
+			uc: "with not existing key store",
+			config: []byte(`
+key_store:
+  path: /does/not/exist.pem
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to load key store")
+			},
+		},
+		{
+			uc: "with key store holding private keys",
+			config: []byte(`
+key_store:
+  path: ` + privateKeyStorePath + `
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to load key store")
+			},
+		},
+		{
+			uc:     "valid configuration with issuer",
+			id:     "auth1",
+			config: []byte(`issuer: https://idp.test.local/`),
+			assert: func(t *testing.T, err error, auth *jwtAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "https://idp.test.local/", auth.issuer)
+				assert.Nil(t, auth.ks)
+				assert.Equal(t, "https://idp.test.local/.well-known/openid-configuration", auth.e.URL)
+				assert.Equal(t, "GET", auth.e.Method)
+				assert.Equal(t, auth.e.Headers["Accept-Type"], "application/json")
+				assert.Equal(t, []string{"https://idp.test.local/"}, auth.a.TrustedIssuers)
+			},
+		},
+		{
+			uc: "valid configuration with issuer and trusted issuers",
+			id: "auth1",
+			config: []byte(`
+issuer: https://idp.test.local
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, auth *jwtAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "https://idp.test.local", auth.issuer)
+				assert.Equal(t, "https://idp.test.local/.well-known/openid-configuration", auth.e.URL)
+				assert.Equal(t, []string{"foobar"}, auth.a.TrustedIssuers)
+			},
+		},
+		{
+			uc: "valid configuration with inline jwks",
+			id: "auth1",
+			config: []byte(`
+jwks: '` + string(jwks) + `'
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, auth *jwtAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Empty(t, auth.e.URL)
+				require.IsType(t, &staticKeySource{}, auth.ks)
+
+				keys := auth.ks.keys(nil).Key("foo")
+				require.Len(t, keys, 1)
+				assert.True(t, keys[0].IsPublic())
+				assert.Equal(t, &signingKey.PublicKey, keys[0].Key)
+			},
+		},
+		{
+			uc: "valid configuration with inline jwks defined as yaml",
+			id: "auth1",
+			config: []byte(`
+jwks:
+  keys:
+    - kty: oct
+      kid: bar
+      alg: HS256
+      k: Zm9vYmFyZm9vYmFyZm9vYmFyZm9vYmFyZm9vYmFy
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, auth *jwtAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.IsType(t, &staticKeySource{}, auth.ks)
+
+				keys := auth.ks.keys(nil).Key("bar")
+				require.Len(t, keys, 1)
+				assert.Equal(t, []byte("foobarfoobarfoobarfoobarfoobar"), keys[0].Key)
+			},
+		},
+		{
+			uc: "valid configuration with jwks file",
+			id: "auth1",
+			config: []byte(`
+jwks_file: ` + jwksFile.Name() + `
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, auth *jwtAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.IsType(t, &jwksFileKeySource{}, auth.ks)
+
+				keys := auth.ks.(*jwksFileKeySource).jwks.Key("foo")
+				require.Len(t, keys, 1)
+				assert.Equal(t, &signingKey.PublicKey, keys[0].Key)
+			},
+		},
+		{
+			uc: "valid configuration with key store",
+			id: "auth1",
+			config: []byte(`
+key_store:
+  path: ` + keyStorePath + `
+assertions:
+  issuers:
+    - foobar`),
+			assert: func(t *testing.T, err error, auth *jwtAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.IsType(t, &staticKeySource{}, auth.ks)
+
+				jwks := auth.ks.keys(nil)
+				assert.Len(t, jwks.Keys, 2)
+
+				ecdsaKeys := jwks.Key(hex.EncodeToString([]byte(kidKeyWithCert)))
+				require.Len(t, ecdsaKeys, 1)
+				assert.Len(t, ecdsaKeys[0].Certificates, 3)
+				assert.Equal(t, string(jose.ES384), ecdsaKeys[0].Algorithm)
+				assert.True(t, ecdsaKeys[0].IsPublic())
+
+				rsaKeys := jwks.Key(hex.EncodeToString([]byte(kidRSAKey)))
+				require.Len(t, rsaKeys, 1)
+				assert.Len(t, rsaKeys[0].Certificates, 2)
+				assert.Equal(t, string(jose.PS256), rsaKeys[0].Algorithm)
+			},
+		},
+		{
+			uc: "with trusted issuers and issuer specific settings on top level",
+			config: []byte(`
+jwks_endpoint:
+  url: http://test.com
+trusted_issuers:
+  - iss: foobar
+    jwks_file: ` + jwksFile.Name()),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "must be configured per entry")
+			},
+		},
+		{
+			uc: "with trusted issuers entry without iss",
+			config: []byte(`
+trusted_issuers:
+  - jwks_file: ` + jwksFile.Name()),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no iss configured for 1 trusted_issuers entry")
+			},
+		},
+		{
+			uc: "with duplicate trusted issuers entries",
+			config: []byte(`
+trusted_issuers:
+  - iss: https://idp.test.local
+    jwks_file: ` + jwksFile.Name() + `
+  - issuer: https://idp.test.local`),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "duplicate trusted_issuers entry for iss=https://idp.test.local")
+			},
+		},
+		{
+			uc: "with trusted issuers entry without key source",
+			config: []byte(`
+trusted_issuers:
+  - iss: foobar`),
+			assert: func(t *testing.T, err error, a *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to configure iss=foobar")
+				assert.Contains(t, err.Error(), "exactly one of")
+			},
+		},
+		{
+			uc: "valid configuration with trusted issuers",
+			id: "auth1",
+			config: []byte(`
+jwt_source:
+  - header: foo-header
+trusted_issuers:
+  - iss: foobar
+    jwks_file: ` + jwksFile.Name() + `
+    assertions:
+      audience:
+        - bar
+    subject:
+      id: identity.id
+    cache_ttl: 5s
+  - issuer: https://idp.test.local
+    assertions:
+      issuers:
+        - https://idp.test.local
+        - https://idp.test.local/
+allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, auth *jwtAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.HandlerID())
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+				assert.Len(t, auth.ads, 1)
+				assert.Nil(t, auth.ks)
+				assert.Empty(t, auth.e.URL)
+				require.Len(t, auth.issuers, 2)
+
+				ia := auth.issuers["foobar"]
+				require.NotNil(t, ia)
+				assert.Equal(t, "auth1", ia.HandlerID())
+				assert.IsType(t, &jwksFileKeySource{}, ia.ks)
+				assert.Equal(t, []string{"foobar"}, ia.a.TrustedIssuers)
+				assert.Equal(t, []string{"bar"}, ia.a.TargetAudiences)
+				assert.Equal(t, &SubjectInfo{IDFrom: "identity.id"}, ia.sf)
+				assert.Equal(t, 5*time.Second, *ia.ttl)
+
+				ia = auth.issuers["https://idp.test.local"]
+				require.NotNil(t, ia)
+				assert.Equal(t, "https://idp.test.local", ia.issuer)
+				assert.Equal(t, "https://idp.test.local/.well-known/openid-configuration", ia.e.URL)
+				assert.Equal(t, []string{"https://idp.test.local", "https://idp.test.local/"}, ia.a.TrustedIssuers)
+				assert.Equal(t, &SubjectInfo{IDFrom: "sub"}, ia.sf)
+				assert.Nil(t, ia.ttl)
 			},
 		},
 		{
@@ -685,6 +1046,73 @@ cache_ttl: 5s`),
 				assert.Contains(t, err.Error(), "has invalid keys: validate_jwk")
 			},
 		},
+		{
+			uc: "prototype with trusted issuers, configured with overwrites",
+			id: "auth2",
+			prototypeConfig: []byte(`
+trusted_issuers:
+  - iss: foo
+    jwks_endpoint:
+      url: http://foo.test.local
+    assertions:
+      audience:
+        - foo
+  - iss: bar
+    jwks_endpoint:
+      url: http://bar.test.local
+    cache_ttl: 5s`),
+			config: []byte(`
+assertions:
+  allowed_algorithms:
+    - ES512
+cache_ttl: 10s
+allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, prototype *jwtAuthenticator, configured *jwtAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, "auth2", configured.HandlerID())
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+				assert.Equal(t, prototype.ads, configured.ads)
+				require.Len(t, configured.issuers, 2)
+
+				for iss, ia := range configured.issuers {
+					pa := prototype.issuers[iss]
+
+					assert.Equal(t, pa.e, ia.e)
+					assert.Equal(t, []string{iss}, ia.a.TrustedIssuers)
+					assert.Equal(t, []string{"ES512"}, ia.a.AllowedAlgorithms)
+					assert.Equal(t, pa.a.TargetAudiences, ia.a.TargetAudiences)
+					assert.Equal(t, 10*time.Second, *ia.ttl)
+				}
+
+				assert.Equal(t, []string{"foo"}, configured.issuers["foo"].a.TargetAudiences)
+				assert.Equal(t, 5*time.Second, *prototype.issuers["bar"].ttl)
+			},
+		},
+		{
+			uc: "prototype with trusted issuers, configured with issuers assertion",
+			id: "auth2",
+			prototypeConfig: []byte(`
+trusted_issuers:
+  - iss: foo
+    jwks_endpoint:
+      url: http://foo.test.local`),
+			config: []byte(`
+assertions:
+  issuers:
+    - bar`),
+			assert: func(t *testing.T, err error, _ *jwtAuthenticator, _ *jwtAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "assertions.issuers cannot be redefined")
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			pc, err := testsupport.DecodeTestConfig(tc.prototypeConfig)
@@ -724,8 +1152,10 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 	}
 
 	var (
-		endpointCalled bool
-		checkRequest   func(req *http.Request)
+		endpointCalled          bool
+		discoveryEndpointCalled bool
+		discoveryContent        []byte
+		checkRequest            func(req *http.Request)
 
 		responseHeaders     map[string]string
 		responseContentType string
@@ -773,9 +1203,19 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 	jwtWithoutKIDSignedWithKeyAndCertJWK := createJWT(t, keyAndCertEntry, subjectID, issuer, audience, false)
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		endpointCalled = true
+		if r.URL.Path == oidcDiscoveryPath {
+			discoveryEndpointCalled = true
 
-		checkRequest(r)
+			w.Header().Set("Content-Type", "application/json")
+			_, err := w.Write(discoveryContent)
+			assert.NoError(t, err)
+
+			return
+		}
+
+		endpointCalled = true
+
+		checkRequest(r)
 
 		for hn, hv := range responseHeaders {
 			w.Header().Set(hn, hv)
@@ -1810,10 +2250,429 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 				assert.Equal(t, "auth3", identifier.HandlerID())
 			},
 		},
+		{
+			uc: "with OpenID Connect discovery endpoint communication error (dns)",
+			authenticator: &jwtAuthenticator{
+				id:     "auth3",
+				e:      endpoint.Endpoint{URL: "http://heimdall.test.local" + oidcDiscoveryPath},
+				issuer: "http://heimdall.test.local",
+				ttl:    &disabledTTL,
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				_ *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				_ *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.False(t, discoveryEndpointCalled)
+				assert.False(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "discovery endpoint failed")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "with issuer mismatch in OpenID Connect provider metadata",
+			authenticator: &jwtAuthenticator{
+				id:     "auth3",
+				e:      endpoint.Endpoint{URL: srv.URL + oidcDiscoveryPath},
+				issuer: srv.URL,
+				ttl:    &disabledTTL,
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				_ *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				_ *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				discoveryContent = []byte(`{"issuer": "https://evil.local", "jwks_uri": "` + srv.URL + `/jwks"}`)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, discoveryEndpointCalled)
+				assert.False(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "does not match")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "with OpenID Connect provider metadata without jwks_uri",
+			authenticator: &jwtAuthenticator{
+				id:     "auth3",
+				e:      endpoint.Endpoint{URL: srv.URL + oidcDiscoveryPath},
+				issuer: srv.URL,
+				ttl:    &disabledTTL,
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				_ *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				_ *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				discoveryContent = []byte(`{"issuer": "` + srv.URL + `"}`)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, discoveryEndpointCalled)
+				assert.False(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "does not contain a jwks_uri")
+			},
+		},
+		{
+			uc: "successful with jwks_uri resolved via OpenID Connect discovery",
+			authenticator: &jwtAuthenticator{
+				e:      endpoint.Endpoint{URL: srv.URL + oidcDiscoveryPath},
+				issuer: srv.URL,
+				a: oauth2.Expectation{
+					AllowedAlgorithms: []string{"ES384"},
+					TrustedIssuers:    []string{issuer},
+					ScopesMatcher:     oauth2.ExactScopeStrategyMatcher{},
+				},
+				sf:  &SubjectInfo{IDFrom: "sub"},
+				ttl: &tenSecondsTTL,
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				cch *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				auth *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				discoveryCacheKey := auth.calculateCacheKey(oidcDiscoveryPath)
+				keyCacheKey := auth.calculateCacheKey(kidKeyWithoutCert)
+
+				var jwks jose.JSONWebKeySet
+				err := json.Unmarshal(jwksWithOneKeyOnlyEntry, &jwks)
+				require.NoError(t, err)
+
+				keys := jwks.Key(kidKeyWithoutCert)
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
+				cch.EXPECT().Get(keyCacheKey).Return(nil)
+				cch.EXPECT().Get(discoveryCacheKey).Return(nil)
+				cch.EXPECT().Set(discoveryCacheKey, srv.URL+"/jwks", *auth.ttl)
+				cch.EXPECT().Set(keyCacheKey, &keys[0], *auth.ttl)
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				discoveryContent = []byte(`{"issuer": "` + srv.URL + `", "jwks_uri": "` + srv.URL + `/jwks"}`)
+
+				checkRequest = func(req *http.Request) {
+					assert.Equal(t, "/jwks", req.URL.Path)
+				}
+
+				responseCode = http.StatusOK
+				responseContent = jwksWithOneKeyOnlyEntry
+				responseContentType = "application/json"
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, discoveryEndpointCalled)
+				assert.True(t, endpointCalled)
+
+				require.NoError(t, err)
+
+				require.NotNil(t, sub)
+				assert.Equal(t, subjectID, sub.ID)
+				assert.Equal(t, issuer, sub.Attributes["iss"])
+			},
+		},
+		{
+			uc: "successful with jwks_uri from cache",
+			authenticator: &jwtAuthenticator{
+				e:      endpoint.Endpoint{URL: srv.URL + oidcDiscoveryPath},
+				issuer: srv.URL,
+				a: oauth2.Expectation{
+					AllowedAlgorithms: []string{"ES384"},
+					TrustedIssuers:    []string{issuer},
+					ScopesMatcher:     oauth2.ExactScopeStrategyMatcher{},
+				},
+				sf:  &SubjectInfo{IDFrom: "sub"},
+				ttl: &tenSecondsTTL,
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				cch *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				auth *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				keyCacheKey := auth.calculateCacheKey(kidKeyWithoutCert)
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
+				cch.EXPECT().Get(keyCacheKey).Return(nil)
+				cch.EXPECT().Get(auth.calculateCacheKey(oidcDiscoveryPath)).Return(srv.URL + "/jwks")
+				cch.EXPECT().Set(keyCacheKey, mock.Anything, *auth.ttl)
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				checkRequest = func(req *http.Request) {
+					assert.Equal(t, "/jwks", req.URL.Path)
+				}
+
+				responseCode = http.StatusOK
+				responseContent = jwksWithOneKeyOnlyEntry
+				responseContentType = "application/json"
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.False(t, discoveryEndpointCalled)
+				assert.True(t, endpointCalled)
+
+				require.NoError(t, err)
+
+				require.NotNil(t, sub)
+				assert.Equal(t, subjectID, sub.ID)
+			},
+		},
+		{
+			uc: "successful with static keys",
+			authenticator: &jwtAuthenticator{
+				ks: &staticKeySource{jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+					keyOnlyEntry.JWK(), keyAndCertEntry.JWK(),
+				}}},
+				a: oauth2.Expectation{
+					AllowedAlgorithms: []string{"ES384"},
+					TrustedIssuers:    []string{issuer},
+					ScopesMatcher:     oauth2.ExactScopeStrategyMatcher{},
+				},
+				sf: &SubjectInfo{IDFrom: "sub"},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				_ *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				_ *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.False(t, endpointCalled)
+
+				require.NoError(t, err)
+
+				require.NotNil(t, sub)
+				assert.Equal(t, subjectID, sub.ID)
+			},
+		},
+		{
+			uc: "successful validation of token without kid using static keys",
+			authenticator: &jwtAuthenticator{
+				ks: &staticKeySource{jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+					keyOnlyEntry.JWK(), keyAndCertEntry.JWK(),
+				}}},
+				a: oauth2.Expectation{
+					AllowedAlgorithms: []string{"ES384"},
+					TrustedIssuers:    []string{issuer},
+					ScopesMatcher:     oauth2.ExactScopeStrategyMatcher{},
+				},
+				sf: &SubjectInfo{IDFrom: "sub"},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				_ *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				_ *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtWithoutKIDSignedWithKeyAndCertJWK, nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.False(t, endpointCalled)
+
+				require.NoError(t, err)
+
+				require.NotNil(t, sub)
+				assert.Equal(t, subjectID, sub.ID)
+			},
+		},
+		{
+			uc: "with multiple issuers and JWT from untrusted issuer",
+			authenticator: &jwtAuthenticator{
+				id: "auth3",
+				issuers: map[string]*jwtAuthenticator{
+					"barfoo": {id: "auth3"},
+				},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				_ *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				_ *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.False(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "issuer of the JWT is not trusted")
+				assert.NotContains(t, err.Error(), "foobar")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "with multiple issuers and JWT signed with key of other issuer",
+			authenticator: &jwtAuthenticator{
+				id: "auth3",
+				issuers: map[string]*jwtAuthenticator{
+					issuer: {
+						id: "auth3",
+						ks: &staticKeySource{jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+							keyRSAEntry.JWK(),
+						}}},
+						a: oauth2.Expectation{
+							AllowedAlgorithms: []string{"ES384", "PS256"},
+							TrustedIssuers:    []string{issuer},
+							ScopesMatcher:     oauth2.ExactScopeStrategyMatcher{},
+						},
+						sf: &SubjectInfo{IDFrom: "sub"},
+					},
+					"barfoo": {
+						id: "auth3",
+						ks: &staticKeySource{jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+							keyOnlyEntry.JWK(),
+						}}},
+					},
+				},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				_ *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				_ *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "no (unique) key found")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "successful with multiple issuers",
+			authenticator: &jwtAuthenticator{
+				issuers: map[string]*jwtAuthenticator{
+					issuer: {
+						ks: &staticKeySource{jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+							keyOnlyEntry.JWK(),
+						}}},
+						a: oauth2.Expectation{
+							AllowedAlgorithms: []string{"ES384"},
+							TrustedIssuers:    []string{issuer},
+							ScopesMatcher:     oauth2.ExactScopeStrategyMatcher{},
+						},
+						sf: &SubjectInfo{IDFrom: "iss"},
+					},
+					"barfoo": {
+						ks: &staticKeySource{jwks: &jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+							keyRSAEntry.JWK(),
+						}}},
+						a: oauth2.Expectation{
+							AllowedAlgorithms: []string{"PS256"},
+							TrustedIssuers:    []string{"barfoo"},
+							ScopesMatcher:     oauth2.ExactScopeStrategyMatcher{},
+						},
+						sf: &SubjectInfo{IDFrom: "sub"},
+					},
+				},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.ContextMock,
+				_ *mocks.CacheMock,
+				ads *mocks2.AuthDataExtractStrategyMock,
+				_ *jwtAuthenticator,
+			) {
+				t.Helper()
+
+				ads.EXPECT().GetAuthData(ctx).Return(jwtSignedWithKeyOnlyJWK, nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.False(t, endpointCalled)
+
+				require.NoError(t, err)
+
+				// the subject is created as configured for the issuer of the JWT
+				require.NotNil(t, sub)
+				assert.Equal(t, issuer, sub.ID)
+				assert.Equal(t, subjectID, sub.Attributes["sub"])
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
 			endpointCalled = false
+			discoveryEndpointCalled = false
+			discoveryContent = nil
 			responseHeaders = nil
 			responseContentType = ""
 			responseContent = nil
@@ -1858,6 +2717,91 @@ func TestJwtAuthenticatorExecute(t *testing.T) {
 func createKS(t *testing.T) keystore.KeyStore {
 	t.Helper()
 
+	ks, err := keystore.NewKeyStoreFromPEMBytes(createKeyStorePEM(t), "")
+	require.NoError(t, err)
+
+	return ks
+}
+
+func createKeyStoreFile(t *testing.T, pemBytes []byte) string {
+	t.Helper()
+
+	file, err := os.CreateTemp("", "test-jwt-authenticator-key-store-*")
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	_, err = file.Write(pemBytes)
+	require.NoError(t, err)
+
+	return file.Name()
+}
+
+// createCertificatesPEM creates the certificates of the keys used to sign JWTs, as used by the
+// key_store of the authenticator. The PEM holds no private keys.
+func createCertificatesPEM(t *testing.T) []byte {
+	t.Helper()
+
+	rootCA1, err := testsupport.NewRootCA("Test Root CA 1", time.Hour*24)
+	require.NoError(t, err)
+
+	intCA1PrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	intCA1Cert, err := rootCA1.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{
+			CommonName:   "Test Int CA 1",
+			Organization: []string{"Test"},
+			Country:      []string{"EU"},
+		}),
+		testsupport.WithIsCA(),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&intCA1PrivKey.PublicKey, x509.ECDSAWithSHA384))
+	require.NoError(t, err)
+
+	intCA1 := testsupport.NewCA(intCA1PrivKey, intCA1Cert)
+
+	ee1PrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	ee1Cert, err := intCA1.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{
+			CommonName:   "Test EE 1",
+			Organization: []string{"Test"},
+			Country:      []string{"EU"},
+		}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&ee1PrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithSubjectKeyID([]byte(kidKeyWithCert)),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature))
+	require.NoError(t, err)
+
+	ee2PrivKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ee2Cert, err := rootCA1.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{
+			CommonName:   "Test EE 2",
+			Organization: []string{"Test"},
+			Country:      []string{"EU"},
+		}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&ee2PrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithSubjectKeyID([]byte(kidRSAKey)),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature))
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(
+		pemx.WithX509Certificate(ee1Cert),
+		pemx.WithX509Certificate(ee2Cert),
+		pemx.WithX509Certificate(intCA1Cert),
+		pemx.WithX509Certificate(rootCA1.Certificate),
+	)
+	require.NoError(t, err)
+
+	return pemBytes
+}
+
+func createKeyStorePEM(t *testing.T) []byte {
+	t.Helper()
+
 	// ROOT CAs
 	rootCA1, err := testsupport.NewRootCA("Test Root CA 1", time.Hour*24)
 	require.NoError(t, err)
@@ -1908,10 +2852,7 @@ func createKS(t *testing.T) keystore.KeyStore {
 	)
 	require.NoError(t, err)
 
-	ks, err := keystore.NewKeyStoreFromPEMBytes(pemBytes, "")
-	require.NoError(t, err)
-
-	return ks
+	return pemBytes
 }
 
 func createJWT(t *testing.T, keyEntry *keystore.Entry, subject, issuer, audience string, setKid bool) string {
@@ -0,0 +1,253 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/pkix"
+	"github.com/dadrus/heimdall/internal/x/stringx"
+)
+
+// keySource provides the keys used to verify JWTs without retrieving these from a JWKS endpoint.
+type keySource interface {
+	keys(ctx heimdall.Context) *jose.JSONWebKeySet
+}
+
+type staticKeySource struct {
+	jwks *jose.JSONWebKeySet
+}
+
+func newKeySourceFromJWKS(value any) (keySource, error) {
+	var (
+		rawJWKS []byte
+		err     error
+	)
+
+	if str, ok := value.(string); ok {
+		rawJWKS = stringx.ToBytes(str)
+	} else if rawJWKS, err = json.Marshal(value); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to marshal jwks").CausedBy(err)
+	}
+
+	jwks, err := parseJWKS(rawJWKS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &staticKeySource{jwks: jwks}, nil
+}
+
+// newKeySourceFromKeyStore creates a key source from a PEM file holding certificates only. Each end
+// entity certificate provides a key, which is used together with the certificate chain built from
+// the remaining certificates for JWT signature verification purposes.
+func newKeySourceFromKeyStore(path string) (keySource, error) {
+	certs, err := truststore.NewTrustStoreFromPEMFile(path, true)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to load key store").CausedBy(err)
+	}
+
+	jwks := &jose.JSONWebKeySet{}
+
+	for _, cert := range certs {
+		if cert.IsCA {
+			continue
+		}
+
+		key, err := jwkFromCertificate(cert, certs)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed to use certificate %s from key store %s", cert.Subject, path).CausedBy(err)
+		}
+
+		jwks.Keys = append(jwks.Keys, key)
+	}
+
+	if len(jwks.Keys) == 0 {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"key store %s does not contain any end entity certificates", path)
+	}
+
+	return &staticKeySource{jwks: jwks}, nil
+}
+
+func jwkFromCertificate(cert *x509.Certificate, pool []*x509.Certificate) (jose.JSONWebKey, error) {
+	chain := keystore.FindChain(cert.PublicKey, pool)
+	if err := keystore.ValidateChain(chain); err != nil {
+		return jose.JSONWebKey{}, err
+	}
+
+	alg, err := signatureAlgorithmOf(cert.PublicKey)
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+
+	// like for the key store used by the signer, the subject key identifier is used as key id
+	keyID := cert.SubjectKeyId
+	if len(keyID) == 0 {
+		if keyID, err = pkix.SubjectKeyID(cert.PublicKey); err != nil {
+			return jose.JSONWebKey{}, err
+		}
+	}
+
+	return jose.JSONWebKey{
+		KeyID:        hex.EncodeToString(keyID),
+		Algorithm:    string(alg),
+		Key:          cert.PublicKey,
+		Use:          "sig",
+		Certificates: chain,
+	}, nil
+}
+
+// signatureAlgorithmOf returns the algorithm the signer uses for keys of the given type and size.
+func signatureAlgorithmOf(key crypto.PublicKey) (jose.SignatureAlgorithm, error) {
+	const (
+		bitsInByte = 8
+
+		rsa2048 = 2048
+		rsa3072 = 3072
+		rsa4096 = 4096
+
+		ecdsa256 = 256
+		ecdsa384 = 384
+		ecdsa521 = 521
+	)
+
+	switch typedKey := key.(type) {
+	case *rsa.PublicKey:
+		switch typedKey.Size() * bitsInByte {
+		case rsa2048:
+			return jose.PS256, nil
+		case rsa3072:
+			return jose.PS384, nil
+		case rsa4096:
+			return jose.PS512, nil
+		}
+	case *ecdsa.PublicKey:
+		switch typedKey.Params().BitSize {
+		case ecdsa256:
+			return jose.ES256, nil
+		case ecdsa384:
+			return jose.ES384, nil
+		case ecdsa521:
+			return jose.ES512, nil
+		}
+	}
+
+	return "", errorchain.NewWithMessage(heimdall.ErrConfiguration,
+		"unsupported key type or size; only rsa and ecdsa keys are supported")
+}
+
+func (s *staticKeySource) keys(_ heimdall.Context) *jose.JSONWebKeySet { return s.jwks }
+
+type jwksFileKeySource struct {
+	path    string
+	jwks    *jose.JSONWebKeySet
+	mut     sync.RWMutex
+	tracker *fileChangeTracker
+}
+
+func newKeySourceFromJWKSFile(path string) (keySource, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to get the absolute path for the jwks file").CausedBy(err)
+	}
+
+	source := &jwksFileKeySource{path: absPath}
+
+	if source.jwks, err = source.load(); err != nil {
+		return nil, err
+	}
+
+	if source.tracker, err = newFileChangeTracker(absPath); err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+// keys returns the keys from the jwks file. The file is loaded again if it has been changed.
+// If that fails, the previously loaded keys are used and the reload is retried on next usage.
+func (s *jwksFileKeySource) keys(ctx heimdall.Context) *jose.JSONWebKeySet {
+	if generation, changed := s.tracker.changed(); changed {
+		if jwks, err := s.load(); err != nil {
+			zerolog.Ctx(ctx.AppContext()).Warn().Err(err).
+				Msg("Failed to reload jwks file. Using previously loaded keys")
+		} else {
+			s.mut.Lock()
+			s.jwks = jwks
+			s.mut.Unlock()
+
+			s.tracker.ack(generation)
+		}
+	}
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	return s.jwks
+}
+
+func (s *jwksFileKeySource) load() (*jose.JSONWebKeySet, error) {
+	contents, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"failed to read jwks file %s", s.path).CausedBy(err)
+	}
+
+	return parseJWKS(contents)
+}
+
+func parseJWKS(rawJWKS []byte) (*jose.JSONWebKeySet, error) {
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(rawJWKS, &jwks); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to unmarshal jwks").CausedBy(err)
+	}
+
+	if len(jwks.Keys) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "jwks does not contain any keys")
+	}
+
+	for idx := range jwks.Keys {
+		// private keys can be used for verification only by their public part.
+		// Symmetric keys have no public part and are used as is.
+		if pub := jwks.Keys[idx].Public(); pub.Key != nil {
+			jwks.Keys[idx] = pub
+		}
+	}
+
+	return &jwks, nil
+}
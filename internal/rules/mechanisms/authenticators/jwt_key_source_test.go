@@ -0,0 +1,78 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+)
+
+func TestJWKSFileKeySourceReload(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeFileAtomically(t, path, `{"keys": [{"kty": "oct", "kid": "foo", "k": "Zm9v"}]}`)
+
+	ks, err := newKeySourceFromJWKSFile(path)
+	require.NoError(t, err)
+
+	source, ok := ks.(*jwksFileKeySource)
+	require.True(t, ok)
+
+	ctx := mocks.NewContextMock(t)
+	ctx.EXPECT().AppContext().Return(context.Background())
+
+	assert.Len(t, source.keys(ctx).Key("foo"), 1)
+
+	// WHEN
+	writeFileAtomically(t, path, `{"keys": [{"kty": "oct", "kid": "bar", "k": "YmFy"}]}`)
+
+	// THEN
+	require.Eventually(t, changeReported(source.tracker), 2*time.Second, 10*time.Millisecond)
+
+	jwks := source.keys(ctx)
+	assert.Empty(t, jwks.Key("foo"))
+	assert.Len(t, jwks.Key("bar"), 1)
+
+	// WHEN
+	writeFileAtomically(t, path, "foo")
+
+	// THEN
+	require.Eventually(t, changeReported(source.tracker), 2*time.Second, 10*time.Millisecond)
+
+	// previously loaded keys are kept
+	assert.Len(t, source.keys(ctx).Key("bar"), 1)
+
+	// the failed reload is retried
+	assert.True(t, changeReported(source.tracker)())
+
+	// WHEN
+	writeFileAtomically(t, path, `{"keys": [{"kty": "oct", "kid": "baz", "k": "YmF6"}]}`)
+
+	// THEN
+	jwks = source.keys(ctx)
+	assert.Empty(t, jwks.Key("bar"))
+	assert.Len(t, jwks.Key("baz"), 1)
+}
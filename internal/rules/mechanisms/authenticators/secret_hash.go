@@ -0,0 +1,131 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	hashPrefixArgon2id = "$argon2id$"
+
+	argon2idHashElements = 6
+
+	// bounds of the argon2id parameters accepted for stored hashes. These limit the resources
+	// required to verify a secret on the request path.
+	argon2idMinMemoryPerThread = 8       // KiB, as required by the argon2 specification
+	argon2idMaxMemory          = 1 << 20 // KiB, that is 1 GiB
+	argon2idMinHashLength      = 4
+	argon2idMaxHashLength      = 128
+)
+
+// secretHash is implemented by the hashes of secrets, like api keys or passwords, held by the
+// stores used by the authenticators.
+type secretHash interface {
+	matches(secret string) bool
+}
+
+type argon2idHash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	hash    []byte
+}
+
+func (h *argon2idHash) matches(secret string) bool {
+	computed := argon2.IDKey([]byte(secret), h.salt, h.time, h.memory, h.threads, uint32(len(h.hash)))
+
+	return subtle.ConstantTimeCompare(computed, h.hash) == 1
+}
+
+type bcryptHash []byte
+
+func (h bcryptHash) matches(secret string) bool {
+	return bcrypt.CompareHashAndPassword(h, []byte(secret)) == nil
+}
+
+// parseArgon2idHash parses a hash in the PHC string format,
+// like $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+func parseArgon2idHash(value string) (*argon2idHash, error) {
+	parts := strings.Split(value, "$")
+	if len(parts) != argon2idHashElements {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "unsupported argon2id version")
+	}
+
+	var hash argon2idHash
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hash.memory, &hash.time, &hash.threads); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "malformed argon2id parameters").
+			CausedBy(err)
+	}
+
+	if err := hash.validateParameters(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "malformed argon2id salt").
+			CausedBy(err)
+	}
+
+	if hash.hash, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "malformed argon2id hash value")
+	}
+
+	if len(hash.hash) < argon2idMinHashLength || len(hash.hash) > argon2idMaxHashLength {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"argon2id hash value must have a length between %d and %d bytes",
+			argon2idMinHashLength, argon2idMaxHashLength)
+	}
+
+	return &hash, nil
+}
+
+func (h *argon2idHash) validateParameters() error {
+	if h.time < 1 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"argon2id time parameter must be at least 1")
+	}
+
+	if h.threads < 1 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"argon2id parallelism parameter must be at least 1")
+	}
+
+	minMemory := argon2idMinMemoryPerThread * uint32(h.threads)
+	if h.memory < minMemory || h.memory > argon2idMaxMemory {
+		return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"argon2id memory parameter must be between %d and %d KiB", minMemory, argon2idMaxMemory)
+	}
+
+	return nil
+}
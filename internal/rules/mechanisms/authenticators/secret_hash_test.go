@@ -0,0 +1,207 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+func TestParseArgon2idHash(t *testing.T) {
+	t.Parallel()
+
+	salt := base64.RawStdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	hashOfLen := func(length int) string {
+		return base64.RawStdEncoding.EncodeToString([]byte(strings.Repeat("a", length)))
+	}
+	hashWith := func(params, hash string) string {
+		return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s", argon2.Version, params, salt, hash)
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		value  string
+		assert func(t *testing.T, err error, hash *argon2idHash)
+	}{
+		{
+			uc:    "malformed hash",
+			value: "$argon2id$v=19$m=1024,t=1,p=1$" + salt,
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "malformed argon2id hash")
+			},
+		},
+		{
+			uc:    "unsupported version",
+			value: "$argon2id$v=16$m=1024,t=1,p=1$" + salt + "$" + hashOfLen(32),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported argon2id version")
+			},
+		},
+		{
+			uc:    "malformed parameters",
+			value: hashWith("m=foo", hashOfLen(32)),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "malformed argon2id parameters")
+			},
+		},
+		{
+			uc:    "parallelism exceeding its value range",
+			value: hashWith("m=1024,t=1,p=256", hashOfLen(32)),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "malformed argon2id parameters")
+			},
+		},
+		{
+			uc:    "time parameter of 0",
+			value: hashWith("m=1024,t=0,p=1", hashOfLen(32)),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "time parameter must be at least 1")
+			},
+		},
+		{
+			uc:    "parallelism parameter of 0",
+			value: hashWith("m=1024,t=1,p=0", hashOfLen(32)),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "parallelism parameter must be at least 1")
+			},
+		},
+		{
+			uc:    "memory parameter below the minimum required by the parallelism",
+			value: hashWith("m=31,t=1,p=4", hashOfLen(32)),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "memory parameter must be between 32 and 1048576 KiB")
+			},
+		},
+		{
+			uc:    "memory parameter above the maximum",
+			value: hashWith("m=1048577,t=1,p=1", hashOfLen(32)),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "memory parameter must be between 8 and 1048576 KiB")
+			},
+		},
+		{
+			uc:    "malformed salt",
+			value: fmt.Sprintf("$argon2id$v=%d$m=1024,t=1,p=1$%s$%s", argon2.Version, "!", hashOfLen(32)),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "malformed argon2id salt")
+			},
+		},
+		{
+			uc:    "malformed hash value",
+			value: hashWith("m=1024,t=1,p=1", "!"),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "malformed argon2id hash value")
+			},
+		},
+		{
+			uc:    "empty hash value",
+			value: hashWith("m=1024,t=1,p=1", ""),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "hash value must have a length between 4 and 128 bytes")
+			},
+		},
+		{
+			uc:    "oversized hash value",
+			value: hashWith("m=1024,t=1,p=1", hashOfLen(129)),
+			assert: func(t *testing.T, err error, _ *argon2idHash) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "hash value must have a length between 4 and 128 bytes")
+			},
+		},
+		{
+			uc:    "valid hash",
+			value: argon2idHashOf("foo"),
+			assert: func(t *testing.T, err error, hash *argon2idHash) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, uint32(1024), hash.memory)
+				assert.Equal(t, uint32(1), hash.time)
+				assert.Equal(t, uint8(1), hash.threads)
+				assert.True(t, hash.matches("foo"))
+				assert.False(t, hash.matches("bar"))
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN
+			hash, err := parseArgon2idHash(tc.value)
+
+			// THEN
+			tc.assert(t, err, hash)
+		})
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/errorhandlers/matcher"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/pkix"
+)
+
+const (
+	forwardedCertificateFormatPEM  = "pem"
+	forwardedCertificateFormatXFCC = "xfcc"
+
+	spiffeScheme = "spiffe"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthenticatorTypeFactory(
+		func(id string, typ string, conf map[string]any) (bool, Authenticator, error) {
+			if typ != AuthenticatorX509 {
+				return false, nil, nil
+			}
+
+			auth, err := newX509Authenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type forwardedCertificate struct {
+	header         string
+	format         string
+	trustedProxies *matcher.CIDRMatcher
+}
+
+type x509Authenticator struct {
+	id                   string
+	trustStore           truststore.TrustStore
+	fc                   *forwardedCertificate
+	sf                   SubjectFactory
+	allowFallbackOnError bool
+}
+
+func newX509Authenticator(id string, rawConfig map[string]any) (*x509Authenticator, error) {
+	type ForwardedCertificate struct {
+		Header         string   `mapstructure:"header"`
+		Format         string   `mapstructure:"format"`
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
+	}
+
+	type Config struct {
+		TrustStore           truststore.TrustStore `mapstructure:"trust_store"`
+		ForwardedCertificate *ForwardedCertificate `mapstructure:"forwarded_certificate"`
+		SubjectInfo          SubjectInfo           `mapstructure:"subject"`
+		AllowFallbackOnError bool                  `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode x509 authenticator config").
+			CausedBy(err)
+	}
+
+	if len(conf.TrustStore) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "x509 authenticator requires trust_store to be set")
+	}
+
+	if len(conf.SubjectInfo.IDFrom) == 0 {
+		conf.SubjectInfo.IDFrom = "subject"
+	}
+
+	var fc *forwardedCertificate
+
+	if conf.ForwardedCertificate != nil {
+		if len(conf.ForwardedCertificate.Header) == 0 {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"x509 authenticator requires header to be set for forwarded certificates")
+		}
+
+		format := x.IfThenElse(len(conf.ForwardedCertificate.Format) == 0,
+			forwardedCertificateFormatPEM, conf.ForwardedCertificate.Format)
+		if format != forwardedCertificateFormatPEM && format != forwardedCertificateFormatXFCC {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"unsupported forwarded certificate format '%s'", format)
+		}
+
+		// forwarded certificates are only accepted from explicitly trusted proxies.
+		// Otherwise, any client could impersonate any other one by just setting the header
+		if len(conf.ForwardedCertificate.TrustedProxies) == 0 {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"x509 authenticator requires trusted_proxies to be set for forwarded certificates")
+		}
+
+		trustedProxies, err := matcher.NewCIDRMatcher(conf.ForwardedCertificate.TrustedProxies)
+		if err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"failed to parse trusted_proxies").CausedBy(err)
+		}
+
+		fc = &forwardedCertificate{
+			header:         conf.ForwardedCertificate.Header,
+			format:         format,
+			trustedProxies: trustedProxies,
+		}
+	}
+
+	return &x509Authenticator{
+		id:                   id,
+		trustStore:           conf.TrustStore,
+		fc:                   fc,
+		sf:                   &conf.SubjectInfo,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *x509Authenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Str("_id", a.id).Msg("Authenticating using x509 authenticator")
+
+	certs, err := a.clientCertificates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = pkix.ValidateCertificate(certs[0],
+		pkix.WithRootCACertificates(a.trustStore),
+		pkix.WithIntermediateCACertificates(certs[1:]),
+		pkix.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth),
+	); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "client certificate is not trusted").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	rawData, err := json.Marshal(certificateAttributes(certs[0]))
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal client certificate attributes").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	sub, err := a.sf.CreateSubject(rawData)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from client certificate").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return sub, nil
+}
+
+func (a *x509Authenticator) WithConfig(config map[string]any) (Authenticator, error) {
+	// this authenticator allows only the fallback behavior to be redefined on the rule level
+	if len(config) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		AllowFallbackOnError *bool `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(config, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode x509 authenticator config").
+			CausedBy(err)
+	}
+
+	return &x509Authenticator{
+		id:         a.id,
+		trustStore: a.trustStore,
+		fc:         a.fc,
+		sf:         a.sf,
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *x509Authenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *x509Authenticator) HandlerID() string {
+	return a.id
+}
+
+func (a *x509Authenticator) clientCertificates(ctx heimdall.Context) ([]*x509.Certificate, error) {
+	req := ctx.Request()
+
+	// if the request has been received from a trusted proxy, the certificate presented in the TLS
+	// handshake (if any) belongs to that proxy and not to the actual client.
+	if a.fc != nil && a.fc.trustedProxies.Match(req.PeerAddress()) {
+		certs, err := a.fc.certificates(req.Header(a.fc.header))
+		if err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrAuthentication, "failed to read forwarded client certificate").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		return certs, nil
+	}
+
+	certs := req.ClientCertificates()
+	if len(certs) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "no client certificate present").
+			WithErrorContext(a)
+	}
+
+	return certs, nil
+}
+
+func (fc *forwardedCertificate) certificates(value string) ([]*x509.Certificate, error) {
+	if len(value) == 0 {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrArgument, "no %s header present", fc.header)
+	}
+
+	if fc.format == forwardedCertificateFormatXFCC {
+		return certificatesFromXFCC(value)
+	}
+
+	return certificatesFromEscapedPEM(value)
+}
+
+// certificatesFromXFCC reads the client certificate and its chain from the value of the
+// x-forwarded-client-cert header as set by envoy. If the request passed multiple proxies,
+// the last element, which has been added by the proxy in front of heimdall, is used.
+func certificatesFromXFCC(value string) ([]*x509.Certificate, error) {
+	elements := splitQuoted(value, ',')
+
+	var cert, chain string
+
+	for _, pair := range splitQuoted(elements[len(elements)-1], ';') {
+		key, val, _ := strings.Cut(strings.TrimSpace(pair), "=")
+
+		switch strings.ToLower(key) {
+		case "cert":
+			cert = unquote(val)
+		case "chain":
+			chain = unquote(val)
+		}
+	}
+
+	// the chain includes the leaf certificate as well
+	if len(chain) != 0 {
+		return certificatesFromEscapedPEM(chain)
+	}
+
+	if len(cert) != 0 {
+		return certificatesFromEscapedPEM(cert)
+	}
+
+	return nil, errorchain.NewWithMessage(heimdall.ErrArgument,
+		"x-forwarded-client-cert header does not contain a certificate")
+}
+
+// certificatesFromEscapedPEM parses url encoded PEM data. The first certificate is expected
+// to be the leaf certificate, all the following ones are treated as intermediate certificates.
+func certificatesFromEscapedPEM(value string) ([]*x509.Certificate, error) {
+	// PathUnescape is used by intention, as QueryUnescape would replace '+' of the
+	// base64 encoded data with spaces
+	pemData, err := url.PathUnescape(value)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrArgument,
+			"failed to decode forwarded certificate").CausedBy(err)
+	}
+
+	certs, err := truststore.NewTrustStoreFromPEMBytes([]byte(pemData), true)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrArgument,
+			"failed to parse forwarded certificate").CausedBy(err)
+	}
+
+	return certs, nil
+}
+
+func splitQuoted(value string, sep byte) []string {
+	var (
+		parts  []string
+		quoted bool
+		start  int
+	)
+
+	for idx := 0; idx < len(value); idx++ {
+		switch value[idx] {
+		case '\\':
+			// skip escaped character
+			idx++
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, value[start:idx])
+				start = idx + 1
+			}
+		}
+	}
+
+	return append(parts, value[start:])
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	}
+
+	return value
+}
+
+func certificateAttributes(cert *x509.Certificate) map[string]any {
+	ipAddresses := make([]string, len(cert.IPAddresses))
+	for idx, ip := range cert.IPAddresses {
+		ipAddresses[idx] = ip.String()
+	}
+
+	var spiffeID string
+
+	uris := make([]string, len(cert.URIs))
+	for idx, uri := range cert.URIs {
+		uris[idx] = uri.String()
+
+		if len(spiffeID) == 0 && uri.Scheme == spiffeScheme {
+			spiffeID = uri.String()
+		}
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	return map[string]any{
+		"subject":             cert.Subject.String(),
+		"common_name":         cert.Subject.CommonName,
+		"organization":        orEmpty(cert.Subject.Organization),
+		"organizational_unit": orEmpty(cert.Subject.OrganizationalUnit),
+		"issuer":              cert.Issuer.String(),
+		"serial_number":       cert.SerialNumber.String(),
+		"dns_names":           orEmpty(cert.DNSNames),
+		"email_addresses":     orEmpty(cert.EmailAddresses),
+		"ip_addresses":        ipAddresses,
+		"uris":                uris,
+		"spiffe_id":           spiffeID,
+		"not_before":          cert.NotBefore.Unix(),
+		"not_after":           cert.NotAfter.Unix(),
+		"fingerprint":         hex.EncodeToString(fingerprint[:]),
+	}
+}
+
+func orEmpty(values []string) []string {
+	return x.IfThenElse(values != nil, values, []string{})
+}
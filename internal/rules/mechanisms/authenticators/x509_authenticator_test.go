@@ -0,0 +1,743 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authenticators
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/x/pkix/pemx"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func createX509TrustStoreFile(t *testing.T, certs ...*x509.Certificate) string {
+	t.Helper()
+
+	opts := make([]pemx.EntryOption, len(certs))
+	for idx, cert := range certs {
+		opts[idx] = pemx.WithX509Certificate(cert)
+	}
+
+	pemBytes, err := pemx.BuildPEM(opts...)
+	require.NoError(t, err)
+
+	file, err := os.CreateTemp(t.TempDir(), "test-x509-authenticator-*")
+	require.NoError(t, err)
+
+	_, err = file.Write(pemBytes)
+	require.NoError(t, err)
+
+	require.NoError(t, file.Close())
+
+	return file.Name()
+}
+
+func escapedPEM(t *testing.T, certs ...*x509.Certificate) string {
+	t.Helper()
+
+	opts := make([]pemx.EntryOption, len(certs))
+	for idx, cert := range certs {
+		opts[idx] = pemx.WithX509Certificate(cert)
+	}
+
+	pemBytes, err := pemx.BuildPEM(opts...)
+	require.NoError(t, err)
+
+	return url.PathEscape(string(pemBytes))
+}
+
+func TestCreateX509Authenticator(t *testing.T) {
+	t.Parallel()
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", 24*time.Hour)
+	require.NoError(t, err)
+
+	trustStorePath := createX509TrustStoreFile(t, rootCA.Certificate)
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *x509Authenticator)
+	}{
+		{
+			uc:     "with unsupported fields",
+			config: []byte("foo: bar"),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc:     "without trust store",
+			config: []byte("allow_fallback_on_error: true"),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires trust_store")
+			},
+		},
+		{
+			uc: "forwarded certificate without header",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires header")
+			},
+		},
+		{
+			uc: "forwarded certificate with unsupported format",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Client-Cert
+  format: der
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported forwarded certificate format")
+			},
+		},
+		{
+			uc: "forwarded certificate without trusted proxies",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Client-Cert
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires trusted_proxies")
+			},
+		},
+		{
+			uc: "forwarded certificate with malformed trusted proxies",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Client-Cert
+  trusted_proxies:
+    - foo
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to parse trusted_proxies")
+			},
+		},
+		{
+			uc:     "minimal configuration",
+			id:     "auth1",
+			config: []byte("trust_store: " + trustStorePath),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.HandlerID())
+				require.Len(t, auth.trustStore, 1)
+				assert.Equal(t, rootCA.Certificate, auth.trustStore[0])
+				assert.Nil(t, auth.fc)
+				assert.Equal(t, &SubjectInfo{IDFrom: "subject"}, auth.sf)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "full configuration with forwarded certificate in default format",
+			id: "auth2",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Client-Cert
+  trusted_proxies:
+    - 10.0.0.0/8
+subject:
+  id: spiffe_id
+  attributes: "@this"
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth2", auth.HandlerID())
+				require.Len(t, auth.trustStore, 1)
+				require.NotNil(t, auth.fc)
+				assert.Equal(t, "X-Client-Cert", auth.fc.header)
+				assert.Equal(t, forwardedCertificateFormatPEM, auth.fc.format)
+				assert.True(t, auth.fc.trustedProxies.Match("10.1.2.3"))
+				assert.False(t, auth.fc.trustedProxies.Match("192.168.1.1"))
+				assert.Equal(t, &SubjectInfo{IDFrom: "spiffe_id", AttributesFrom: "@this"}, auth.sf)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "configuration with forwarded certificate in xfcc format",
+			id: "auth3",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Forwarded-Client-Cert
+  format: xfcc
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth3", auth.HandlerID())
+				require.NotNil(t, auth.fc)
+				assert.Equal(t, "X-Forwarded-Client-Cert", auth.fc.header)
+				assert.Equal(t, forwardedCertificateFormatXFCC, auth.fc.format)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newX509Authenticator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateX509AuthenticatorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", 24*time.Hour)
+	require.NoError(t, err)
+
+	trustStorePath := createX509TrustStoreFile(t, rootCA.Certificate)
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *x509Authenticator, configured *x509Authenticator)
+	}{
+		{
+			uc: "no new configuration provided",
+			assert: func(t *testing.T, err error, prototype *x509Authenticator, configured *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "fallback on error redefined",
+			config: []byte("allow_fallback_on_error: true"),
+			assert: func(t *testing.T, err error, prototype *x509Authenticator, configured *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.trustStore, configured.trustStore)
+				assert.Equal(t, prototype.fc, configured.fc)
+				assert.Equal(t, prototype.sf, configured.sf)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "trust store cannot be redefined",
+			config: []byte("trust_store: " + trustStorePath),
+			assert: func(t *testing.T, err error, prototype *x509Authenticator, configured *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			pc, err := testsupport.DecodeTestConfig([]byte("trust_store: " + trustStorePath))
+			require.NoError(t, err)
+
+			rc, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newX509Authenticator("auth", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(rc)
+
+			// THEN
+			var configured *x509Authenticator
+			if err == nil {
+				configured, _ = auth.(*x509Authenticator)
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+// nolint: maintidx
+func TestX509AuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	type HandlerIdentifier interface {
+		HandlerID() string
+	}
+
+	// GIVEN
+	rootCA, err := testsupport.NewRootCA("Test Root CA", 24*time.Hour)
+	require.NoError(t, err)
+
+	untrustedCA, err := testsupport.NewRootCA("Untrusted CA", 24*time.Hour)
+	require.NoError(t, err)
+
+	intCAPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	intCACert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test Int CA", Organization: []string{"Test"}}),
+		testsupport.WithIsCA(),
+		testsupport.WithValidity(time.Now(), 24*time.Hour),
+		testsupport.WithSubjectPubKey(&intCAPrivKey.PublicKey, x509.ECDSAWithSHA384))
+	require.NoError(t, err)
+
+	intCA := testsupport.NewCA(intCAPrivKey, intCACert)
+
+	clientPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	spiffeID, err := url.Parse("spiffe://example.org/ns/default/sa/client")
+	require.NoError(t, err)
+
+	clientCert, err := intCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{
+			CommonName:         "client",
+			Organization:       []string{"Test"},
+			OrganizationalUnit: []string{"Dev"},
+		}),
+		testsupport.WithValidity(time.Now(), 12*time.Hour),
+		testsupport.WithSubjectPubKey(&clientPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth),
+		testsupport.WithDNSNames("client.example.org"),
+		testsupport.WithEmailAddresses("client@example.org"),
+		testsupport.WithURIs(spiffeID))
+	require.NoError(t, err)
+
+	serverCert, err := intCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "server"}),
+		testsupport.WithValidity(time.Now(), 12*time.Hour),
+		testsupport.WithSubjectPubKey(&clientPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageServerAuth))
+	require.NoError(t, err)
+
+	untrustedCert, err := untrustedCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "client"}),
+		testsupport.WithValidity(time.Now(), 12*time.Hour),
+		testsupport.WithSubjectPubKey(&clientPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth))
+	require.NoError(t, err)
+
+	trustStorePath := createX509TrustStoreFile(t, rootCA.Certificate)
+
+	for _, tc := range []struct {
+		uc               string
+		config           []byte
+		configureContext func(t *testing.T, fnt *mocks.RequestFunctionsMock)
+		assert           func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:     "no client certificate present",
+			config: []byte("trust_store: " + trustStorePath),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().ClientCertificates().Return(nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "no client certificate present")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "client certificate issued by an untrusted CA",
+			config: []byte("trust_store: " + trustStorePath),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().ClientCertificates().Return([]*x509.Certificate{untrustedCert})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "not trusted")
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "client certificate without intermediate CA certificate",
+			config: []byte("trust_store: " + trustStorePath),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().ClientCertificates().Return([]*x509.Certificate{clientCert})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "not trusted")
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "certificate not usable for client authentication",
+			config: []byte("trust_store: " + trustStorePath),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().ClientCertificates().Return([]*x509.Certificate{serverCert, intCACert})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "not trusted")
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "successful with client certificate from the TLS handshake",
+			config: []byte("trust_store: " + trustStorePath),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().ClientCertificates().Return([]*x509.Certificate{clientCert, intCACert})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, clientCert.Subject.String(), sub.ID)
+				assert.Equal(t, "client", sub.Attributes["common_name"])
+				assert.Equal(t, []any{"Test"}, sub.Attributes["organization"])
+				assert.Equal(t, []any{"Dev"}, sub.Attributes["organizational_unit"])
+				assert.Equal(t, intCACert.Subject.String(), sub.Attributes["issuer"])
+				assert.Equal(t, clientCert.SerialNumber.String(), sub.Attributes["serial_number"])
+				assert.Equal(t, []any{"client.example.org"}, sub.Attributes["dns_names"])
+				assert.Equal(t, []any{"client@example.org"}, sub.Attributes["email_addresses"])
+				assert.Equal(t, []any{}, sub.Attributes["ip_addresses"])
+				assert.Equal(t, []any{spiffeID.String()}, sub.Attributes["uris"])
+				assert.Equal(t, spiffeID.String(), sub.Attributes["spiffe_id"])
+				assert.Equal(t, float64(clientCert.NotBefore.Unix()), sub.Attributes["not_before"])
+				assert.Equal(t, float64(clientCert.NotAfter.Unix()), sub.Attributes["not_after"])
+				assert.Len(t, sub.Attributes["fingerprint"], 64)
+			},
+		},
+		{
+			uc: "successful with subject id taken from the SPIFFE id",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+subject:
+  id: spiffe_id
+`),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().ClientCertificates().Return([]*x509.Certificate{clientCert, intCACert})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, spiffeID.String(), sub.ID)
+			},
+		},
+		{
+			uc: "forwarded certificate header is ignored if not sent by a trusted proxy",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Client-Cert
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().PeerAddress().Return("192.168.1.1")
+				fnt.EXPECT().ClientCertificates().Return(nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "no client certificate present")
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "forwarded certificate header not present in request from a trusted proxy",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Client-Cert
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().PeerAddress().Return("10.1.2.3")
+				fnt.EXPECT().Header("X-Client-Cert").Return("")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "no X-Client-Cert header present")
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "malformed forwarded certificate",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Client-Cert
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().PeerAddress().Return("10.1.2.3")
+				fnt.EXPECT().Header("X-Client-Cert").Return("foobar")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "failed to parse forwarded certificate")
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "successful with forwarded certificate in PEM format",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Client-Cert
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().PeerAddress().Return("10.1.2.3")
+				fnt.EXPECT().Header("X-Client-Cert").Return(escapedPEM(t, clientCert, intCACert))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, clientCert.Subject.String(), sub.ID)
+			},
+		},
+		{
+			uc: "forwarded certificate in xfcc format without certificate",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Forwarded-Client-Cert
+  format: xfcc
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().PeerAddress().Return("10.1.2.3")
+				fnt.EXPECT().Header("X-Forwarded-Client-Cert").
+					Return(`By=spiffe://example.org/heimdall;Hash=abcdef;Subject="CN=client,O=Test"`)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "does not contain a certificate")
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "successful with forwarded certificate chain in xfcc format",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Forwarded-Client-Cert
+  format: xfcc
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().PeerAddress().Return("10.1.2.3")
+				fnt.EXPECT().Header("X-Forwarded-Client-Cert").
+					Return(`By=spiffe://example.org/proxy;Cert="` + escapedPEM(t, untrustedCert) + `"` +
+						`,By=spiffe://example.org/heimdall;Hash=abcdef;Subject="CN=client,OU=Dev,O=Test";` +
+						`Chain="` + escapedPEM(t, clientCert, intCACert) + `";URI=` + spiffeID.String())
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, clientCert.Subject.String(), sub.ID)
+				assert.Equal(t, spiffeID.String(), sub.Attributes["spiffe_id"])
+			},
+		},
+		{
+			uc: "forwarded certificate in xfcc format without intermediate CA certificate",
+			config: []byte(`
+trust_store: ` + trustStorePath + `
+forwarded_certificate:
+  header: X-Forwarded-Client-Cert
+  format: xfcc
+  trusted_proxies:
+    - 10.0.0.0/8
+`),
+			configureContext: func(t *testing.T, fnt *mocks.RequestFunctionsMock) {
+				t.Helper()
+
+				fnt.EXPECT().PeerAddress().Return("10.1.2.3")
+				fnt.EXPECT().Header("X-Forwarded-Client-Cert").
+					Return(`Hash=abcdef;Cert=` + escapedPEM(t, clientCert))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "not trusted")
+
+				assert.Nil(t, sub)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			auth, err := newX509Authenticator("auth", conf)
+			require.NoError(t, err)
+
+			fnt := mocks.NewRequestFunctionsMock(t)
+			tc.configureContext(t, fnt)
+
+			ctx := mocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(&heimdall.Request{RequestFunctions: fnt})
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+		})
+	}
+}
@@ -293,12 +293,12 @@ expressions:
 				ctx.EXPECT().Request().Return(&heimdall.Request{
 					RequestFunctions: reqf,
 					Method:           http.MethodGet,
-					URL: &url.URL{
+					URL: &heimdall.URL{URL: url.URL{
 						Scheme:   "http",
 						Host:     "localhost",
 						Path:     "/test",
 						RawQuery: "foo=bar&baz=zab",
-					},
+					}},
 					ClientIP: []string{"127.0.0.1", "10.10.10.10"},
 				})
 			},
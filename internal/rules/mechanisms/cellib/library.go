@@ -34,7 +34,7 @@ var (
 	//nolint:gochecknoglobals
 	requestType = types.NewTypeValue(reflect.TypeOf(heimdall.Request{}).String(), traits.ReceiverType)
 	//nolint:gochecknoglobals
-	urlType = types.NewTypeValue(reflect.TypeOf(url.URL{}).String(), traits.ReceiverType)
+	urlType = types.NewTypeValue(reflect.TypeOf(heimdall.URL{}).String(), traits.ReceiverType)
 )
 
 type heimdallLibrary struct{}
@@ -49,6 +49,7 @@ func (heimdallLibrary) CompileOptions() []cel.EnvOption {
 		ext.NativeTypes(
 			reflect.TypeOf(&subject.Subject{}),
 			reflect.TypeOf(&heimdall.Request{}),
+			reflect.TypeOf(&heimdall.URL{}),
 			reflect.TypeOf(&url.URL{})),
 		cel.Variable("Payload", cel.DynType),
 		cel.Variable("Subject", cel.DynType),
@@ -82,7 +83,7 @@ func (heimdallLibrary) CompileOptions() []cel.EnvOption {
 				[]*cel.Type{cel.ObjectType(urlType.TypeName())}, cel.StringType,
 				cel.UnaryBinding(func(value ref.Val) ref.Val {
 					// nolint: forcetypeassert
-					return types.String(value.Value().(*url.URL).String())
+					return types.String(value.Value().(*heimdall.URL).String())
 				}),
 			),
 		),
@@ -91,7 +92,7 @@ func (heimdallLibrary) CompileOptions() []cel.EnvOption {
 				[]*cel.Type{cel.ObjectType(urlType.TypeName())}, cel.DynType,
 				cel.UnaryBinding(func(value ref.Val) ref.Val {
 					// nolint: forcetypeassert
-					return types.NewDynamicMap(types.DefaultTypeAdapter, value.Value().(*url.URL).Query())
+					return types.NewDynamicMap(types.DefaultTypeAdapter, value.Value().(*heimdall.URL).Query())
 				}),
 			),
 		),
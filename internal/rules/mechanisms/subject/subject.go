@@ -25,8 +25,19 @@ import (
 type Subject struct {
 	ID         string         `json:"id"`
 	Attributes map[string]any `json:"attributes"`
+
+	anonymous bool
+}
+
+// NewAnonymous creates a subject for a not authenticated request. All such subjects share
+// the given id.
+func NewAnonymous(id string) *Subject {
+	return &Subject{ID: id, Attributes: make(map[string]any), anonymous: true}
 }
 
+// IsAnonymous returns true if the subject has been created for a not authenticated request.
+func (s *Subject) IsAnonymous() bool { return s.anonymous }
+
 func (s *Subject) Hash() []byte {
 	hash := sha256.New()
 	rawSub, _ := json.Marshal(s)
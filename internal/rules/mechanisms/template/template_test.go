@@ -42,8 +42,11 @@ func TestTemplateRender(t *testing.T) {
 	ctx.EXPECT().Request().Return(&heimdall.Request{
 		RequestFunctions: reqf,
 		Method:           http.MethodPatch,
-		URL:              &url.URL{Scheme: "http", Host: "foobar.baz", Path: "zab", RawQuery: "my_query_param=query_value"},
-		ClientIP:         []string{"192.168.1.1"},
+		URL: &heimdall.URL{
+			URL:      url.URL{Scheme: "http", Host: "foobar.baz", Path: "zab", RawQuery: "my_query_param=query_value"},
+			Captures: map[string]string{"tenant": "acme"},
+		},
+		ClientIP: []string{"192.168.1.1"},
 	})
 
 	sub := &subject.Subject{
@@ -66,6 +69,7 @@ func TestTemplateRender(t *testing.T) {
 "my_cookie": {{ .Request.Cookie "session_cookie" | quote }},
 "my_query_param": {{ index .Request.URL.Query.my_query_param 0 | quote }},
 "ips": {{ range $i, $el := .Request.ClientIP -}}{{ if $i }} {{ end }}{{ quote $el }}{{ end }},
+"tenant": {{ quote .Request.URL.Captures.tenant }},
 "values": [{{ quote .Values.key1 }}, {{ quote .Values.key2 }}]
 }`)
 	require.NoError(t, err)
@@ -91,6 +95,7 @@ func TestTemplateRender(t *testing.T) {
 "my_cookie": "session-value",
 "my_query_param": "query_value",
 "ips": "192.168.1.1",
+"tenant": "acme",
 "values": ["foo", "bar"]
 }`, res)
 }
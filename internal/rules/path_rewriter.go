@@ -0,0 +1,85 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/template"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+type pathRewriter struct {
+	tpl         template.Template
+	stripPrefix string
+	regex       *regexp.Regexp
+	replacement string
+	addPrefix   string
+}
+
+func newPathRewriter(conf *config.UpstreamRewrite) (*pathRewriter, error) {
+	var (
+		rewriter pathRewriter
+		err      error
+	)
+
+	if len(conf.PathTemplate) != 0 {
+		if rewriter.tpl, err = template.New(conf.PathTemplate); err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"bad path template in upstream rewrite").CausedBy(err)
+		}
+	}
+
+	if conf.PathRegex != nil {
+		if rewriter.regex, err = regexp.Compile(conf.PathRegex.Pattern); err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"bad path regex in upstream rewrite").CausedBy(err)
+		}
+
+		rewriter.replacement = conf.PathRegex.Replacement
+	}
+
+	rewriter.stripPrefix = conf.StripPathPrefix
+	rewriter.addPrefix = conf.AddPathPrefix
+
+	return &rewriter, nil
+}
+
+func (r *pathRewriter) rewrite(value *heimdall.URL) (string, error) {
+	path := value.Path
+
+	if r.tpl != nil {
+		var err error
+
+		path, err = r.tpl.Render(map[string]any{"URL": value})
+		if err != nil {
+			return "", errorchain.NewWithMessage(heimdall.ErrInternal,
+				"failed to render upstream path template").CausedBy(err)
+		}
+	}
+
+	path = strings.TrimPrefix(path, r.stripPrefix)
+
+	if r.regex != nil {
+		path = r.regex.ReplaceAllString(path, r.replacement)
+	}
+
+	return r.addPrefix + path, nil
+}
@@ -36,6 +36,9 @@ func (m *globMatcher) Match(value string) bool {
 	return m.compiled.Match(value)
 }
 
+// Captures always returns nil, as glob patterns without named parameters can't capture anything.
+func (m *globMatcher) Captures(string) map[string]string { return nil }
+
 func newGlobMatcher(pattern string) (*globMatcher, error) {
 	if len(pattern) == 0 {
 		return nil, ErrNoGlobPatternDefined
@@ -49,6 +52,18 @@ func newGlobMatcher(pattern string) (*globMatcher, error) {
 	return &globMatcher{compiled: compiled}, nil
 }
 
+// newGlobRegexMatcher is used for glob patterns with named parameters, as the values of these can only be
+// captured by a regular expression. All glob expressions are translated into equivalent regular expressions
+// for that purpose.
+func newGlobRegexMatcher(pattern string) (*regexpMatcher, error) {
+	expanded, err := expandNamedParameters(pattern, globToRegex)
+	if err != nil {
+		return nil, err
+	}
+
+	return compileRegexMatcher(expanded)
+}
+
 func compileGlob(pattern string, delimiterStart, delimiterEnd rune) (glob.Glob, error) {
 	// Check if it is well-formed.
 	idxs, errBraces := delimiterIndices(pattern, delimiterStart, delimiterEnd)
@@ -0,0 +1,152 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package patternmatcher
+
+import (
+	"regexp"
+	"strings"
+)
+
+// namedParameterRegex matches path segments like /:tenant, which are used to capture the values of
+// the corresponding segments by the given name. These are only recognized outside <...> expressions.
+var namedParameterRegex = regexp.MustCompile(`/:([A-Za-z_][A-Za-z0-9_]*)`)
+
+func hasNamedParameters(pattern string) bool {
+	found := false
+
+	_ = forEachStaticPart(pattern, func(part string) {
+		found = found || namedParameterRegex.MatchString(part)
+	})
+
+	return found
+}
+
+// expandNamedParameters returns a regex pattern for the given pattern, with all named parameters
+// replaced by named capture groups. Each <...> expression is rewritten using the given function.
+func expandNamedParameters(pattern string, rewriteExpression func(string) (string, error)) (string, error) {
+	idxs, err := delimiterIndices(pattern, '<', '>')
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		buffer strings.Builder
+		end    int
+	)
+
+	for ind := 0; ind < len(idxs); ind += 2 {
+		raw := pattern[end:idxs[ind]]
+		end = idxs[ind+1]
+
+		expr, err := rewriteExpression(pattern[idxs[ind]+1 : end-1])
+		if err != nil {
+			return "", err
+		}
+
+		buffer.WriteString(namedParameterRegex.ReplaceAllString(raw, "/<(?P<$1>[^/]+)>"))
+		buffer.WriteString("<" + expr + ">")
+	}
+
+	buffer.WriteString(namedParameterRegex.ReplaceAllString(pattern[end:], "/<(?P<$1>[^/]+)>"))
+
+	return buffer.String(), nil
+}
+
+func forEachStaticPart(pattern string, apply func(part string)) error {
+	idxs, err := delimiterIndices(pattern, '<', '>')
+	if err != nil {
+		return err
+	}
+
+	var end int
+
+	for ind := 0; ind < len(idxs); ind += 2 {
+		apply(pattern[end:idxs[ind]])
+		end = idxs[ind+1]
+	}
+
+	apply(pattern[end:])
+
+	return nil
+}
+
+// globToRegex translates a glob expression into a regular expression with the same semantics.
+// '.' and '/' are treated as separators, like it is done by the glob matcher.
+func globToRegex(expr string) (string, error) { //nolint: cyclop
+	var (
+		buffer       strings.Builder
+		alternatives int
+	)
+
+	for idx := 0; idx < len(expr); idx++ {
+		switch char := expr[idx]; char {
+		case '*':
+			if idx+1 < len(expr) && expr[idx+1] == '*' {
+				buffer.WriteString(".*")
+				idx++
+			} else {
+				buffer.WriteString("[^./]*")
+			}
+		case '?':
+			buffer.WriteString("[^./]")
+		case '[':
+			end := strings.IndexByte(expr[idx:], ']')
+			if end < 0 {
+				return "", ErrUnbalancedPattern
+			}
+
+			class := expr[idx+1 : idx+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			buffer.WriteString("[" + class + "]")
+			idx += end
+		case '{':
+			alternatives++
+
+			buffer.WriteString("(?:")
+		case '}':
+			if alternatives == 0 {
+				return "", ErrUnbalancedPattern
+			}
+
+			alternatives--
+
+			buffer.WriteString(")")
+		case ',':
+			if alternatives != 0 {
+				buffer.WriteString("|")
+			} else {
+				buffer.WriteString(",")
+			}
+		case '\\':
+			if idx+1 < len(expr) {
+				idx++
+				buffer.WriteString(regexp.QuoteMeta(expr[idx : idx+1]))
+			}
+		default:
+			buffer.WriteString(regexp.QuoteMeta(expr[idx : idx+1]))
+		}
+	}
+
+	if alternatives != 0 {
+		return "", ErrUnbalancedPattern
+	}
+
+	return buffer.String(), nil
+}
@@ -18,17 +18,26 @@ package patternmatcher
 
 import (
 	"errors"
+	"strings"
 )
 
 var ErrUnsupportedPatternMatcher = errors.New("unsupported pattern matcher")
 
 type PatternMatcher interface {
 	Match(value string) bool
+	// Captures returns the values of the named parameters and named groups of the pattern,
+	// captured from the given value. Returns nil if there is nothing to capture, or the
+	// value does not match.
+	Captures(value string) map[string]string
 }
 
 func NewPatternMatcher(typ, pattern string) (PatternMatcher, error) {
 	switch typ {
 	case "glob":
+		if hasNamedParameters(pattern) {
+			return newGlobRegexMatcher(pattern)
+		}
+
 		return newGlobMatcher(pattern)
 	case "regex":
 		return newRegexMatcher(pattern)
@@ -36,3 +45,23 @@ func NewPatternMatcher(typ, pattern string) (PatternMatcher, error) {
 		return nil, ErrUnsupportedPatternMatcher
 	}
 }
+
+// StaticPrefix returns the part of the given pattern preceding the first <...> expression
+// or, for the glob strategy, the first named parameter. As that part is matched literally by
+// all supported strategies, each value matching the pattern must start with it.
+func StaticPrefix(typ, pattern string) string {
+	if idx := strings.IndexByte(pattern, '<'); idx >= 0 {
+		pattern = pattern[:idx]
+	}
+
+	if typ != "glob" {
+		return pattern
+	}
+
+	if loc := namedParameterRegex.FindStringIndex(pattern); loc != nil {
+		// the leading slash is still part of the prefix
+		pattern = pattern[:loc[0]+1]
+	}
+
+	return pattern
+}
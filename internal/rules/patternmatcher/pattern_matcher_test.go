@@ -0,0 +1,179 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package patternmatcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticPrefix(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		strategy string
+		pattern  string
+		prefix   string
+	}{
+		{strategy: "glob", pattern: "", prefix: ""},
+		{strategy: "glob", pattern: "http://foo.bar/baz", prefix: "http://foo.bar/baz"},
+		{strategy: "glob", pattern: "http://foo.bar/<**>", prefix: "http://foo.bar/"},
+		{strategy: "glob", pattern: "<{http,https}>://foo.bar/<**>", prefix: ""},
+		{strategy: "regex", pattern: "http://foo.bar/api/<v[0-9]+>/<.*>", prefix: "http://foo.bar/api/"},
+		{strategy: "glob", pattern: "http://foo.bar:8080/api/:tenant/<**>", prefix: "http://foo.bar:8080/api/"},
+		{strategy: "regex", pattern: "http://foo.bar:8080/api/:tenant/<.*>", prefix: "http://foo.bar:8080/api/:tenant/"},
+	} {
+		t.Run(tc.strategy+":"+tc.pattern, func(t *testing.T) {
+			assert.Equal(t, tc.prefix, StaticPrefix(tc.strategy, tc.pattern))
+		})
+	}
+}
+
+func TestPatternMatcherCaptures(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		strategy string
+		pattern  string
+		value    string
+		matched  bool
+		captures map[string]string
+	}{
+		{
+			uc:       "glob without named parameters",
+			strategy: "glob",
+			pattern:  "http://foo.bar/<*>",
+			value:    "http://foo.bar/baz",
+			matched:  true,
+		},
+		{
+			uc:       "glob with named parameters",
+			strategy: "glob",
+			pattern:  "http://foo.bar:8080/api/tenants/:tenant/users/:id",
+			value:    "http://foo.bar:8080/api/tenants/acme.org/users/42",
+			matched:  true,
+			captures: map[string]string{"tenant": "acme.org", "id": "42"},
+		},
+		{
+			uc:       "glob with named parameters and glob expressions",
+			strategy: "glob",
+			pattern:  "<{http,https}>://<*>.bar/:tenant/<**>",
+			value:    "https://foo.bar/acme/users/42",
+			matched:  true,
+			captures: map[string]string{"tenant": "acme"},
+		},
+		{
+			uc:       "glob with named parameters not matching",
+			strategy: "glob",
+			pattern:  "http://foo.bar/:tenant/users",
+			value:    "http://foo.bar/acme/groups",
+			matched:  false,
+		},
+		{
+			uc:       "named parameter does not span multiple segments",
+			strategy: "glob",
+			pattern:  "http://foo.bar/:tenant",
+			value:    "http://foo.bar/acme/users",
+			matched:  false,
+		},
+		{
+			uc:       "regex with literal /: is not expanded to a named parameter",
+			strategy: "regex",
+			pattern:  "http://foo.bar/:tenant/users/<[0-9]+>",
+			value:    "http://foo.bar/:tenant/users/42",
+			matched:  true,
+		},
+		{
+			uc:       "regex with literal /: does not match other segments",
+			strategy: "regex",
+			pattern:  "http://foo.bar/:tenant/users/<[0-9]+>",
+			value:    "http://foo.bar/acme/users/42",
+			matched:  false,
+		},
+		{
+			uc:       "regex with named groups",
+			strategy: "regex",
+			pattern:  "http://foo.bar/<(?P<tenant>[a-z]+)>/users/<(?<id>[0-9]+)>",
+			value:    "http://foo.bar/acme/users/42",
+			matched:  true,
+			captures: map[string]string{"tenant": "acme", "id": "42"},
+		},
+		{
+			uc:       "regex with named groups not matching",
+			strategy: "regex",
+			pattern:  "http://foo.bar/<(?P<tenant>[a-z]+)>/users",
+			value:    "http://foo.bar/42/users",
+			matched:  false,
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			matcher, err := NewPatternMatcher(tc.strategy, tc.pattern)
+			require.NoError(t, err)
+
+			// WHEN
+			matched := matcher.Match(tc.value)
+			captures := matcher.Captures(tc.value)
+
+			// THEN
+			assert.Equal(t, tc.matched, matched)
+			assert.Equal(t, tc.captures, captures)
+		})
+	}
+}
+
+func TestGlobToRegexKeepsGlobSemantics(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		pattern string
+		values  []string
+	}{
+		{pattern: "urn:foo:<?>", values: []string{"urn:foo:user", "urn:foo:u", "urn:foo:"}},
+		{pattern: "urn:foo:<*>:role:<?>", values: []string{"urn:foo:usr:role:a", "urn:foo:usr:role:admin"}},
+		{pattern: "urn:foo:<m[a,o,u]n>", values: []string{"urn:foo:moon", "urn:foo:man", "urn:foo:m,n"}},
+		{pattern: "urn:foo:<m[!a,o,u]n>", values: []string{"urn:foo:man", "urn:foo:min"}},
+		{pattern: "urn:foo:<m[a-c]n>", values: []string{"urn:foo:mbn", "urn:foo:mdn"}},
+		{
+			pattern: "http://<*>.com/<*>",
+			values:  []string{"http://foo.com/bar", "http://foo.bar.com/bar", "http://foo.com/a/b"},
+		},
+		{pattern: "http://foo.com/<**>", values: []string{"http://foo.com/a/b.c", "http://foo.org/a"}},
+		{
+			pattern: "http://foo.com/<{foo*,bar.*}>",
+			values:  []string{"http://foo.com/foo1", "http://foo.com/bar.1", "http://foo.com/baz"},
+		},
+		{pattern: "http://foo.com/<\\*>", values: []string{"http://foo.com/*", "http://foo.com/a"}},
+	} {
+		t.Run(tc.pattern, func(t *testing.T) {
+			// GIVEN
+			globMatcher, err := newGlobMatcher(tc.pattern)
+			require.NoError(t, err)
+
+			regexMatcher, err := newGlobRegexMatcher(tc.pattern)
+			require.NoError(t, err)
+
+			for _, value := range tc.values {
+				// WHEN & THEN
+				assert.Equal(t, globMatcher.Match(value), regexMatcher.Match(value), value)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package patternmatcher
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/dlclark/regexp2"
 	"github.com/ory/ladon/compiler"
@@ -34,6 +35,12 @@ func newRegexMatcher(pattern string) (*regexpMatcher, error) {
 		return nil, ErrNoRegexPatternDefined
 	}
 
+	// named parameters are not supported, as /: might be a literal part of existing patterns.
+	// Named groups serve the same purpose.
+	return compileRegexMatcher(pattern)
+}
+
+func compileRegexMatcher(pattern string) (*regexpMatcher, error) {
 	compiled, err := compiler.CompileRegex(pattern, '<', '>')
 	if err != nil {
 		return nil, err
@@ -48,3 +55,27 @@ func (m *regexpMatcher) Match(matchAgainst string) bool {
 
 	return ok
 }
+
+func (m *regexpMatcher) Captures(value string) map[string]string {
+	match, err := m.compiled.FindStringMatch(value)
+	if err != nil || match == nil {
+		return nil
+	}
+
+	var captures map[string]string
+
+	for _, group := range match.Groups() {
+		// unnamed groups are named by their index
+		if _, err := strconv.Atoi(group.Name); err == nil {
+			continue
+		}
+
+		if captures == nil {
+			captures = make(map[string]string)
+		}
+
+		captures[group.Name] = group.String()
+	}
+
+	return captures
+}
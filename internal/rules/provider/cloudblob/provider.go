@@ -20,11 +20,11 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"fmt"
 	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
@@ -32,6 +32,7 @@ import (
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	rule_config "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/signature"
 	"github.com/dadrus/heimdall/internal/rules/rule"
 	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
@@ -52,6 +53,7 @@ type provider struct {
 func newProvider(
 	conf *config.Configuration,
 	processor rule.SetProcessor,
+	reg prometheus.Registerer,
 	logger zerolog.Logger,
 ) (*provider, error) {
 	rawConf := conf.Rules.Providers.CloudBlob
@@ -63,6 +65,7 @@ func newProvider(
 	type Config struct {
 		Buckets       []*ruleSetEndpoint `mapstructure:"buckets"`
 		WatchInterval *time.Duration     `mapstructure:"watch_interval"`
+		Signature     *signature.Config  `mapstructure:"signature"`
 	}
 
 	var providerConf Config
@@ -79,6 +82,16 @@ func newProvider(
 
 	logger = logger.With().Str("_provider_type", "cloud_blob").Logger()
 
+	var verifier signature.Verifier
+
+	if providerConf.Signature != nil {
+		var err error
+
+		if verifier, err = signature.NewVerifier(providerConf.Signature, "cloud_blob", reg, logger); err != nil {
+			return nil, err
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	ctx = logger.With().Logger().WithContext(ctx)
 
@@ -99,6 +112,8 @@ func newProvider(
 				"missing url for #%d bucket in cloud_blob rule provider configuration", idx)
 		}
 
+		bucket.verifier = verifier
+
 		if _, err := x.IfThenElseExec(providerConf.WatchInterval != nil && *providerConf.WatchInterval > 0,
 			func() *gocron.Scheduler { return prov.s.Every(*providerConf.WatchInterval) },
 			func() *gocron.Scheduler { return prov.s.Every(1 * time.Second).LimitRunsTo(1) }).
@@ -188,7 +203,7 @@ func (p *provider) ruleSetsUpdated(ruleSets []*rule_config.RuleSet, state Bucket
 	for _, ID := range removedIDs {
 		conf := &rule_config.RuleSet{
 			MetaData: rule_config.MetaData{
-				Source:  fmt.Sprintf("blob:%s", ID),
+				Source:  ID,
 				ModTime: time.Now(),
 			},
 		}
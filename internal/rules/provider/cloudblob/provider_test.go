@@ -26,6 +26,7 @@ import (
 
 	"github.com/johannesboyne/gofakes3"
 	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
@@ -127,6 +128,21 @@ buckets:
 				assert.False(t, prov.s.Jobs()[1].IsRunning())
 			},
 		},
+		{
+			uc: "with signature verification without trust store",
+			conf: []byte(`
+buckets:
+  - url: s3://foobar
+signature: {}
+`),
+			assert: func(t *testing.T, err error, prov *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no trust_store")
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -140,7 +156,7 @@ buckets:
 			}
 
 			// WHEN
-			prov, err := newProvider(conf, mocks.NewRuleSetProcessorMock(t), log.Logger)
+			prov, err := newProvider(conf, mocks.NewRuleSetProcessorMock(t), prometheus.NewRegistry(), log.Logger)
 
 			// THEN
 			tc.assert(t, err, prov)
@@ -542,7 +558,7 @@ rules:
 			}
 
 			logs := &strings.Builder{}
-			prov, err := newProvider(conf, mock, zerolog.New(logs))
+			prov, err := newProvider(conf, mock, prometheus.NewRegistry(), zerolog.New(logs))
 			require.NoError(t, err)
 
 			ctx := context.Background()
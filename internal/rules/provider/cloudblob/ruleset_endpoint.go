@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
 
 	"gocloud.dev/blob"
 	_ "gocloud.dev/blob/azureblob" // to support azure blobs
@@ -31,13 +32,20 @@ import (
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/signature"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
+// signatureBlobSuffix is the suffix of blobs holding the detached signature of the rule set
+// blob with the same key, but without that suffix.
+const signatureBlobSuffix = ".sig"
+
 type ruleSetEndpoint struct {
 	URL             *url.URL `mapstructure:"url"`
 	Prefix          string   `mapstructure:"prefix"`
 	RulesPathPrefix string   `mapstructure:"rule_path_match_prefix"`
+
+	verifier signature.Verifier
 }
 
 func (e *ruleSetEndpoint) ID() string {
@@ -75,7 +83,11 @@ func (e *ruleSetEndpoint) readAllBlobs(ctx context.Context, bucket *blob.Bucket)
 			return nil, mapError(err, "failed iterate blobs")
 		}
 
-		ruleSet, err := e.readRuleSet(ctx, bucket, obj.Key)
+		if strings.HasSuffix(obj.Key, signatureBlobSuffix) {
+			continue
+		}
+
+		blobRuleSets, err := e.readRuleSets(ctx, bucket, obj.Key)
 		if err != nil {
 			if errors.Is(err, config.ErrEmptyRuleSet) {
 				continue
@@ -84,14 +96,14 @@ func (e *ruleSetEndpoint) readAllBlobs(ctx context.Context, bucket *blob.Bucket)
 			return nil, err
 		}
 
-		ruleSets = append(ruleSets, ruleSet)
+		ruleSets = append(ruleSets, blobRuleSets...)
 	}
 
 	return ruleSets, nil
 }
 
 func (e *ruleSetEndpoint) readSingleBlob(ctx context.Context, bucket *blob.Bucket) ([]*config.RuleSet, error) {
-	ruleSet, err := e.readRuleSet(ctx, bucket, e.URL.Path)
+	ruleSets, err := e.readRuleSets(ctx, bucket, e.URL.Path)
 	if err != nil {
 		if errors.Is(err, config.ErrEmptyRuleSet) {
 			return []*config.RuleSet{}, nil
@@ -100,40 +112,63 @@ func (e *ruleSetEndpoint) readSingleBlob(ctx context.Context, bucket *blob.Bucke
 		return nil, err
 	}
 
-	return []*config.RuleSet{ruleSet}, nil
+	return ruleSets, nil
 }
 
-func (e *ruleSetEndpoint) readRuleSet(ctx context.Context, bucket *blob.Bucket, key string) (
-	*config.RuleSet, error,
+func (e *ruleSetEndpoint) readRuleSets(ctx context.Context, bucket *blob.Bucket, key string) (
+	[]*config.RuleSet, error,
 ) {
 	attrs, err := bucket.Attributes(ctx, key)
 	if err != nil {
 		return nil, mapError(err, "failed to get blob attributes")
 	}
 
-	reader, err := bucket.NewReader(ctx, key, nil)
+	data, err := bucket.ReadAll(ctx, key)
 	if err != nil {
 		return nil, mapError(err, "failed reading blob contents")
 	}
 
-	defer reader.Close()
+	src := fmt.Sprintf("%s@%s", key, e.ID())
 
-	contents, err := config.ParseRules(attrs.ContentType, reader)
+	// empty blobs are verified as well, as these result in the removal of the rule sets
+	if e.verifier != nil {
+		if err = e.verifySignature(ctx, bucket, key, src, data); err != nil {
+			return nil, err
+		}
+	}
+
+	ruleSets, err := config.ParseRuleSets(attrs.ContentType, src, data)
 	if err != nil {
 		return nil, errorchain.
 			NewWithMessage(heimdall.ErrInternal, "failed to decode received rule set").
 			CausedBy(err)
 	}
 
-	if err = contents.VerifyPathPrefix(e.RulesPathPrefix); err != nil {
-		return nil, err
+	for _, ruleSet := range ruleSets {
+		if err = ruleSet.VerifyPathPrefix(e.RulesPathPrefix); err != nil {
+			return nil, err
+		}
+
+		ruleSet.ModTime = attrs.ModTime
+	}
+
+	return ruleSets, nil
+}
+
+func (e *ruleSetEndpoint) verifySignature(
+	ctx context.Context, bucket *blob.Bucket, key, src string, data []byte,
+) error {
+	sig, err := bucket.ReadAll(ctx, key+signatureBlobSuffix)
+	if err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return mapError(err, "failed reading rule set signature")
 	}
 
-	contents.Hash = attrs.MD5
-	contents.Source = fmt.Sprintf("%s@%s", key, e.ID())
-	contents.ModTime = attrs.ModTime
+	if err = e.verifier.Verify(src, data, sig); err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to verify rule set signature").
+			CausedBy(err)
+	}
 
-	return contents, nil
+	return nil
 }
 
 func mapError(err error, message string) error {
@@ -17,21 +17,34 @@
 package cloudblob
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"fmt"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/johannesboyne/gofakes3"
 	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/signature"
+	"github.com/dadrus/heimdall/internal/truststore"
 	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
 )
 
 func TestFetchRuleSets(t *testing.T) { //nolint:maintidx
@@ -62,6 +75,41 @@ func TestFetchRuleSets(t *testing.T) { //nolint:maintidx
 		}
 	}
 
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	signerPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	signerCert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test Signer"}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&signerPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageCodeSigning))
+	require.NoError(t, err)
+
+	verifier, err := signature.NewVerifier(&signature.Config{TrustStore: truststore.TrustStore{rootCA.Certificate}},
+		"cloud_blob", prometheus.NewRegistry(), log.Logger)
+	require.NoError(t, err)
+
+	putSignedRuleSet := func(t *testing.T, key, contents string) {
+		t.Helper()
+
+		sig, err := testsupport.SignDetached([]byte(contents), jose.ES384, signerPrivKey, signerCert)
+		require.NoError(t, err)
+
+		_, err = backend.PutObject(bucketName, key,
+			map[string]string{"Content-Type": "application/yaml"},
+			strings.NewReader(contents), int64(len(contents)))
+		require.NoError(t, err)
+
+		_, err = backend.PutObject(bucketName, key+".sig",
+			map[string]string{"Content-Type": "application/jose"},
+			strings.NewReader(sig), int64(len(sig)))
+		require.NoError(t, err)
+	}
+
 	for _, tc := range []struct {
 		uc       string
 		endpoint ruleSetEndpoint
@@ -276,6 +324,68 @@ rules:
 				assert.Equal(t, "barfoo", ruleSets[1].Rules[0].ID)
 			},
 		},
+		{
+			uc: "rule set bundle and multi-document yaml stream",
+			endpoint: ruleSetEndpoint{
+				URL: &url.URL{
+					Scheme:   "s3",
+					Host:     bucketName,
+					RawQuery: fmt.Sprintf("endpoint=%s&disableSSL=true&s3ForcePathStyle=true&region=eu-central-1", srv.URL),
+				},
+			},
+			setup: func(t *testing.T) {
+				t.Helper()
+
+				ruleSet := func(name string) string {
+					return fmt.Sprintf(`
+version: "1"
+name: %s
+rules:
+- id: %s
+  match: http://<**>/%s
+  execute:
+  - authenticator: foo`, name, name, name)
+				}
+
+				bundle := testsupport.CreateTarGzBundle(t, map[string]string{
+					"foo.yaml": ruleSet("foo"),
+					"bar.yaml": ruleSet("bar"),
+				})
+				stream := ruleSet("baz") + "\n---\n" + ruleSet("zab")
+
+				_, err := backend.PutObject(bucketName, "bundle",
+					map[string]string{"Content-Type": "application/gzip"},
+					bytes.NewReader(bundle), int64(len(bundle)))
+				require.NoError(t, err)
+
+				_, err = backend.PutObject(bucketName, "stream",
+					map[string]string{"Content-Type": "application/yaml"},
+					strings.NewReader(stream), int64(len(stream)))
+				require.NoError(t, err)
+			},
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.Len(t, ruleSets, 4)
+
+				assert.True(t, strings.HasPrefix(ruleSets[0].Source, "bundle@"))
+				assert.True(t, strings.HasSuffix(ruleSets[0].Source, "#bar.yaml#bar"))
+				assert.Equal(t, "bar", ruleSets[0].Rules[0].ID)
+				assert.True(t, strings.HasSuffix(ruleSets[1].Source, "#foo.yaml#foo"))
+				assert.Equal(t, "foo", ruleSets[1].Rules[0].ID)
+				assert.True(t, strings.HasPrefix(ruleSets[2].Source, "stream@"))
+				assert.True(t, strings.HasSuffix(ruleSets[2].Source, "#baz"))
+				assert.Equal(t, "baz", ruleSets[2].Rules[0].ID)
+				assert.True(t, strings.HasSuffix(ruleSets[3].Source, "#zab"))
+				assert.Equal(t, "zab", ruleSets[3].Rules[0].ID)
+
+				for _, ruleSet := range ruleSets {
+					assert.NotEmpty(t, ruleSet.Hash)
+				}
+			},
+		},
 		{
 			uc: "only one rule set adhering to the required prefix",
 			endpoint: ruleSetEndpoint{
@@ -425,6 +535,125 @@ rules:
 				assert.Equal(t, "foobar", ruleSets[0].Rules[0].ID)
 			},
 		},
+		{
+			uc: "signed and unsigned rule sets with signature verification",
+			endpoint: ruleSetEndpoint{
+				URL: &url.URL{
+					Scheme:   "s3",
+					Host:     bucketName,
+					RawQuery: fmt.Sprintf("endpoint=%s&disableSSL=true&s3ForcePathStyle=true&region=eu-central-1", srv.URL),
+				},
+				verifier: verifier,
+			},
+			setup: func(t *testing.T) {
+				t.Helper()
+
+				ruleSet := `
+version: "1"
+name: test
+rules:
+- id: foo
+  match: http://<**>/foo
+  execute:
+  - authenticator: foo`
+
+				putSignedRuleSet(t, "test-rule1", ruleSet)
+
+				_, err := backend.PutObject(bucketName, "test-rule2",
+					map[string]string{"Content-Type": "application/yaml"},
+					strings.NewReader(ruleSet), int64(len(ruleSet)))
+				require.NoError(t, err)
+			},
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				require.ErrorIs(t, err, signature.ErrMissingSignature)
+				assert.Contains(t, err.Error(), "test-rule2")
+			},
+		},
+		{
+			uc: "unsigned empty blob with signature verification",
+			endpoint: ruleSetEndpoint{
+				URL: &url.URL{
+					Scheme:   "s3",
+					Host:     bucketName,
+					RawQuery: fmt.Sprintf("endpoint=%s&disableSSL=true&s3ForcePathStyle=true&region=eu-central-1", srv.URL),
+				},
+				verifier: verifier,
+			},
+			setup: func(t *testing.T) {
+				t.Helper()
+
+				_, err := backend.PutObject(bucketName, "test-rule",
+					map[string]string{"Content-Type": "application/yaml"},
+					strings.NewReader(""), 0)
+				require.NoError(t, err)
+			},
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, signature.ErrMissingSignature)
+			},
+		},
+		{
+			uc: "signed empty blob with signature verification",
+			endpoint: ruleSetEndpoint{
+				URL: &url.URL{
+					Scheme:   "s3",
+					Host:     bucketName,
+					RawQuery: fmt.Sprintf("endpoint=%s&disableSSL=true&s3ForcePathStyle=true&region=eu-central-1", srv.URL),
+				},
+				verifier: verifier,
+			},
+			setup: func(t *testing.T) {
+				t.Helper()
+
+				putSignedRuleSet(t, "test-rule", "")
+			},
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Empty(t, ruleSets)
+			},
+		},
+		{
+			uc: "signed rule set with signature verification",
+			endpoint: ruleSetEndpoint{
+				URL: &url.URL{
+					Scheme:   "s3",
+					Host:     bucketName,
+					RawQuery: fmt.Sprintf("endpoint=%s&disableSSL=true&s3ForcePathStyle=true&region=eu-central-1", srv.URL),
+				},
+				verifier: verifier,
+			},
+			setup: func(t *testing.T) {
+				t.Helper()
+
+				putSignedRuleSet(t, "test-rule", `
+version: "1"
+name: test
+rules:
+- id: foo
+  match: http://<**>/foo
+  execute:
+  - authenticator: foo`)
+			},
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.Len(t, ruleSets, 1)
+
+				assert.Contains(t, ruleSets[0].Source, "test-rule")
+				assert.Len(t, ruleSets[0].Rules, 1)
+				assert.Equal(t, "foo", ruleSets[0].Rules[0].ID)
+			},
+		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
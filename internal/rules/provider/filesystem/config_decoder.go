@@ -18,11 +18,14 @@ package filesystem
 
 import (
 	"github.com/mitchellh/mapstructure"
+
+	"github.com/dadrus/heimdall/internal/truststore"
 )
 
 func decodeConfig(input any, output any) error {
 	dec, err := mapstructure.NewDecoder(
 		&mapstructure.DecoderConfig{
+			DecodeHook:  truststore.DecodeTrustStoreHookFunc(),
 			Result:      output,
 			ErrorUnused: true,
 		})
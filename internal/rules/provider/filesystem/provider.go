@@ -19,35 +19,59 @@ package filesystem
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
 	"errors"
 	"fmt"
-	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/signature"
 	"github.com/dadrus/heimdall/internal/rules/rule"
+	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
+// signatureFileSuffix is the suffix of files holding the detached signature of the rule set
+// file with the same name, but without that suffix.
+const signatureFileSuffix = ".sig"
+
+// atomicWriterEntryPrefix is the prefix of the entries created by kubelet in ConfigMap and Secret
+// mounts. The data is placed into a timestamped directory, which is referenced by the ..data
+// symlink. The visible files are symlinks pointing into ..data. An update creates a new timestamped
+// directory, swaps the ..data symlink and removes the old directory.
+const atomicWriterEntryPrefix = ".."
+
+type FileState map[string][]byte
+
 type Provider struct {
 	src        string
+	recursive  bool
+	include    []string
+	exclude    []string
 	w          *fsnotify.Watcher
 	p          rule.SetProcessor
+	v          signature.Verifier
 	l          zerolog.Logger
 	states     sync.Map
 	configured bool
 }
 
-func NewProvider(conf *config.Configuration, processor rule.SetProcessor, logger zerolog.Logger) (*Provider, error) {
+func NewProvider(
+	conf *config.Configuration,
+	processor rule.SetProcessor,
+	reg prometheus.Registerer,
+	logger zerolog.Logger,
+) (*Provider, error) {
 	rawConf := conf.Rules.Providers.FileSystem
 
 	if conf.Rules.Providers.FileSystem == nil {
@@ -55,8 +79,12 @@ func NewProvider(conf *config.Configuration, processor rule.SetProcessor, logger
 	}
 
 	type Config struct {
-		Src   string `koanf:"src"`
-		Watch bool   `koanf:"watch"`
+		Src       string            `koanf:"src"`
+		Watch     bool              `koanf:"watch"`
+		Recursive bool              `koanf:"recursive"`
+		Include   []string          `koanf:"include"`
+		Exclude   []string          `koanf:"exclude"`
+		Signature *signature.Config `koanf:"signature"`
 	}
 
 	var providerConf Config
@@ -71,6 +99,15 @@ func NewProvider(conf *config.Configuration, processor rule.SetProcessor, logger
 			NewWithMessage(heimdall.ErrConfiguration, "no src configured for file_system rule provider")
 	}
 
+	for _, pattern := range append(providerConf.Include, providerConf.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errorchain.
+				NewWithMessagef(heimdall.ErrConfiguration,
+					"invalid pattern %q configured for file_system rule provider", pattern).
+				CausedBy(err)
+		}
+	}
+
 	absPath, err := filepath.Abs(providerConf.Src)
 	if err != nil {
 		return nil, errorchain.
@@ -97,12 +134,25 @@ func NewProvider(conf *config.Configuration, processor rule.SetProcessor, logger
 	}
 
 	logger = logger.With().Str("_provider_type", "file_system").Logger()
+
+	var verifier signature.Verifier
+	if providerConf.Signature != nil {
+		verifier, err = signature.NewVerifier(providerConf.Signature, "file_system", reg, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	logger.Info().Msg("Rule provider configured.")
 
 	return &Provider{
 		src:        absPath,
+		recursive:  providerConf.Recursive,
+		include:    providerConf.Include,
+		exclude:    providerConf.Exclude,
 		w:          watcher,
 		p:          processor,
+		v:          verifier,
 		l:          logger,
 		configured: true,
 	}, nil
@@ -128,7 +178,10 @@ func (p *Provider) Start(_ context.Context) error {
 		return nil
 	}
 
-	if err := p.w.Add(p.src); err != nil {
+	// a single file is watched via its directory. Otherwise, atomic replacements of that file, like
+	// done by editors, or by kubelet for ConfigMap mounts, would end the watch. This covers the
+	// signature file as well.
+	if err := p.watchDir(x.IfThenElse(isDir(p.src), p.src, filepath.Dir(p.src))); err != nil {
 		p.l.Error().Err(err).Msg("Failed to start rule definitions provider")
 
 		return err
@@ -180,26 +233,123 @@ func (p *Provider) watchFiles() {
 	}
 }
 
+func (p *Provider) watchDir(dir string) error {
+	if err := p.w.Add(dir); err != nil {
+		return err
+	}
+
+	if !p.recursive || !isDir(p.src) {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || isAtomicWriterEntry(entry.Name()) {
+			continue
+		}
+
+		if err = p.watchDir(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (p *Provider) ruleSetsChanged(evt fsnotify.Event) error {
 	p.l.Debug().
 		Str("_event", evt.String()).
 		Str("_src", evt.Name).
 		Msg("Rule update event received")
 
-	var err error
-
 	switch {
-	case evt.Has(fsnotify.Create) || evt.Has(fsnotify.Write) || evt.Has(fsnotify.Chmod):
-		err = p.ruleSetCreatedOrUpdated(evt.Name)
-	case evt.Has(fsnotify.Remove):
-		err = p.ruleSetDeleted(evt.Name)
+	case isAtomicWriterEntry(filepath.Base(evt.Name)):
+		// the visible files of a ConfigMap mount do not emit any events if the ..data symlink
+		// is swapped. So all files have to be checked for changes.
+		return p.resync()
+	case isDir(evt.Name):
+		if !p.recursive || !isDir(p.src) {
+			return nil
+		}
+
+		if evt.Has(fsnotify.Create) {
+			if err := p.watchDir(evt.Name); err != nil {
+				return err
+			}
+		}
+
+		// the directory could have been created together with its contents
+		return p.resync()
+	case strings.HasSuffix(evt.Name, signatureFileSuffix):
+		fileName := strings.TrimSuffix(evt.Name, signatureFileSuffix)
+		if p.v == nil || !p.selected(fileName) {
+			return nil
+		}
+
+		// a changed signature requires the verification of the rule set to be repeated
+		return p.ruleSetCreatedOrUpdated(fileName)
+	case evt.Has(fsnotify.Remove) || evt.Has(fsnotify.Rename):
+		if _, ok := p.states.Load(evt.Name); ok {
+			// the file could have been replaced in the meantime
+			return p.ruleSetCreatedOrUpdated(evt.Name)
+		}
+
+		if p.recursive {
+			// could have been a directory with rule set files
+			return p.resync()
+		}
+
+		return nil
+	case !p.selected(evt.Name):
+		return nil
+	default:
+		return p.ruleSetCreatedOrUpdated(evt.Name)
 	}
+}
+
+// resync loads all rule sets again, and removes the ones, which are not present any more.
+// Only rule sets with changed contents are reported as updated.
+func (p *Provider) resync() error {
+	sources, err := p.sources()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	present := make(map[string]bool, len(sources))
+
+	for _, src := range sources {
+		present[src] = true
 
-	return err
+		if err = p.ruleSetCreatedOrUpdated(src); err != nil {
+			p.l.Warn().Err(err).Str("_src", src).Msg("Failed to apply rule set changes")
+		}
+	}
+
+	var removed []string
+
+	p.states.Range(func(key, _ any) bool {
+		if fileName := key.(string); !present[fileName] { // nolint: forcetypeassert
+			removed = append(removed, fileName)
+		}
+
+		return true
+	})
+
+	for _, fileName := range removed {
+		if err = p.ruleSetDeleted(fileName); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (p *Provider) ruleSetCreatedOrUpdated(fileName string) error {
-	ruleSet, err := p.loadRuleSet(fileName)
+	ruleSets, err := p.loadRuleSets(fileName)
 	if err != nil {
 		if errors.Is(err, config2.ErrEmptyRuleSet) || errors.Is(err, os.ErrNotExist) {
 			return p.ruleSetDeleted(fileName)
@@ -208,45 +358,64 @@ func (p *Provider) ruleSetCreatedOrUpdated(fileName string) error {
 		return err
 	}
 
-	var hash []byte
+	value, _ := p.states.LoadOrStore(fileName, make(FileState))
+	state := value.(FileState) // nolint: forcetypeassert
 
-	value, ok := p.states.Load(fileName)
-	if ok {
-		hash = value.([]byte) // nolint: forcetypeassert
+	// a file can hold multiple rule sets. Only the ones, which have been removed
+	// or changed are reported
+	present := make(map[string]bool, len(ruleSets))
+	for _, ruleSet := range ruleSets {
+		present[ruleSet.Source] = true
 	}
 
-	switch {
-	case len(hash) == 0:
-		err = p.p.OnCreated(ruleSet)
-	case !bytes.Equal(hash, ruleSet.Hash):
-		err = p.p.OnUpdated(ruleSet)
-	default:
-		return nil
-	}
+	for src := range state {
+		if present[src] {
+			continue
+		}
 
-	if err != nil {
-		return err
+		if err = p.p.OnDeleted(deletedRuleSet(src)); err != nil {
+			return err
+		}
+
+		delete(state, src)
 	}
 
-	p.states.Store(fileName, ruleSet.Hash)
+	for _, ruleSet := range ruleSets {
+		hash, known := state[ruleSet.Source]
+
+		switch {
+		case !known:
+			err = p.p.OnCreated(ruleSet)
+		case !bytes.Equal(hash, ruleSet.Hash):
+			err = p.p.OnUpdated(ruleSet)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		state[ruleSet.Source] = ruleSet.Hash
+	}
 
 	return nil
 }
 
 func (p *Provider) ruleSetDeleted(fileName string) error {
-	if _, ok := p.states.Load(fileName); !ok {
+	value, ok := p.states.Load(fileName)
+	if !ok {
 		return nil
 	}
 
-	conf := &config2.RuleSet{
-		MetaData: config2.MetaData{
-			Source:  fmt.Sprintf("file_system:%s", fileName),
-			ModTime: time.Now(),
-		},
-	}
+	state := value.(FileState) // nolint: forcetypeassert
 
-	if err := p.p.OnDeleted(conf); err != nil {
-		return err
+	for src := range state {
+		if err := p.p.OnDeleted(deletedRuleSet(src)); err != nil {
+			return err
+		}
+
+		delete(state, src)
 	}
 
 	p.states.Delete(fileName)
@@ -254,16 +423,36 @@ func (p *Provider) ruleSetDeleted(fileName string) error {
 	return nil
 }
 
-func (p *Provider) loadRuleSet(fileName string) (*config2.RuleSet, error) {
-	file, err := os.Open(fileName)
+func (p *Provider) loadRuleSets(fileName string) ([]*config2.RuleSet, error) {
+	contents, err := os.ReadFile(fileName)
 	if err != nil {
 		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
-			"failed opening file %s", fileName).CausedBy(err)
+			"failed reading file %s", fileName).CausedBy(err)
+	}
+
+	src := fmt.Sprintf("file_system:%s", fileName)
+
+	// empty files are verified as well, as these result in the removal of the rule sets
+	if p.v != nil {
+		sig, err := os.ReadFile(fileName + signatureFileSuffix)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"failed reading signature file for %s", fileName).CausedBy(err)
+		}
+
+		if err = p.v.Verify(src, contents, sig); err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to verify rule set signature").
+				CausedBy(err)
+		}
 	}
 
-	md := sha256.New()
+	// files without a known extension are expected to be YAML files
+	contentType := config2.ContentTypeByExtension(fileName)
+	if len(contentType) == 0 {
+		contentType = config2.ContentTypeYAML
+	}
 
-	ruleSet, err := config2.ParseRules("application/yaml", io.TeeReader(file, md))
+	ruleSets, err := config2.ParseRuleSets(contentType, src, contents)
 	if err != nil {
 		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to parse received rule set").
 			CausedBy(err)
@@ -271,11 +460,11 @@ func (p *Provider) loadRuleSet(fileName string) (*config2.RuleSet, error) {
 
 	stat, _ := os.Stat(fileName)
 
-	ruleSet.Hash = md.Sum(nil)
-	ruleSet.Source = fmt.Sprintf("file_system:%s", fileName)
-	ruleSet.ModTime = stat.ModTime()
+	for _, ruleSet := range ruleSets {
+		ruleSet.ModTime = stat.ModTime()
+	}
 
-	return ruleSet, nil
+	return ruleSets, nil
 }
 
 func (p *Provider) loadInitialRuleSet() error {
@@ -296,33 +485,109 @@ func (p *Provider) loadInitialRuleSet() error {
 }
 
 func (p *Provider) sources() ([]string, error) {
-	var sources []string
-
 	fInfo, err := os.Stat(p.src)
 	if err != nil {
 		return nil, err
 	}
 
-	if fInfo.IsDir() {
-		dirEntries, err := os.ReadDir(p.src)
-		if err != nil {
-			return nil, err
-		}
+	if !fInfo.IsDir() {
+		return []string{p.src}, nil
+	}
 
-		for _, entry := range dirEntries {
-			path := filepath.Join(p.src, entry.Name())
+	return p.dirSources(p.src)
+}
 
-			if entry.IsDir() {
-				p.l.Warn().Str("_path", path).Msg("Ignoring directory")
+func (p *Provider) dirSources(dir string) ([]string, error) {
+	var sources []string
+
+	dirEntries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
 
-				continue
+	for _, entry := range dirEntries {
+		entryPath := filepath.Join(dir, entry.Name())
+
+		switch {
+		case isAtomicWriterEntry(entry.Name()):
+			continue
+		case entry.IsDir() && !p.recursive:
+			p.l.Warn().Str("_path", entryPath).Msg("Ignoring directory")
+		case entry.IsDir():
+			nested, err := p.dirSources(entryPath)
+			if err != nil {
+				return nil, err
 			}
 
-			sources = append(sources, path)
+			sources = append(sources, nested...)
+		case p.selected(entryPath):
+			sources = append(sources, entryPath)
 		}
-	} else {
-		sources = append(sources, p.src)
 	}
 
 	return sources, nil
 }
+
+// selected checks whether the given file is a rule set file, which should be loaded by
+// this provider, taking the configured include and exclude patterns into account.
+func (p *Provider) selected(fileName string) bool {
+	if !isDir(p.src) {
+		return fileName == p.src
+	}
+
+	rel, err := filepath.Rel(p.src, fileName)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	rel = filepath.ToSlash(rel)
+	if !p.recursive && strings.Contains(rel, "/") {
+		return false
+	}
+
+	for _, element := range strings.Split(rel, "/") {
+		if isAtomicWriterEntry(element) {
+			return false
+		}
+	}
+
+	if strings.HasSuffix(rel, signatureFileSuffix) {
+		return false
+	}
+
+	return (len(p.include) == 0 || matchesAny(p.include, rel)) && !matchesAny(p.exclude, rel)
+}
+
+// matchesAny checks whether the given slash separated path, relative to the src directory,
+// matches one of the given patterns. Patterns without a slash are matched against the
+// file name only.
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		value := x.IfThenElse(strings.Contains(pattern, "/"), rel, path.Base(rel))
+
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func deletedRuleSet(src string) *config2.RuleSet {
+	return &config2.RuleSet{
+		MetaData: config2.MetaData{
+			Source:  src,
+			ModTime: time.Now(),
+		},
+	}
+}
+
+func isAtomicWriterEntry(name string) bool {
+	return strings.HasPrefix(name, atomicWriterEntryPrefix)
+}
+
+func isDir(path string) bool {
+	fInfo, err := os.Stat(path)
+
+	return err == nil && fInfo.IsDir()
+}
@@ -18,21 +18,33 @@ package filesystem
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
 
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/signature"
 	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
+	"github.com/dadrus/heimdall/internal/truststore"
 	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/pkix/pemx"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
 	mock2 "github.com/dadrus/heimdall/internal/x/testsupport/mock"
 )
 
@@ -44,6 +56,20 @@ func TestNewProvider(t *testing.T) {
 
 	defer os.Remove(tmpFile.Name())
 
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	pemBytes, err := pemx.BuildPEM(pemx.WithX509Certificate(rootCA.Certificate))
+	require.NoError(t, err)
+
+	trustStoreFile, err := os.CreateTemp(os.TempDir(), "test-trust-store-")
+	require.NoError(t, err)
+
+	defer os.Remove(trustStoreFile.Name())
+
+	_, err = trustStoreFile.Write(pemBytes)
+	require.NoError(t, err)
+
 	for _, tc := range []struct {
 		uc     string
 		conf   map[string]any
@@ -118,12 +144,71 @@ func TestNewProvider(t *testing.T) {
 				assert.NotNil(t, prov.w)
 			},
 		},
+		{
+			uc:   "invalid include pattern",
+			conf: map[string]any{"src": tmpFile.Name(), "include": []string{"[*.yaml"}},
+			assert: func(t *testing.T, err error, prov *Provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid pattern")
+			},
+		},
+		{
+			uc: "successfully created provider with recursive lookup and include and exclude patterns",
+			conf: map[string]any{
+				"src":       os.TempDir(),
+				"recursive": true,
+				"include":   []string{"*.yaml", "*.json"},
+				"exclude":   []string{"*.bak.*"},
+			},
+			assert: func(t *testing.T, err error, prov *Provider) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, prov)
+				assert.True(t, prov.configured)
+				assert.True(t, prov.recursive)
+				assert.Equal(t, []string{"*.yaml", "*.json"}, prov.include)
+				assert.Equal(t, []string{"*.bak.*"}, prov.exclude)
+			},
+		},
+		{
+			uc: "signature verification without trust store",
+			conf: map[string]any{
+				"src":       tmpFile.Name(),
+				"signature": map[string]any{},
+			},
+			assert: func(t *testing.T, err error, prov *Provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no trust_store")
+			},
+		},
+		{
+			uc: "successfully created provider with signature verification",
+			conf: map[string]any{
+				"src":       tmpFile.Name(),
+				"signature": map[string]any{"trust_store": trustStoreFile.Name()},
+			},
+			assert: func(t *testing.T, err error, prov *Provider) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, prov)
+				assert.True(t, prov.configured)
+				assert.NotNil(t, prov.v)
+			},
+		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
 			conf := &config.Configuration{Rules: config.Rules{Providers: config.RuleProviders{FileSystem: tc.conf}}}
 
-			prov, err := NewProvider(conf, nil, log.Logger)
+			prov, err := NewProvider(conf, nil, prometheus.NewRegistry(), log.Logger)
 
 			tc.assert(t, err, prov)
 		})
@@ -132,9 +217,40 @@ func TestNewProvider(t *testing.T) {
 
 // nolint: maintidx
 func TestProviderLifecycle(t *testing.T) {
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	signerPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	signerCert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test Signer"}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&signerPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageCodeSigning))
+	require.NoError(t, err)
+
+	verifier, err := signature.NewVerifier(&signature.Config{TrustStore: truststore.TrustStore{rootCA.Certificate}},
+		"file_system", prometheus.NewRegistry(), log.Logger)
+	require.NoError(t, err)
+
+	sign := func(t *testing.T, fileName string, contents []byte) {
+		t.Helper()
+
+		sig, err := testsupport.SignDetached(contents, jose.ES384, signerPrivKey, signerCert)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(fileName+".sig", []byte(sig), 0o600))
+	}
+
 	for _, tc := range []struct {
 		uc             string
 		watch          bool
+		recursive      bool
+		include        []string
+		exclude        []string
+		verify         bool
 		setupContents  func(t *testing.T, file *os.File, dir string) string
 		setupProcessor func(t *testing.T, processor *mocks.RuleSetProcessorMock)
 		writeContents  func(t *testing.T, file *os.File, dir string)
@@ -424,6 +540,527 @@ rules:
 				assert.Contains(t, ruleSet.Source, "file_system:")
 			},
 		},
+		{
+			uc:        "successfully start provider without watcher using dir with nested directories recursively",
+			recursive: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				writeFile(t, filepath.Join(dir, "foo.yaml"), "version: \"1\"\nrules:\n- id: foo\n")
+				writeFile(t, filepath.Join(dir, "nested", "deeper", "bar.yaml"), "version: \"1\"\nrules:\n- id: bar\n")
+
+				return dir
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Twice()
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				ruleSets := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Values()
+				require.Len(t, ruleSets, 2)
+				assert.Equal(t, "file_system:"+filepath.Join(provider.src, "foo.yaml"), ruleSets[0].Source)
+				assert.Equal(t, "file_system:"+filepath.Join(provider.src, "nested", "deeper", "bar.yaml"),
+					ruleSets[1].Source)
+			},
+		},
+		{
+			uc:        "successfully start provider without watcher using dir with include and exclude patterns",
+			recursive: true,
+			include:   []string{"*.yaml", "*.json"},
+			exclude:   []string{"old-*", "nested/*.json"},
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				writeFile(t, filepath.Join(dir, "foo.yaml"), "version: \"1\"\nrules:\n- id: foo\n")
+				writeFile(t, filepath.Join(dir, "foo.yaml.bak"), "version: \"1\"\nrules:\n- id: foo\n")
+				writeFile(t, filepath.Join(dir, "old-foo.yaml"), "version: \"1\"\nrules:\n- id: foo\n")
+				writeFile(t, filepath.Join(dir, "README.md"), "# Rules")
+				writeFile(t, filepath.Join(dir, "nested", "bar.json"), `{"version": "1", "rules": [{"id": "bar"}]}`)
+				writeFile(t, filepath.Join(dir, "nested", "baz.yaml"), "version: \"1\"\nrules:\n- id: baz\n")
+
+				return dir
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Twice()
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				ruleSets := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Values()
+				require.Len(t, ruleSets, 2)
+				assert.Equal(t, "foo", ruleSets[0].Rules[0].ID)
+				assert.Equal(t, "baz", ruleSets[1].Rules[0].ID)
+			},
+		},
+		{
+			uc: "successfully start provider with watcher and recursive lookup using initially empty dir, " +
+				"adding nested directory with rule file and deleting it then",
+			watch:     true,
+			recursive: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0o700))
+
+				return dir
+			},
+			writeContents: func(t *testing.T, file *os.File, dir string) {
+				t.Helper()
+
+				require.NoError(t, os.Mkdir(filepath.Join(dir, "nested", "deeper"), 0o700))
+
+				time.Sleep(200 * time.Millisecond)
+
+				writeFile(t, filepath.Join(dir, "nested", "deeper", "foo.yaml"), "version: \"1\"\nrules:\n- id: foo\n")
+
+				time.Sleep(200 * time.Millisecond)
+
+				require.NoError(t, os.RemoveAll(filepath.Join(dir, "nested")))
+
+				time.Sleep(200 * time.Millisecond)
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				call1 := processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Once()
+
+				processor.EXPECT().OnDeleted(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor2").Capture).
+					Return(nil).Once().NotBefore(call1)
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				src := "file_system:" + filepath.Join(provider.src, "nested", "deeper", "foo.yaml")
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Value()
+				assert.Equal(t, src, ruleSet.Source)
+				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
+
+				ruleSet = mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor2").Value()
+				assert.Equal(t, src, ruleSet.Source)
+			},
+		},
+		{
+			uc: "successfully start provider without watcher using dir with rule set bundle and multi-document file",
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				writeFile(t, filepath.Join(dir, "bundle.tar.gz"), string(testsupport.CreateTarGzBundle(t,
+					map[string]string{
+						"foo.yaml":        "version: \"1\"\nrules:\n- id: foo\n",
+						"nested/bar.json": `{"version": "1", "rules": [{"id": "bar"}]}`,
+					})))
+				writeFile(t, filepath.Join(dir, "rules.yaml"),
+					"version: \"1\"\nname: baz\nrules:\n- id: baz\n---\nversion: \"1\"\nname: zab\nrules:\n- id: zab\n")
+
+				return dir
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Times(4)
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				bundleSrc := "file_system:" + filepath.Join(provider.src, "bundle.tar.gz")
+				fileSrc := "file_system:" + filepath.Join(provider.src, "rules.yaml")
+
+				ruleSets := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Values()
+				require.Len(t, ruleSets, 4)
+				assert.Equal(t, bundleSrc+"#foo.yaml", ruleSets[0].Source)
+				assert.Equal(t, "foo", ruleSets[0].Rules[0].ID)
+				assert.Equal(t, bundleSrc+"#nested/bar.json", ruleSets[1].Source)
+				assert.Equal(t, "bar", ruleSets[1].Rules[0].ID)
+				assert.Equal(t, fileSrc+"#baz", ruleSets[2].Source)
+				assert.Equal(t, "baz", ruleSets[2].Rules[0].ID)
+				assert.Equal(t, fileSrc+"#zab", ruleSets[3].Source)
+				assert.Equal(t, "zab", ruleSets[3].Rules[0].ID)
+			},
+		},
+		{
+			uc: "successfully start provider with watcher using multi-document file and updating " +
+				"single rule sets in it",
+			watch: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				writeFile(t, filepath.Join(dir, "rules.yaml"),
+					"version: \"1\"\nname: foo\nrules:\n- id: foo\n---\nversion: \"1\"\nname: bar\nrules:\n- id: bar\n")
+
+				return dir
+			},
+			writeContents: func(t *testing.T, file *os.File, dir string) {
+				t.Helper()
+
+				// replace the file at once to not observe partially written contents
+				tmpFile := filepath.Join(os.TempDir(), filepath.Base(dir)+"-rules.yaml")
+				writeFile(t, tmpFile,
+					"version: \"1\"\nname: bar\nrules:\n- id: baz\n---\nversion: \"1\"\nname: zab\nrules:\n- id: zab\n")
+				require.NoError(t, os.Rename(tmpFile, filepath.Join(dir, "rules.yaml")))
+
+				time.Sleep(200 * time.Millisecond)
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Times(3)
+
+				processor.EXPECT().OnUpdated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor2").Capture).
+					Return(nil).Once()
+
+				processor.EXPECT().OnDeleted(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor3").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				src := "file_system:" + filepath.Join(provider.src, "rules.yaml")
+
+				ruleSets := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Values()
+				require.Len(t, ruleSets, 3)
+				assert.Equal(t, src+"#foo", ruleSets[0].Source)
+				assert.Equal(t, src+"#bar", ruleSets[1].Source)
+				assert.Equal(t, src+"#zab", ruleSets[2].Source)
+				assert.Equal(t, "zab", ruleSets[2].Rules[0].ID)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor2").Value()
+				assert.Equal(t, src+"#bar", ruleSet.Source)
+				assert.Equal(t, "baz", ruleSet.Rules[0].ID)
+
+				ruleSet = mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor3").Value()
+				assert.Equal(t, src+"#foo", ruleSet.Source)
+			},
+		},
+		{
+			uc:    "successfully start provider with watcher using ConfigMap like mounted dir and updating it",
+			watch: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				writeAtomically(t, dir, "..2023_07_01_10_00_00.1", map[string]string{
+					"foo.yaml": "version: \"1\"\nrules:\n- id: foo\n",
+					"bar.yaml": "version: \"1\"\nrules:\n- id: bar\n",
+				})
+
+				return dir
+			},
+			writeContents: func(t *testing.T, file *os.File, dir string) {
+				t.Helper()
+
+				time.Sleep(200 * time.Millisecond)
+
+				writeAtomically(t, dir, "..2023_07_01_10_05_00.2", map[string]string{
+					"foo.yaml": "version: \"1\"\nrules:\n- id: baz\n",
+					"bar.yaml": "version: \"1\"\nrules:\n- id: bar\n",
+				})
+
+				time.Sleep(200 * time.Millisecond)
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				call1 := processor.EXPECT().OnCreated(mock.Anything).Return(nil).Twice()
+
+				processor.EXPECT().OnUpdated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Once().NotBefore(call1)
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Value()
+				assert.Equal(t, "file_system:"+filepath.Join(provider.src, "foo.yaml"), ruleSet.Source)
+				assert.Equal(t, "baz", ruleSet.Rules[0].ID)
+			},
+		},
+		{
+			uc:    "successfully start provider with watcher using file in ConfigMap like mounted dir and updating it",
+			watch: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				writeAtomically(t, dir, "..2023_07_01_10_00_00.1", map[string]string{
+					"foo.yaml": "version: \"1\"\nrules:\n- id: foo\n",
+				})
+
+				return filepath.Join(dir, "foo.yaml")
+			},
+			writeContents: func(t *testing.T, file *os.File, dir string) {
+				t.Helper()
+
+				time.Sleep(200 * time.Millisecond)
+
+				writeAtomically(t, dir, "..2023_07_01_10_05_00.2", map[string]string{
+					"foo.yaml": "version: \"1\"\nrules:\n- id: bar\n",
+				})
+
+				time.Sleep(200 * time.Millisecond)
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				call1 := processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+
+				processor.EXPECT().OnUpdated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Once().NotBefore(call1)
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Value()
+				assert.Equal(t, "file_system:"+provider.src, ruleSet.Source)
+				assert.Equal(t, "bar", ruleSet.Rules[0].ID)
+			},
+		},
+		{
+			uc:     "start provider with signature verification using unsigned file",
+			verify: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				_, err := file.Write([]byte(`
+version: "1"
+rules:
+- id: foo
+`))
+				require.NoError(t, err)
+
+				return file.Name()
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, signature.ErrMissingSignature)
+			},
+		},
+		{
+			uc:     "start provider with signature verification using file with invalid signature",
+			verify: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				_, err := file.Write([]byte(`
+version: "1"
+rules:
+- id: foo
+`))
+				require.NoError(t, err)
+
+				sign(t, file.Name(), []byte("foo"))
+
+				return file.Name()
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, signature.ErrInvalidSignature)
+			},
+		},
+		{
+			uc:     "successfully start provider with signature verification using dir with signed rule file",
+			verify: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				contents := []byte(`
+version: "1"
+rules:
+- id: foo
+`)
+
+				tmpFile, err := os.CreateTemp(dir, "test-rule-")
+				require.NoError(t, err)
+
+				_, err = tmpFile.Write(contents)
+				require.NoError(t, err)
+
+				sign(t, tmpFile.Name(), contents)
+
+				return dir
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Value()
+				assert.Contains(t, ruleSet.Source, "file_system:")
+				assert.Equal(t, "1", ruleSet.Version)
+				assert.Len(t, ruleSet.Rules, 1)
+				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
+			},
+		},
+		{
+			uc:     "start provider with watcher and signature verification using signed rule file, emptying it without signing",
+			watch:  true,
+			verify: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				contents := []byte(`
+version: "1"
+rules:
+- id: foo
+`)
+
+				_, err := file.Write(contents)
+				require.NoError(t, err)
+
+				sign(t, file.Name(), contents)
+
+				return file.Name()
+			},
+			writeContents: func(t *testing.T, file *os.File, dir string) {
+				t.Helper()
+
+				require.NoError(t, file.Truncate(0))
+
+				time.Sleep(200 * time.Millisecond)
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				// the rule set is not removed as the empty file is not signed
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:     "start provider with watcher and signature verification using signed rule file, emptying and signing it",
+			watch:  true,
+			verify: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				contents := []byte(`
+version: "1"
+rules:
+- id: foo
+`)
+
+				_, err := file.Write(contents)
+				require.NoError(t, err)
+
+				sign(t, file.Name(), contents)
+
+				return file.Name()
+			},
+			writeContents: func(t *testing.T, file *os.File, dir string) {
+				t.Helper()
+
+				sign(t, file.Name(), []byte{})
+				require.NoError(t, file.Truncate(0))
+
+				time.Sleep(200 * time.Millisecond)
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+				processor.EXPECT().OnDeleted(mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc: "successfully start provider with watcher and signature verification using initially empty dir, " +
+				"adding unsigned rule file and signing it then",
+			watch:  true,
+			verify: true,
+			setupContents: func(t *testing.T, file *os.File, dir string) string {
+				t.Helper()
+
+				return dir
+			},
+			writeContents: func(t *testing.T, file *os.File, dir string) {
+				t.Helper()
+
+				contents := []byte(`
+version: "1"
+rules:
+- id: foo
+`)
+
+				tmpFile, err := os.CreateTemp(dir, "test-rule-")
+				require.NoError(t, err)
+
+				_, err = tmpFile.Write(contents)
+				require.NoError(t, err)
+
+				time.Sleep(200 * time.Millisecond)
+
+				sign(t, tmpFile.Name(), contents)
+
+				time.Sleep(200 * time.Millisecond)
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error, provider *Provider, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Value()
+				assert.Contains(t, ruleSet.Source, "file_system:")
+				assert.Equal(t, "1", ruleSet.Version)
+				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			ctx := context.Background()
@@ -431,11 +1068,12 @@ rules:
 			require.NoError(t, err)
 
 			defer os.Remove(tmpFile.Name())
+			defer os.Remove(tmpFile.Name() + ".sig")
 
 			tmpDir, err := os.MkdirTemp(os.TempDir(), "test-rule-")
 			require.NoError(t, err)
 
-			defer os.Remove(tmpDir)
+			defer os.RemoveAll(tmpDir)
 
 			writeContents := x.IfThenElse(tc.writeContents != nil,
 				tc.writeContents,
@@ -468,7 +1106,11 @@ rules:
 			// GIVEN
 			prov := &Provider{
 				src:        setupContents(t, tmpFile, tmpDir),
+				recursive:  tc.recursive,
+				include:    tc.include,
+				exclude:    tc.exclude,
 				p:          processor,
+				v:          x.IfThenElse(tc.verify, verifier, nil),
 				l:          log.Logger,
 				w:          watcher,
 				configured: true,
@@ -486,3 +1128,35 @@ rules:
 		})
 	}
 }
+
+func writeFile(t *testing.T, fileName, contents string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(fileName), 0o700))
+	require.NoError(t, os.WriteFile(fileName, []byte(contents), 0o600))
+}
+
+// writeAtomically mimics the way kubelet updates ConfigMap mounts.
+func writeAtomically(t *testing.T, dir, tsDir string, files map[string]string) {
+	t.Helper()
+
+	oldTSDir, _ := os.Readlink(filepath.Join(dir, "..data"))
+
+	for name, contents := range files {
+		writeFile(t, filepath.Join(dir, tsDir, name), contents)
+	}
+
+	require.NoError(t, os.Symlink(tsDir, filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+	for name := range files {
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); err != nil {
+			require.NoError(t, os.Symlink(filepath.Join("..data", name), link))
+		}
+	}
+
+	if len(oldTSDir) != 0 {
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, oldTSDir)))
+	}
+}
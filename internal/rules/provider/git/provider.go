@@ -0,0 +1,208 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/provider/state"
+	"github.com/dadrus/heimdall/internal/rules/rule"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+type repositoryState struct {
+	commit   string
+	ruleSets *state.Tracker
+}
+
+type provider struct {
+	p            rule.SetProcessor
+	l            zerolog.Logger
+	s            *gocron.Scheduler
+	cancel       context.CancelFunc
+	repositories []*ruleSetRepository
+	states       sync.Map
+	configured   bool
+}
+
+func newProvider(
+	conf *config.Configuration,
+	processor rule.SetProcessor,
+	logger zerolog.Logger,
+) (*provider, error) {
+	rawConf := conf.Rules.Providers.Git
+
+	if rawConf == nil {
+		return &provider{}, nil
+	}
+
+	type Config struct {
+		Repositories  []*ruleSetRepository `mapstructure:"repositories"`
+		WatchInterval *time.Duration       `mapstructure:"watch_interval"`
+	}
+
+	var providerConf Config
+	if err := decodeConfig(rawConf, &providerConf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode git rule provider config").
+			CausedBy(err)
+	}
+
+	if len(providerConf.Repositories) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"no repositories configured for git rule provider")
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"git rule provider requires the git executable to be available").
+			CausedBy(err)
+	}
+
+	logger = logger.With().Str("_provider_type", "git").Logger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = logger.With().Logger().WithContext(ctx)
+
+	scheduler := gocron.NewScheduler(time.UTC)
+	scheduler.SingletonModeAll()
+
+	prov := &provider{
+		p:            processor,
+		l:            logger,
+		s:            scheduler,
+		cancel:       cancel,
+		repositories: providerConf.Repositories,
+		configured:   true,
+	}
+
+	for idx, repo := range providerConf.Repositories {
+		if err := repo.init(); err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed to initialize #%d repository in git rule provider configuration", idx).
+				CausedBy(err)
+		}
+
+		if _, err := x.IfThenElseExec(providerConf.WatchInterval != nil && *providerConf.WatchInterval > 0,
+			func() *gocron.Scheduler { return prov.s.Every(*providerConf.WatchInterval) },
+			func() *gocron.Scheduler { return prov.s.Every(1 * time.Second).LimitRunsTo(1) }).
+			Do(prov.watchChanges, ctx, repo); err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"failed to create a rule provider worker to fetch rules sets from #%d repository", idx).
+				CausedBy(err)
+		}
+	}
+
+	logger.Info().Msg("Rule provider configured.")
+
+	return prov, nil
+}
+
+func (p *provider) Start(_ context.Context) error {
+	if !p.configured {
+		return nil
+	}
+
+	p.l.Info().Msg("Starting rule definitions provider")
+
+	p.s.StartAsync() //nolint:contextcheck
+
+	return nil
+}
+
+func (p *provider) Stop(_ context.Context) error {
+	if !p.configured {
+		return nil
+	}
+
+	p.l.Info().Msg("Tearing down rule provider.")
+
+	p.s.Stop()
+	p.cancel()
+
+	for _, repo := range p.repositories {
+		if err := repo.close(); err != nil {
+			p.l.Warn().Err(err).Str("_repository", repo.ID()).Msg("Failed to remove local clone of repository")
+		}
+	}
+
+	return nil
+}
+
+func (p *provider) watchChanges(ctx context.Context, repo *ruleSetRepository) error {
+	p.l.Debug().Str("_repository", repo.ID()).Msg("Checking repository for new commits")
+
+	commit, err := repo.Fetch(ctx)
+	if err != nil {
+		return p.handleFetchError(err, repo)
+	}
+
+	state := p.getRepositoryState(repo)
+
+	if state.commit == commit {
+		p.l.Debug().Str("_repository", repo.ID()).Str("_commit", commit).Msg("No updates received")
+
+		return nil
+	}
+
+	ruleSets, err := repo.ReadRuleSets(ctx, commit)
+	if err != nil {
+		return p.handleFetchError(err, repo)
+	}
+
+	if err = state.ruleSets.Apply(ruleSets); err != nil {
+		p.l.Warn().Err(err).Str("_repository", repo.ID()).Msg("Failed to apply rule set changes")
+
+		return nil
+	}
+
+	p.l.Info().Str("_repository", repo.ID()).Str("_commit", commit).Msg("Rule sets synchronized")
+
+	state.commit = commit
+
+	return nil
+}
+
+// handleFetchError logs the given error. In contrast to other providers, rule sets known
+// from the past are kept as is, as an unreachable repository does not mean these have been removed.
+func (p *provider) handleFetchError(err error, repo *ruleSetRepository) error {
+	if errors.Is(err, context.Canceled) {
+		p.l.Debug().Msg("Watcher closed")
+
+		return nil
+	}
+
+	p.l.Warn().Err(err).Str("_repository", repo.ID()).Msg("Failed to fetch rule sets")
+
+	return err
+}
+
+func (p *provider) getRepositoryState(repo *ruleSetRepository) *repositoryState {
+	value, _ := p.states.LoadOrStore(repo, &repositoryState{ruleSets: state.NewTracker(p.p, p.l)})
+
+	return value.(*repositoryState) // nolint: forcetypeassert
+}
@@ -0,0 +1,365 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+	mock2 "github.com/dadrus/heimdall/internal/x/testsupport/mock"
+)
+
+func TestNewProvider(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   []byte
+		assert func(t *testing.T, err error, prov *provider)
+	}{
+		{
+			uc:   "with unknown field",
+			conf: []byte(`foo: bar`),
+			assert: func(t *testing.T, err error, prov *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc:   "without repositories",
+			conf: []byte(`watch_interval: 5s`),
+			assert: func(t *testing.T, err error, prov *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no repositories configured")
+			},
+		},
+		{
+			uc: "without url in one of the configured repositories",
+			conf: []byte(`
+repositories:
+  - url: https://github.com/acme/rules.git
+  - ref: main
+`),
+			assert: func(t *testing.T, err error, prov *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "#1 repository")
+			},
+		},
+		{
+			uc: "with watch interval and two repositories configured",
+			conf: []byte(`
+watch_interval: 5s
+repositories:
+  - url: https://github.com/acme/rules.git
+  - url: git@github.com:acme/other-rules.git
+    ref: v1.0.0
+    path: rules
+    rule_path_match_prefix: /foo
+`),
+			assert: func(t *testing.T, err error, prov *provider) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.NotNil(t, prov)
+				assert.NotNil(t, prov.s)
+				assert.NotNil(t, prov.p)
+				assert.NotNil(t, prov.cancel)
+				assert.False(t, prov.s.IsRunning())
+				assert.Len(t, prov.s.Jobs(), 2)
+				require.Len(t, prov.repositories, 2)
+				assert.Equal(t, "HEAD", prov.repositories[0].Ref)
+				assert.Equal(t, "v1.0.0", prov.repositories[1].Ref)
+				assert.Equal(t, "rules", prov.repositories[1].Path)
+				assert.Equal(t, "/foo", prov.repositories[1].RulesPathPrefix)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			providerConf, err := testsupport.DecodeTestConfig(tc.conf)
+			require.NoError(t, err)
+
+			conf := &config.Configuration{
+				Rules: config.Rules{
+					Providers: config.RuleProviders{Git: providerConf},
+				},
+			}
+
+			// WHEN
+			prov, err := newProvider(conf, mocks.NewRuleSetProcessorMock(t), log.Logger)
+
+			// THEN
+			tc.assert(t, err, prov)
+		})
+	}
+}
+
+func TestProviderLifecycle(t *testing.T) { //nolint:maintidx
+	t.Parallel()
+
+	type testCase struct {
+		uc             string
+		watchInterval  string
+		setupRepo      func(t *testing.T, repo *testRepository)
+		setupProcessor func(t *testing.T, processor *mocks.RuleSetProcessorMock)
+		assert         func(t *testing.T, repo *testRepository, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock)
+	}
+
+	for _, tc := range []testCase{
+		{
+			uc: "with repository without rule sets",
+			setupRepo: func(t *testing.T, repo *testRepository) {
+				t.Helper()
+
+				repo.writeFile("README.md", "# Rules")
+				repo.commit()
+			},
+			assert: func(t *testing.T, _ *testRepository, logs fmt.Stringer, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				messages := logs.String()
+				assert.NotContains(t, messages, "error")
+				assert.Contains(t, messages, "Rule sets synchronized")
+			},
+		},
+		{
+			uc: "with rule set and without watch interval",
+			setupRepo: func(t *testing.T, repo *testRepository) {
+				t.Helper()
+
+				repo.writeFile("foo.yaml", "version: \"1\"\nrules:\n- id: foo\n")
+				repo.commit()
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, repo *testRepository, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Value()
+				assert.Equal(t, "git:"+repo.dir+"/foo.yaml", ruleSet.Source)
+				assert.Equal(t, "1", ruleSet.Version)
+				require.Len(t, ruleSet.Rules, 1)
+				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
+			},
+		},
+		{
+			uc:            "rule set changes with watch interval",
+			watchInterval: "250ms",
+			setupRepo: func(t *testing.T, repo *testRepository) {
+				t.Helper()
+
+				repo.writeFile("foo.yaml", "version: \"1\"\nrules:\n- id: foo\n")
+				repo.writeFile("bar.yaml", "version: \"1\"\nrules:\n- id: bar\n")
+				repo.commit()
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "created").Capture).
+					Return(nil).Twice()
+				processor.EXPECT().OnUpdated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "updated").Capture).
+					Return(nil).Once()
+				processor.EXPECT().OnDeleted(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "deleted").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, repo *testRepository, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				// a commit modifying one of the rule sets
+				repo.writeFile("foo.yaml", "version: \"1\"\nrules:\n- id: baz\n")
+				repo.commit()
+
+				time.Sleep(500 * time.Millisecond)
+
+				// a commit removing the other one
+				repo.removeFile("bar.yaml")
+				repo.commit()
+
+				time.Sleep(500 * time.Millisecond)
+
+				assert.Contains(t, logs.String(), "No updates received")
+
+				created := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "created").Values()
+				require.Len(t, created, 2)
+				assert.Equal(t, "git:"+repo.dir+"/bar.yaml", created[0].Source)
+				assert.Equal(t, "git:"+repo.dir+"/foo.yaml", created[1].Source)
+
+				updated := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "updated").Value()
+				assert.Equal(t, "git:"+repo.dir+"/foo.yaml", updated.Source)
+				assert.Equal(t, "baz", updated.Rules[0].ID)
+
+				deleted := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "deleted").Value()
+				assert.Equal(t, "git:"+repo.dir+"/bar.yaml", deleted.Source)
+			},
+		},
+		{
+			uc:            "changes to single rule sets of a multi-document file",
+			watchInterval: "250ms",
+			setupRepo: func(t *testing.T, repo *testRepository) {
+				t.Helper()
+
+				repo.writeFile("rules.yaml",
+					"version: \"1\"\nname: foo\nrules:\n- id: foo\n---\nversion: \"1\"\nname: bar\nrules:\n- id: bar\n")
+				repo.commit()
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "created").Capture).
+					Return(nil).Twice()
+				processor.EXPECT().OnUpdated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "updated").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, repo *testRepository, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				// a commit modifying one of the rule sets only
+				repo.writeFile("rules.yaml",
+					"version: \"1\"\nname: foo\nrules:\n- id: foo\n---\nversion: \"1\"\nname: bar\nrules:\n- id: baz\n")
+				repo.commit()
+
+				time.Sleep(500 * time.Millisecond)
+
+				created := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "created").Values()
+				require.Len(t, created, 2)
+				assert.Equal(t, "git:"+repo.dir+"/rules.yaml#bar", created[0].Source)
+				assert.Equal(t, "git:"+repo.dir+"/rules.yaml#foo", created[1].Source)
+
+				updated := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "updated").Value()
+				assert.Equal(t, "git:"+repo.dir+"/rules.yaml#bar", updated.Source)
+				assert.Equal(t, "baz", updated.Rules[0].ID)
+			},
+		},
+		{
+			uc:            "failing rule set is retried",
+			watchInterval: "250ms",
+			setupRepo: func(t *testing.T, repo *testRepository) {
+				t.Helper()
+
+				repo.writeFile("foo.yaml", "version: \"1\"\nrules:\n- id: foo\n")
+				repo.commit()
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(testsupport.ErrTestPurpose).Once()
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, _ *testRepository, logs fmt.Stringer, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				messages := logs.String()
+				assert.Contains(t, messages, "Failed to apply rule set changes")
+				assert.Contains(t, messages, "Rule sets synchronized")
+				assert.Contains(t, messages, "No updates received")
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			repo := newTestRepository(t)
+			tc.setupRepo(t, repo)
+
+			setupProcessor := x.IfThenElse(
+				tc.setupProcessor != nil,
+				tc.setupProcessor,
+				func(t *testing.T, _ *mocks.RuleSetProcessorMock) { t.Helper() },
+			)
+
+			conf := fmt.Sprintf("repositories:\n- url: %s\n", repo.dir)
+			if len(tc.watchInterval) != 0 {
+				conf += "watch_interval: " + tc.watchInterval + "\n"
+			}
+
+			providerConf, err := testsupport.DecodeTestConfig([]byte(conf))
+			require.NoError(t, err)
+
+			processor := mocks.NewRuleSetProcessorMock(t)
+			setupProcessor(t, processor)
+
+			logs := &strings.Builder{}
+			prov, err := newProvider(&config.Configuration{
+				Rules: config.Rules{Providers: config.RuleProviders{Git: providerConf}},
+			}, processor, zerolog.New(logs))
+			require.NoError(t, err)
+
+			ctx := context.Background()
+
+			// WHEN
+			err = prov.Start(ctx)
+
+			// THEN
+			require.NoError(t, err)
+			tc.assert(t, repo, logs, processor)
+
+			require.NoError(t, prov.Stop(ctx))
+
+			for _, repo := range prov.repositories {
+				assert.Empty(t, repo.dir)
+			}
+		})
+	}
+}
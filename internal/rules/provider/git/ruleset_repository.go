@@ -0,0 +1,245 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const defaultRef = "HEAD"
+
+var errGitCommandFailed = errors.New("git command failed")
+
+type ruleSetRepository struct {
+	URL             string `mapstructure:"url"`
+	Ref             string `mapstructure:"ref"`
+	Path            string `mapstructure:"path"`
+	RulesPathPrefix string `mapstructure:"rule_path_match_prefix"`
+
+	dir string
+}
+
+// ID returns the url of the repository with the password, if present, being redacted.
+func (r *ruleSetRepository) ID() string {
+	if repoURL, err := url.Parse(r.URL); err == nil && repoURL.User != nil {
+		return repoURL.Redacted()
+	}
+
+	return r.URL
+}
+
+func (r *ruleSetRepository) init() error {
+	if len(r.URL) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration, "missing url")
+	}
+
+	if len(r.Ref) == 0 {
+		r.Ref = defaultRef
+	}
+
+	r.Path = strings.Trim(path.Clean("/"+r.Path), "/")
+
+	return nil
+}
+
+// Fetch clones the repository on first use, respectively fetches all branches and tags on subsequent
+// uses and returns the hash of the commit the configured ref points to.
+func (r *ruleSetRepository) Fetch(ctx context.Context) (string, error) {
+	if len(r.dir) == 0 {
+		if err := r.clone(ctx); err != nil {
+			return "", err
+		}
+	} else if _, err := r.git(ctx, "fetch", "--quiet", "--prune", "--force", "origin",
+		"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"); err != nil {
+		return "", r.mapError(ctx, err, heimdall.ErrCommunication, "failed to fetch repository")
+	}
+
+	out, err := r.git(ctx, "rev-parse", "--verify", "--quiet", r.Ref+"^{commit}")
+	if err != nil {
+		return "", r.mapError(ctx, err, heimdall.ErrConfiguration,
+			fmt.Sprintf("failed to resolve ref '%s'", r.Ref))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ReadRuleSets reads all rule sets available at the given commit. The returned rule sets are indexed
+// by the path of the file, they have been read from, followed by the ID of the rule set within the file,
+// if the file is a rule set bundle. Files not having a yaml, json, tar.gz or zip extension, as well
+// as empty rule sets are ignored.
+func (r *ruleSetRepository) ReadRuleSets(ctx context.Context, commit string) (map[string]*config.RuleSet, error) {
+	args := []string{"ls-tree", "-r", "-z", "--name-only", commit}
+	if len(r.Path) != 0 {
+		args = append(args, "--", r.Path)
+	}
+
+	out, err := r.git(ctx, args...)
+	if err != nil {
+		return nil, r.mapError(ctx, err, heimdall.ErrInternal, "failed to list files")
+	}
+
+	ruleSets := make(map[string]*config.RuleSet)
+
+	for _, fileName := range strings.Split(string(out), "\x00") {
+		contentType := config.ContentTypeByExtension(fileName)
+		if len(contentType) == 0 {
+			continue
+		}
+
+		fileRuleSets, err := r.readRuleSets(ctx, commit, fileName, contentType)
+		if err != nil {
+			if errors.Is(err, config.ErrEmptyRuleSet) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		for id, ruleSet := range fileRuleSets {
+			ruleSets[fileName+id] = ruleSet
+		}
+	}
+
+	return ruleSets, nil
+}
+
+// readRuleSets reads the rule sets from the given file, which can be a rule set bundle. The returned
+// rule sets are indexed by their IDs within the bundle, which is empty for files with a single rule set.
+func (r *ruleSetRepository) readRuleSets(
+	ctx context.Context, commit, fileName, contentType string,
+) (map[string]*config.RuleSet, error) {
+	// the commit, which has modified the file last, is used as the revision and its time as the
+	// modification time of the rule set. That way the rule sets of files, which have not been
+	// modified by a new commit, are not affected
+	out, err := r.git(ctx, "log", "-1", "--format=%H %ct", commit, "--", fileName)
+	if err != nil {
+		return nil, r.mapError(ctx, err, heimdall.ErrInternal,
+			fmt.Sprintf("failed to retrieve the history of %s", fileName))
+	}
+
+	revision, commitTime, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	timestamp, _ := strconv.ParseInt(commitTime, 10, 64)
+
+	contents, err := r.git(ctx, "cat-file", "blob", commit+":"+fileName)
+	if err != nil {
+		return nil, r.mapError(ctx, err, heimdall.ErrInternal,
+			fmt.Sprintf("failed to read contents of %s", fileName))
+	}
+
+	// the source must be stable across commits to have changes applied as updates to the already
+	// loaded rule sets. Otherwise, a broken commit would remove these without replacement.
+	src := fmt.Sprintf("git:%s/%s", strings.TrimSuffix(r.ID(), "/"), fileName)
+
+	parsed, err := config.ParseRuleSets(contentType, src, contents)
+	if err != nil {
+		if errors.Is(err, config.ErrEmptyRuleSet) {
+			return nil, err
+		}
+
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal, "failed to parse rule set from %s", fileName).
+			CausedBy(err)
+	}
+
+	ruleSets := make(map[string]*config.RuleSet, len(parsed))
+
+	for _, ruleSet := range parsed {
+		if err = ruleSet.VerifyPathPrefix(r.RulesPathPrefix); err != nil {
+			return nil, err
+		}
+
+		ruleSet.ModTime = time.Unix(timestamp, 0)
+		ruleSet.Revision = revision
+		ruleSets[strings.TrimPrefix(ruleSet.Source, src)] = ruleSet
+	}
+
+	return ruleSets, nil
+}
+
+func (r *ruleSetRepository) clone(ctx context.Context) error {
+	dir, err := os.MkdirTemp("", "heimdall-git-")
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to create directory for the repository").
+			CausedBy(err)
+	}
+
+	// the clone is bare, as the files are read directly from the object database
+	if _, err = r.run(ctx, "", "clone", "--quiet", "--bare", "--", r.URL, dir); err != nil {
+		_ = os.RemoveAll(dir)
+
+		return r.mapError(ctx, err, heimdall.ErrCommunication, "failed to clone repository")
+	}
+
+	r.dir = dir
+
+	return nil
+}
+
+func (r *ruleSetRepository) close() error {
+	if len(r.dir) == 0 {
+		return nil
+	}
+
+	err := os.RemoveAll(r.dir)
+	r.dir = ""
+
+	return err
+}
+
+func (r *ruleSetRepository) git(ctx context.Context, args ...string) ([]byte, error) {
+	return r.run(ctx, r.dir, args...)
+}
+
+func (r *ruleSetRepository) run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	// never wait for credentials to be entered
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: git %s: %s", errGitCommandFailed, args[0],
+			x.IfThenElse(stderr.Len() != 0, strings.TrimSpace(stderr.String()), err.Error()))
+	}
+
+	return out, nil
+}
+
+func (r *ruleSetRepository) mapError(ctx context.Context, err error, kind error, message string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return errorchain.NewWithMessage(kind, message).CausedBy(err)
+}
@@ -20,22 +20,27 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"fmt"
 	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 
 	"github.com/dadrus/heimdall/internal/cache"
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/signature"
 	"github.com/dadrus/heimdall/internal/rules/rule"
-	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/slicex"
 )
 
+type EndpointState map[string][]byte
+
 type provider struct {
 	p          rule.SetProcessor
 	l          zerolog.Logger
@@ -49,6 +54,7 @@ func newProvider(
 	conf *config.Configuration,
 	cch cache.Cache,
 	processor rule.SetProcessor,
+	reg prometheus.Registerer,
 	logger zerolog.Logger,
 ) (*provider, error) {
 	rawConf := conf.Rules.Providers.HTTPEndpoint
@@ -60,6 +66,7 @@ func newProvider(
 	type Config struct {
 		Endpoints     []*ruleSetEndpoint `mapstructure:"endpoints"`
 		WatchInterval *time.Duration     `mapstructure:"watch_interval"`
+		Signature     *signature.Config  `mapstructure:"signature"`
 	}
 
 	var providerConf Config
@@ -85,6 +92,18 @@ func newProvider(
 	}
 
 	logger = logger.With().Str("_provider_type", "http_endpoint").Logger()
+
+	if providerConf.Signature != nil {
+		verifier, err := signature.NewVerifier(providerConf.Signature, "http_endpoint", reg, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ep := range providerConf.Endpoints {
+			ep.verifier = verifier
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	ctx = logger.WithContext(cache.WithContext(ctx, cch))
 
@@ -100,10 +119,20 @@ func newProvider(
 	}
 
 	for idx, ep := range providerConf.Endpoints {
-		if _, err := x.IfThenElseExec(providerConf.WatchInterval != nil && *providerConf.WatchInterval > 0,
-			func() *gocron.Scheduler { return prov.s.Every(*providerConf.WatchInterval) },
-			func() *gocron.Scheduler { return prov.s.Every(1 * time.Second).LimitRunsTo(1) },
-		).Do(prov.watchChanges, ctx, ep); err != nil {
+		var job *gocron.Scheduler
+
+		switch {
+		case ep.LongPollTimeout > 0:
+			// the server holds the request until the rule set changes. Since the jobs are run in
+			// singleton mode, the next request is sent latest a second after the previous one returned
+			job = prov.s.Every(1 * time.Second)
+		case providerConf.WatchInterval != nil && *providerConf.WatchInterval > 0:
+			job = prov.s.Every(*providerConf.WatchInterval)
+		default:
+			job = prov.s.Every(1 * time.Second).LimitRunsTo(1)
+		}
+
+		if _, err := job.Do(prov.watchChanges, ctx, ep); err != nil {
 			return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
 				"failed to create a rule provider worker to fetch rules sets from #%d http_endpoint", idx).
 				CausedBy(err)
@@ -145,7 +174,7 @@ func (p *provider) watchChanges(ctx context.Context, rsf RuleSetFetcher) error {
 		Str("_endpoint", rsf.ID()).
 		Msg("Retrieving rule set")
 
-	ruleSet, err := rsf.FetchRuleSet(ctx)
+	ruleSets, err := rsf.FetchRuleSets(ctx)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			p.l.Debug().Msg("Watcher closed")
@@ -153,6 +182,14 @@ func (p *provider) watchChanges(ctx context.Context, rsf RuleSetFetcher) error {
 			return nil
 		}
 
+		if errors.Is(err, ErrRuleSetNotModified) {
+			p.l.Debug().
+				Str("_endpoint", rsf.ID()).
+				Msg("No updates received")
+
+			return nil
+		}
+
 		p.l.Warn().Err(err).
 			Str("_endpoint", rsf.ID()).
 			Msg("Failed to fetch rule set")
@@ -161,64 +198,103 @@ func (p *provider) watchChanges(ctx context.Context, rsf RuleSetFetcher) error {
 			(errors.Is(err, heimdall.ErrInternal) || errors.Is(err, heimdall.ErrConfiguration)) {
 			return err
 		}
+	}
 
-		ruleSet = &config2.RuleSet{
-			MetaData: config2.MetaData{
-				Source:  fmt.Sprintf("http_endpoint:%s", rsf.ID()),
-				ModTime: time.Now(),
-			},
-		}
+	state := p.getEndpointState(rsf.ID())
+
+	// if no rule sets are available and no rule sets were known from the past
+	if len(ruleSets) == 0 && len(state) == 0 {
+		p.l.Debug().
+			Str("_endpoint", rsf.ID()).
+			Msg("No updates received")
+
+		rsf.RuleSetsApplied()
+
+		return nil
 	}
 
-	if err = p.ruleSetsUpdated(ruleSet, rsf.ID()); err != nil {
+	if err = p.ruleSetsUpdated(ruleSets, state, rsf.ID()); err != nil {
 		p.l.Warn().Err(err).
 			Str("_src", rsf.ID()).
 			Msg("Failed to apply rule set changes")
+
+		// the response is not confirmed, so that the rule sets are retrieved and applied again
+		return nil
 	}
 
+	rsf.RuleSetsApplied()
+
 	return nil
 }
 
-func (p *provider) ruleSetsUpdated(ruleSet *config2.RuleSet, stateID string) error {
-	var hash []byte
+func (p *provider) ruleSetsUpdated(ruleSets []*config2.RuleSet, state EndpointState, endpointID string) error {
+	// check which were present in the past and are not present now
+	// and which are new
+	currentIDs := toRuleSetIDs(ruleSets)
+	oldIDs := maps.Keys(state)
 
-	if value, ok := p.states.Load(stateID); ok { //nolint:nestif
-		hash = value.([]byte) // nolint: forcetypeassert
+	removedIDs := slicex.Subtract(oldIDs, currentIDs)
+	newIDs := slicex.Subtract(currentIDs, oldIDs)
 
-		// rule set was known
-		if len(ruleSet.Rules) == 0 {
-			// rule set removed
-			if err := p.p.OnDeleted(ruleSet); err != nil {
-				return err
-			}
+	for _, ID := range removedIDs {
+		conf := &config2.RuleSet{
+			MetaData: config2.MetaData{
+				Source:  ID,
+				ModTime: time.Now(),
+			},
+		}
 
-			p.states.Delete(stateID)
+		if err := p.p.OnDeleted(conf); err != nil {
+			return err
+		}
 
-			return nil
-		} else if !bytes.Equal(hash, ruleSet.Hash) {
-			// rule set updated
-			if err := p.p.OnUpdated(ruleSet); err != nil {
-				return err
-			}
+		delete(state, ID)
+	}
 
-			p.states.Store(stateID, ruleSet.Hash)
+	// check which rule sets are new and which are modified
+	for _, ruleSet := range ruleSets {
+		isNew := slices.Contains(newIDs, ruleSet.Source)
+		hasChanged := !isNew && !bytes.Equal(state[ruleSet.Source], ruleSet.Hash)
 
-			return nil
+		if !isNew && !hasChanged {
+			p.l.Debug().
+				Str("_endpoint", endpointID).
+				Str("_rule_set", ruleSet.Source).
+				Msg("No updates received")
+
+			continue
 		}
-	} else if len(ruleSet.Rules) != 0 {
-		// previously unknown rule set
-		if err := p.p.OnCreated(ruleSet); err != nil {
-			return err
+
+		var err error
+
+		if isNew {
+			err = p.p.OnCreated(ruleSet)
+		} else {
+			err = p.p.OnUpdated(ruleSet)
 		}
 
-		p.states.Store(stateID, ruleSet.Hash)
+		if err != nil {
+			return err
+		}
 
-		return nil
+		state[ruleSet.Source] = ruleSet.Hash
 	}
 
-	p.l.Debug().
-		Str("_endpoint", stateID).
-		Msg("No updates received")
-
 	return nil
 }
+
+func (p *provider) getEndpointState(key string) EndpointState {
+	value, _ := p.states.LoadOrStore(key, make(EndpointState))
+
+	return value.(EndpointState) // nolint: forcetypeassert
+}
+
+func toRuleSetIDs(ruleSets []*config2.RuleSet) []string {
+	currentIDs := make([]string, len(ruleSets))
+
+	for idx, ruleSet := range ruleSets {
+		currentIDs[idx] = ruleSet.Source
+	}
+
+	return currentIDs
+}
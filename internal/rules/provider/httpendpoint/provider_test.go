@@ -26,6 +26,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
@@ -143,6 +144,21 @@ endpoints:
 				assert.False(t, prov.s.Jobs()[1].IsRunning())
 			},
 		},
+		{
+			uc: "with signature verification without trust store",
+			conf: []byte(`
+endpoints:
+- url: http://foo.bar/rules.yaml
+signature: {}
+`),
+			assert: func(t *testing.T, err error, prov *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no trust_store")
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -156,7 +172,8 @@ endpoints:
 			}
 
 			// WHEN
-			prov, err := newProvider(conf, memory.New(), mocks.NewRuleSetProcessorMock(t), log.Logger)
+			prov, err := newProvider(conf, memory.New(), mocks.NewRuleSetProcessorMock(t),
+				prometheus.NewRegistry(), log.Logger)
 
 			// THEN
 			tc.assert(t, err, prov)
@@ -167,7 +184,7 @@ endpoints:
 func TestProviderLifecycle(t *testing.T) { //nolint:maintidx
 	t.Parallel()
 
-	type ResponseWriter func(t *testing.T, w http.ResponseWriter)
+	type ResponseWriter func(t *testing.T, r *http.Request, w http.ResponseWriter)
 
 	var (
 		writeResponse ResponseWriter
@@ -180,7 +197,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx
 		requestCount++
 		rcm.Unlock()
 
-		writeResponse(t, w)
+		writeResponse(t, r, w)
 	}))
 
 	defer srv.Close()
@@ -214,7 +231,7 @@ endpoints:
 endpoints:
 - url: ` + srv.URL + `
 `),
-			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+			writeResponse: func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 				t.Helper()
 
 				w.WriteHeader(http.StatusBadRequest)
@@ -235,7 +252,7 @@ endpoints:
 endpoints:
 - url: ` + srv.URL + `
 `),
-			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+			writeResponse: func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 				t.Helper()
 
 				w.WriteHeader(http.StatusOK)
@@ -255,7 +272,7 @@ endpoints:
 endpoints:
 - url: ` + srv.URL + `
 `),
-			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+			writeResponse: func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 				t.Helper()
 
 				w.Header().Set("Content-Type", "application/yaml")
@@ -297,7 +314,7 @@ watch_interval: 250ms
 endpoints:
   - url: ` + srv.URL + `
 `),
-			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+			writeResponse: func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 				t.Helper()
 
 				w.Header().Set("Content-Type", "application/yaml")
@@ -342,7 +359,7 @@ endpoints:
 			writeResponse: func() ResponseWriter {
 				callIdx := 1
 
-				return func(t *testing.T, w http.ResponseWriter) {
+				return func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 					t.Helper()
 
 					switch callIdx {
@@ -408,6 +425,125 @@ rules:
 				assert.Empty(t, ruleSet.Rules)
 			},
 		},
+		{
+			uc: "unchanged rule set is not processed again",
+			conf: []byte(`
+watch_interval: 250ms
+endpoints:
+  - url: ` + srv.URL + `
+`),
+			writeResponse: func() ResponseWriter {
+				callIdx := 1
+
+				return func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
+					t.Helper()
+
+					if callIdx == 1 {
+						w.Header().Set("Content-Type", "application/yaml")
+						w.Header().Set("ETag", `"v1"`)
+						_, err := w.Write([]byte(`
+version: "1"
+name: test
+rules:
+- id: foo
+`))
+						require.NoError(t, err)
+					} else {
+						w.WriteHeader(http.StatusNotModified)
+					}
+
+					callIdx++
+				}
+			}(),
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1000 * time.Millisecond)
+
+				rcm.Lock()
+				defer rcm.Unlock()
+
+				assert.GreaterOrEqual(t, requestCount, 3)
+				assert.Contains(t, logs.String(), "No updates received")
+				assert.NotContains(t, logs.String(), "Failed to fetch rule set")
+			},
+		},
+		{
+			uc: "long polling",
+			conf: []byte(`
+endpoints:
+  - url: ` + srv.URL + `
+    long_poll_timeout: 1s
+`),
+			writeResponse: func() ResponseWriter {
+				callIdx := 1
+
+				return func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
+					t.Helper()
+
+					switch callIdx {
+					case 1:
+						w.Header().Set("Content-Type", "application/yaml")
+						w.Header().Set("ETag", `"v1"`)
+						_, err := w.Write([]byte(`
+version: "1"
+name: test
+rules:
+- id: foo
+`))
+						require.NoError(t, err)
+					case 2:
+						// the rule set changes while the request is held
+						time.Sleep(200 * time.Millisecond)
+
+						w.Header().Set("Content-Type", "application/yaml")
+						w.Header().Set("ETag", `"v2"`)
+						_, err := w.Write([]byte(`
+version: "1"
+name: test
+rules:
+- id: bar
+`))
+						require.NoError(t, err)
+					default:
+						// nothing changes till the timeout
+						time.Sleep(1 * time.Second)
+
+						w.WriteHeader(http.StatusNotModified)
+					}
+
+					callIdx++
+				}
+			}(),
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+				processor.EXPECT().OnUpdated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(2500 * time.Millisecond)
+
+				rcm.Lock()
+				defer rcm.Unlock()
+
+				// one request per second at most, as the last requests are held by the server
+				assert.GreaterOrEqual(t, requestCount, 3)
+				assert.LessOrEqual(t, requestCount, 4)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Value()
+				assert.Equal(t, "bar", ruleSet.Rules[0].ID)
+			},
+		},
 		{
 			uc: "successive changes to the rule set in each retrieval",
 			conf: []byte(`
@@ -418,7 +554,7 @@ endpoints:
 			writeResponse: func() ResponseWriter {
 				callIdx := 1
 
-				return func(t *testing.T, w http.ResponseWriter) {
+				return func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 					t.Helper()
 
 					switch callIdx {
@@ -509,6 +645,105 @@ rules:
 				assert.Equal(t, "foz", ruleSets[2].Rules[0].ID)
 			},
 		},
+		{
+			uc: "changes to single rule sets of a multi-document rule set stream",
+			conf: []byte(`
+watch_interval: 200ms
+endpoints:
+  - url: ` + srv.URL + `
+`),
+			writeResponse: func() ResponseWriter {
+				callIdx := 1
+
+				return func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
+					t.Helper()
+
+					w.Header().Set("Content-Type", "application/yaml")
+
+					switch callIdx {
+					case 1:
+						_, err := w.Write([]byte(`
+version: "1"
+name: foo
+rules:
+- id: foo
+---
+version: "1"
+name: bar
+rules:
+- id: bar
+`))
+						require.NoError(t, err)
+					case 2:
+						_, err := w.Write([]byte(`
+version: "1"
+name: foo
+rules:
+- id: foo
+---
+version: "1"
+name: bar
+rules:
+- id: baz
+`))
+						require.NoError(t, err)
+					default:
+						_, err := w.Write([]byte(`
+version: "1"
+name: foo
+rules:
+- id: foo
+---
+version: "1"
+name: baz
+rules:
+- id: zab
+`))
+						require.NoError(t, err)
+					}
+
+					callIdx++
+				}
+			}(),
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Times(3)
+
+				processor.EXPECT().OnUpdated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor2").Capture).
+					Return(nil).Once()
+
+				processor.EXPECT().OnDeleted(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor3").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1 * time.Second)
+
+				assert.True(t, requestCount >= 3)
+
+				created := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Values()
+				require.Len(t, created, 3)
+				assert.Equal(t, "http_endpoint:"+srv.URL+"#foo", created[0].Source)
+				assert.Equal(t, "foo", created[0].Rules[0].ID)
+				assert.Equal(t, "http_endpoint:"+srv.URL+"#bar", created[1].Source)
+				assert.Equal(t, "bar", created[1].Rules[0].ID)
+				assert.Equal(t, "http_endpoint:"+srv.URL+"#baz", created[2].Source)
+				assert.Equal(t, "zab", created[2].Rules[0].ID)
+
+				updated := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor2").Value()
+				assert.Equal(t, "http_endpoint:"+srv.URL+"#bar", updated.Source)
+				assert.Equal(t, "baz", updated.Rules[0].ID)
+
+				deleted := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor3").Value()
+				assert.Equal(t, "http_endpoint:"+srv.URL+"#bar", deleted.Source)
+			},
+		},
 		{
 			uc: "response is cached",
 			conf: []byte(`
@@ -516,7 +751,7 @@ watch_interval: 250ms
 endpoints:
   - url: ` + srv.URL + `
 `),
-			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+			writeResponse: func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 				t.Helper()
 
 				w.Header().Set("Expires", time.Now().Add(20*time.Second).UTC().Format(http.TimeFormat))
@@ -560,7 +795,7 @@ endpoints:
   - url: ` + srv.URL + `
     enable_http_cache: false
 `),
-			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+			writeResponse: func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 				t.Helper()
 
 				w.Header().Set("Expires", time.Now().Add(20*time.Second).UTC().Format(http.TimeFormat))
@@ -604,7 +839,7 @@ rules:
 endpoints:
 - url: ` + srv.URL + `
 `),
-			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+			writeResponse: func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 				t.Helper()
 
 				w.Header().Set("Content-Type", "application/yaml")
@@ -640,7 +875,7 @@ endpoints:
 			writeResponse: func() ResponseWriter {
 				callIdx := 1
 
-				return func(t *testing.T, w http.ResponseWriter) {
+				return func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 					t.Helper()
 
 					if callIdx == 1 {
@@ -681,6 +916,48 @@ rules:
 				assert.Contains(t, logs.String(), "Failed to apply rule set changes")
 			},
 		},
+		{
+			uc: "not applied rule set is retrieved again",
+			conf: []byte(`
+watch_interval: 200ms
+endpoints:
+- url: ` + srv.URL + `
+`),
+			writeResponse: func(t *testing.T, r *http.Request, w http.ResponseWriter) {
+				t.Helper()
+
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					w.WriteHeader(http.StatusNotModified)
+
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/yaml")
+				w.Header().Set("ETag", `"v1"`)
+				_, err := w.Write([]byte(`
+version: "1"
+name: test
+rules:
+- id: bar
+`))
+				require.NoError(t, err)
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(testsupport.ErrTestPurpose).Once()
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, logs fmt.Stringer, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1 * time.Second)
+
+				messages := logs.String()
+				assert.Contains(t, messages, "Failed to apply rule set changes")
+				assert.Contains(t, messages, "No updates received")
+			},
+		},
 		{
 			uc: "deleted rule set with error on delete",
 			conf: []byte(`
@@ -691,7 +968,7 @@ endpoints:
 			writeResponse: func() ResponseWriter {
 				callIdx := 1
 
-				return func(t *testing.T, w http.ResponseWriter) {
+				return func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 					t.Helper()
 
 					if callIdx == 1 {
@@ -747,14 +1024,14 @@ rules:
 			setupProcessor(t, processor)
 
 			logs := &strings.Builder{}
-			prov, err := newProvider(conf, memory.New(), processor, zerolog.New(logs))
+			prov, err := newProvider(conf, memory.New(), processor, prometheus.NewRegistry(), zerolog.New(logs))
 			require.NoError(t, err)
 
 			ctx := context.Background()
 
 			writeResponse = x.IfThenElse(tc.writeResponse != nil,
 				tc.writeResponse,
-				func(t *testing.T, w http.ResponseWriter) {
+				func(t *testing.T, _ *http.Request, w http.ResponseWriter) {
 					t.Helper()
 
 					w.WriteHeader(http.StatusOK)
@@ -18,7 +18,6 @@ package httpendpoint
 
 import (
 	"context"
-	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -29,18 +28,46 @@ import (
 	"github.com/dadrus/heimdall/internal/endpoint"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/signature"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
+// signatureHeader is the response header carrying the detached signature of the rule set.
+const signatureHeader = "X-Rule-Set-Signature"
+
+// longPollGracePeriod is the time a long polling request may take in addition to the
+// configured long poll timeout, before it is considered as timed out.
+const longPollGracePeriod = 10 * time.Second
+
+var ErrRuleSetNotModified = errors.New("rule set not modified")
+
 type ruleSetEndpoint struct {
 	endpoint.Endpoint `mapstructure:",squash"`
 
-	RulesPathPrefix string `mapstructure:"rule_path_match_prefix"`
+	RulesPathPrefix string        `mapstructure:"rule_path_match_prefix"`
+	LongPollTimeout time.Duration `mapstructure:"long_poll_timeout"`
+
+	// validators of the last successfully applied rule set. Used to make requests conditional.
+	etag         string
+	lastModified string
+	// validators of the last retrieved rule set, which has not been applied yet. As long as
+	// it is not applied, the requests must not be conditional to have it retrieved again.
+	pendingETag         string
+	pendingLastModified string
+
+	verifier signature.Verifier
 }
 
 func (e *ruleSetEndpoint) ID() string { return e.URL }
 
-func (e *ruleSetEndpoint) FetchRuleSet(ctx context.Context) (*config.RuleSet, error) {
+func (e *ruleSetEndpoint) FetchRuleSets(ctx context.Context) ([]*config.RuleSet, error) {
+	if e.LongPollTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, e.LongPollTimeout+longPollGracePeriod)
+		defer cancel()
+	}
+
 	req, err := e.CreateRequest(ctx, nil, nil)
 	if err != nil {
 		return nil, errorchain.
@@ -48,6 +75,25 @@ func (e *ruleSetEndpoint) FetchRuleSet(ctx context.Context) (*config.RuleSet, er
 			CausedBy(err)
 	}
 
+	if len(e.etag) != 0 {
+		req.Header.Set("If-None-Match", e.etag)
+	}
+
+	if len(e.lastModified) != 0 {
+		req.Header.Set("If-Modified-Since", e.lastModified)
+	}
+
+	if e.LongPollTimeout > 0 {
+		// see RFC 7240
+		req.Header.Set("Prefer", fmt.Sprintf("wait=%d", int(e.LongPollTimeout.Seconds())))
+	}
+
+	// the validators are only valid as long as the rule set retrieved with them is in use,
+	// which is not the case if the response does not confirm it
+	etag, lastModified := e.etag, e.lastModified
+	e.etag, e.lastModified = "", ""
+	e.pendingETag, e.pendingLastModified = "", ""
+
 	client := e.CreateClient(req.URL.Hostname())
 
 	resp, err := client.Do(req)
@@ -66,28 +112,61 @@ func (e *ruleSetEndpoint) FetchRuleSet(ctx context.Context) (*config.RuleSet, er
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		e.etag, e.lastModified = etag, lastModified
+
+		return nil, ErrRuleSetNotModified
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
 			"unexpected response code: %v", resp.StatusCode)
 	}
 
-	md := sha256.New()
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication, "failed reading rule set").
+			CausedBy(err)
+	}
+
+	src := fmt.Sprintf("http_endpoint:%s", e.ID())
+
+	// empty responses are verified as well, as these result in the removal of the rule sets
+	if e.verifier != nil {
+		if err = e.verifier.Verify(src, contents, []byte(resp.Header.Get(signatureHeader))); err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to verify rule set signature").
+				CausedBy(err)
+		}
+	}
 
-	ruleSet, err := config.ParseRules(resp.Header.Get("Content-Type"), io.TeeReader(resp.Body, md))
+	ruleSets, err := config.ParseRuleSets(resp.Header.Get("Content-Type"), src, contents)
 	if err != nil {
 		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to parse received rule set").
 			CausedBy(err)
 	}
 
-	if err = ruleSet.VerifyPathPrefix(e.RulesPathPrefix); err != nil {
-		return nil, err
+	modTime := time.Now()
+	if lastModified, parseErr := http.ParseTime(resp.Header.Get("Last-Modified")); parseErr == nil {
+		modTime = lastModified
 	}
 
-	ruleSet.Hash = md.Sum(nil)
-	ruleSet.Source = fmt.Sprintf("http_endpoint:%s", e.ID())
-	ruleSet.ModTime = time.Now()
+	for _, ruleSet := range ruleSets {
+		if err = ruleSet.VerifyPathPrefix(e.RulesPathPrefix); err != nil {
+			return nil, err
+		}
+
+		ruleSet.ModTime = modTime
+	}
+
+	e.pendingETag = resp.Header.Get("ETag")
+	e.pendingLastModified = resp.Header.Get("Last-Modified")
 
-	return ruleSet, nil
+	return ruleSets, nil
+}
+
+func (e *ruleSetEndpoint) RuleSetsApplied() {
+	e.etag, e.lastModified = e.pendingETag, e.pendingLastModified
+	e.pendingETag, e.pendingLastModified = "", ""
 }
 
 func (e *ruleSetEndpoint) init() error {
@@ -96,10 +175,16 @@ func (e *ruleSetEndpoint) init() error {
 			CausedBy(err)
 	}
 
+	if e.LongPollTimeout > 0 && e.LongPollTimeout < time.Second {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"long_poll_timeout must be at least one second")
+	}
+
 	e.Method = http.MethodGet
 
 	if e.HTTPCacheEnabled == nil {
-		cacheEnabled := true
+		// cached responses would defeat long polling
+		cacheEnabled := e.LongPollTimeout <= 0
 		e.HTTPCacheEnabled = &cacheEnabled
 	}
 
@@ -18,23 +18,34 @@ package httpendpoint
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
+	"gopkg.in/square/go-jose.v2"
 
 	"github.com/dadrus/heimdall/internal/cache"
 	"github.com/dadrus/heimdall/internal/cache/mocks"
 	"github.com/dadrus/heimdall/internal/endpoint"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/signature"
+	"github.com/dadrus/heimdall/internal/truststore"
 	"github.com/dadrus/heimdall/internal/x"
 	otelmock "github.com/dadrus/heimdall/internal/x/opentelemetry/mocks"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
 )
 
 func TestRuleSetEndpointInit(t *testing.T) {
@@ -56,6 +67,34 @@ func TestRuleSetEndpointInit(t *testing.T) {
 				assert.Contains(t, err.Error(), "validation")
 			},
 		},
+		{
+			uc: "init fails due to too short long poll timeout",
+			ep: &ruleSetEndpoint{
+				Endpoint:        endpoint.Endpoint{URL: "http://foo.bar"},
+				LongPollTimeout: 100 * time.Millisecond,
+			},
+			assert: func(t *testing.T, err error, ep *ruleSetEndpoint) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "long_poll_timeout")
+			},
+		},
+		{
+			uc: "init with long polling disables http cache by default",
+			ep: &ruleSetEndpoint{
+				Endpoint:        endpoint.Endpoint{URL: "http://foo.bar"},
+				LongPollTimeout: 30 * time.Second,
+			},
+			assert: func(t *testing.T, err error, ep *ruleSetEndpoint) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, ep.HTTPCacheEnabled)
+				assert.False(t, *ep.HTTPCacheEnabled)
+			},
+		},
 		{
 			uc: "init successful",
 			ep: &ruleSetEndpoint{Endpoint: endpoint.Endpoint{URL: "http://foo.bar"}},
@@ -80,7 +119,7 @@ func TestRuleSetEndpointInit(t *testing.T) {
 	}
 }
 
-func TestRuleSetEndpointFetchRuleSet(t *testing.T) { //nolint:maintidx
+func TestRuleSetEndpointFetchRuleSets(t *testing.T) { //nolint:maintidx
 	t.Parallel()
 
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
@@ -102,11 +141,43 @@ func TestRuleSetEndpointFetchRuleSet(t *testing.T) { //nolint:maintidx
 
 	defer srv.Close()
 
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	signerPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	signerCert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test Signer"}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&signerPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageCodeSigning))
+	require.NoError(t, err)
+
+	verifier, err := signature.NewVerifier(&signature.Config{TrustStore: truststore.TrustStore{rootCA.Certificate}},
+		"http_endpoint", prometheus.NewRegistry(), log.Logger)
+	require.NoError(t, err)
+
+	signedRuleSet := []byte(`
+version: "1"
+name: test
+rules:
+- id: foo
+  match: http://<**>/foo
+`)
+
+	sig, err := testsupport.SignDetached(signedRuleSet, jose.ES384, signerPrivKey, signerCert)
+	require.NoError(t, err)
+
+	emptySig, err := testsupport.SignDetached([]byte{}, jose.ES384, signerPrivKey, signerCert)
+	require.NoError(t, err)
+
 	for _, tc := range []struct {
 		uc            string
 		ep            *ruleSetEndpoint
 		writeResponse ResponseWriter
-		assert        func(t *testing.T, err error, ruleSet *config.RuleSet)
+		assert        func(t *testing.T, err error, ruleSets []*config.RuleSet)
 	}{
 		{
 			uc: "rule set loading error due to DNS error",
@@ -116,7 +187,7 @@ func TestRuleSetEndpointFetchRuleSet(t *testing.T) { //nolint:maintidx
 					Method: http.MethodGet,
 				},
 			},
-			assert: func(t *testing.T, err error, _ *config.RuleSet) {
+			assert: func(t *testing.T, err error, _ []*config.RuleSet) {
 				t.Helper()
 
 				require.Error(t, err)
@@ -137,7 +208,7 @@ func TestRuleSetEndpointFetchRuleSet(t *testing.T) { //nolint:maintidx
 
 				w.WriteHeader(http.StatusBadRequest)
 			},
-			assert: func(t *testing.T, err error, _ *config.RuleSet) {
+			assert: func(t *testing.T, err error, _ []*config.RuleSet) {
 				t.Helper()
 
 				require.Error(t, err)
@@ -164,7 +235,7 @@ rules:
 `))
 				require.NoError(t, err)
 			},
-			assert: func(t *testing.T, err error, _ *config.RuleSet) {
+			assert: func(t *testing.T, err error, _ []*config.RuleSet) {
 				t.Helper()
 
 				require.Error(t, err)
@@ -185,7 +256,7 @@ rules:
 
 				w.WriteHeader(http.StatusOK)
 			},
-			assert: func(t *testing.T, err error, ruleSet *config.RuleSet) {
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
 				t.Helper()
 
 				require.Error(t, err)
@@ -212,15 +283,15 @@ rules:
 `))
 				require.NoError(t, err)
 			},
-			assert: func(t *testing.T, err error, ruleSet *config.RuleSet) {
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
 				t.Helper()
 
 				require.NoError(t, err)
 
-				require.NotNil(t, ruleSet)
-				assert.Len(t, ruleSet.Rules, 1)
-				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
-				require.NotEmpty(t, ruleSet.Hash)
+				require.Len(t, ruleSets, 1)
+				assert.Len(t, ruleSets[0].Rules, 1)
+				assert.Equal(t, "foo", ruleSets[0].Rules[0].ID)
+				require.NotEmpty(t, ruleSets[0].Hash)
 			},
 		},
 		{
@@ -244,15 +315,47 @@ rules:
 }`))
 				require.NoError(t, err)
 			},
-			assert: func(t *testing.T, err error, ruleSet *config.RuleSet) {
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.Len(t, ruleSets, 1)
+				assert.Len(t, ruleSets[0].Rules, 1)
+				assert.Equal(t, "foo", ruleSets[0].Rules[0].ID)
+				require.NotEmpty(t, ruleSets[0].Hash)
+			},
+		},
+		{
+			uc: "valid rule set bundle",
+			ep: &ruleSetEndpoint{
+				Endpoint: endpoint.Endpoint{
+					URL:    srv.URL,
+					Method: http.MethodGet,
+				},
+			},
+			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+				t.Helper()
+
+				w.Header().Set("Content-Type", "application/gzip")
+				_, err := w.Write(testsupport.CreateTarGzBundle(t, map[string]string{
+					"foo.yaml": "version: \"1\"\nname: foo\nrules:\n- id: foo\n",
+					"bar.json": `{"version": "1", "name": "bar", "rules": [{"id": "bar"}]}`,
+				}))
+				require.NoError(t, err)
+			},
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
 				t.Helper()
 
 				require.NoError(t, err)
 
-				require.NotNil(t, ruleSet)
-				assert.Len(t, ruleSet.Rules, 1)
-				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
-				require.NotEmpty(t, ruleSet.Hash)
+				require.Len(t, ruleSets, 2)
+				assert.Equal(t, "http_endpoint:"+srv.URL+"#bar.json#bar", ruleSets[0].Source)
+				assert.Equal(t, "bar", ruleSets[0].Rules[0].ID)
+				require.NotEmpty(t, ruleSets[0].Hash)
+				assert.Equal(t, "http_endpoint:"+srv.URL+"#foo.yaml#foo", ruleSets[1].Source)
+				assert.Equal(t, "foo", ruleSets[1].Rules[0].ID)
+				require.NotEmpty(t, ruleSets[1].Hash)
 			},
 		},
 		{
@@ -277,7 +380,7 @@ rules:
 }`))
 				require.NoError(t, err)
 			},
-			assert: func(t *testing.T, err error, _ *config.RuleSet) {
+			assert: func(t *testing.T, err error, _ []*config.RuleSet) {
 				t.Helper()
 
 				require.Error(t, err)
@@ -307,7 +410,7 @@ rules:
 }`))
 				require.NoError(t, err)
 			},
-			assert: func(t *testing.T, err error, _ *config.RuleSet) {
+			assert: func(t *testing.T, err error, _ []*config.RuleSet) {
 				t.Helper()
 
 				require.Error(t, err)
@@ -337,15 +440,131 @@ rules:
 }`))
 				require.NoError(t, err)
 			},
-			assert: func(t *testing.T, err error, ruleSet *config.RuleSet) {
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.Len(t, ruleSets, 1)
+				assert.Len(t, ruleSets[0].Rules, 1)
+				assert.Equal(t, "foo", ruleSets[0].Rules[0].ID)
+				require.NotEmpty(t, ruleSets[0].Hash)
+			},
+		},
+		{
+			uc: "unsigned rule set with signature verification",
+			ep: &ruleSetEndpoint{
+				Endpoint: endpoint.Endpoint{
+					URL:    srv.URL,
+					Method: http.MethodGet,
+				},
+				verifier: verifier,
+			},
+			writeResponse: func(t *testing.T, w http.ResponseWriter) {
 				t.Helper()
 
+				w.Header().Set("Content-Type", "application/yaml")
+				_, err := w.Write(signedRuleSet)
 				require.NoError(t, err)
+			},
+			assert: func(t *testing.T, err error, _ []*config.RuleSet) {
+				t.Helper()
 
-				require.NotNil(t, ruleSet)
-				assert.Len(t, ruleSet.Rules, 1)
-				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
-				require.NotEmpty(t, ruleSet.Hash)
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.ErrorIs(t, err, signature.ErrMissingSignature)
+			},
+		},
+		{
+			uc: "rule set with invalid signature",
+			ep: &ruleSetEndpoint{
+				Endpoint: endpoint.Endpoint{
+					URL:    srv.URL,
+					Method: http.MethodGet,
+				},
+				verifier: verifier,
+			},
+			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+				t.Helper()
+
+				w.Header().Set("Content-Type", "application/yaml")
+				w.Header().Set("X-Rule-Set-Signature", sig)
+				_, err := w.Write(append(signedRuleSet, []byte("  methods: [GET]\n")...))
+				require.NoError(t, err)
+			},
+			assert: func(t *testing.T, err error, _ []*config.RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.ErrorIs(t, err, signature.ErrInvalidSignature)
+			},
+		},
+		{
+			uc: "unsigned empty response with signature verification",
+			ep: &ruleSetEndpoint{
+				Endpoint: endpoint.Endpoint{
+					URL:    srv.URL,
+					Method: http.MethodGet,
+				},
+				verifier: verifier,
+			},
+			assert: func(t *testing.T, err error, _ []*config.RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.ErrorIs(t, err, signature.ErrMissingSignature)
+				assert.NotErrorIs(t, err, config.ErrEmptyRuleSet)
+			},
+		},
+		{
+			uc: "signed empty response with signature verification",
+			ep: &ruleSetEndpoint{
+				Endpoint: endpoint.Endpoint{
+					URL:    srv.URL,
+					Method: http.MethodGet,
+				},
+				verifier: verifier,
+			},
+			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+				t.Helper()
+
+				w.Header().Set("X-Rule-Set-Signature", emptySig)
+				w.WriteHeader(http.StatusOK)
+			},
+			assert: func(t *testing.T, err error, _ []*config.RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, config.ErrEmptyRuleSet)
+			},
+		},
+		{
+			uc: "rule set with valid signature",
+			ep: &ruleSetEndpoint{
+				Endpoint: endpoint.Endpoint{
+					URL:    srv.URL,
+					Method: http.MethodGet,
+				},
+				verifier: verifier,
+			},
+			writeResponse: func(t *testing.T, w http.ResponseWriter) {
+				t.Helper()
+
+				w.Header().Set("Content-Type", "application/yaml")
+				w.Header().Set("X-Rule-Set-Signature", sig)
+				_, err := w.Write(signedRuleSet)
+				require.NoError(t, err)
+			},
+			assert: func(t *testing.T, err error, ruleSets []*config.RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.Len(t, ruleSets, 1)
+				assert.Len(t, ruleSets[0].Rules, 1)
+				assert.Equal(t, "foo", ruleSets[0].Rules[0].ID)
 			},
 		},
 	} {
@@ -366,10 +585,104 @@ rules:
 				})
 
 			// WHEN
-			ruleSet, err := tc.ep.FetchRuleSet(ctx)
+			ruleSets, err := tc.ep.FetchRuleSets(ctx)
 
 			// THEN
-			tc.assert(t, err, ruleSet)
+			tc.assert(t, err, ruleSets)
 		})
 	}
 }
+
+func TestRuleSetEndpointFetchRuleSetConditionally(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	lastModified := time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC)
+	callIdx := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callIdx++
+
+		assert.Equal(t, "wait=5", r.Header.Get("Prefer"))
+
+		switch callIdx {
+		case 1, 2:
+			// the second request is not conditional as the rule set retrieved with the first one has not been applied
+			assert.Empty(t, r.Header.Get("If-None-Match"))
+			assert.Empty(t, r.Header.Get("If-Modified-Since"))
+
+			w.Header().Set("Content-Type", "application/yaml")
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+			_, err := w.Write([]byte(`
+version: "1"
+name: test
+rules:
+- id: foo
+`))
+			assert.NoError(t, err)
+		case 3:
+			assert.Equal(t, `"v1"`, r.Header.Get("If-None-Match"))
+			assert.Equal(t, lastModified.Format(http.TimeFormat), r.Header.Get("If-Modified-Since"))
+
+			w.WriteHeader(http.StatusNotModified)
+		case 4:
+			assert.Equal(t, `"v1"`, r.Header.Get("If-None-Match"))
+
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			assert.Empty(t, r.Header.Get("If-None-Match"))
+			assert.Empty(t, r.Header.Get("If-Modified-Since"))
+
+			w.WriteHeader(http.StatusNotModified)
+		}
+	}))
+
+	defer srv.Close()
+
+	ep := &ruleSetEndpoint{
+		Endpoint:        endpoint.Endpoint{URL: srv.URL},
+		LongPollTimeout: 5 * time.Second,
+	}
+	require.NoError(t, ep.init())
+
+	ctx := log.Logger.WithContext(context.Background())
+
+	// WHEN
+	ruleSets, err := ep.FetchRuleSets(ctx)
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, ruleSets, 1)
+	assert.Equal(t, "foo", ruleSets[0].Rules[0].ID)
+	assert.Equal(t, lastModified, ruleSets[0].ModTime.UTC())
+
+	// WHEN
+	ruleSets, err = ep.FetchRuleSets(ctx)
+	ep.RuleSetsApplied()
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, ruleSets, 1)
+
+	// WHEN
+	ruleSets, err = ep.FetchRuleSets(ctx)
+
+	// THEN
+	require.ErrorIs(t, err, ErrRuleSetNotModified)
+	assert.Nil(t, ruleSets)
+
+	// WHEN
+	_, err = ep.FetchRuleSets(ctx)
+
+	// THEN
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrCommunication)
+
+	// WHEN
+	_, err = ep.FetchRuleSets(ctx)
+
+	// THEN
+	require.ErrorIs(t, err, ErrRuleSetNotModified)
+	assert.Equal(t, 5, callIdx)
+}
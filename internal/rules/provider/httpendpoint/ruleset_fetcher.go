@@ -23,6 +23,9 @@ import (
 )
 
 type RuleSetFetcher interface {
-	FetchRuleSet(ctx context.Context) (*config.RuleSet, error)
+	FetchRuleSets(ctx context.Context) ([]*config.RuleSet, error)
+	// RuleSetsApplied is called after the rule sets returned by the last FetchRuleSets call
+	// have been applied successfully.
+	RuleSetsApplied()
 	ID() string
 }
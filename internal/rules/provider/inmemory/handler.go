@@ -0,0 +1,155 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inmemory
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+const EndpointRuleSets = "/rulesets"
+
+type ruleSetInfo struct {
+	Name    string    `json:"name"`
+	Source  string    `json:"source"`
+	Version string    `json:"version"`
+	Rules   int       `json:"rules"`
+	Hash    string    `json:"hash"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+func infoOf(stored *storedRuleSet) ruleSetInfo {
+	return ruleSetInfo{
+		Name:    stored.Name,
+		Source:  stored.ruleSet.Source,
+		Version: stored.ruleSet.Version,
+		Rules:   len(stored.ruleSet.Rules),
+		Hash:    hex.EncodeToString(stored.ruleSet.Hash),
+		ModTime: stored.ModTime,
+	}
+}
+
+func (p *provider) registerRoutes(router fiber.Router) {
+	p.l.Debug().Msg("Registering rule set management routes")
+
+	group := router.Group(EndpointRuleSets, authenticate(p.tokens))
+
+	group.Get("/", listRuleSets(p))
+	group.Get("/:name", getRuleSet(p))
+	group.Put("/:name", putRuleSet(p))
+	group.Delete("/:name", deleteRuleSet(p))
+}
+
+// authenticate expects one of the configured tokens to be present in the Authorization header
+// according to https://datatracker.ietf.org/doc/html/rfc6750#section-2.1
+func authenticate(tokens [][]byte) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		scheme, token, found := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			md := sha256.Sum256([]byte(token))
+
+			for _, expected := range tokens {
+				if subtle.ConstantTimeCompare(md[:], expected) == 1 {
+					return c.Next()
+				}
+			}
+		}
+
+		c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="heimdall"`)
+
+		return fiber.ErrUnauthorized
+	}
+}
+
+func listRuleSets(p *provider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ruleSets := p.List()
+
+		infos := make([]ruleSetInfo, len(ruleSets))
+		for idx, stored := range ruleSets {
+			infos[idx] = infoOf(stored)
+		}
+
+		return c.JSON(infos)
+	}
+}
+
+func getRuleSet(p *provider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		stored, err := p.Get(c.Params("name"))
+		if err != nil {
+			return p.errorResponse(err)
+		}
+
+		c.Set(fiber.HeaderContentType, stored.ContentType)
+
+		return c.SendString(stored.Contents)
+	}
+}
+
+func putRuleSet(p *provider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		contentType, _, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+		if err != nil || contentType != "application/json" && contentType != "application/yaml" {
+			return fiber.NewError(fiber.StatusUnsupportedMediaType,
+				"rule sets must be provided as application/json or application/yaml")
+		}
+
+		// the values provided by fiber are only valid within the handler
+		stored, created, err := p.Put(strings.Clone(c.Params("name")), contentType, bytes.Clone(c.Body()))
+		if err != nil {
+			return p.errorResponse(err)
+		}
+
+		return c.Status(x.IfThenElse(created, fiber.StatusCreated, fiber.StatusOK)).JSON(infoOf(stored))
+	}
+}
+
+func deleteRuleSet(p *provider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := p.Delete(c.Params("name")); err != nil {
+			return p.errorResponse(err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+func (p *provider) errorResponse(err error) error {
+	switch {
+	case errors.Is(err, ErrUnknownRuleSet):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalidRuleSet):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrRejectedRuleSet):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		p.l.Error().Err(err).Msg("Failed to apply rule set changes")
+
+		return fiber.ErrInternalServerError
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inmemory
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+	mock2 "github.com/dadrus/heimdall/internal/x/testsupport/mock"
+)
+
+func TestRuleSetManagementAPI(t *testing.T) { // nolint: maintidx
+	t.Parallel()
+
+	const token = "s3cr3t"
+
+	newRequest := func(method, path, contentType, body string) *http.Request {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		if len(contentType) != 0 {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		return req
+	}
+
+	readBody := func(t *testing.T, resp *http.Response) string {
+		t.Helper()
+
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return string(data)
+	}
+
+	for _, tc := range []struct {
+		uc             string
+		setupProcessor func(t *testing.T, processor *mocks.RuleSetProcessorMock)
+		setupProvider  func(t *testing.T, prov *provider)
+		request        func(t *testing.T) *http.Request
+		assert         func(t *testing.T, resp *http.Response, processor *mocks.RuleSetProcessorMock)
+	}{
+		{
+			uc: "request without token",
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return httptest.NewRequest(http.MethodGet, EndpointRuleSets, nil)
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+				assert.Equal(t, `Bearer realm="heimdall"`, resp.Header.Get("WWW-Authenticate"))
+			},
+		},
+		{
+			uc: "request with wrong token",
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				req := httptest.NewRequest(http.MethodDelete, EndpointRuleSets+"/test", nil)
+				req.Header.Set("Authorization", "Bearer foo")
+
+				return req
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			},
+		},
+		{
+			uc: "list rule sets",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Twice()
+			},
+			setupProvider: func(t *testing.T, prov *provider) {
+				t.Helper()
+
+				_, _, err := prov.Put("test", "application/yaml", []byte(testRuleSet))
+				require.NoError(t, err)
+
+				_, _, err = prov.Put("other", "application/json",
+					[]byte(`{"version": "1", "rules": [{"id": "bar"}, {"id": "baz"}]}`))
+				require.NoError(t, err)
+			},
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodGet, EndpointRuleSets, "", "")
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				var infos []ruleSetInfo
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&infos))
+				require.Len(t, infos, 2)
+				assert.Equal(t, "other", infos[0].Name)
+				assert.Equal(t, "in_memory:other", infos[0].Source)
+				assert.Equal(t, "1", infos[0].Version)
+				assert.Equal(t, 2, infos[0].Rules)
+				assert.NotEmpty(t, infos[0].Hash)
+				assert.False(t, infos[0].ModTime.IsZero())
+				assert.Equal(t, "test", infos[1].Name)
+				assert.Equal(t, 1, infos[1].Rules)
+			},
+		},
+		{
+			uc: "get not existing rule set",
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodGet, EndpointRuleSets+"/test", "", "")
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			},
+		},
+		{
+			uc: "get existing rule set",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+			},
+			setupProvider: func(t *testing.T, prov *provider) {
+				t.Helper()
+
+				_, _, err := prov.Put("test", "application/yaml", []byte(testRuleSet))
+				require.NoError(t, err)
+			},
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodGet, EndpointRuleSets+"/test", "", "")
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, "application/yaml", resp.Header.Get("Content-Type"))
+				assert.Equal(t, testRuleSet, readBody(t, resp))
+			},
+		},
+		{
+			uc: "put rule set with unsupported content type",
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodPut, EndpointRuleSets+"/test", "text/plain", testRuleSet)
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+			},
+		},
+		{
+			uc: "put rule set with invalid name",
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodPut, EndpointRuleSets+"/..test", "application/yaml", testRuleSet)
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Contains(t, readBody(t, resp), "invalid rule set name")
+			},
+		},
+		{
+			uc: "put malformed rule set",
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodPut, EndpointRuleSets+"/test", "application/json", `{"rules": [`)
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Contains(t, readBody(t, resp), "failed to parse rule set")
+			},
+		},
+		{
+			uc: "put rule set with not matching name",
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodPut, EndpointRuleSets+"/other", "application/yaml", testRuleSet)
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Contains(t, readBody(t, resp), "does not match")
+			},
+		},
+		{
+			uc: "put rule set rejected by the processor",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(testsupport.ErrTestPurpose).Once()
+			},
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodPut, EndpointRuleSets+"/test", "application/yaml; charset=utf-8",
+					testRuleSet)
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+				assert.Contains(t, readBody(t, resp), testsupport.ErrTestPurpose.Error())
+			},
+		},
+		{
+			uc: "put new rule set",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "created").Capture).
+					Return(nil).Once()
+			},
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodPut, EndpointRuleSets+"/test", "application/yaml", testRuleSet)
+			},
+			assert: func(t *testing.T, resp *http.Response, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+				var info ruleSetInfo
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+				assert.Equal(t, "test", info.Name)
+				assert.Equal(t, 1, info.Rules)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "created").Value()
+				assert.Equal(t, "in_memory:test", ruleSet.Source)
+				assert.Equal(t, "test", ruleSet.Name)
+				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
+			},
+		},
+		{
+			uc: "put unchanged rule set",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+			},
+			setupProvider: func(t *testing.T, prov *provider) {
+				t.Helper()
+
+				_, _, err := prov.Put("test", "application/yaml", []byte(testRuleSet))
+				require.NoError(t, err)
+			},
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodPut, EndpointRuleSets+"/test", "application/yaml", testRuleSet)
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			},
+		},
+		{
+			uc: "put updated rule set",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+				processor.EXPECT().OnUpdated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "updated").Capture).
+					Return(nil).Once()
+			},
+			setupProvider: func(t *testing.T, prov *provider) {
+				t.Helper()
+
+				_, _, err := prov.Put("test", "application/yaml", []byte(testRuleSet))
+				require.NoError(t, err)
+			},
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodPut, EndpointRuleSets+"/test", "application/json",
+					`{"version": "1", "rules": [{"id": "bar"}]}`)
+			},
+			assert: func(t *testing.T, resp *http.Response, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "updated").Value()
+				assert.Equal(t, "in_memory:test", ruleSet.Source)
+				assert.Equal(t, "bar", ruleSet.Rules[0].ID)
+			},
+		},
+		{
+			uc: "delete not existing rule set",
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodDelete, EndpointRuleSets+"/test", "", "")
+			},
+			assert: func(t *testing.T, resp *http.Response, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+			},
+		},
+		{
+			uc: "delete existing rule set",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+				processor.EXPECT().OnDeleted(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "deleted").Capture).
+					Return(nil).Once()
+			},
+			setupProvider: func(t *testing.T, prov *provider) {
+				t.Helper()
+
+				_, _, err := prov.Put("test", "application/yaml", []byte(testRuleSet))
+				require.NoError(t, err)
+			},
+			request: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return newRequest(http.MethodDelete, EndpointRuleSets+"/test", "", "")
+			},
+			assert: func(t *testing.T, resp *http.Response, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "deleted").Value()
+				assert.Equal(t, "in_memory:test", ruleSet.Source)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			processor := mocks.NewRuleSetProcessorMock(t)
+			if tc.setupProcessor != nil {
+				tc.setupProcessor(t, processor)
+			}
+
+			prov, err := newProvider(&config.Configuration{
+				Rules: config.Rules{Providers: config.RuleProviders{InMemory: map[string]any{
+					"api_tokens": []string{"foo-bar", token},
+				}}},
+			}, processor, log.Logger)
+			require.NoError(t, err)
+
+			if tc.setupProvider != nil {
+				tc.setupProvider(t, prov)
+			}
+
+			app := fiber.New()
+			prov.registerRoutes(app)
+
+			// WHEN
+			resp, err := app.Test(tc.request(t), -1)
+
+			// THEN
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			tc.assert(t, resp, processor)
+		})
+	}
+}
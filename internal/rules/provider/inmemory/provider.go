@@ -0,0 +1,404 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	rule_config "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/rule"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+var (
+	ErrInvalidRuleSet  = errors.New("invalid rule set")
+	ErrRejectedRuleSet = errors.New("rejected rule set")
+	ErrUnknownRuleSet  = errors.New("unknown rule set")
+
+	namePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,252}$`)
+)
+
+// storedRuleSet is a rule set as received via the management api. The contents are kept as is to
+// be able to return these unmodified and to persist them.
+type storedRuleSet struct {
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Contents    string    `json:"contents"`
+	ModTime     time.Time `json:"mod_time"`
+
+	ruleSet *rule_config.RuleSet
+}
+
+type provider struct {
+	p          rule.SetProcessor
+	l          zerolog.Logger
+	tokens     [][]byte
+	file       string
+	ruleSets   map[string]*storedRuleSet
+	mutex      sync.Mutex
+	configured bool
+}
+
+func newProvider(
+	conf *config.Configuration,
+	processor rule.SetProcessor,
+	logger zerolog.Logger,
+) (*provider, error) {
+	rawConf := conf.Rules.Providers.InMemory
+
+	if rawConf == nil {
+		return &provider{}, nil
+	}
+
+	type Config struct {
+		APITokens       []string `mapstructure:"api_tokens"`
+		PersistenceFile string   `mapstructure:"persistence_file"`
+	}
+
+	var providerConf Config
+	if err := decodeConfig(rawConf, &providerConf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode in_memory rule provider config").
+			CausedBy(err)
+	}
+
+	if len(providerConf.APITokens) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"no api_tokens configured for in_memory rule provider")
+	}
+
+	tokens := make([][]byte, len(providerConf.APITokens))
+
+	for idx, token := range providerConf.APITokens {
+		if len(token) == 0 {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"#%d api token of in_memory rule provider is empty", idx)
+		}
+
+		// only the hashes are kept to allow comparison in constant time regardless of the length
+		md := sha256.Sum256([]byte(token))
+		tokens[idx] = md[:]
+	}
+
+	logger = logger.With().Str("_provider_type", "in_memory").Logger()
+
+	logger.Info().Msg("Rule provider configured.")
+
+	return &provider{
+		p:          processor,
+		l:          logger,
+		tokens:     tokens,
+		file:       providerConf.PersistenceFile,
+		ruleSets:   make(map[string]*storedRuleSet),
+		configured: true,
+	}, nil
+}
+
+func (p *provider) Start(_ context.Context) error {
+	if !p.configured {
+		return nil
+	}
+
+	p.l.Info().Msg("Starting rule definitions provider")
+
+	if len(p.file) == 0 {
+		return nil
+	}
+
+	if err := p.loadPersistedRuleSets(); err != nil {
+		p.l.Error().Err(err).Msg("Failed loading persisted rule sets")
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *provider) Stop(_ context.Context) error {
+	if !p.configured {
+		return nil
+	}
+
+	p.l.Info().Msg("Tearing down rule provider")
+
+	return nil
+}
+
+// Put creates or replaces the rule set with the given name. The returned flag is true if the
+// rule set has been created.
+func (p *provider) Put(name, contentType string, contents []byte) (*storedRuleSet, bool, error) {
+	if !namePattern.MatchString(name) {
+		return nil, false, errorchain.NewWithMessagef(ErrInvalidRuleSet, "invalid rule set name %q", name)
+	}
+
+	ruleSet, err := parseRuleSet(name, contentType, contents)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	previous, exists := p.ruleSets[name]
+	if exists && bytes.Equal(previous.ruleSet.Hash, ruleSet.Hash) {
+		return previous, false, nil
+	}
+
+	if exists {
+		err = p.p.OnUpdated(ruleSet)
+	} else {
+		err = p.p.OnCreated(ruleSet)
+	}
+
+	if err != nil {
+		return nil, false, errorchain.New(ErrRejectedRuleSet).CausedBy(err)
+	}
+
+	stored := &storedRuleSet{
+		Name:        name,
+		ContentType: contentType,
+		Contents:    string(contents),
+		ModTime:     ruleSet.ModTime,
+		ruleSet:     ruleSet,
+	}
+
+	p.ruleSets[name] = stored
+
+	if err = p.persist(); err != nil {
+		// the rule set must not be active if it would be lost on restart
+		p.rollback(name, previous)
+
+		return nil, false, err
+	}
+
+	return stored, !exists, nil
+}
+
+// Get returns the rule set with the given name.
+func (p *provider) Get(name string) (*storedRuleSet, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stored, ok := p.ruleSets[name]
+	if !ok {
+		return nil, errorchain.NewWithMessagef(ErrUnknownRuleSet, "rule set %q does not exist", name)
+	}
+
+	return stored, nil
+}
+
+// List returns all rule sets ordered by their names.
+func (p *provider) List() []*storedRuleSet {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	names := maps.Keys(p.ruleSets)
+	slices.Sort(names)
+
+	ruleSets := make([]*storedRuleSet, len(names))
+	for idx, name := range names {
+		ruleSets[idx] = p.ruleSets[name]
+	}
+
+	return ruleSets
+}
+
+// Delete removes the rule set with the given name.
+func (p *provider) Delete(name string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stored, ok := p.ruleSets[name]
+	if !ok {
+		return errorchain.NewWithMessagef(ErrUnknownRuleSet, "rule set %q does not exist", name)
+	}
+
+	if err := p.p.OnDeleted(&rule_config.RuleSet{
+		MetaData: rule_config.MetaData{
+			Source:  stored.ruleSet.Source,
+			ModTime: time.Now(),
+		},
+	}); err != nil {
+		return errorchain.New(ErrRejectedRuleSet).CausedBy(err)
+	}
+
+	delete(p.ruleSets, name)
+
+	if err := p.persist(); err != nil {
+		// the rule set must stay active as it would otherwise be back on restart
+		p.restore(name, stored)
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *provider) restore(name string, stored *storedRuleSet) {
+	p.ruleSets[name] = stored
+
+	if err := p.p.OnCreated(stored.ruleSet); err != nil {
+		p.l.Error().Err(err).Str("_name", name).Msg("Failed to roll back rule set deletion")
+	}
+}
+
+func (p *provider) rollback(name string, previous *storedRuleSet) {
+	var err error
+
+	if previous != nil {
+		p.ruleSets[name] = previous
+		err = p.p.OnUpdated(previous.ruleSet)
+	} else {
+		delete(p.ruleSets, name)
+		err = p.p.OnDeleted(&rule_config.RuleSet{
+			MetaData: rule_config.MetaData{Source: source(name), ModTime: time.Now()},
+		})
+	}
+
+	if err != nil {
+		p.l.Error().Err(err).Str("_name", name).Msg("Failed to roll back rule set changes")
+	}
+}
+
+// persist writes all rule sets to the configured file. The file is replaced atomically to not
+// end up with a partially written one.
+func (p *provider) persist() error {
+	if len(p.file) == 0 {
+		return nil
+	}
+
+	names := maps.Keys(p.ruleSets)
+	slices.Sort(names)
+
+	ruleSets := make([]*storedRuleSet, len(names))
+	for idx, name := range names {
+		ruleSets[idx] = p.ruleSets[name]
+	}
+
+	data, err := json.Marshal(ruleSets)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to marshal rule sets").CausedBy(err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(p.file), filepath.Base(p.file)+".tmp-")
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to persist rule sets").CausedBy(err)
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), p.file)
+	}
+
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to persist rule sets").CausedBy(err)
+	}
+
+	return nil
+}
+
+func (p *provider) loadPersistedRuleSets() error {
+	p.l.Info().Str("_file", p.file).Msg("Loading persisted rule sets")
+
+	data, err := os.ReadFile(p.file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return errorchain.NewWithMessagef(heimdall.ErrInternal, "failed reading file %s", p.file).
+			CausedBy(err)
+	}
+
+	var ruleSets []*storedRuleSet
+	if err = json.Unmarshal(data, &ruleSets); err != nil {
+		return errorchain.NewWithMessagef(heimdall.ErrInternal, "failed to decode file %s", p.file).
+			CausedBy(err)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, stored := range ruleSets {
+		ruleSet, err := parseRuleSet(stored.Name, stored.ContentType, []byte(stored.Contents))
+		if err != nil {
+			return err
+		}
+
+		ruleSet.ModTime = stored.ModTime
+
+		if err = p.p.OnCreated(ruleSet); err != nil {
+			return errorchain.NewWithMessagef(ErrRejectedRuleSet, "persisted rule set %q", stored.Name).
+				CausedBy(err)
+		}
+
+		stored.ruleSet = ruleSet
+		p.ruleSets[stored.Name] = stored
+	}
+
+	return nil
+}
+
+func parseRuleSet(name, contentType string, contents []byte) (*rule_config.RuleSet, error) {
+	ruleSet, err := rule_config.ParseRules(contentType, bytes.NewReader(contents))
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(ErrInvalidRuleSet, "failed to parse rule set %q", name).
+			CausedBy(err)
+	}
+
+	switch {
+	case len(ruleSet.Name) == 0:
+		ruleSet.Name = name
+	case ruleSet.Name != name:
+		return nil, errorchain.NewWithMessagef(ErrInvalidRuleSet,
+			"name %q of the rule set does not match %q", ruleSet.Name, name)
+	}
+
+	md := sha256.Sum256(contents)
+
+	ruleSet.Hash = md[:]
+	ruleSet.Source = source(name)
+	ruleSet.ModTime = time.Now()
+
+	return ruleSet, nil
+}
+
+func source(name string) string {
+	return fmt.Sprintf("in_memory:%s", name)
+}
@@ -0,0 +1,365 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inmemory
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+	mock2 "github.com/dadrus/heimdall/internal/x/testsupport/mock"
+)
+
+const testRuleSet = `
+version: "1"
+name: test
+rules:
+- id: foo
+`
+
+func TestNewProvider(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   []byte
+		assert func(t *testing.T, err error, prov *provider)
+	}{
+		{
+			uc:   "with unknown field",
+			conf: []byte(`foo: bar`),
+			assert: func(t *testing.T, err error, _ *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc:   "without api tokens",
+			conf: []byte(`persistence_file: /tmp/rule_sets.json`),
+			assert: func(t *testing.T, err error, _ *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no api_tokens configured")
+			},
+		},
+		{
+			uc: "with empty api token",
+			conf: []byte(`
+api_tokens:
+  - foo
+  - ""
+`),
+			assert: func(t *testing.T, err error, _ *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "#1 api token")
+			},
+		},
+		{
+			uc: "with api tokens and persistence file",
+			conf: []byte(`
+api_tokens:
+  - foo
+  - bar
+persistence_file: /tmp/rule_sets.json
+`),
+			assert: func(t *testing.T, err error, prov *provider) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, prov)
+				assert.True(t, prov.configured)
+				assert.NotNil(t, prov.p)
+				assert.Len(t, prov.tokens, 2)
+				assert.NotContains(t, prov.tokens, []byte("foo"))
+				assert.Equal(t, "/tmp/rule_sets.json", prov.file)
+				assert.Empty(t, prov.ruleSets)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			providerConf, err := testsupport.DecodeTestConfig(tc.conf)
+			require.NoError(t, err)
+
+			conf := &config.Configuration{
+				Rules: config.Rules{
+					Providers: config.RuleProviders{InMemory: providerConf},
+				},
+			}
+
+			// WHEN
+			prov, err := newProvider(conf, mocks.NewRuleSetProcessorMock(t), log.Logger)
+
+			// THEN
+			tc.assert(t, err, prov)
+		})
+	}
+}
+
+func TestProviderPersistence(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc             string
+		setupFile      func(t *testing.T, file string)
+		setupProcessor func(t *testing.T, processor *mocks.RuleSetProcessorMock)
+		assert         func(t *testing.T, err error, prov *provider, file string, processor *mocks.RuleSetProcessorMock)
+	}{
+		{
+			uc: "not existing persistence file",
+			assert: func(t *testing.T, err error, prov *provider, file string, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Empty(t, prov.List())
+				assert.NoFileExists(t, file)
+			},
+		},
+		{
+			uc: "malformed persistence file",
+			setupFile: func(t *testing.T, file string) {
+				t.Helper()
+
+				require.NoError(t, os.WriteFile(file, []byte("foo"), 0o600))
+			},
+			assert: func(t *testing.T, err error, _ *provider, _ string, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc: "persisted rule set rejected by the processor",
+			setupFile: func(t *testing.T, file string) {
+				t.Helper()
+
+				data, err := json.Marshal([]*storedRuleSet{
+					{Name: "test", ContentType: "application/yaml", Contents: testRuleSet},
+				})
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(file, data, 0o600))
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(testsupport.ErrTestPurpose)
+			},
+			assert: func(t *testing.T, err error, _ *provider, _ string, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrRejectedRuleSet)
+				assert.ErrorIs(t, err, testsupport.ErrTestPurpose)
+			},
+		},
+		{
+			uc: "rule sets are persisted and loaded again",
+			setupFile: func(t *testing.T, file string) {
+				t.Helper()
+
+				data, err := json.Marshal([]*storedRuleSet{
+					{Name: "test", ContentType: "application/yaml", Contents: testRuleSet},
+				})
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(file, data, 0o600))
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "created").Capture).
+					Return(nil).Twice()
+				processor.EXPECT().OnDeleted(mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error, prov *provider, file string, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				ruleSets := prov.List()
+				require.Len(t, ruleSets, 1)
+				assert.Equal(t, "test", ruleSets[0].Name)
+
+				_, _, err = prov.Put("other", "application/json",
+					[]byte(`{"version": "1", "rules": [{"id": "bar"}]}`))
+				require.NoError(t, err)
+
+				require.NoError(t, prov.Delete("test"))
+
+				created := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "created").Values()
+				require.Len(t, created, 2)
+				assert.Equal(t, "in_memory:test", created[0].Source)
+				assert.Equal(t, "in_memory:other", created[1].Source)
+				assert.Equal(t, "other", created[1].Name)
+
+				var persisted []*storedRuleSet
+
+				data, err := os.ReadFile(file)
+				require.NoError(t, err)
+				require.NoError(t, json.Unmarshal(data, &persisted))
+				require.Len(t, persisted, 1)
+				assert.Equal(t, "other", persisted[0].Name)
+				assert.Equal(t, "application/json", persisted[0].ContentType)
+				assert.JSONEq(t, `{"version": "1", "rules": [{"id": "bar"}]}`, persisted[0].Contents)
+			},
+		},
+		{
+			uc: "changes are rolled back if these cannot be persisted",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+				processor.EXPECT().OnDeleted(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "deleted").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error, prov *provider, file string, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				prov.file = filepath.Join(file, "not-existing-dir", "rule_sets.json")
+
+				_, _, err = prov.Put("test", "application/yaml", []byte(testRuleSet))
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to persist")
+
+				assert.Empty(t, prov.List())
+
+				deleted := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "deleted").Value()
+				assert.Equal(t, "in_memory:test", deleted.Source)
+			},
+		},
+		{
+			uc: "deletion is rolled back if it cannot be persisted",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "created").Capture).
+					Return(nil).Twice()
+				processor.EXPECT().OnDeleted(mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error, prov *provider, file string, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				_, _, err = prov.Put("test", "application/yaml", []byte(testRuleSet))
+				require.NoError(t, err)
+
+				prov.file = filepath.Join(file, "not-existing-dir", "rule_sets.json")
+
+				err = prov.Delete("test")
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to persist")
+
+				ruleSets := prov.List()
+				require.Len(t, ruleSets, 1)
+				assert.Equal(t, "test", ruleSets[0].Name)
+
+				created := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "created").Values()
+				require.Len(t, created, 2)
+				assert.Equal(t, "in_memory:test", created[1].Source)
+			},
+		},
+		{
+			uc: "deletion rejected by the processor",
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+				processor.EXPECT().OnDeleted(mock.Anything).Return(testsupport.ErrTestPurpose).Once()
+			},
+			assert: func(t *testing.T, err error, prov *provider, file string, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				_, _, err = prov.Put("test", "application/yaml", []byte(testRuleSet))
+				require.NoError(t, err)
+
+				err = prov.Delete("test")
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrRejectedRuleSet)
+				assert.ErrorIs(t, err, testsupport.ErrTestPurpose)
+
+				assert.Len(t, prov.List(), 1)
+
+				var persisted []*storedRuleSet
+
+				data, err := os.ReadFile(file)
+				require.NoError(t, err)
+				require.NoError(t, json.Unmarshal(data, &persisted))
+				require.Len(t, persisted, 1)
+				assert.Equal(t, "test", persisted[0].Name)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			file := filepath.Join(t.TempDir(), "rule_sets.json")
+
+			if tc.setupFile != nil {
+				tc.setupFile(t, file)
+			}
+
+			processor := mocks.NewRuleSetProcessorMock(t)
+			if tc.setupProcessor != nil {
+				tc.setupProcessor(t, processor)
+			}
+
+			prov, err := newProvider(&config.Configuration{
+				Rules: config.Rules{Providers: config.RuleProviders{InMemory: map[string]any{
+					"api_tokens":       []string{"foo"},
+					"persistence_file": file,
+				}}},
+			}, processor, log.Logger)
+			require.NoError(t, err)
+
+			// WHEN
+			err = prov.Start(context.Background())
+
+			// THEN
+			tc.assert(t, err, prov, file, processor)
+		})
+	}
+}
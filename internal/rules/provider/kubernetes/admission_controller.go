@@ -0,0 +1,205 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/rs/zerolog"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dadrus/heimdall/internal/config"
+	accesslogmiddleware "github.com/dadrus/heimdall/internal/fiber/middleware/accesslog"
+	loggermiddlerware "github.com/dadrus/heimdall/internal/fiber/middleware/logger"
+	"github.com/dadrus/heimdall/internal/handler/listener"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha1"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	admissionControllerPort = 4458
+
+	EndpointValidateRuleSet = "/validate-ruleset"
+	EndpointConvertRuleSet  = "/convert-ruleset"
+)
+
+type ruleSetValidator func(rs *v1alpha2.RuleSet) error
+
+// admissionController implements a kubernetes validating admission webhook, which rejects invalid
+// RuleSet resources before these are persisted, as well as the conversion webhook, which converts
+// RuleSet resources between the supported versions.
+type admissionController struct {
+	app *fiber.App
+	ln  net.Listener
+	l   zerolog.Logger
+}
+
+func newAdmissionController(
+	tlsConf *config.TLS, validate ruleSetValidator, logger zerolog.Logger,
+) (*admissionController, error) {
+	app := newAdmissionControllerApp(validate, logger)
+
+	// kubernetes communicates with admission webhooks over https only
+	ln, err := listener.New(app.Config().Network, config.ServiceConfig{Port: admissionControllerPort, TLS: tlsConf})
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to create listener for the admission controller").
+			CausedBy(err)
+	}
+
+	return &admissionController{app: app, ln: ln, l: logger}, nil
+}
+
+func newAdmissionControllerApp(validate ruleSetValidator, logger zerolog.Logger) *fiber.App {
+	app := fiber.New(fiber.Config{
+		AppName:               "Heimdall's RuleSet Admission Controller",
+		ReadTimeout:           5 * time.Second,  // nolint: gomnd
+		WriteTimeout:          10 * time.Second, // nolint: gomnd
+		IdleTimeout:           2 * time.Minute,  // nolint: gomnd
+		DisableStartupMessage: true,
+		JSONDecoder:           json.Unmarshal,
+		JSONEncoder:           json.Marshal,
+	})
+
+	app.Use(recover.New(recover.Config{EnableStackTrace: true}))
+	app.Use(accesslogmiddleware.New(logger))
+	app.Use(loggermiddlerware.New(logger))
+
+	app.Post(EndpointValidateRuleSet, reviewRuleSet(validate))
+	app.Post(EndpointConvertRuleSet, convertRuleSets)
+
+	return app
+}
+
+func (ac *admissionController) Start(_ context.Context) error {
+	go func() {
+		ac.l.Info().Str("_address", ac.ln.Addr().String()).Msg("Admission controller starts listening")
+
+		if err := ac.app.Listener(ac.ln); err != nil {
+			ac.l.Error().Err(err).Msg("Could not start admission controller")
+		}
+	}()
+
+	return nil
+}
+
+func (ac *admissionController) Stop(_ context.Context) error {
+	ac.l.Info().Msg("Tearing down admission controller")
+
+	return ac.app.Shutdown()
+}
+
+func reviewRuleSet(validate ruleSetValidator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var review admissionv1.AdmissionReview
+
+		if err := c.BodyParser(&review); err != nil || review.Request == nil {
+			return fiber.NewError(fiber.StatusBadRequest, "failed to parse admission review")
+		}
+
+		response := admitRuleSet(review.Request, validate)
+		response.UID = review.Request.UID
+
+		return c.JSON(admissionv1.AdmissionReview{
+			TypeMeta: review.TypeMeta,
+			Response: response,
+		})
+	}
+}
+
+func admitRuleSet(req *admissionv1.AdmissionRequest, validate ruleSetValidator) *admissionv1.AdmissionResponse {
+	if req.Kind.Group != v1alpha2.GroupName || req.Kind.Kind != "RuleSet" {
+		return deny(http.StatusBadRequest,
+			fmt.Sprintf("unexpected resource %s/%s", req.Kind.Group, req.Kind.Kind))
+	}
+
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return allow(fmt.Sprintf("operation %s is not subject to validation", req.Operation))
+	}
+
+	ruleSet, err := decodeRuleSet(req.Kind.Version, req.Object.Raw)
+	if err != nil {
+		return deny(http.StatusBadRequest, fmt.Sprintf("failed to decode RuleSet: %s", err))
+	}
+
+	if err = validate(ruleSet); err != nil {
+		return deny(http.StatusForbidden, err.Error())
+	}
+
+	return allow("RuleSet is valid")
+}
+
+// decodeRuleSet decodes the given RuleSet resource of the given version and converts it to
+// v1alpha2 if required.
+func decodeRuleSet(version string, raw []byte) (*v1alpha2.RuleSet, error) {
+	switch version {
+	case v1alpha1.GroupVersion:
+		var ruleSet v1alpha1.RuleSet
+		if err := json.Unmarshal(raw, &ruleSet); err != nil {
+			return nil, err
+		}
+
+		var converted v1alpha2.RuleSet
+		if err := ruleSet.ConvertTo(&converted); err != nil {
+			return nil, err
+		}
+
+		return &converted, nil
+	case v1alpha2.GroupVersion:
+		var ruleSet v1alpha2.RuleSet
+		if err := json.Unmarshal(raw, &ruleSet); err != nil {
+			return nil, err
+		}
+
+		return &ruleSet, nil
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrArgument, "unsupported version %s", version)
+	}
+}
+
+func allow(message string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: true,
+		Result: &metav1.Status{
+			Status:  metav1.StatusSuccess,
+			Code:    http.StatusOK,
+			Message: message,
+		},
+	}
+}
+
+func deny(code int32, message string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    code,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: message,
+		},
+	}
+}
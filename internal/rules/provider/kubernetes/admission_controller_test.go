@@ -0,0 +1,625 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha1"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
+	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func newAdmissionReview(
+	t *testing.T, operation admissionv1.Operation, version, kind string, object any,
+) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(object)
+	require.NoError(t, err)
+
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"),
+			Kind:      metav1.GroupVersionKind{Group: v1alpha2.GroupName, Version: version, Kind: kind},
+			Operation: operation,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+
+	body, err := json.Marshal(review)
+	require.NoError(t, err)
+
+	return body
+}
+
+func TestAdmissionControllerReviewRuleSet(t *testing.T) {
+	t.Parallel()
+
+	ruleSet := v1alpha2.RuleSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "heimdall.dadrus.github.com/v1alpha2", Kind: "RuleSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-rules", Namespace: "foo"},
+		Spec: v1alpha2.RuleSetSpec{
+			AuthClassName: "bar",
+			Rules: []config2.Rule{
+				{ID: "test", RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"}},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		uc       string
+		body     func(t *testing.T) []byte
+		validate ruleSetValidator
+		assert   func(t *testing.T, code int, review *admissionv1.AdmissionReview)
+	}{
+		{
+			uc:   "malformed admission review",
+			body: func(t *testing.T) []byte { t.Helper(); return []byte("foo") },
+			assert: func(t *testing.T, code int, review *admissionv1.AdmissionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, code)
+			},
+		},
+		{
+			uc:   "admission review without request",
+			body: func(t *testing.T) []byte { t.Helper(); return []byte(`{"kind": "AdmissionReview"}`) },
+			assert: func(t *testing.T, code int, review *admissionv1.AdmissionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, code)
+			},
+		},
+		{
+			uc: "unexpected resource",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newAdmissionReview(t, admissionv1.Create, v1alpha2.GroupVersion, "Pod", ruleSet)
+			},
+			assert: func(t *testing.T, code int, review *admissionv1.AdmissionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.False(t, review.Response.Allowed)
+				assert.Equal(t, int32(http.StatusBadRequest), review.Response.Result.Code)
+				assert.Contains(t, review.Response.Result.Message, "unexpected resource")
+			},
+		},
+		{
+			uc: "deletion is not validated",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newAdmissionReview(t, admissionv1.Delete, v1alpha2.GroupVersion, "RuleSet", nil)
+			},
+			validate: func(_ *v1alpha2.RuleSet) error { return testsupport.ErrTestPurpose },
+			assert: func(t *testing.T, code int, review *admissionv1.AdmissionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.True(t, review.Response.Allowed)
+			},
+		},
+		{
+			uc: "not decodable rule set",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newAdmissionReview(t, admissionv1.Create, v1alpha2.GroupVersion, "RuleSet", map[string]any{"spec": "foo"})
+			},
+			assert: func(t *testing.T, code int, review *admissionv1.AdmissionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.False(t, review.Response.Allowed)
+				assert.Equal(t, int32(http.StatusBadRequest), review.Response.Result.Code)
+				assert.Contains(t, review.Response.Result.Message, "failed to decode RuleSet")
+			},
+		},
+		{
+			uc: "invalid rule set",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newAdmissionReview(t, admissionv1.Update, v1alpha2.GroupVersion, "RuleSet", ruleSet)
+			},
+			validate: func(_ *v1alpha2.RuleSet) error { return testsupport.ErrTestPurpose },
+			assert: func(t *testing.T, code int, review *admissionv1.AdmissionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.Equal(t, types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"), review.Response.UID)
+				assert.False(t, review.Response.Allowed)
+				assert.Equal(t, metav1.StatusFailure, review.Response.Result.Status)
+				assert.Equal(t, metav1.StatusReasonInvalid, review.Response.Result.Reason)
+				assert.Equal(t, int32(http.StatusForbidden), review.Response.Result.Code)
+				assert.Equal(t, testsupport.ErrTestPurpose.Error(), review.Response.Result.Message)
+			},
+		},
+		{
+			uc: "unsupported version",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newAdmissionReview(t, admissionv1.Create, "v1beta1", "RuleSet", ruleSet)
+			},
+			assert: func(t *testing.T, code int, review *admissionv1.AdmissionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.False(t, review.Response.Allowed)
+				assert.Equal(t, int32(http.StatusBadRequest), review.Response.Result.Code)
+				assert.Contains(t, review.Response.Result.Message, "unsupported version v1beta1")
+			},
+		},
+		{
+			uc: "valid v1alpha1 rule set",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				rs := v1alpha1.RuleSet{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "heimdall.dadrus.github.com/v1alpha1", Kind: "RuleSet"},
+					ObjectMeta: metav1.ObjectMeta{Name: "test-rules", Namespace: "foo"},
+					Spec: v1alpha1.RuleSetSpec{
+						AuthClassName: "bar",
+						Rules: []v1alpha1.Rule{
+							{ID: "test", RuleMatcher: v1alpha1.Matcher{URL: "http://foo.bar", Strategy: "glob"}},
+						},
+					},
+				}
+
+				return newAdmissionReview(t, admissionv1.Create, v1alpha1.GroupVersion, "RuleSet", rs)
+			},
+			validate: func(rs *v1alpha2.RuleSet) error {
+				if rs.APIVersion != "heimdall.dadrus.github.com/v1alpha2" || rs.Spec.AuthClassName != "bar" ||
+					len(rs.Spec.Rules) != 1 {
+					return errors.New("unexpected rule set")
+				}
+
+				return nil
+			},
+			assert: func(t *testing.T, code int, review *admissionv1.AdmissionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.True(t, review.Response.Allowed)
+			},
+		},
+		{
+			uc: "valid rule set",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newAdmissionReview(t, admissionv1.Create, v1alpha2.GroupVersion, "RuleSet", ruleSet)
+			},
+			validate: func(rs *v1alpha2.RuleSet) error {
+				if rs.Name != "test-rules" || rs.Spec.AuthClassName != "bar" || len(rs.Spec.Rules) != 1 {
+					return errors.New("unexpected rule set")
+				}
+
+				return nil
+			},
+			assert: func(t *testing.T, code int, review *admissionv1.AdmissionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				assert.Equal(t, "admission.k8s.io/v1", review.APIVersion)
+				assert.Equal(t, "AdmissionReview", review.Kind)
+				require.NotNil(t, review.Response)
+				assert.Equal(t, types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"), review.Response.UID)
+				assert.True(t, review.Response.Allowed)
+				assert.Equal(t, metav1.StatusSuccess, review.Response.Result.Status)
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			validate := tc.validate
+			if validate == nil {
+				validate = func(_ *v1alpha2.RuleSet) error {
+					t.Fatal("validation not expected")
+
+					return nil
+				}
+			}
+
+			app := newAdmissionControllerApp(validate, log.Logger)
+
+			req := httptest.NewRequest(http.MethodPost, EndpointValidateRuleSet, bytes.NewReader(tc.body(t)))
+			req.Header.Set("Content-Type", "application/json")
+
+			// WHEN
+			resp, err := app.Test(req, -1)
+
+			// THEN
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			var review admissionv1.AdmissionReview
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&review))
+			}
+
+			tc.assert(t, resp.StatusCode, &review)
+		})
+	}
+}
+
+func TestProviderValidateRuleSet(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc           string
+		ruleSet      *v1alpha2.RuleSet
+		authClass    string
+		setupFactory func(t *testing.T, factory *mocks.FactoryMock)
+		assert       func(t *testing.T, err error)
+	}{
+		{
+			uc: "rule set for other auth class is not validated",
+			ruleSet: &v1alpha2.RuleSet{
+				Spec: v1alpha2.RuleSetSpec{AuthClassName: "foo", Rules: []config2.Rule{{ID: "test"}}},
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc: "rule set without auth class is validated if default auth class is used",
+			ruleSet: &v1alpha2.RuleSet{
+				Spec: v1alpha2.RuleSetSpec{Rules: []config2.Rule{{ID: "test"}}},
+			},
+			authClass: DefaultClass,
+			setupFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().CreateRule(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, testsupport.ErrTestPurpose)
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, testsupport.ErrTestPurpose)
+			},
+		},
+		{
+			uc: "rule extending unknown template",
+			ruleSet: &v1alpha2.RuleSet{
+				Spec: v1alpha2.RuleSetSpec{AuthClassName: "bar", Rules: []config2.Rule{{ID: "test", Extends: "foo"}}},
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unknown template")
+			},
+		},
+		{
+			uc: "rule referencing unknown mechanisms",
+			ruleSet: &v1alpha2.RuleSet{
+				Spec: v1alpha2.RuleSetSpec{AuthClassName: "bar", Rules: []config2.Rule{{ID: "test"}}},
+			},
+			setupFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().CreateRule(mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, testsupport.ErrTestPurpose)
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.ErrorIs(t, err, testsupport.ErrTestPurpose)
+				assert.Contains(t, err.Error(), "ID=test")
+			},
+		},
+		{
+			uc: "valid rule set",
+			ruleSet: &v1alpha2.RuleSet{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "heimdall.dadrus.github.com/v1alpha2", Kind: "RuleSet"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test-rules", Namespace: "foo", UID: "dfb2a2f1"},
+				Spec: v1alpha2.RuleSetSpec{
+					AuthClassName: "bar",
+					Mode:          config2.RuleModeShadow,
+					Rules:         []config2.Rule{{ID: "test1"}, {ID: "test2", Mode: config2.RuleModeEnforce}},
+				},
+			},
+			setupFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().CreateRule("1", "kubernetes:foo:dfb2a2f1",
+					mock.MatchedBy(func(rc config2.Rule) bool { return rc.ID == "test1" && rc.Mode == config2.RuleModeShadow }),
+				).Return(nil, nil).Once()
+				factory.EXPECT().CreateRule("1", "kubernetes:foo:dfb2a2f1",
+					mock.MatchedBy(func(rc config2.Rule) bool { return rc.ID == "test2" && rc.Mode == config2.RuleModeEnforce }),
+				).Return(nil, nil).Once()
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			setupFactory := x.IfThenElse(tc.setupFactory != nil,
+				tc.setupFactory,
+				func(t *testing.T, _ *mocks.FactoryMock) { t.Helper() })
+
+			factory := mocks.NewFactoryMock(t)
+			setupFactory(t, factory)
+
+			prov := &provider{f: factory, ac: x.IfThenElse(len(tc.authClass) != 0, tc.authClass, "bar"), l: log.Logger}
+
+			// WHEN
+			err := prov.validateRuleSet(tc.ruleSet)
+
+			// THEN
+			tc.assert(t, err)
+		})
+	}
+}
+
+func TestAdmissionControllerConvertRuleSets(t *testing.T) {
+	t.Parallel()
+
+	v1alpha1RuleSet := map[string]any{
+		"apiVersion": "heimdall.dadrus.github.com/v1alpha1",
+		"kind":       "RuleSet",
+		"metadata":   map[string]any{"name": "test-rules", "namespace": "foo"},
+		"spec": map[string]any{
+			"authClassName": "bar",
+			"rules": []any{
+				map[string]any{
+					"id":       "test",
+					"match":    map[string]any{"url": "http://foo.bar", "strategy": "glob"},
+					"upstream": "http://bar",
+					"execute":  []any{map[string]any{"authenticator": "foo"}},
+				},
+			},
+		},
+	}
+
+	v1alpha2RuleSet := map[string]any{
+		"apiVersion": "heimdall.dadrus.github.com/v1alpha2",
+		"kind":       "RuleSet",
+		"metadata":   map[string]any{"name": "test-rules", "namespace": "foo"},
+		"spec": map[string]any{
+			"mode": "shadow",
+			"rules": []any{
+				map[string]any{
+					"id":      "test",
+					"match":   map[string]any{"url": "http://foo.bar", "strategy": "glob", "host": "foo.bar"},
+					"execute": []any{map[string]any{"authenticator": "foo"}},
+				},
+			},
+		},
+	}
+
+	newConversionReview := func(t *testing.T, desiredAPIVersion string, objects ...any) []byte {
+		t.Helper()
+
+		review := conversionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+			Request: &conversionRequest{
+				UID:               types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"),
+				DesiredAPIVersion: desiredAPIVersion,
+			},
+		}
+
+		for _, object := range objects {
+			raw, err := json.Marshal(object)
+			require.NoError(t, err)
+
+			review.Request.Objects = append(review.Request.Objects, runtime.RawExtension{Raw: raw})
+		}
+
+		body, err := json.Marshal(review)
+		require.NoError(t, err)
+
+		return body
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		body   func(t *testing.T) []byte
+		assert func(t *testing.T, code int, review *conversionReview)
+	}{
+		{
+			uc:   "malformed conversion review",
+			body: func(t *testing.T) []byte { t.Helper(); return []byte("foo") },
+			assert: func(t *testing.T, code int, _ *conversionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, code)
+			},
+		},
+		{
+			uc: "unsupported desired version",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newConversionReview(t, "heimdall.dadrus.github.com/v1beta1", v1alpha1RuleSet)
+			},
+			assert: func(t *testing.T, code int, review *conversionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.Equal(t, metav1.StatusFailure, review.Response.Result.Status)
+				assert.Contains(t, review.Response.Result.Message, "unsupported version")
+				assert.Empty(t, review.Response.ConvertedObjects)
+			},
+		},
+		{
+			uc: "unexpected resource",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newConversionReview(t, "heimdall.dadrus.github.com/v1alpha2",
+					map[string]any{"apiVersion": "v1", "kind": "Pod"})
+			},
+			assert: func(t *testing.T, code int, review *conversionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.Equal(t, metav1.StatusFailure, review.Response.Result.Status)
+				assert.Contains(t, review.Response.Result.Message, "unexpected resource")
+			},
+		},
+		{
+			uc: "conversion of v1alpha1 and v1alpha2 rule sets to v1alpha2",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newConversionReview(t, "heimdall.dadrus.github.com/v1alpha2", v1alpha1RuleSet, v1alpha2RuleSet)
+			},
+			assert: func(t *testing.T, code int, review *conversionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				assert.Equal(t, "ConversionReview", review.Kind)
+				require.NotNil(t, review.Response)
+				assert.Equal(t, types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"), review.Response.UID)
+				assert.Equal(t, metav1.StatusSuccess, review.Response.Result.Status)
+				require.Len(t, review.Response.ConvertedObjects, 2)
+
+				var converted v1alpha2.RuleSet
+
+				require.NoError(t, json.Unmarshal(review.Response.ConvertedObjects[0].Raw, &converted))
+				assert.Equal(t, "heimdall.dadrus.github.com/v1alpha2", converted.APIVersion)
+				assert.Equal(t, "test-rules", converted.Name)
+				assert.Equal(t, "bar", converted.Spec.AuthClassName)
+				require.Len(t, converted.Spec.Rules, 1)
+				assert.Equal(t, "http://foo.bar", converted.Spec.Rules[0].RuleMatcher.URL)
+				assert.Equal(t, "http://bar", converted.Spec.Rules[0].Upstream.Targets[0].URL)
+
+				// objects already in the desired version are not modified
+				expected, err := json.Marshal(v1alpha2RuleSet)
+				require.NoError(t, err)
+				assert.JSONEq(t, string(expected), string(review.Response.ConvertedObjects[1].Raw))
+			},
+		},
+		{
+			uc: "conversion of v1alpha2 rule set to v1alpha1 and back",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newConversionReview(t, "heimdall.dadrus.github.com/v1alpha1", v1alpha2RuleSet)
+			},
+			assert: func(t *testing.T, code int, review *conversionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.Equal(t, metav1.StatusSuccess, review.Response.Result.Status)
+				require.Len(t, review.Response.ConvertedObjects, 1)
+
+				var converted v1alpha1.RuleSet
+
+				require.NoError(t, json.Unmarshal(review.Response.ConvertedObjects[0].Raw, &converted))
+				assert.Equal(t, "heimdall.dadrus.github.com/v1alpha1", converted.APIVersion)
+				assert.Equal(t, v1alpha2.DefaultAuthClassName, converted.Spec.AuthClassName)
+				require.Len(t, converted.Spec.Rules, 1)
+				assert.Equal(t, "http://foo.bar", converted.Spec.Rules[0].RuleMatcher.URL)
+				assert.Contains(t, converted.Annotations, v1alpha1.AnnotationV1alpha2Spec)
+
+				// the properties not supported by v1alpha1 are restored
+				raw, err := convertRuleSet(review.Response.ConvertedObjects[0].Raw, "heimdall.dadrus.github.com/v1alpha2")
+				require.NoError(t, err)
+
+				var restored v1alpha2.RuleSet
+
+				require.NoError(t, json.Unmarshal(raw, &restored))
+				assert.Empty(t, restored.Annotations)
+				assert.Equal(t, "shadow", restored.Spec.Mode)
+				require.Len(t, restored.Spec.Rules, 1)
+				assert.Equal(t, "foo.bar", restored.Spec.Rules[0].RuleMatcher.Host)
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			app := newAdmissionControllerApp(func(_ *v1alpha2.RuleSet) error { return nil }, log.Logger)
+
+			req := httptest.NewRequest(http.MethodPost, EndpointConvertRuleSet, bytes.NewReader(tc.body(t)))
+			req.Header.Set("Content-Type", "application/json")
+
+			// WHEN
+			resp, err := app.Test(req, -1)
+
+			// THEN
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			var review conversionReview
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&review))
+			}
+
+			tc.assert(t, resp.StatusCode, &review)
+		})
+	}
+}
@@ -44,6 +44,8 @@ func addKnownTypes(gv schema.GroupVersion) func(scheme *runtime.Scheme) error {
 type RuleSetRepository interface {
 	List(ctx context.Context, opts metav1.ListOptions) (*RuleSetList, error)
 	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*RuleSet, error)
+	UpdateStatus(ctx context.Context, ruleSet *RuleSet, opts metav1.UpdateOptions) (*RuleSet, error)
 }
 
 type Client interface {
@@ -109,3 +111,33 @@ func (r *repository) Watch(ctx context.Context, opts metav1.ListOptions) (watch.
 		VersionedParams(&opts, scheme.ParameterCodec).
 		Watch(ctx)
 }
+
+func (r *repository) Get(ctx context.Context, name string, opts metav1.GetOptions) (*RuleSet, error) {
+	result := &RuleSet{}
+	err := r.cl.Get().
+		Namespace(r.ns).
+		Resource("rulesets").
+		Name(name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+
+	return result, err
+}
+
+func (r *repository) UpdateStatus(
+	ctx context.Context, ruleSet *RuleSet, opts metav1.UpdateOptions,
+) (*RuleSet, error) {
+	result := &RuleSet{}
+	err := r.cl.Put().
+		Namespace(r.ns).
+		Resource("rulesets").
+		Name(ruleSet.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(ruleSet).
+		Do(ctx).
+		Into(result)
+
+	return result, err
+}
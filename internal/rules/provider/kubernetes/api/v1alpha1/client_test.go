@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -69,6 +70,40 @@ const response = `{
   }
 }`
 
+const singleResponse = `{
+  "apiVersion": "heimdall.dadrus.github.com/v1alpha1",
+  "kind": "RuleSet",
+  "metadata": {
+    "name": "test-rule-set",
+    "namespace": "foo",
+    "resourceVersion": "684780",
+    "generation": 2,
+    "uid": "3c49d7b6-710d-446d-95da-334bc2c1072b"
+  },
+  "spec": {
+    "authClassName": "foobar",
+    "rules": [{
+        "execute": [
+          { "authenticator": "test_authn" }
+        ],
+        "id": "test:rule",
+        "match": "http://127.0.0.1:9090/foobar/<{foos*}>"
+      }
+    ]
+  },
+  "status": {
+    "activeIn": "1/1",
+    "conditions": [{
+      "type": "heimdall-0/Ready",
+      "status": "True",
+      "observedGeneration": 2,
+      "lastTransitionTime": "2023-06-01T10:00:00Z",
+      "reason": "RuleSetActive",
+      "message": "rule set is active"
+    }]
+  }
+}`
+
 type ClientTestSuite struct {
 	suite.Suite
 
@@ -83,9 +118,16 @@ func (s *ClientTestSuite) SetupSuite() {
 
 		var err error
 
-		if qWatch == "true" {
+		switch {
+		case r.Method == http.MethodPut &&
+			r.URL.Path == "/apis/heimdall.dadrus.github.com/v1alpha1/namespaces/foo/rulesets/test-rule-set/status":
+			// echo the received object
+			_, err = io.Copy(w, r.Body)
+		case r.URL.Path == "/apis/heimdall.dadrus.github.com/v1alpha1/namespaces/foo/rulesets/test-rule-set":
+			_, err = w.Write([]byte(singleResponse))
+		case qWatch == "true":
 			_, err = w.Write([]byte(watchResponse))
-		} else {
+		default:
 			_, err = w.Write([]byte(response))
 		}
 		require.NoError(s.T(), err)
@@ -158,3 +200,54 @@ func (s *ClientTestSuite) TestRuleSetsWatch() {
 	// nolint: forcetypeassert
 	verifyRuleSetList(s.T(), evt.Object.(*RuleSetList))
 }
+
+func (s *ClientTestSuite) TestRuleSetGet() {
+	// WHEN
+	ruleSet, err := s.cl.RuleSetRepository("foo").Get(context.Background(), "test-rule-set", metav1.GetOptions{})
+
+	// THEN
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), ruleSet)
+	assert.Equal(s.T(), "test-rule-set", ruleSet.Name)
+	assert.Equal(s.T(), int64(2), ruleSet.Generation)
+	assert.Len(s.T(), ruleSet.Spec.Rules, 1)
+	assert.Equal(s.T(), "1/1", ruleSet.Status.ActiveIn)
+	require.Len(s.T(), ruleSet.Status.Conditions, 1)
+
+	condition := ruleSet.Status.Conditions[0]
+	assert.Equal(s.T(), "heimdall-0/Ready", condition.Type)
+	assert.Equal(s.T(), metav1.ConditionTrue, condition.Status)
+	assert.Equal(s.T(), int64(2), condition.ObservedGeneration)
+	assert.Equal(s.T(), "RuleSetActive", condition.Reason)
+}
+
+func (s *ClientTestSuite) TestRuleSetUpdateStatus() {
+	// GIVEN
+	ruleSet := &RuleSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "heimdall.dadrus.github.com/v1alpha1", Kind: "RuleSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-rule-set", Namespace: "foo"},
+		Status: RuleSetStatus{
+			ActiveIn: "0/1",
+			Conditions: []metav1.Condition{
+				{
+					Type:               "heimdall-0/Ready",
+					Status:             metav1.ConditionFalse,
+					LastTransitionTime: metav1.Now(),
+					Reason:             "Invalid",
+					Message:            "test error",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	result, err := s.cl.RuleSetRepository("foo").UpdateStatus(context.Background(), ruleSet, metav1.UpdateOptions{})
+
+	// THEN
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), result)
+	assert.Equal(s.T(), "0/1", result.Status.ActiveIn)
+	require.Len(s.T(), result.Status.Conditions, 1)
+	assert.Equal(s.T(), "Invalid", result.Status.Conditions[0].Reason)
+	assert.Equal(s.T(), "test error", result.Status.Conditions[0].Message)
+}
@@ -0,0 +1,182 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"bytes"
+
+	"github.com/goccy/go-json"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// AnnotationV1alpha2Spec holds the spec of a v1alpha2 rule set, which cannot be represented in
+// v1alpha1 without loss, e.g. because it makes use of rule templates. The spec is restored when
+// the rule set is converted back to v1alpha2.
+const AnnotationV1alpha2Spec = GroupName + "/v1alpha2-spec"
+
+// ConvertTo converts the given v1alpha1 rule set to v1alpha2. Properties not supported by v1alpha1
+// are restored from the AnnotationV1alpha2Spec annotation if present. Restored rules are only
+// taken over if these have not been modified in v1alpha1.
+func (in *RuleSet) ConvertTo(dst *v1alpha2.RuleSet) error {
+	src := in.DeepCopy()
+
+	dst.TypeMeta = src.TypeMeta
+	dst.APIVersion = GroupName + "/" + v1alpha2.GroupVersion
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec = v1alpha2.RuleSetSpec{
+		AuthClassName: src.Spec.AuthClassName,
+		Rules:         make([]config.Rule, len(src.Spec.Rules)),
+	}
+	dst.Status = v1alpha2.RuleSetStatus{
+		ActiveIn:   src.Status.ActiveIn,
+		Conditions: src.Status.Conditions,
+	}
+
+	for idx, rule := range src.Spec.Rules {
+		dst.Spec.Rules[idx] = rule.convertTo()
+	}
+
+	stashed, present := dst.Annotations[AnnotationV1alpha2Spec]
+	if !present {
+		return nil
+	}
+
+	delete(dst.Annotations, AnnotationV1alpha2Spec)
+
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	var spec v1alpha2.RuleSetSpec
+	if err := json.Unmarshal([]byte(stashed), &spec); err != nil {
+		return errorchain.NewWithMessagef(heimdall.ErrArgument,
+			"failed to decode the %s annotation", AnnotationV1alpha2Spec).CausedBy(err)
+	}
+
+	dst.Spec.Mode = spec.Mode
+	dst.Spec.Defaults = spec.Defaults
+	dst.Spec.Templates = spec.Templates
+
+	for idx, rule := range src.Spec.Rules {
+		for _, original := range spec.Rules {
+			if original.ID == rule.ID && equal(convertFrom(original), rule) {
+				dst.Spec.Rules[idx] = original
+
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the given v1alpha2 rule set to v1alpha1. If the rule set makes use of
+// properties not supported by v1alpha1, its spec is preserved in the AnnotationV1alpha2Spec annotation.
+func (in *RuleSet) ConvertFrom(src *v1alpha2.RuleSet) error {
+	src = src.DeepCopy()
+
+	in.TypeMeta = src.TypeMeta
+	in.APIVersion = GroupName + "/" + GroupVersion
+	in.ObjectMeta = src.ObjectMeta
+	in.Spec = RuleSetSpec{
+		AuthClassName: x.IfThenElse(len(src.Spec.AuthClassName) != 0,
+			src.Spec.AuthClassName, v1alpha2.DefaultAuthClassName),
+		Rules: make([]Rule, len(src.Spec.Rules)),
+	}
+	in.Status = RuleSetStatus{
+		ActiveIn:   src.Status.ActiveIn,
+		Conditions: src.Status.Conditions,
+	}
+
+	lossless := len(src.Spec.Mode) == 0 && src.Spec.Defaults == nil && len(src.Spec.Templates) == 0
+
+	for idx, rule := range src.Spec.Rules {
+		in.Spec.Rules[idx] = convertFrom(rule)
+
+		lossless = lossless && equal(rule, in.Spec.Rules[idx].convertTo())
+	}
+
+	if lossless {
+		return nil
+	}
+
+	raw, err := json.Marshal(src.Spec)
+	if err != nil {
+		return errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"failed to encode the %s annotation", AnnotationV1alpha2Spec).CausedBy(err)
+	}
+
+	if in.Annotations == nil {
+		in.Annotations = make(map[string]string, 1)
+	}
+
+	in.Annotations[AnnotationV1alpha2Spec] = string(raw)
+
+	return nil
+}
+
+func (in Rule) convertTo() config.Rule {
+	rule := config.Rule{
+		ID:           in.ID,
+		RuleMatcher:  config.Matcher{URL: in.RuleMatcher.URL, Strategy: in.RuleMatcher.Strategy},
+		Methods:      in.Methods,
+		Execute:      in.Execute,
+		ErrorHandler: in.ErrorHandler,
+	}
+
+	if len(in.Upstream) != 0 {
+		rule.Upstream = &config.Upstream{Targets: []config.UpstreamTarget{{URL: in.Upstream}}}
+	}
+
+	return rule
+}
+
+func convertFrom(rule config.Rule) Rule {
+	var upstream string
+
+	if rule.Upstream != nil && len(rule.Upstream.Targets) != 0 {
+		upstream = rule.Upstream.Targets[0].URL
+	}
+
+	return Rule{
+		ID:           rule.ID,
+		RuleMatcher:  Matcher{URL: rule.RuleMatcher.URL, Strategy: rule.RuleMatcher.Strategy},
+		Upstream:     upstream,
+		Methods:      rule.Methods,
+		Execute:      rule.Execute,
+		ErrorHandler: rule.ErrorHandler,
+	}
+}
+
+func equal[T any](first, second T) bool {
+	firstRaw, err := json.Marshal(first)
+	if err != nil {
+		return false
+	}
+
+	secondRaw, err := json.Marshal(second)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(firstRaw, secondRaw)
+}
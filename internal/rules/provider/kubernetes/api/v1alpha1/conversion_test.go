@@ -0,0 +1,312 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/dadrus/heimdall/internal/config"
+	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
+)
+
+func TestRuleSetConvertTo(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		src    func() *RuleSet
+		assert func(t *testing.T, err error, src *RuleSet, dst *v1alpha2.RuleSet)
+	}{
+		{
+			uc: "without preserved v1alpha2 spec",
+			src: func() *RuleSet {
+				return &RuleSet{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "heimdall.dadrus.github.com/v1alpha1", Kind: "RuleSet"},
+					ObjectMeta: metav1.ObjectMeta{Name: "test-rules", Namespace: "foo", UID: "dfb2a2f1", Generation: 2},
+					Spec: RuleSetSpec{
+						AuthClassName: "bar",
+						Rules: []Rule{
+							{
+								ID:          "test",
+								RuleMatcher: Matcher{URL: "http://foo.bar", Strategy: "glob"},
+								Upstream:    "http://bar",
+								Methods:     []string{"GET"},
+								Execute:     []config.MechanismConfig{{"authenticator": "foo"}},
+							},
+						},
+					},
+					Status: RuleSetStatus{
+						ActiveIn:   "1/1",
+						Conditions: []metav1.Condition{{Type: "foo/Ready", Status: metav1.ConditionTrue}},
+					},
+				}
+			},
+			assert: func(t *testing.T, err error, src *RuleSet, dst *v1alpha2.RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "heimdall.dadrus.github.com/v1alpha2", dst.APIVersion)
+				assert.Equal(t, "RuleSet", dst.Kind)
+				assert.Equal(t, src.ObjectMeta, dst.ObjectMeta)
+				assert.Equal(t, "bar", dst.Spec.AuthClassName)
+				assert.Empty(t, dst.Spec.Mode)
+				assert.Nil(t, dst.Spec.Defaults)
+				assert.Empty(t, dst.Spec.Templates)
+				require.Len(t, dst.Spec.Rules, 1)
+
+				rule := dst.Spec.Rules[0]
+				assert.Equal(t, "test", rule.ID)
+				assert.Equal(t, config2.Matcher{URL: "http://foo.bar", Strategy: "glob"}, rule.RuleMatcher)
+				require.NotNil(t, rule.Upstream)
+				assert.Equal(t, []config2.UpstreamTarget{{URL: "http://bar"}}, rule.Upstream.Targets)
+				assert.Equal(t, []string{"GET"}, rule.Methods)
+				assert.Equal(t, src.Spec.Rules[0].Execute, rule.Execute)
+				assert.Equal(t, "1/1", dst.Status.ActiveIn)
+				assert.Equal(t, src.Status.Conditions, dst.Status.Conditions)
+
+				// the source is not shared
+				dst.Spec.Rules[0].Methods[0] = "POST"
+				assert.Equal(t, "GET", src.Spec.Rules[0].Methods[0])
+			},
+		},
+		{
+			uc: "with malformed preserved v1alpha2 spec",
+			src: func() *RuleSet {
+				return &RuleSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "test-rules",
+						Annotations: map[string]string{AnnotationV1alpha2Spec: "foo"},
+					},
+				}
+			},
+			assert: func(t *testing.T, err error, _ *RuleSet, _ *v1alpha2.RuleSet) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			src := tc.src()
+
+			var dst v1alpha2.RuleSet
+
+			// WHEN
+			err := src.ConvertTo(&dst)
+
+			// THEN
+			tc.assert(t, err, src, &dst)
+		})
+	}
+}
+
+func TestRuleSetConvertFrom(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc        string
+		authClass string
+		mode      string
+		matcher   config2.Matcher
+		expected  string
+		preserved bool
+	}{
+		{
+			uc:        "with auth class",
+			authClass: "bar",
+			matcher:   config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+			expected:  "bar",
+		},
+		{
+			uc:       "without auth class",
+			matcher:  config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+			expected: v1alpha2.DefaultAuthClassName,
+		},
+		{
+			uc:        "with rule set mode not supported by v1alpha1",
+			authClass: "bar",
+			mode:      config2.RuleModeShadow,
+			matcher:   config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+			expected:  "bar",
+			preserved: true,
+		},
+		{
+			uc:        "with rule matcher properties not supported by v1alpha1",
+			authClass: "bar",
+			matcher:   config2.Matcher{URL: "http://foo.bar", Strategy: "glob", Host: "foo.bar"},
+			expected:  "bar",
+			preserved: true,
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			src := &v1alpha2.RuleSet{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "heimdall.dadrus.github.com/v1alpha2", Kind: "RuleSet"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test-rules", Namespace: "foo"},
+				Spec: v1alpha2.RuleSetSpec{
+					AuthClassName: tc.authClass,
+					Mode:          tc.mode,
+					Rules:         []config2.Rule{{ID: "test", RuleMatcher: tc.matcher}},
+				},
+				Status: v1alpha2.RuleSetStatus{ActiveIn: "0/1"},
+			}
+
+			var dst RuleSet
+
+			// WHEN
+			err := dst.ConvertFrom(src)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, "heimdall.dadrus.github.com/v1alpha1", dst.APIVersion)
+			assert.Equal(t, "RuleSet", dst.Kind)
+			assert.Equal(t, src.Name, dst.Name)
+			assert.Equal(t, tc.expected, dst.Spec.AuthClassName)
+			require.Len(t, dst.Spec.Rules, 1)
+			assert.Equal(t, "test", dst.Spec.Rules[0].ID)
+			assert.Equal(t, Matcher{URL: tc.matcher.URL, Strategy: tc.matcher.Strategy}, dst.Spec.Rules[0].RuleMatcher)
+			assert.Equal(t, "0/1", dst.Status.ActiveIn)
+
+			if tc.preserved {
+				assert.Contains(t, dst.Annotations, AnnotationV1alpha2Spec)
+			} else {
+				assert.Empty(t, dst.Annotations)
+			}
+		})
+	}
+}
+
+func TestRuleSetConversionRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	original := &v1alpha2.RuleSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: "heimdall.dadrus.github.com/v1alpha2", Kind: "RuleSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-rules",
+			Namespace:   "foo",
+			Annotations: map[string]string{"foo": "bar"},
+		},
+		Spec: v1alpha2.RuleSetSpec{
+			AuthClassName: "bar",
+			Mode:          config2.RuleModeShadow,
+			Defaults:      &config2.RuleSetDefaults{Methods: []string{"GET"}},
+			Templates: []config2.RuleTemplate{
+				{ID: "tpl", Execute: []config.MechanismConfig{{"authenticator": "foo"}}},
+			},
+			Rules: []config2.Rule{
+				{
+					ID:          "rule1",
+					Extends:     "tpl",
+					RuleMatcher: config2.Matcher{URL: "http://foo.bar/1", Strategy: "glob", Host: "foo.bar"},
+					Priority:    10,
+				},
+				{
+					ID:          "rule2",
+					RuleMatcher: config2.Matcher{URL: "http://foo.bar/2", Strategy: "glob"},
+					Upstream: &config2.Upstream{
+						Targets:       []config2.UpstreamTarget{{URL: "http://bar", Weight: 2}, {URL: "http://baz"}},
+						LoadBalancing: "round_robin",
+					},
+					Execute: []config.MechanismConfig{{"authenticator": "bar"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		modify func(rs *RuleSet)
+		assert func(t *testing.T, converted *v1alpha2.RuleSet)
+	}{
+		{
+			uc:     "without modifications",
+			modify: func(_ *RuleSet) {},
+			assert: func(t *testing.T, converted *v1alpha2.RuleSet) {
+				t.Helper()
+
+				assert.Equal(t, original.ObjectMeta, converted.ObjectMeta)
+				assert.Equal(t, original.Spec, converted.Spec)
+			},
+		},
+		{
+			uc: "with rules modified in v1alpha1",
+			modify: func(rs *RuleSet) {
+				rs.Spec.Rules[1].RuleMatcher.URL = "http://foo.bar/3"
+				rs.Spec.Rules = append(rs.Spec.Rules, Rule{
+					ID:          "rule3",
+					RuleMatcher: Matcher{URL: "http://foo.bar/4", Strategy: "glob"},
+					Execute:     []config.MechanismConfig{{"authenticator": "baz"}},
+				})
+			},
+			assert: func(t *testing.T, converted *v1alpha2.RuleSet) {
+				t.Helper()
+
+				assert.Equal(t, original.ObjectMeta, converted.ObjectMeta)
+				assert.Equal(t, original.Spec.Mode, converted.Spec.Mode)
+				assert.Equal(t, original.Spec.Defaults, converted.Spec.Defaults)
+				assert.Equal(t, original.Spec.Templates, converted.Spec.Templates)
+				require.Len(t, converted.Spec.Rules, 3)
+
+				// unmodified rules are restored
+				assert.Equal(t, original.Spec.Rules[0], converted.Spec.Rules[0])
+
+				// modified rules are taken over as defined in v1alpha1
+				assert.Equal(t, "http://foo.bar/3", converted.Spec.Rules[1].RuleMatcher.URL)
+				assert.Equal(t, []config2.UpstreamTarget{{URL: "http://bar"}}, converted.Spec.Rules[1].Upstream.Targets)
+				assert.Empty(t, converted.Spec.Rules[1].Upstream.LoadBalancing)
+				assert.Equal(t, "rule3", converted.Spec.Rules[2].ID)
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			var (
+				intermediate RuleSet
+				converted    v1alpha2.RuleSet
+			)
+
+			require.NoError(t, intermediate.ConvertFrom(original))
+			tc.modify(&intermediate)
+
+			// WHEN
+			err := intermediate.ConvertTo(&converted)
+
+			// THEN
+			require.NoError(t, err)
+			tc.assert(t, &converted)
+		})
+	}
+}
@@ -19,16 +19,56 @@ package v1alpha1
 //go:generate controller-gen object paths=$GOFILE
 
 import (
+	"github.com/goccy/go-json"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
-	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/config"
+	config2 "github.com/dadrus/heimdall/internal/rules/config"
 )
 
+// +kubebuilder:object:generate=true
+type Matcher struct {
+	URL      string `json:"url"`
+	Strategy string `json:"strategy"`
+}
+
+func (m *Matcher) UnmarshalJSON(data []byte) error {
+	// the url to match can also be specified directly
+	var matcher config2.Matcher
+	if err := json.Unmarshal(data, &matcher); err != nil {
+		return err
+	}
+
+	m.URL = matcher.URL
+	m.Strategy = matcher.Strategy
+
+	return nil
+}
+
+// +kubebuilder:object:generate=true
+type Rule struct {
+	ID           string                   `json:"id"`
+	RuleMatcher  Matcher                  `json:"match"`
+	Upstream     string                   `json:"upstream,omitempty"`
+	Methods      []string                 `json:"methods"`
+	Execute      []config.MechanismConfig `json:"execute"`
+	ErrorHandler []config.MechanismConfig `json:"on_error"`
+}
+
 // +kubebuilder:object:generate=true
 type RuleSetSpec struct {
-	AuthClassName string        `json:"authClassName"` //nolint:tagliatelle
-	Rules         []config.Rule `json:"rules"`
+	AuthClassName string `json:"authClassName"` //nolint:tagliatelle
+	Rules         []Rule `json:"rules"`
+}
+
+// +kubebuilder:object:generate=true
+type RuleSetStatus struct {
+	// ActiveIn states in how many heimdall instances the rule set is active, like "2/3"
+	ActiveIn string `json:"activeIn,omitempty"` //nolint:tagliatelle
+	// Conditions holds a Ready condition per heimdall instance. Its type is prefixed
+	// with the name of the instance
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
@@ -36,7 +76,8 @@ type RuleSet struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec RuleSetSpec `json:"spec"`
+	Spec   RuleSetSpec   `json:"spec"`
+	Status RuleSetStatus `json:"status,omitempty"`
 }
 
 func (in *RuleSet) DeepCopyObject() runtime.Object { return in.DeepCopy() }
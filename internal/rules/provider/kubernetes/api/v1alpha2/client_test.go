@@ -0,0 +1,253 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha2
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+)
+
+const watchResponse = `{
+  "type": "ADDED",
+  "object": ` + response + `
+}
+`
+
+const response = `{
+  "apiVersion": "heimdall.dadrus.github.com/v1alpha2",
+  "items": [{
+      "apiVersion": "heimdall.dadrus.github.com/v1alpha2",
+      "kind": "RuleSet",
+      "metadata": {
+        "name": "test-rule-set",
+        "namespace": "foo",
+        "resourceVersion": "684780",
+        "uid": "3c49d7b6-710d-446d-95da-334bc2c1072b"
+      },
+      "spec": {
+        "authClassName": "foobar",
+        "rules": [{
+            "execute": [
+              { "authenticator": "test_authn" },
+              { "authorizer": "test_authz" }
+            ],
+            "id": "test:rule",
+            "matching_strategy": "glob",
+            "match": "http://127.0.0.1:9090/foobar/<{foos*}>",
+            "upstream": "http://foobar"
+          }
+        ]
+      }
+    }
+  ],
+  "kind": "RuleSetList",
+  "metadata": {
+    "resourceVersion": "685324"
+  }
+}`
+
+const singleResponse = `{
+  "apiVersion": "heimdall.dadrus.github.com/v1alpha2",
+  "kind": "RuleSet",
+  "metadata": {
+    "name": "test-rule-set",
+    "namespace": "foo",
+    "resourceVersion": "684780",
+    "generation": 2,
+    "uid": "3c49d7b6-710d-446d-95da-334bc2c1072b"
+  },
+  "spec": {
+    "authClassName": "foobar",
+    "rules": [{
+        "execute": [
+          { "authenticator": "test_authn" }
+        ],
+        "id": "test:rule",
+        "match": "http://127.0.0.1:9090/foobar/<{foos*}>"
+      }
+    ]
+  },
+  "status": {
+    "activeIn": "1/1",
+    "conditions": [{
+      "type": "heimdall-0/Ready",
+      "status": "True",
+      "observedGeneration": 2,
+      "lastTransitionTime": "2023-06-01T10:00:00Z",
+      "reason": "RuleSetActive",
+      "message": "rule set is active"
+    }]
+  }
+}`
+
+type ClientTestSuite struct {
+	suite.Suite
+
+	srv *httptest.Server
+	cl  Client
+}
+
+func (s *ClientTestSuite) SetupSuite() {
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		qWatch := r.URL.Query().Get("watch")
+
+		var err error
+
+		switch {
+		case r.Method == http.MethodPut &&
+			r.URL.Path == "/apis/heimdall.dadrus.github.com/v1alpha2/namespaces/foo/rulesets/test-rule-set/status":
+			// echo the received object
+			_, err = io.Copy(w, r.Body)
+		case r.URL.Path == "/apis/heimdall.dadrus.github.com/v1alpha2/namespaces/foo/rulesets/test-rule-set":
+			_, err = w.Write([]byte(singleResponse))
+		case qWatch == "true":
+			_, err = w.Write([]byte(watchResponse))
+		default:
+			_, err = w.Write([]byte(response))
+		}
+		require.NoError(s.T(), err)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var err error
+
+	s.cl, err = NewClient(&rest.Config{Host: s.srv.URL})
+	require.NoError(s.T(), err)
+}
+
+func (s *ClientTestSuite) TearDownSuite() {
+	s.srv.Close()
+}
+
+func TestClientTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
+
+func verifyRuleSetList(t *testing.T, rls *RuleSetList) {
+	t.Helper()
+
+	require.NotNil(t, rls)
+	assert.Len(t, rls.Items, 1)
+
+	ruleSet := rls.Items[0]
+	assert.Equal(t, "RuleSet", ruleSet.Kind)
+	assert.Equal(t, "heimdall.dadrus.github.com/v1alpha2", ruleSet.APIVersion)
+	assert.Equal(t, "test-rule-set", ruleSet.Name)
+	assert.Equal(t, "foo", ruleSet.Namespace)
+	assert.Equal(t, "foobar", ruleSet.Spec.AuthClassName)
+	assert.Len(t, ruleSet.Spec.Rules, 1)
+
+	rule := ruleSet.Spec.Rules[0]
+	assert.Equal(t, "test:rule", rule.ID)
+	assert.Equal(t, "glob", rule.RuleMatcher.Strategy)
+	assert.Equal(t, "http://127.0.0.1:9090/foobar/<{foos*}>", rule.RuleMatcher.URL)
+	assert.Empty(t, rule.Methods)
+	assert.Empty(t, rule.ErrorHandler)
+	assert.Equal(t, "http://foobar", rule.Upstream.Targets[0].URL)
+	assert.Len(t, rule.Execute, 2)
+	assert.Equal(t, "test_authn", rule.Execute[0]["authenticator"])
+	assert.Equal(t, "test_authz", rule.Execute[1]["authorizer"])
+}
+
+func (s *ClientTestSuite) TestRuleSetsList() {
+	// WHEN
+	rls, err := s.cl.RuleSetRepository("foo").List(context.Background(), metav1.ListOptions{})
+
+	// THEN
+	require.NoError(s.T(), err)
+	verifyRuleSetList(s.T(), rls)
+}
+
+func (s *ClientTestSuite) TestRuleSetsWatch() {
+	// WHEN
+	watcher, err := s.cl.RuleSetRepository("foo").Watch(context.Background(), metav1.ListOptions{})
+
+	// THEN
+	require.NoError(s.T(), err)
+
+	evtChain := watcher.ResultChan()
+
+	evt := <-evtChain
+
+	assert.Equal(s.T(), watch.Added, evt.Type)
+	assert.IsType(s.T(), &RuleSetList{}, evt.Object)
+	// nolint: forcetypeassert
+	verifyRuleSetList(s.T(), evt.Object.(*RuleSetList))
+}
+
+func (s *ClientTestSuite) TestRuleSetGet() {
+	// WHEN
+	ruleSet, err := s.cl.RuleSetRepository("foo").Get(context.Background(), "test-rule-set", metav1.GetOptions{})
+
+	// THEN
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), ruleSet)
+	assert.Equal(s.T(), "test-rule-set", ruleSet.Name)
+	assert.Equal(s.T(), int64(2), ruleSet.Generation)
+	assert.Len(s.T(), ruleSet.Spec.Rules, 1)
+	assert.Equal(s.T(), "1/1", ruleSet.Status.ActiveIn)
+	require.Len(s.T(), ruleSet.Status.Conditions, 1)
+
+	condition := ruleSet.Status.Conditions[0]
+	assert.Equal(s.T(), "heimdall-0/Ready", condition.Type)
+	assert.Equal(s.T(), metav1.ConditionTrue, condition.Status)
+	assert.Equal(s.T(), int64(2), condition.ObservedGeneration)
+	assert.Equal(s.T(), "RuleSetActive", condition.Reason)
+}
+
+func (s *ClientTestSuite) TestRuleSetUpdateStatus() {
+	// GIVEN
+	ruleSet := &RuleSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "heimdall.dadrus.github.com/v1alpha2", Kind: "RuleSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-rule-set", Namespace: "foo"},
+		Status: RuleSetStatus{
+			ActiveIn: "0/1",
+			Conditions: []metav1.Condition{
+				{
+					Type:               "heimdall-0/Ready",
+					Status:             metav1.ConditionFalse,
+					LastTransitionTime: metav1.Now(),
+					Reason:             "Invalid",
+					Message:            "test error",
+				},
+			},
+		},
+	}
+
+	// WHEN
+	result, err := s.cl.RuleSetRepository("foo").UpdateStatus(context.Background(), ruleSet, metav1.UpdateOptions{})
+
+	// THEN
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), result)
+	assert.Equal(s.T(), "0/1", result.Status.ActiveIn)
+	require.Len(s.T(), result.Status.Conditions, 1)
+	assert.Equal(s.T(), "Invalid", result.Status.Conditions[0].Reason)
+	assert.Equal(s.T(), "test error", result.Status.Conditions[0].Message)
+}
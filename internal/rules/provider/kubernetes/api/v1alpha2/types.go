@@ -0,0 +1,67 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha2
+
+//go:generate controller-gen object paths=$GOFILE
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/dadrus/heimdall/internal/rules/config"
+)
+
+// +kubebuilder:object:generate=true
+type RuleSetSpec struct {
+	// AuthClassName references the heimdall deployments, which should use this rule set.
+	// Compared to v1alpha1, it is optional. If not set, the default auth class is used.
+	AuthClassName string                  `json:"authClassName,omitempty"` //nolint:tagliatelle
+	Mode          string                  `json:"mode,omitempty"`
+	Defaults      *config.RuleSetDefaults `json:"defaults,omitempty"`
+	Templates     []config.RuleTemplate   `json:"templates,omitempty"`
+	Rules         []config.Rule           `json:"rules"`
+}
+
+// +kubebuilder:object:generate=true
+type RuleSetStatus struct {
+	// ActiveIn states in how many heimdall instances the rule set is active, like "2/3"
+	ActiveIn string `json:"activeIn,omitempty"` //nolint:tagliatelle
+	// Conditions holds a Ready condition per heimdall instance. Its type is prefixed
+	// with the name of the instance
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+type RuleSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RuleSetSpec   `json:"spec"`
+	Status RuleSetStatus `json:"status,omitempty"`
+}
+
+func (in *RuleSet) DeepCopyObject() runtime.Object { return in.DeepCopy() }
+
+// +kubebuilder:object:generate=true
+type RuleSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []RuleSet `json:"items"`
+}
+
+func (in *RuleSetList) DeepCopyObject() runtime.Object { return in.DeepCopy() }
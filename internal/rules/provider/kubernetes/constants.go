@@ -16,7 +16,26 @@
 
 package kubernetes
 
+import "time"
+
 const (
 	DefaultClass = "default"
 	ProviderType = "kubernetes"
+
+	ConditionReady       = "Ready"
+	ReasonRuleSetActive  = "RuleSetActive"
+	ReasonRuleSetInvalid = "Invalid"
+)
+
+const (
+	// statusHeartbeatInterval is the interval, in which each heimdall instance renews its lease.
+	statusHeartbeatInterval = 1 * time.Minute
+	// staleConditionThreshold is the number of missed heartbeats, after which the lease of an
+	// instance expires and its conditions are considered stale, e.g. because the corresponding
+	// pod has been killed.
+	staleConditionThreshold = 5
+
+	leaseNamePrefix             = "heimdall-"
+	podNamespaceEnvVar          = "POD_NAMESPACE"
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
 )
@@ -0,0 +1,128 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha1"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// conversionReview mirrors the ConversionReview resource of the apiextensions.k8s.io/v1 API.
+type conversionReview struct {
+	metav1.TypeMeta `json:",inline"`
+
+	Request  *conversionRequest  `json:"request,omitempty"`
+	Response *conversionResponse `json:"response,omitempty"`
+}
+
+type conversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"` //nolint:tagliatelle
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+type conversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"` //nolint:tagliatelle
+	Result           metav1.Status          `json:"result"`
+}
+
+func convertRuleSets(c *fiber.Ctx) error {
+	var review conversionReview
+
+	if err := c.BodyParser(&review); err != nil || review.Request == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "failed to parse conversion review")
+	}
+
+	response := &conversionResponse{
+		UID:              review.Request.UID,
+		ConvertedObjects: make([]runtime.RawExtension, len(review.Request.Objects)),
+		Result:           metav1.Status{Status: metav1.StatusSuccess},
+	}
+
+	for idx, object := range review.Request.Objects {
+		converted, err := convertRuleSet(object.Raw, review.Request.DesiredAPIVersion)
+		if err != nil {
+			// the conversion of all objects fails if a single one cannot be converted
+			response.ConvertedObjects = nil
+			response.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Code:    http.StatusBadRequest,
+				Reason:  metav1.StatusReasonBadRequest,
+				Message: err.Error(),
+			}
+
+			break
+		}
+
+		response.ConvertedObjects[idx] = runtime.RawExtension{Raw: converted}
+	}
+
+	return c.JSON(conversionReview{
+		TypeMeta: review.TypeMeta,
+		Response: response,
+	})
+}
+
+// convertRuleSet converts the given RuleSet resource to the desired version. All conversions are
+// done by converting the resource to v1alpha2 first.
+func convertRuleSet(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrArgument, "failed to decode RuleSet").CausedBy(err)
+	}
+
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	source, err := schema.ParseGroupVersion(typeMeta.APIVersion)
+	if err != nil || source.Group != v1alpha2.GroupName || typeMeta.Kind != "RuleSet" {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrArgument,
+			"unexpected resource %s/%s", typeMeta.APIVersion, typeMeta.Kind)
+	}
+
+	ruleSet, err := decodeRuleSet(source.Version, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch desiredAPIVersion {
+	case v1alpha2.GroupName + "/" + v1alpha2.GroupVersion:
+		return json.Marshal(ruleSet)
+	case v1alpha1.GroupName + "/" + v1alpha1.GroupVersion:
+		var converted v1alpha1.RuleSet
+		if err = converted.ConvertFrom(ruleSet); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(&converted)
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrArgument, "unsupported version %s", desiredAPIVersion)
+	}
+}
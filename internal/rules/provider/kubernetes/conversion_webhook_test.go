@@ -0,0 +1,383 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha1"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
+)
+
+const (
+	apiVersionV1alpha1 = v1alpha1.GroupName + "/" + v1alpha1.GroupVersion
+	apiVersionV1alpha2 = v1alpha2.GroupName + "/" + v1alpha2.GroupVersion
+)
+
+func newV1alpha2RuleSet() *v1alpha2.RuleSet {
+	return &v1alpha2.RuleSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apiVersionV1alpha2, Kind: "RuleSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-rules", Namespace: "foo"},
+		Spec: v1alpha2.RuleSetSpec{
+			AuthClassName: "bar",
+			Mode:          config2.RuleModeShadow,
+			Templates:     []config2.RuleTemplate{{ID: "tpl", Methods: []string{http.MethodGet}}},
+			Rules: []config2.Rule{
+				{
+					ID:          "test",
+					Extends:     "tpl",
+					RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				},
+			},
+		},
+	}
+}
+
+func TestConvertRuleSet(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc      string
+		object  func(t *testing.T) []byte
+		version string
+		assert  func(t *testing.T, err error, converted []byte)
+	}{
+		{
+			uc:      "not decodable object",
+			object:  func(t *testing.T) []byte { t.Helper(); return []byte("foo") },
+			version: apiVersionV1alpha2,
+			assert: func(t *testing.T, err error, _ []byte) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "failed to decode RuleSet")
+			},
+		},
+		{
+			uc: "unexpected resource",
+			object: func(t *testing.T) []byte {
+				t.Helper()
+
+				return []byte(`{"apiVersion":"v1","kind":"Pod"}`)
+			},
+			version: apiVersionV1alpha2,
+			assert: func(t *testing.T, err error, _ []byte) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "unexpected resource v1/Pod")
+			},
+		},
+		{
+			uc: "unsupported source version",
+			object: func(t *testing.T) []byte {
+				t.Helper()
+
+				return []byte(`{"apiVersion":"heimdall.dadrus.github.com/v1beta1","kind":"RuleSet"}`)
+			},
+			version: apiVersionV1alpha2,
+			assert: func(t *testing.T, err error, _ []byte) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "unsupported version v1beta1")
+			},
+		},
+		{
+			uc: "unsupported desired version",
+			object: func(t *testing.T) []byte {
+				t.Helper()
+
+				raw, err := json.Marshal(newV1alpha2RuleSet())
+				require.NoError(t, err)
+
+				return raw
+			},
+			version: v1alpha2.GroupName + "/v1beta1",
+			assert: func(t *testing.T, err error, _ []byte) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "unsupported version")
+			},
+		},
+		{
+			uc: "object in desired version is taken over as is",
+			object: func(t *testing.T) []byte {
+				t.Helper()
+
+				return []byte(`{"apiVersion":"heimdall.dadrus.github.com/v1alpha2","kind":"RuleSet","foo":"bar"}`)
+			},
+			version: apiVersionV1alpha2,
+			assert: func(t *testing.T, err error, converted []byte) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"apiVersion":"heimdall.dadrus.github.com/v1alpha2","kind":"RuleSet","foo":"bar"}`,
+					string(converted))
+			},
+		},
+		{
+			uc: "v1alpha1 to v1alpha2",
+			object: func(t *testing.T) []byte {
+				t.Helper()
+
+				raw, err := json.Marshal(v1alpha1.RuleSet{
+					TypeMeta:   metav1.TypeMeta{APIVersion: apiVersionV1alpha1, Kind: "RuleSet"},
+					ObjectMeta: metav1.ObjectMeta{Name: "test-rules", Namespace: "foo"},
+					Spec: v1alpha1.RuleSetSpec{
+						AuthClassName: "bar",
+						Rules: []v1alpha1.Rule{
+							{ID: "test", RuleMatcher: v1alpha1.Matcher{URL: "http://foo.bar", Strategy: "glob"}},
+						},
+					},
+				})
+				require.NoError(t, err)
+
+				return raw
+			},
+			version: apiVersionV1alpha2,
+			assert: func(t *testing.T, err error, converted []byte) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				var ruleSet v1alpha2.RuleSet
+				require.NoError(t, json.Unmarshal(converted, &ruleSet))
+
+				assert.Equal(t, apiVersionV1alpha2, ruleSet.APIVersion)
+				assert.Equal(t, "RuleSet", ruleSet.Kind)
+				assert.Equal(t, "test-rules", ruleSet.Name)
+				assert.Equal(t, "bar", ruleSet.Spec.AuthClassName)
+				require.Len(t, ruleSet.Spec.Rules, 1)
+				assert.Equal(t, "test", ruleSet.Spec.Rules[0].ID)
+				assert.Equal(t, "http://foo.bar", ruleSet.Spec.Rules[0].RuleMatcher.URL)
+			},
+		},
+		{
+			uc: "v1alpha2 to v1alpha1 stashes properties not supported by v1alpha1",
+			object: func(t *testing.T) []byte {
+				t.Helper()
+
+				raw, err := json.Marshal(newV1alpha2RuleSet())
+				require.NoError(t, err)
+
+				return raw
+			},
+			version: apiVersionV1alpha1,
+			assert: func(t *testing.T, err error, converted []byte) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				var ruleSet v1alpha1.RuleSet
+				require.NoError(t, json.Unmarshal(converted, &ruleSet))
+
+				assert.Equal(t, apiVersionV1alpha1, ruleSet.APIVersion)
+				assert.Equal(t, "bar", ruleSet.Spec.AuthClassName)
+				require.Len(t, ruleSet.Spec.Rules, 1)
+				assert.Equal(t, "test", ruleSet.Spec.Rules[0].ID)
+				assert.Contains(t, ruleSet.Annotations, v1alpha1.AnnotationV1alpha2Spec)
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// WHEN
+			converted, err := convertRuleSet(tc.object(t), tc.version)
+
+			// THEN
+			tc.assert(t, err, converted)
+		})
+	}
+}
+
+func TestConvertRuleSetRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	original := newV1alpha2RuleSet()
+
+	raw, err := json.Marshal(original)
+	require.NoError(t, err)
+
+	// WHEN
+	stashed, err := convertRuleSet(raw, apiVersionV1alpha1)
+	require.NoError(t, err)
+
+	restored, err := convertRuleSet(stashed, apiVersionV1alpha2)
+	require.NoError(t, err)
+
+	// THEN
+	var ruleSet v1alpha2.RuleSet
+	require.NoError(t, json.Unmarshal(restored, &ruleSet))
+
+	assert.Equal(t, original.TypeMeta, ruleSet.TypeMeta)
+	assert.Equal(t, original.Name, ruleSet.Name)
+	assert.NotContains(t, ruleSet.Annotations, v1alpha1.AnnotationV1alpha2Spec)
+	assert.Equal(t, original.Spec, ruleSet.Spec)
+}
+
+func TestConvertRuleSets(t *testing.T) {
+	t.Parallel()
+
+	newConversionReview := func(t *testing.T, desiredAPIVersion string, objects ...[]byte) []byte {
+		t.Helper()
+
+		review := conversionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+			Request: &conversionRequest{
+				UID:               types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"),
+				DesiredAPIVersion: desiredAPIVersion,
+			},
+		}
+
+		for _, object := range objects {
+			review.Request.Objects = append(review.Request.Objects, runtime.RawExtension{Raw: object})
+		}
+
+		body, err := json.Marshal(review)
+		require.NoError(t, err)
+
+		return body
+	}
+
+	ruleSet, err := json.Marshal(newV1alpha2RuleSet())
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		body   func(t *testing.T) []byte
+		assert func(t *testing.T, code int, review *conversionReview)
+	}{
+		{
+			uc:   "malformed conversion review",
+			body: func(t *testing.T) []byte { t.Helper(); return []byte("foo") },
+			assert: func(t *testing.T, code int, _ *conversionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, code)
+			},
+		},
+		{
+			uc:   "conversion review without request",
+			body: func(t *testing.T) []byte { t.Helper(); return []byte(`{"kind": "ConversionReview"}`) },
+			assert: func(t *testing.T, code int, _ *conversionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, code)
+			},
+		},
+		{
+			uc: "conversion fails if a single object cannot be converted",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newConversionReview(t, apiVersionV1alpha1,
+					ruleSet, []byte(`{"apiVersion":"v1","kind":"Pod"}`))
+			},
+			assert: func(t *testing.T, code int, review *conversionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				require.NotNil(t, review.Response)
+				assert.Equal(t, types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"), review.Response.UID)
+				assert.Empty(t, review.Response.ConvertedObjects)
+				assert.Equal(t, metav1.StatusFailure, review.Response.Result.Status)
+				assert.Equal(t, int32(http.StatusBadRequest), review.Response.Result.Code)
+				assert.Contains(t, review.Response.Result.Message, "unexpected resource")
+			},
+		},
+		{
+			uc: "successful conversion",
+			body: func(t *testing.T) []byte {
+				t.Helper()
+
+				return newConversionReview(t, apiVersionV1alpha1, ruleSet, ruleSet)
+			},
+			assert: func(t *testing.T, code int, review *conversionReview) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusOK, code)
+				assert.Equal(t, "apiextensions.k8s.io/v1", review.APIVersion)
+				assert.Equal(t, "ConversionReview", review.Kind)
+				require.NotNil(t, review.Response)
+				assert.Equal(t, types.UID("705ab4f5-6393-11e8-b7cc-42010a800002"), review.Response.UID)
+				assert.Equal(t, metav1.StatusSuccess, review.Response.Result.Status)
+				require.Len(t, review.Response.ConvertedObjects, 2)
+
+				for _, object := range review.Response.ConvertedObjects {
+					var converted v1alpha1.RuleSet
+					require.NoError(t, json.Unmarshal(object.Raw, &converted))
+					assert.Equal(t, apiVersionV1alpha1, converted.APIVersion)
+					assert.Contains(t, converted.Annotations, v1alpha1.AnnotationV1alpha2Spec)
+				}
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			app := newAdmissionControllerApp(func(_ *v1alpha2.RuleSet) error {
+				t.Fatal("validation not expected")
+
+				return nil
+			}, log.Logger)
+
+			req := httptest.NewRequest(http.MethodPost, EndpointConvertRuleSet, bytes.NewReader(tc.body(t)))
+			req.Header.Set("Content-Type", "application/json")
+
+			// WHEN
+			resp, err := app.Test(req, -1)
+
+			// THEN
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			var review conversionReview
+			if resp.StatusCode == http.StatusOK {
+				require.NoError(t, json.NewDecoder(resp.Body).Decode(&review))
+			}
+
+			tc.assert(t, resp.StatusCode, &review)
+		})
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
+)
+
+//nolint:gochecknoglobals
+var leaseLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "heimdall",
+	"app.kubernetes.io/component":  "rule-provider",
+}
+
+// leaseRepository provides access to the leases of the heimdall instances. Only the operations
+// required to maintain these are supported.
+type leaseRepository interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*coordinationv1.LeaseList, error)
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*coordinationv1.Lease, error)
+	Create(ctx context.Context, lease *coordinationv1.Lease, opts metav1.CreateOptions) (*coordinationv1.Lease, error)
+	Update(ctx context.Context, lease *coordinationv1.Lease, opts metav1.UpdateOptions) (*coordinationv1.Lease, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+}
+
+func newLeaseRepository(conf *rest.Config, namespace string) (leaseRepository, error) {
+	config := *conf
+	config.ContentConfig.GroupVersion = &coordinationv1.SchemeGroupVersion
+	config.APIPath = "/apis"
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+	config.UserAgent = rest.DefaultKubernetesUserAgent()
+
+	cl, err := rest.RESTClientFor(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &restLeaseRepository{cl: cl, ns: namespace}, nil
+}
+
+type restLeaseRepository struct {
+	cl rest.Interface
+	ns string
+}
+
+func (l *restLeaseRepository) List(ctx context.Context, opts metav1.ListOptions) (*coordinationv1.LeaseList, error) {
+	result := &coordinationv1.LeaseList{}
+	err := l.cl.Get().
+		Namespace(l.ns).
+		Resource("leases").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+
+	return result, err
+}
+
+func (l *restLeaseRepository) Get(ctx context.Context, name string, opts metav1.GetOptions) (*coordinationv1.Lease, error) {
+	result := &coordinationv1.Lease{}
+	err := l.cl.Get().
+		Namespace(l.ns).
+		Resource("leases").
+		Name(name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+
+	return result, err
+}
+
+func (l *restLeaseRepository) Create(
+	ctx context.Context, lease *coordinationv1.Lease, opts metav1.CreateOptions,
+) (*coordinationv1.Lease, error) {
+	result := &coordinationv1.Lease{}
+	err := l.cl.Post().
+		Namespace(l.ns).
+		Resource("leases").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(lease).
+		Do(ctx).
+		Into(result)
+
+	return result, err
+}
+
+func (l *restLeaseRepository) Update(
+	ctx context.Context, lease *coordinationv1.Lease, opts metav1.UpdateOptions,
+) (*coordinationv1.Lease, error) {
+	result := &coordinationv1.Lease{}
+	err := l.cl.Put().
+		Namespace(l.ns).
+		Resource("leases").
+		Name(lease.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(lease).
+		Do(ctx).
+		Into(result)
+
+	return result, err
+}
+
+func (l *restLeaseRepository) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return l.cl.Delete().
+		Namespace(l.ns).
+		Resource("leases").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// instanceNamespace returns the namespace, the leases of the heimdall instances are maintained in.
+// This is the namespace of the pod heimdall is running in.
+func instanceNamespace() string {
+	if namespace := os.Getenv(podNamespaceEnvVar); len(namespace) != 0 {
+		return namespace
+	}
+
+	if namespace, err := os.ReadFile(serviceAccountNamespaceFile); err == nil &&
+		len(strings.TrimSpace(string(namespace))) != 0 {
+		return strings.TrimSpace(string(namespace))
+	}
+
+	return "default"
+}
+
+func (p *provider) leaseName() string { return leaseNamePrefix + p.id }
+
+// renewLease creates the lease of this instance, respectively renews it, if it already exists.
+func (p *provider) renewLease(ctx context.Context) {
+	now := metav1.NowMicro()
+	duration := int32((staleConditionThreshold * p.hbi).Seconds())
+
+	lease, err := p.leases.Get(ctx, p.leaseName(), metav1.GetOptions{})
+
+	switch {
+	case k8serrors.IsNotFound(err):
+		_, err = p.leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: p.leaseName(), Labels: leaseLabels},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &p.id,
+				LeaseDurationSeconds: &duration,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+	case err == nil:
+		lease.Spec.HolderIdentity = &p.id
+		lease.Spec.LeaseDurationSeconds = &duration
+		lease.Spec.RenewTime = &now
+
+		_, err = p.leases.Update(ctx, lease, metav1.UpdateOptions{})
+	}
+
+	if err != nil {
+		p.l.Warn().Err(err).Msgf("Failed to renew lease %s", p.leaseName())
+	}
+}
+
+func (p *provider) releaseLease(ctx context.Context) {
+	err := p.leases.Delete(ctx, p.leaseName(), metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		p.l.Warn().Err(err).Msgf("Failed to release lease %s", p.leaseName())
+	}
+}
+
+// pruneStaleInstances removes the conditions of the instances, the leases of which have expired,
+// from the status of the rule sets and deletes these leases afterwards. Such leases and conditions
+// are usually left over by instances, which have not been shut down gracefully. Conditions of
+// instances without a lease are kept, as these might be maintained in another namespace.
+func (p *provider) pruneStaleInstances(ctx context.Context) {
+	expired, err := p.expiredLeases(ctx)
+	if err != nil {
+		p.l.Warn().Err(err).Msg("Failed to list leases of heimdall instances")
+
+		return
+	}
+
+	if len(expired) == 0 {
+		return
+	}
+
+	stale := make(map[string]bool, len(expired))
+	for _, lease := range expired {
+		stale[*lease.Spec.HolderIdentity] = true
+	}
+
+	pruned := true
+
+	for _, obj := range p.store.List() {
+		// should never be of a different type. ok if panics
+		rs := obj.(*v1alpha2.RuleSet) // nolint: forcetypeassert
+
+		if err = p.modifyLatestStatus(ctx, rs, func(status *v1alpha2.RuleSetStatus) bool {
+			return p.pruneStaleConditions(status, stale)
+		}); err != nil {
+			pruned = false
+		}
+	}
+
+	// the leases are kept until all conditions of the corresponding instances have been removed.
+	// Otherwise, the conditions, which could not be removed, would stay forever
+	if !pruned {
+		return
+	}
+
+	for _, lease := range expired {
+		if err = p.leases.Delete(ctx, lease.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			p.l.Warn().Err(err).Msgf("Failed to delete expired lease %s", lease.Name)
+		}
+	}
+}
+
+// expiredLeases returns the leases of other heimdall instances, which have not been renewed in time.
+func (p *provider) expiredLeases(ctx context.Context) ([]coordinationv1.Lease, error) {
+	leases, err := p.leases.List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(leaseLabels).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []coordinationv1.Lease
+
+	now := time.Now()
+
+	for _, lease := range leases.Items {
+		holder := lease.Spec.HolderIdentity
+		if holder == nil || len(*holder) == 0 || *holder == p.id {
+			continue
+		}
+
+		if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil ||
+			lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds)*time.Second).Before(now) {
+			expired = append(expired, lease)
+		}
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,245 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+type testLeaseRepository struct {
+	leases map[string]*coordinationv1.Lease
+}
+
+func (r *testLeaseRepository) List(_ context.Context, _ metav1.ListOptions) (*coordinationv1.LeaseList, error) {
+	list := &coordinationv1.LeaseList{}
+	for _, lease := range r.leases {
+		list.Items = append(list.Items, *lease.DeepCopy())
+	}
+
+	return list, nil
+}
+
+func (r *testLeaseRepository) Get(_ context.Context, name string, _ metav1.GetOptions) (*coordinationv1.Lease, error) {
+	lease, ok := r.leases[name]
+	if !ok {
+		return nil, k8serrors.NewNotFound(coordinationv1.Resource("leases"), name)
+	}
+
+	return lease.DeepCopy(), nil
+}
+
+func (r *testLeaseRepository) Create(
+	_ context.Context, lease *coordinationv1.Lease, _ metav1.CreateOptions,
+) (*coordinationv1.Lease, error) {
+	r.leases[lease.Name] = lease.DeepCopy()
+
+	return lease, nil
+}
+
+func (r *testLeaseRepository) Update(
+	_ context.Context, lease *coordinationv1.Lease, _ metav1.UpdateOptions,
+) (*coordinationv1.Lease, error) {
+	r.leases[lease.Name] = lease.DeepCopy()
+
+	return lease, nil
+}
+
+func (r *testLeaseRepository) Delete(_ context.Context, name string, _ metav1.DeleteOptions) error {
+	delete(r.leases, name)
+
+	return nil
+}
+
+type testRuleSetClient struct {
+	ruleSets  map[string]*v1alpha2.RuleSet
+	updateErr error
+}
+
+func (c *testRuleSetClient) RuleSetRepository(_ string) v1alpha2.RuleSetRepository { return c }
+
+func (c *testRuleSetClient) List(_ context.Context, _ metav1.ListOptions) (*v1alpha2.RuleSetList, error) {
+	return &v1alpha2.RuleSetList{}, nil
+}
+
+func (c *testRuleSetClient) Watch(_ context.Context, _ metav1.ListOptions) (watch.Interface, error) {
+	return watch.NewEmptyWatch(), nil
+}
+
+func (c *testRuleSetClient) Get(_ context.Context, name string, _ metav1.GetOptions) (*v1alpha2.RuleSet, error) {
+	return c.ruleSets[name].DeepCopy(), nil
+}
+
+func (c *testRuleSetClient) UpdateStatus(
+	_ context.Context, ruleSet *v1alpha2.RuleSet, _ metav1.UpdateOptions,
+) (*v1alpha2.RuleSet, error) {
+	if c.updateErr != nil {
+		return nil, c.updateErr
+	}
+
+	c.ruleSets[ruleSet.Name] = ruleSet.DeepCopy()
+
+	return ruleSet, nil
+}
+
+func newTestLease(holder string, renewed time.Time) *coordinationv1.Lease {
+	duration := int32((staleConditionThreshold * time.Minute).Seconds())
+	renewTime := metav1.NewMicroTime(renewed)
+
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: leaseNamePrefix + holder, Labels: leaseLabels},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			RenewTime:            &renewTime,
+		},
+	}
+}
+
+func TestProviderRenewLease(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	leases := &testLeaseRepository{leases: map[string]*coordinationv1.Lease{}}
+	prov := &provider{id: "self", hbi: time.Minute, l: log.Logger, leases: leases}
+
+	// WHEN
+	prov.renewLease(context.Background())
+
+	// THEN
+	created := leases.leases["heimdall-self"]
+	require.NotNil(t, created)
+	assert.Equal(t, leaseLabels, created.Labels)
+	assert.Equal(t, "self", *created.Spec.HolderIdentity)
+	assert.Equal(t, int32(300), *created.Spec.LeaseDurationSeconds)
+	require.NotNil(t, created.Spec.AcquireTime)
+	require.NotNil(t, created.Spec.RenewTime)
+
+	// WHEN
+	time.Sleep(10 * time.Millisecond)
+	prov.renewLease(context.Background())
+
+	// THEN
+	renewed := leases.leases["heimdall-self"]
+	assert.True(t, created.Spec.AcquireTime.Equal(renewed.Spec.AcquireTime))
+	assert.True(t, renewed.Spec.RenewTime.After(created.Spec.RenewTime.Time))
+
+	// WHEN
+	prov.releaseLease(context.Background())
+
+	// THEN
+	assert.Empty(t, leases.leases)
+}
+
+func TestProviderPruneStaleInstances(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc        string
+		updateErr error
+		assert    func(t *testing.T, client *testRuleSetClient, leases *testLeaseRepository)
+	}{
+		{
+			uc: "conditions of instances with expired leases are removed together with the leases",
+			assert: func(t *testing.T, client *testRuleSetClient, leases *testLeaseRepository) {
+				t.Helper()
+
+				status := client.ruleSets["test"].Status
+				require.Len(t, status.Conditions, 3)
+				assert.Equal(t, "self/"+ConditionReady, status.Conditions[0].Type)
+				assert.Equal(t, "foo/"+ConditionReady, status.Conditions[1].Type)
+				// the instance without a lease might be maintained in another namespace
+				assert.Equal(t, "baz/"+ConditionReady, status.Conditions[2].Type)
+				assert.Equal(t, "3/3", status.ActiveIn)
+
+				assert.Len(t, leases.leases, 2)
+				assert.Contains(t, leases.leases, "heimdall-self")
+				assert.Contains(t, leases.leases, "heimdall-foo")
+			},
+		},
+		{
+			uc:        "expired leases are kept if conditions could not be removed",
+			updateErr: testsupport.ErrTestPurpose,
+			assert: func(t *testing.T, client *testRuleSetClient, leases *testLeaseRepository) {
+				t.Helper()
+
+				assert.Len(t, client.ruleSets["test"].Status.Conditions, 4)
+				assert.Len(t, leases.leases, 3)
+				assert.Contains(t, leases.leases, "heimdall-bar")
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			now := time.Now()
+			expired := now.Add(-staleConditionThreshold * time.Minute).Add(-time.Second)
+
+			leases := &testLeaseRepository{leases: map[string]*coordinationv1.Lease{
+				// the own lease is never considered, even if it expired
+				"heimdall-self": newTestLease("self", expired),
+				"heimdall-foo":  newTestLease("foo", now),
+				"heimdall-bar":  newTestLease("bar", expired),
+			}}
+
+			ruleSet := &v1alpha2.RuleSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Status: v1alpha2.RuleSetStatus{
+					ActiveIn: "4/4",
+					Conditions: []metav1.Condition{
+						{Type: "self/" + ConditionReady, Status: metav1.ConditionTrue},
+						{Type: "foo/" + ConditionReady, Status: metav1.ConditionTrue},
+						{Type: "bar/" + ConditionReady, Status: metav1.ConditionTrue},
+						{Type: "baz/" + ConditionReady, Status: metav1.ConditionTrue},
+					},
+				},
+			}
+
+			client := &testRuleSetClient{
+				ruleSets:  map[string]*v1alpha2.RuleSet{"test": ruleSet.DeepCopy()},
+				updateErr: tc.updateErr,
+			}
+
+			store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+			require.NoError(t, store.Add(ruleSet))
+
+			prov := &provider{id: "self", hbi: time.Minute, l: log.Logger, leases: leases, cl: client, store: store}
+
+			// WHEN
+			prov.pruneStaleInstances(context.Background())
+
+			// THEN
+			tc.assert(t, client, leases)
+		})
+	}
+}
@@ -20,22 +20,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-logr/zerologr"
 	"github.com/rs/zerolog"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	config2 "github.com/dadrus/heimdall/internal/rules/config"
-	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha1"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
 	"github.com/dadrus/heimdall/internal/rules/rule"
 	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
@@ -47,18 +52,25 @@ type ConfigFactory func() (*rest.Config, error)
 
 type provider struct {
 	p          rule.SetProcessor
+	f          rule.Factory
+	adc        *admissionController
 	l          zerolog.Logger
-	cl         v1alpha1.Client
+	cl         v1alpha2.Client
+	leases     leaseRepository
+	store      cache.Store
 	cancel     context.CancelFunc
 	configured bool
 	wg         sync.WaitGroup
 	ac         string
+	id         string
+	hbi        time.Duration
 }
 
 func newProvider(
 	conf *config.Configuration,
 	k8sCF ConfigFactory,
 	processor rule.SetProcessor,
+	factory rule.Factory,
 	logger zerolog.Logger,
 ) (*provider, error) {
 	rawConf := conf.Rules.Providers.Kubernetes
@@ -75,10 +87,11 @@ func newProvider(
 	}
 
 	type Config struct {
-		AuthClass string `mapstructure:"auth_class"`
+		AuthClass string      `mapstructure:"auth_class"`
+		TLS       *config.TLS `mapstructure:"tls"`
 	}
 
-	client, err := v1alpha1.NewClient(k8sConf)
+	client, err := v1alpha2.NewClient(k8sConf)
 	if err != nil {
 		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
 			"failed creating client for connecting to kubernetes cluster").
@@ -92,40 +105,71 @@ func newProvider(
 			CausedBy(err)
 	}
 
-	logger = logger.With().Str("_provider_type", ProviderType).Logger()
+	leases, err := newLeaseRepository(k8sConf, instanceNamespace())
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed creating client for maintaining leases").
+			CausedBy(err)
+	}
 
-	logger.Info().Msg("Rule provider configured.")
+	// the hostname of a pod is its name by default, which makes it a good candidate to identify
+	// the conditions of this instance in the status of the rule sets
+	instanceID, err := os.Hostname()
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to determine the name of the heimdall instance").
+			CausedBy(err)
+	}
+
+	logger = logger.With().Str("_provider_type", ProviderType).Logger()
 
-	return &provider{
+	prov := &provider{
 		p:          processor,
+		f:          factory,
 		l:          logger,
 		cl:         client,
+		leases:     leases,
 		ac:         x.IfThenElse(len(providerConf.AuthClass) != 0, providerConf.AuthClass, DefaultClass),
+		id:         strings.ToLower(instanceID),
+		hbi:        statusHeartbeatInterval,
 		configured: true,
-	}, nil
+	}
+
+	if providerConf.TLS != nil {
+		if prov.adc, err = newAdmissionController(providerConf.TLS, prov.validateRuleSet, logger); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Info().Msg("Rule provider configured.")
+
+	return prov, nil
 }
 
-func (p *provider) newController(ctx context.Context, namespace string) cache.Controller {
+func (p *provider) newController(ctx context.Context, namespace string) (cache.Store, cache.Controller) {
 	repository := p.cl.RuleSetRepository(namespace)
-	_, controller := cache.NewTransformingInformer(
+
+	return cache.NewTransformingInformer(
 		&cache.ListWatch{
 			ListFunc:  func(opts metav1.ListOptions) (runtime.Object, error) { return repository.List(ctx, opts) },
 			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) { return repository.Watch(ctx, opts) },
 		},
-		&v1alpha1.RuleSet{},
+		&v1alpha2.RuleSet{},
 		0,
-		cache.ResourceEventHandlerFuncs{AddFunc: p.addRuleSet, DeleteFunc: p.deleteRuleSet, UpdateFunc: p.updateRuleSet},
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj any) { p.addRuleSet(ctx, obj) },
+			DeleteFunc: p.deleteRuleSet,
+			UpdateFunc: func(oldObj, newObj any) { p.updateRuleSet(ctx, oldObj, newObj) },
+		},
 		p.filterAuthClass,
 	)
-
-	return controller
 }
 
 func (p *provider) filterAuthClass(input any) (any, error) {
 	// should never be of a different type. ok if panics
-	rs := input.(*v1alpha1.RuleSet) // nolint: forcetypeassert
+	rs := input.(*v1alpha2.RuleSet) // nolint: forcetypeassert
 
-	if rs.Spec.AuthClassName != p.ac {
+	if authClassName(rs) != p.ac {
 		p.l.Info().
 			Msgf("Ignoring ruleset due to authClassName mismatch (namespace=%s, name=%s, uid=%s)",
 				rs.Namespace, rs.Name, rs.UID)
@@ -136,7 +180,11 @@ func (p *provider) filterAuthClass(input any) (any, error) {
 	return input, nil
 }
 
-func (p *provider) Start(_ context.Context) error {
+func authClassName(rs *v1alpha2.RuleSet) string {
+	return x.IfThenElse(len(rs.Spec.AuthClassName) != 0, rs.Spec.AuthClassName, v1alpha2.DefaultAuthClassName)
+}
+
+func (p *provider) Start(ctx context.Context) error {
 	if !p.configured {
 		return nil
 	}
@@ -145,15 +193,26 @@ func (p *provider) Start(_ context.Context) error {
 
 	p.l.Info().Msg("Starting rule definitions provider")
 
+	if p.adc != nil {
+		if err := p.adc.Start(ctx); err != nil {
+			return err
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	ctx = p.l.With().Logger().WithContext(ctx)
 
 	p.cancel = cancel
 
+	// the lease indicates to other instances, that the conditions of this instance are not stale
+	p.renewLease(ctx) //nolint:contextcheck
+
 	// contextcheck disabled as the context object passed to Start
 	// will time out. We need however a fresh context here, which can be
 	// canceled
-	controller := p.newController(ctx, "") //nolint:contextcheck
+	store, controller := p.newController(ctx, "") //nolint:contextcheck
+
+	p.store = store
 
 	p.wg.Add(1)
 
@@ -162,6 +221,13 @@ func (p *provider) Start(_ context.Context) error {
 		p.wg.Done()
 	}()
 
+	p.wg.Add(1)
+
+	go func() {
+		p.heartbeat(ctx)
+		p.wg.Done()
+	}()
+
 	return nil
 }
 
@@ -172,8 +238,22 @@ func (p *provider) Stop(ctx context.Context) error {
 
 	p.l.Info().Msg("Tearing down rule provider.")
 
+	if p.adc != nil {
+		if err := p.adc.Stop(ctx); err != nil {
+			p.l.Warn().Err(err).Msg("Failed to tear down admission controller")
+		}
+	}
+
 	p.cancel()
 
+	// this instance does not serve the rule sets any more
+	for _, obj := range p.store.List() {
+		// should never be of a different type. ok if panics
+		p.removeStatusCondition(ctx, obj.(*v1alpha2.RuleSet)) // nolint: forcetypeassert
+	}
+
+	p.releaseLease(ctx)
+
 	done := make(chan struct{})
 
 	go func() {
@@ -191,61 +271,85 @@ func (p *provider) Stop(ctx context.Context) error {
 	}
 }
 
-func (p *provider) updateRuleSet(_, newObj any) {
+func (p *provider) updateRuleSet(ctx context.Context, oldObj, newObj any) {
 	// should never be of a different type. ok if panics
-	rs := newObj.(*v1alpha1.RuleSet) // nolint: forcetypeassert
+	rs := newObj.(*v1alpha2.RuleSet)    // nolint: forcetypeassert
+	oldRs := oldObj.(*v1alpha2.RuleSet) // nolint: forcetypeassert
 
-	conf := &config2.RuleSet{
-		MetaData: config2.MetaData{
-			Source:  fmt.Sprintf("%s:%s:%s", ProviderType, rs.Namespace, rs.UID),
-			ModTime: rs.CreationTimestamp.Time,
-		},
-		Version: p.mapVersion(rs.APIVersion),
-		Name:    rs.Name,
-		Rules:   rs.Spec.Rules,
+	if rs.Generation == oldRs.Generation {
+		// only the metadata or the status has been changed, e.g. by the status updates of heimdall
+		return
 	}
 
+	conf := p.toRuleSet(rs)
+
 	p.l.Info().Msg("Rule set update received")
 
 	p.l.Debug().Str("_src", conf.Source).
 		Msgf("Rule set resource version mapped from '%s' to '%s'", rs.APIVersion, conf.Version)
 
-	if err := p.p.OnUpdated(conf); err != nil {
+	err := p.p.OnUpdated(conf)
+	if err != nil {
 		p.l.Warn().Err(err).Str("_src", conf.Source).Msg("Failed to apply rule set updates")
 	} else {
 		p.l.Info().Str("_src", conf.Source).Msg("Rule set updated")
 	}
+
+	p.updateStatus(ctx, rs, err)
 }
 
-func (p *provider) addRuleSet(obj any) {
+func (p *provider) addRuleSet(ctx context.Context, obj any) {
 	// should never be of a different type. ok if panics
-	rs := obj.(*v1alpha1.RuleSet) // nolint: forcetypeassert
+	rs := obj.(*v1alpha2.RuleSet) // nolint: forcetypeassert
 
-	conf := &config2.RuleSet{
-		MetaData: config2.MetaData{
-			Source:  fmt.Sprintf("%s:%s:%s", ProviderType, rs.Namespace, rs.UID),
-			ModTime: rs.CreationTimestamp.Time,
-		},
-		Version: p.mapVersion(rs.APIVersion),
-		Name:    rs.Name,
-		Rules:   rs.Spec.Rules,
-	}
+	conf := p.toRuleSet(rs)
 
 	p.l.Info().Msg("New rule set received")
 
 	p.l.Debug().Str("_src", conf.Source).
 		Msgf("Rule set resource version mapped from '%s' to '%s'", rs.APIVersion, conf.Version)
 
-	if err := p.p.OnCreated(conf); err != nil {
+	err := p.p.OnCreated(conf)
+	if err != nil {
 		p.l.Warn().Err(err).Str("_src", conf.Source).Msg("Failed creating rule set")
 	} else {
 		p.l.Info().Str("_src", conf.Source).Msg("Rule set created")
 	}
+
+	p.updateStatus(ctx, rs, err)
+}
+
+func (p *provider) toRuleSet(rs *v1alpha2.RuleSet) *config2.RuleSet {
+	return &config2.RuleSet{
+		MetaData: config2.MetaData{
+			Source:  fmt.Sprintf("%s:%s:%s", ProviderType, rs.Namespace, rs.UID),
+			ModTime: rs.CreationTimestamp.Time,
+		},
+		Version:   p.mapVersion(rs.APIVersion),
+		Name:      rs.Name,
+		Mode:      rs.Spec.Mode,
+		Defaults:  rs.Spec.Defaults,
+		Templates: rs.Spec.Templates,
+		Rules:     rs.Spec.Rules,
+	}
+}
+
+// validateRuleSet applies the same checks to the given rule set the rule set processor applies
+// while loading it, without however loading it. Rule sets addressing other heimdall deployments
+// are not subject to validation.
+func (p *provider) validateRuleSet(rs *v1alpha2.RuleSet) error {
+	if authClassName(rs) != p.ac {
+		return nil
+	}
+
+	_, err := rule.CreateRules(p.f, p.toRuleSet(rs))
+
+	return err
 }
 
 func (p *provider) deleteRuleSet(obj any) {
 	// should never be of a different type. ok if panics
-	rs := obj.(*v1alpha1.RuleSet) // nolint: forcetypeassert
+	rs := obj.(*v1alpha2.RuleSet) // nolint: forcetypeassert
 
 	conf := &config2.RuleSet{
 		MetaData: config2.MetaData{
@@ -268,7 +372,167 @@ func (p *provider) deleteRuleSet(obj any) {
 	}
 }
 
+// updateStatus sets the Ready condition of this instance in the status of the given rule set
+// according to the outcome of its processing.
+func (p *provider) updateStatus(ctx context.Context, rs *v1alpha2.RuleSet, err error) {
+	condition := metav1.Condition{
+		Type:               p.conditionType(),
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: rs.Generation,
+		Reason:             ReasonRuleSetActive,
+		Message:            "rule set is active",
+	}
+
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = ReasonRuleSetInvalid
+		condition.Message = err.Error()
+	}
+
+	_ = p.modifyStatus(ctx, rs, func(status *v1alpha2.RuleSetStatus) bool {
+		existing := meta.FindStatusCondition(status.Conditions, condition.Type)
+		if existing != nil && existing.Status == condition.Status && existing.Reason == condition.Reason &&
+			existing.Message == condition.Message && existing.ObservedGeneration == condition.ObservedGeneration {
+			return false
+		}
+
+		meta.SetStatusCondition(&status.Conditions, condition)
+
+		return true
+	})
+}
+
+// heartbeat periodically renews the lease of this instance and removes the conditions of instances,
+// the leases of which have expired.
+func (p *provider) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(p.hbi)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.renewLease(ctx)
+			p.pruneStaleInstances(ctx)
+		}
+	}
+}
+
+// pruneStaleConditions removes the Ready conditions of the given stale instances. It returns true
+// if any condition has been removed.
+func (p *provider) pruneStaleConditions(status *v1alpha2.RuleSetStatus, stale map[string]bool) bool {
+	conditions := status.Conditions[:0]
+
+	for _, condition := range status.Conditions {
+		instance, found := strings.CutSuffix(condition.Type, "/"+ConditionReady)
+		if found && instance != p.id && stale[instance] {
+			p.l.Debug().Msgf("Removing stale condition %s", condition.Type)
+
+			continue
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	pruned := len(conditions) != len(status.Conditions)
+	status.Conditions = conditions
+
+	return pruned
+}
+
+func (p *provider) removeStatusCondition(ctx context.Context, rs *v1alpha2.RuleSet) {
+	_ = p.modifyLatestStatus(ctx, rs, func(status *v1alpha2.RuleSetStatus) bool {
+		if meta.FindStatusCondition(status.Conditions, p.conditionType()) == nil {
+			return false
+		}
+
+		meta.RemoveStatusCondition(&status.Conditions, p.conditionType())
+
+		return true
+	})
+}
+
+// modifyLatestStatus is like modifyStatus, but applies the modification to the latest version of
+// the given rule set, as the cached one does not necessarily reflect the status written by all instances.
+func (p *provider) modifyLatestStatus(
+	ctx context.Context, rs *v1alpha2.RuleSet, modify func(status *v1alpha2.RuleSetStatus) bool,
+) error {
+	latest, err := p.cl.RuleSetRepository(rs.Namespace).Get(ctx, rs.Name, metav1.GetOptions{})
+	if err != nil {
+		p.l.Warn().Err(err).
+			Msgf("Failed to update status of rule set (namespace=%s, name=%s, uid=%s)",
+				rs.Namespace, rs.Name, rs.UID)
+
+		return err
+	}
+
+	return p.modifyStatus(ctx, latest, modify)
+}
+
+// modifyStatus applies the given modification to the status of the given rule set and writes it
+// back. As all heimdall instances update the status of the same rule sets, conflicts are resolved
+// by applying the modification to the latest version of the rule set.
+func (p *provider) modifyStatus(
+	ctx context.Context, rs *v1alpha2.RuleSet, modify func(status *v1alpha2.RuleSetStatus) bool,
+) error {
+	repository := p.cl.RuleSetRepository(rs.Namespace)
+	ruleSet := rs.DeepCopy()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !modify(&ruleSet.Status) {
+			return nil
+		}
+
+		ruleSet.Status.ActiveIn = activeIn(ruleSet.Status.Conditions)
+
+		_, err := repository.UpdateStatus(ctx, ruleSet, metav1.UpdateOptions{})
+		if k8serrors.IsConflict(err) {
+			latest, getErr := repository.Get(ctx, rs.Name, metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+
+			ruleSet = latest
+		}
+
+		return err
+	})
+	if err != nil {
+		p.l.Warn().Err(err).
+			Msgf("Failed to update status of rule set (namespace=%s, name=%s, uid=%s)",
+				rs.Namespace, rs.Name, rs.UID)
+	}
+
+	return err
+}
+
+func (p *provider) conditionType() string {
+	return fmt.Sprintf("%s/%s", p.id, ConditionReady)
+}
+
+// activeIn returns the number of heimdall instances the rule set is active in compared to the number
+// of instances, which have processed it, like "2/3".
+func activeIn(conditions []metav1.Condition) string {
+	var active, total int
+
+	for _, condition := range conditions {
+		if !strings.HasSuffix(condition.Type, "/"+ConditionReady) {
+			continue
+		}
+
+		total++
+
+		if condition.Status == metav1.ConditionTrue {
+			active++
+		}
+	}
+
+	return fmt.Sprintf("%d/%d", active, total)
+}
+
 func (p *provider) mapVersion(_ string) string {
-	// currently the only possible version is v1alpha1, which is mapped to the version "1" used internally
+	// rule sets are watched in the v1alpha2 version only. Those created as v1alpha1 resources are converted
+	// to it by the API server using the conversion webhook. v1alpha2 is mapped to the version "1" used internally
 	return "1"
 }
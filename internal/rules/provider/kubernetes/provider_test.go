@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
@@ -38,7 +40,7 @@ import (
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	config2 "github.com/dadrus/heimdall/internal/rules/config"
-	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha1"
+	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes/api/v1alpha2"
 	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
 	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/testsupport"
@@ -66,6 +68,21 @@ func TestNewProvider(t *testing.T) {
 				assert.Contains(t, err.Error(), "failed to decode")
 			},
 		},
+		{
+			uc: "with tls configured, but not loadable key store",
+			conf: []byte(`
+tls:
+  key_store:
+    path: /no/such/file.pem
+`),
+			assert: func(t *testing.T, err error, prov *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "admission controller")
+			},
+		},
 		{
 			uc:   "with empty configuration",
 			conf: []byte(`{}`),
@@ -77,6 +94,8 @@ func TestNewProvider(t *testing.T) {
 				assert.Equal(t, DefaultClass, prov.ac)
 				assert.Nil(t, prov.cancel)
 				assert.NotNil(t, prov.cl)
+				assert.NotNil(t, prov.leases)
+				assert.Nil(t, prov.adc)
 			},
 		},
 		{
@@ -106,7 +125,7 @@ func TestNewProvider(t *testing.T) {
 			k8sCF := func() (*rest.Config, error) { return &rest.Config{Host: "http://localhost:80001"}, nil }
 
 			// WHEN
-			prov, err := newProvider(conf, k8sCF, mocks.NewRuleSetProcessorMock(t), log.Logger)
+			prov, err := newProvider(conf, k8sCF, mocks.NewRuleSetProcessorMock(t), mocks.NewFactoryMock(t), log.Logger)
 
 			// THEN
 			tc.assert(t, err, prov)
@@ -117,9 +136,82 @@ func TestNewProvider(t *testing.T) {
 func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 	type ResponseWriter func(t *testing.T, watchRequest bool, w http.ResponseWriter)
 
-	var writeResponse ResponseWriter
+	var (
+		writeResponse ResponseWriter
+		statusUpdates []*v1alpha2.RuleSet
+		leaseRequests []string
+		mutex         sync.Mutex
+	)
 
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/apis/coordination.k8s.io/v1/namespaces/") {
+			// maintenance of the lease of the instance. There is no lease initially
+			mutex.Lock()
+			leaseRequests = append(leaseRequests, r.Method)
+			mutex.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+
+			switch r.Method {
+			case http.MethodGet:
+				w.WriteHeader(http.StatusNotFound)
+				require.NoError(t, json.NewEncoder(w).Encode(metav1.Status{
+					TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+					Status:   metav1.StatusFailure,
+					Reason:   metav1.StatusReasonNotFound,
+					Code:     http.StatusNotFound,
+				}))
+			case http.MethodPost:
+				var lease coordinationv1.Lease
+
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&lease))
+				w.WriteHeader(http.StatusCreated)
+				require.NoError(t, json.NewEncoder(w).Encode(lease))
+			default:
+				require.NoError(t, json.NewEncoder(w).Encode(metav1.Status{
+					TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+					Status:   metav1.StatusSuccess,
+				}))
+			}
+
+			return
+		}
+
+		if r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/status") {
+			// status update. The received object is recorded and echoed
+			var ruleSet v1alpha2.RuleSet
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&ruleSet))
+
+			mutex.Lock()
+			statusUpdates = append(statusUpdates, &ruleSet)
+			mutex.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(w).Encode(ruleSet))
+
+			return
+		}
+
+		if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/rulesets/test-rule") {
+			// retrieval of the latest state written by the provider
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if len(statusUpdates) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			latest := statusUpdates[len(statusUpdates)-1]
+
+			w.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(w).Encode(latest))
+
+			return
+		}
+
 		writeResponse(t, r.URL.Query().Get("watch") == "true", w)
 	}))
 
@@ -131,6 +223,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 		writeResponse  ResponseWriter
 		setupProcessor func(t *testing.T, processor *mocks.RuleSetProcessorMock)
 		assert         func(t *testing.T, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock)
+		assertStatus   func(t *testing.T, updates []*v1alpha2.RuleSet)
 	}{
 		{
 			uc:   "rule set filtered due to wrong auth class",
@@ -141,18 +234,18 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 				return func(t *testing.T, watchRequest bool, w http.ResponseWriter) {
 					t.Helper()
 
-					rls := v1alpha1.RuleSetList{
+					rls := v1alpha2.RuleSetList{
 						TypeMeta: metav1.TypeMeta{
-							APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+							APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 							Kind:       "RuleSetList",
 						},
 						ListMeta: metav1.ListMeta{
 							ResourceVersion: "735820",
 						},
-						Items: []v1alpha1.RuleSet{
+						Items: []v1alpha2.RuleSet{
 							{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -163,7 +256,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 									Generation:        1,
 									CreationTimestamp: metav1.NewTime(time.Now()),
 								},
-								Spec: v1alpha1.RuleSetSpec{
+								Spec: v1alpha2.RuleSetSpec{
 									AuthClassName: "bar",
 									Rules: []config2.Rule{
 										{
@@ -172,7 +265,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 												URL:      "http://foo.bar",
 												Strategy: "glob",
 											},
-											Upstream: "http://bar",
+											Upstream: &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar"}}},
 											Methods:  []string{http.MethodGet},
 											Execute: []config.MechanismConfig{
 												{"authenticator": "authn"},
@@ -193,9 +286,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 					err = metav1.Convert_watch_Event_To_v1_WatchEvent(
 						&watch.Event{
 							Type: watch.Bookmark,
-							Object: &v1alpha1.RuleSet{
+							Object: &v1alpha2.RuleSet{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -245,18 +338,18 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 				return func(t *testing.T, watchRequest bool, w http.ResponseWriter) {
 					t.Helper()
 
-					rls := v1alpha1.RuleSetList{
+					rls := v1alpha2.RuleSetList{
 						TypeMeta: metav1.TypeMeta{
-							APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+							APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 							Kind:       "RuleSetList",
 						},
 						ListMeta: metav1.ListMeta{
 							ResourceVersion: "735820",
 						},
-						Items: []v1alpha1.RuleSet{
+						Items: []v1alpha2.RuleSet{
 							{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -267,7 +360,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 									Generation:        1,
 									CreationTimestamp: metav1.NewTime(time.Now()),
 								},
-								Spec: v1alpha1.RuleSetSpec{
+								Spec: v1alpha2.RuleSetSpec{
 									AuthClassName: "bar",
 									Rules: []config2.Rule{
 										{
@@ -276,7 +369,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 												URL:      "http://foo.bar",
 												Strategy: "glob",
 											},
-											Upstream: "http://bar",
+											Upstream: &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar"}}},
 											Methods:  []string{http.MethodGet},
 											Execute: []config.MechanismConfig{
 												{"authenticator": "authn"},
@@ -297,9 +390,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 					err = metav1.Convert_watch_Event_To_v1_WatchEvent(
 						&watch.Event{
 							Type: watch.Bookmark,
-							Object: &v1alpha1.RuleSet{
+							Object: &v1alpha2.RuleSet{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -354,7 +447,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 				rule := ruleSet.Rules[0]
 				assert.Equal(t, "test", rule.ID)
 				assert.Equal(t, "http://foo.bar", rule.RuleMatcher.URL)
-				assert.Equal(t, "http://bar", rule.Upstream)
+				assert.Equal(t, "http://bar", rule.Upstream.Targets[0].URL)
 				assert.Equal(t, "glob", rule.RuleMatcher.Strategy)
 				assert.Len(t, rule.Methods, 1)
 				assert.Contains(t, rule.Methods, http.MethodGet)
@@ -363,6 +456,26 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 				assert.Equal(t, "authn", rule.Execute[0]["authenticator"])
 				assert.Equal(t, "authz", rule.Execute[1]["authorizer"])
 			},
+			assertStatus: func(t *testing.T, updates []*v1alpha2.RuleSet) {
+				t.Helper()
+
+				// the first one is done after the rule set has been loaded,
+				// the second one while tearing down the provider
+				require.Len(t, updates, 2)
+
+				status := updates[0].Status
+				assert.Equal(t, "test-rule", updates[0].Name)
+				assert.Equal(t, "1/1", status.ActiveIn)
+				require.Len(t, status.Conditions, 1)
+				assert.True(t, strings.HasSuffix(status.Conditions[0].Type, "/"+ConditionReady))
+				assert.Equal(t, metav1.ConditionTrue, status.Conditions[0].Status)
+				assert.Equal(t, ReasonRuleSetActive, status.Conditions[0].Reason)
+				assert.Equal(t, int64(1), status.Conditions[0].ObservedGeneration)
+
+				status = updates[1].Status
+				assert.Equal(t, "0/0", status.ActiveIn)
+				assert.Empty(t, status.Conditions)
+			},
 		},
 		{
 			uc:   "adding rule set fails",
@@ -373,18 +486,18 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 				return func(t *testing.T, watchRequest bool, w http.ResponseWriter) {
 					t.Helper()
 
-					rls := v1alpha1.RuleSetList{
+					rls := v1alpha2.RuleSetList{
 						TypeMeta: metav1.TypeMeta{
-							APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+							APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 							Kind:       "RuleSetList",
 						},
 						ListMeta: metav1.ListMeta{
 							ResourceVersion: "735820",
 						},
-						Items: []v1alpha1.RuleSet{
+						Items: []v1alpha2.RuleSet{
 							{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -395,7 +508,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 									Generation:        1,
 									CreationTimestamp: metav1.NewTime(time.Now()),
 								},
-								Spec: v1alpha1.RuleSetSpec{
+								Spec: v1alpha2.RuleSetSpec{
 									AuthClassName: "bar",
 									Rules: []config2.Rule{
 										{
@@ -404,7 +517,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 												URL:      "http://foo.bar",
 												Strategy: "glob",
 											},
-											Upstream: "http://bar",
+											Upstream: &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar"}}},
 											Methods:  []string{http.MethodGet},
 											Execute: []config.MechanismConfig{
 												{"authenticator": "authn"},
@@ -425,9 +538,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 					err = metav1.Convert_watch_Event_To_v1_WatchEvent(
 						&watch.Event{
 							Type: watch.Bookmark,
-							Object: &v1alpha1.RuleSet{
+							Object: &v1alpha2.RuleSet{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -470,6 +583,18 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 
 				assert.Contains(t, logs.String(), "Failed creating rule set")
 			},
+			assertStatus: func(t *testing.T, updates []*v1alpha2.RuleSet) {
+				t.Helper()
+
+				require.NotEmpty(t, updates)
+
+				status := updates[0].Status
+				assert.Equal(t, "0/1", status.ActiveIn)
+				require.Len(t, status.Conditions, 1)
+				assert.Equal(t, metav1.ConditionFalse, status.Conditions[0].Status)
+				assert.Equal(t, ReasonRuleSetInvalid, status.Conditions[0].Reason)
+				assert.Contains(t, status.Conditions[0].Message, testsupport.ErrTestPurpose.Error())
+			},
 		},
 		{
 			uc:   "a ruleset is added and then removed",
@@ -486,9 +611,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 
 						evt := watch.Event{
 							Type: watch.Added,
-							Object: &v1alpha1.RuleSet{
+							Object: &v1alpha2.RuleSet{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -499,7 +624,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 									Generation:        1,
 									CreationTimestamp: metav1.NewTime(time.Now()),
 								},
-								Spec: v1alpha1.RuleSetSpec{
+								Spec: v1alpha2.RuleSetSpec{
 									AuthClassName: "bar",
 									Rules: []config2.Rule{
 										{
@@ -508,7 +633,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 												URL:      "http://foo.bar",
 												Strategy: "glob",
 											},
-											Upstream: "http://bar",
+											Upstream: &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar"}}},
 											Methods:  []string{http.MethodGet},
 											Execute: []config.MechanismConfig{
 												{"authenticator": "authn"},
@@ -553,9 +678,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 						}
 					} else {
 						// empty rule set initially
-						rls := v1alpha1.RuleSetList{
+						rls := v1alpha2.RuleSetList{
 							TypeMeta: metav1.TypeMeta{
-								APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+								APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 								Kind:       "RuleSetList",
 							},
 							ListMeta: metav1.ListMeta{
@@ -600,7 +725,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 				createdRule := ruleSet.Rules[0]
 				assert.Equal(t, "test", createdRule.ID)
 				assert.Equal(t, "http://foo.bar", createdRule.RuleMatcher.URL)
-				assert.Equal(t, "http://bar", createdRule.Upstream)
+				assert.Equal(t, "http://bar", createdRule.Upstream.Targets[0].URL)
 				assert.Equal(t, "glob", createdRule.RuleMatcher.Strategy)
 				assert.Len(t, createdRule.Methods, 1)
 				assert.Contains(t, createdRule.Methods, http.MethodGet)
@@ -630,9 +755,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 
 						evt := watch.Event{
 							Type: watch.Added,
-							Object: &v1alpha1.RuleSet{
+							Object: &v1alpha2.RuleSet{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -643,7 +768,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 									Generation:        1,
 									CreationTimestamp: metav1.NewTime(time.Now()),
 								},
-								Spec: v1alpha1.RuleSetSpec{
+								Spec: v1alpha2.RuleSetSpec{
 									AuthClassName: "bar",
 									Rules: []config2.Rule{
 										{
@@ -652,7 +777,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 												URL:      "http://foo.bar",
 												Strategy: "glob",
 											},
-											Upstream: "http://bar",
+											Upstream: &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar"}}},
 											Methods:  []string{http.MethodGet},
 											Execute: []config.MechanismConfig{
 												{"authenticator": "authn"},
@@ -697,9 +822,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 						}
 					} else {
 						// empty rule set initially
-						rls := v1alpha1.RuleSetList{
+						rls := v1alpha2.RuleSetList{
 							TypeMeta: metav1.TypeMeta{
-								APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+								APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 								Kind:       "RuleSetList",
 							},
 							ListMeta: metav1.ListMeta{
@@ -746,9 +871,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 
 						evt := watch.Event{
 							Type: watch.Added,
-							Object: &v1alpha1.RuleSet{
+							Object: &v1alpha2.RuleSet{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -759,7 +884,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 									Generation:        1,
 									CreationTimestamp: metav1.NewTime(time.Now()),
 								},
-								Spec: v1alpha1.RuleSetSpec{
+								Spec: v1alpha2.RuleSetSpec{
 									AuthClassName: "bar",
 									Rules: []config2.Rule{
 										{
@@ -768,7 +893,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 												URL:      "http://foo.bar",
 												Strategy: "glob",
 											},
-											Upstream: "http://bar",
+											Upstream: &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar"}}},
 											Methods:  []string{http.MethodGet},
 											Execute: []config.MechanismConfig{
 												{"authenticator": "authn"},
@@ -798,8 +923,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 							var watchEvt metav1.WatchEvent
 
 							evt.Type = watch.Modified
-							ruleSet := evt.Object.(*v1alpha1.RuleSet) // nolint:forcetypeassert
-							ruleSet.Spec = v1alpha1.RuleSetSpec{
+							ruleSet := evt.Object.(*v1alpha2.RuleSet) // nolint:forcetypeassert
+							ruleSet.Generation = 2
+							ruleSet.Spec = v1alpha2.RuleSetSpec{
 								AuthClassName: "bar",
 								Rules: []config2.Rule{
 									{
@@ -808,7 +934,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 											URL:      "http://foo.bar",
 											Strategy: "glob",
 										},
-										Upstream: "http://bar",
+										Upstream: &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar"}}},
 										Methods:  []string{http.MethodGet},
 										Execute: []config.MechanismConfig{
 											{"authenticator": "test_authn"},
@@ -832,9 +958,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 						}
 					} else {
 						// empty rule set initially
-						rls := v1alpha1.RuleSetList{
+						rls := v1alpha2.RuleSetList{
 							TypeMeta: metav1.TypeMeta{
-								APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+								APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 								Kind:       "RuleSetList",
 							},
 							ListMeta: metav1.ListMeta{
@@ -879,7 +1005,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 				createdRule := ruleSet.Rules[0]
 				assert.Equal(t, "test", createdRule.ID)
 				assert.Equal(t, "http://foo.bar", createdRule.RuleMatcher.URL)
-				assert.Equal(t, "http://bar", createdRule.Upstream)
+				assert.Equal(t, "http://bar", createdRule.Upstream.Targets[0].URL)
 				assert.Equal(t, "glob", createdRule.RuleMatcher.Strategy)
 				assert.Len(t, createdRule.Methods, 1)
 				assert.Contains(t, createdRule.Methods, http.MethodGet)
@@ -897,7 +1023,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 				updatedRule := ruleSet.Rules[0]
 				assert.Equal(t, "test", updatedRule.ID)
 				assert.Equal(t, "http://foo.bar", updatedRule.RuleMatcher.URL)
-				assert.Equal(t, "http://bar", updatedRule.Upstream)
+				assert.Equal(t, "http://bar", updatedRule.Upstream.Targets[0].URL)
 				assert.Equal(t, "glob", updatedRule.RuleMatcher.Strategy)
 				assert.Len(t, updatedRule.Methods, 1)
 				assert.Contains(t, updatedRule.Methods, http.MethodGet)
@@ -922,9 +1048,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 
 						evt := watch.Event{
 							Type: watch.Added,
-							Object: &v1alpha1.RuleSet{
+							Object: &v1alpha2.RuleSet{
 								TypeMeta: metav1.TypeMeta{
-									APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+									APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 									Kind:       "RuleSet",
 								},
 								ObjectMeta: metav1.ObjectMeta{
@@ -935,7 +1061,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 									Generation:        1,
 									CreationTimestamp: metav1.NewTime(time.Now()),
 								},
-								Spec: v1alpha1.RuleSetSpec{
+								Spec: v1alpha2.RuleSetSpec{
 									AuthClassName: "bar",
 									Rules: []config2.Rule{
 										{
@@ -944,7 +1070,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 												URL:      "http://foo.bar",
 												Strategy: "glob",
 											},
-											Upstream: "http://bar",
+											Upstream: &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar"}}},
 											Methods:  []string{http.MethodGet},
 											Execute: []config.MechanismConfig{
 												{"authenticator": "authn"},
@@ -974,8 +1100,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 							var watchEvt metav1.WatchEvent
 
 							evt.Type = watch.Modified
-							ruleSet := evt.Object.(*v1alpha1.RuleSet) // nolint:forcetypeassert
-							ruleSet.Spec = v1alpha1.RuleSetSpec{
+							ruleSet := evt.Object.(*v1alpha2.RuleSet) // nolint:forcetypeassert
+							ruleSet.Generation = 2
+							ruleSet.Spec = v1alpha2.RuleSetSpec{
 								AuthClassName: "bar",
 								Rules: []config2.Rule{
 									{
@@ -984,7 +1111,7 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 											URL:      "http://foo.bar",
 											Strategy: "glob",
 										},
-										Upstream: "http://bar",
+										Upstream: &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar"}}},
 										Methods:  []string{http.MethodGet},
 										Execute: []config.MechanismConfig{
 											{"authenticator": "test_authn"},
@@ -1008,9 +1135,9 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 						}
 					} else {
 						// empty rule set initially
-						rls := v1alpha1.RuleSetList{
+						rls := v1alpha2.RuleSetList{
 							TypeMeta: metav1.TypeMeta{
-								APIVersion: fmt.Sprintf("%s/%s", v1alpha1.GroupName, v1alpha1.GroupVersion),
+								APIVersion: fmt.Sprintf("%s/%s", v1alpha2.GroupName, v1alpha2.GroupVersion),
 								Kind:       "RuleSetList",
 							},
 							ListMeta: metav1.ListMeta{
@@ -1063,20 +1190,102 @@ func TestProviderLifecycle(t *testing.T) { //nolint:maintidx,gocognit, cyclop
 			setupProcessor(t, processor)
 
 			logs := &strings.Builder{}
-			prov, err := newProvider(conf, k8sCF, processor, zerolog.New(logs))
+			prov, err := newProvider(conf, k8sCF, processor, mocks.NewFactoryMock(t), zerolog.New(logs))
 			require.NoError(t, err)
 
 			ctx := context.Background()
 			writeResponse = tc.writeResponse
 
+			mutex.Lock()
+			statusUpdates = nil
+			leaseRequests = nil
+			mutex.Unlock()
+
 			// WHEN
 			err = prov.Start(ctx)
 
-			defer prov.Stop(ctx) //nolint:errcheck
-
 			// THEN
 			require.NoError(t, err)
 			tc.assert(t, logs, processor)
+
+			require.NoError(t, prov.Stop(ctx))
+
+			mutex.Lock()
+			// the lease of the instance is created on start and released on stop
+			assert.Equal(t, []string{http.MethodGet, http.MethodPost, http.MethodDelete}, leaseRequests)
+			mutex.Unlock()
+
+			if tc.assertStatus != nil {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				tc.assertStatus(t, statusUpdates)
+			}
+		})
+	}
+}
+
+func TestProviderPruneStaleConditions(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc         string
+		conditions []metav1.Condition
+		pruned     bool
+		remaining  []string
+	}{
+		{
+			uc:         "without conditions",
+			conditions: []metav1.Condition{},
+			remaining:  []string{},
+		},
+		{
+			uc: "without conditions of stale instances",
+			conditions: []metav1.Condition{
+				{Type: "foo/" + ConditionReady},
+				{Type: "baz/" + ConditionReady},
+			},
+			remaining: []string{"foo/" + ConditionReady, "baz/" + ConditionReady},
+		},
+		{
+			uc: "with condition of stale instance",
+			conditions: []metav1.Condition{
+				{Type: "foo/" + ConditionReady},
+				{Type: "bar/" + ConditionReady},
+			},
+			pruned:    true,
+			remaining: []string{"foo/" + ConditionReady},
+		},
+		{
+			uc: "with own condition and condition of other type",
+			conditions: []metav1.Condition{
+				{Type: "self/" + ConditionReady},
+				{Type: "bar"},
+			},
+			remaining: []string{"self/" + ConditionReady, "bar"},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			prov := &provider{id: "self", hbi: time.Minute, l: log.Logger}
+			status := &v1alpha2.RuleSetStatus{Conditions: tc.conditions}
+
+			// WHEN
+			pruned := prov.pruneStaleConditions(status, map[string]bool{"bar": true, "self": true})
+
+			// THEN
+			assert.Equal(t, tc.pruned, pruned)
+
+			remaining := make([]string, len(status.Conditions))
+			for idx, condition := range status.Conditions {
+				remaining[idx] = condition.Type
+			}
+
+			assert.Equal(t, tc.remaining, remaining)
 		})
 	}
 }
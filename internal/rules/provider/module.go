@@ -23,8 +23,11 @@ import (
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/rules/provider/cloudblob"
 	"github.com/dadrus/heimdall/internal/rules/provider/filesystem"
+	"github.com/dadrus/heimdall/internal/rules/provider/git"
 	"github.com/dadrus/heimdall/internal/rules/provider/httpendpoint"
+	"github.com/dadrus/heimdall/internal/rules/provider/inmemory"
 	"github.com/dadrus/heimdall/internal/rules/provider/kubernetes"
+	"github.com/dadrus/heimdall/internal/rules/provider/oci"
 )
 
 // Module is used on app bootstrap.
@@ -35,6 +38,9 @@ var Module = fx.Options(
 	httpendpoint.Module,
 	cloudblob.Module,
 	kubernetes.Module,
+	git.Module,
+	oci.Module,
+	inmemory.Module,
 )
 
 func checkRuleProvider(logger zerolog.Logger, conf *config.Configuration) {
@@ -49,6 +55,12 @@ func checkRuleProvider(logger zerolog.Logger, conf *config.Configuration) {
 		ruleProviderConfigured = true
 	case conf.Rules.Providers.Kubernetes != nil:
 		ruleProviderConfigured = true
+	case conf.Rules.Providers.Git != nil:
+		ruleProviderConfigured = true
+	case conf.Rules.Providers.OCI != nil:
+		ruleProviderConfigured = true
+	case conf.Rules.Providers.InMemory != nil:
+		ruleProviderConfigured = true
 	}
 
 	if !ruleProviderConfigured {
@@ -0,0 +1,195 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/provider/state"
+	"github.com/dadrus/heimdall/internal/rules/rule"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+type artifactState struct {
+	digest   string
+	ruleSets *state.Tracker
+}
+
+type provider struct {
+	p          rule.SetProcessor
+	l          zerolog.Logger
+	s          *gocron.Scheduler
+	cancel     context.CancelFunc
+	artifacts  []*ruleSetArtifact
+	states     sync.Map
+	configured bool
+}
+
+func newProvider(
+	conf *config.Configuration,
+	processor rule.SetProcessor,
+	logger zerolog.Logger,
+) (*provider, error) {
+	rawConf := conf.Rules.Providers.OCI
+
+	if rawConf == nil {
+		return &provider{}, nil
+	}
+
+	type Config struct {
+		Artifacts     []*ruleSetArtifact `mapstructure:"artifacts"`
+		WatchInterval *time.Duration     `mapstructure:"watch_interval"`
+	}
+
+	var providerConf Config
+	if err := decodeConfig(rawConf, &providerConf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode oci rule provider config").
+			CausedBy(err)
+	}
+
+	if len(providerConf.Artifacts) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"no artifacts configured for oci rule provider")
+	}
+
+	logger = logger.With().Str("_provider_type", "oci").Logger()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = logger.With().Logger().WithContext(ctx)
+
+	scheduler := gocron.NewScheduler(time.UTC)
+	scheduler.SingletonModeAll()
+
+	prov := &provider{
+		p:          processor,
+		l:          logger,
+		s:          scheduler,
+		cancel:     cancel,
+		artifacts:  providerConf.Artifacts,
+		configured: true,
+	}
+
+	for idx, artifact := range providerConf.Artifacts {
+		if err := artifact.init(); err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed to initialize #%d artifact in oci rule provider configuration", idx).
+				CausedBy(err)
+		}
+
+		if _, err := x.IfThenElseExec(providerConf.WatchInterval != nil && *providerConf.WatchInterval > 0,
+			func() *gocron.Scheduler { return prov.s.Every(*providerConf.WatchInterval) },
+			func() *gocron.Scheduler { return prov.s.Every(1 * time.Second).LimitRunsTo(1) }).
+			Do(prov.watchChanges, ctx, artifact); err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"failed to create a rule provider worker to fetch rules sets from #%d artifact", idx).
+				CausedBy(err)
+		}
+	}
+
+	logger.Info().Msg("Rule provider configured.")
+
+	return prov, nil
+}
+
+func (p *provider) Start(_ context.Context) error {
+	if !p.configured {
+		return nil
+	}
+
+	p.l.Info().Msg("Starting rule definitions provider")
+
+	p.s.StartAsync() //nolint:contextcheck
+
+	return nil
+}
+
+func (p *provider) Stop(_ context.Context) error {
+	if !p.configured {
+		return nil
+	}
+
+	p.l.Info().Msg("Tearing down rule provider.")
+
+	p.s.Stop()
+	p.cancel()
+
+	return nil
+}
+
+func (p *provider) watchChanges(ctx context.Context, artifact *ruleSetArtifact) error {
+	p.l.Debug().Str("_artifact", artifact.ID()).Msg("Resolving artifact reference")
+
+	digest, err := artifact.Resolve(ctx)
+	if err != nil {
+		return p.handleFetchError(err, artifact)
+	}
+
+	state := p.getArtifactState(artifact)
+
+	if state.digest == digest {
+		p.l.Debug().Str("_artifact", artifact.ID()).Str("_digest", digest).Msg("No updates received")
+
+		return nil
+	}
+
+	ruleSets, err := artifact.ReadRuleSets(ctx, digest)
+	if err != nil {
+		return p.handleFetchError(err, artifact)
+	}
+
+	if err = state.ruleSets.Apply(ruleSets); err != nil {
+		p.l.Warn().Err(err).Str("_artifact", artifact.ID()).Msg("Failed to apply rule set changes")
+
+		return nil
+	}
+
+	p.l.Info().Str("_artifact", artifact.ID()).Str("_digest", digest).Msg("Rule sets synchronized")
+
+	state.digest = digest
+
+	return nil
+}
+
+// handleFetchError logs the given error. Rule sets known from the past are kept as is, as an
+// unreachable registry does not mean these have been removed.
+func (p *provider) handleFetchError(err error, artifact *ruleSetArtifact) error {
+	if errors.Is(err, context.Canceled) {
+		p.l.Debug().Msg("Watcher closed")
+
+		return nil
+	}
+
+	p.l.Warn().Err(err).Str("_artifact", artifact.ID()).Msg("Failed to fetch rule sets")
+
+	return err
+}
+
+func (p *provider) getArtifactState(artifact *ruleSetArtifact) *artifactState {
+	value, _ := p.states.LoadOrStore(artifact, &artifactState{ruleSets: state.NewTracker(p.p, p.l)})
+
+	return value.(*artifactState) // nolint: forcetypeassert
+}
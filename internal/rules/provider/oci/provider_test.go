@@ -0,0 +1,392 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	config2 "github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+	mock2 "github.com/dadrus/heimdall/internal/x/testsupport/mock"
+)
+
+func TestNewProvider(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   []byte
+		assert func(t *testing.T, err error, prov *provider)
+	}{
+		{
+			uc:   "with unknown field",
+			conf: []byte(`foo: bar`),
+			assert: func(t *testing.T, err error, _ *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc:   "without artifacts",
+			conf: []byte(`watch_interval: 5s`),
+			assert: func(t *testing.T, err error, _ *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no artifacts configured")
+			},
+		},
+		{
+			uc: "without ref in one of the configured artifacts",
+			conf: []byte(`
+artifacts:
+  - ref: ghcr.io/acme/rules:v1
+  - plain_http: true
+`),
+			assert: func(t *testing.T, err error, _ *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "#1 artifact")
+			},
+		},
+		{
+			uc: "with invalid ref in one of the configured artifacts",
+			conf: []byte(`
+artifacts:
+  - ref: ghcr.io/acme/rules@md5:foo
+`),
+			assert: func(t *testing.T, err error, _ *provider) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.ErrorIs(t, err, ErrInvalidReference)
+				assert.Contains(t, err.Error(), "#0 artifact")
+			},
+		},
+		{
+			uc: "with watch interval and two artifacts configured",
+			conf: []byte(`
+watch_interval: 5s
+artifacts:
+  - ref: ghcr.io/acme/rules:v1
+  - ref: registry.example.com:5000/acme/other-rules
+    plain_http: true
+    rule_path_match_prefix: /foo
+    credentials:
+      username: foo
+      password: bar
+`),
+			assert: func(t *testing.T, err error, prov *provider) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.NotNil(t, prov)
+				assert.NotNil(t, prov.s)
+				assert.NotNil(t, prov.p)
+				assert.NotNil(t, prov.cancel)
+				assert.False(t, prov.s.IsRunning())
+				assert.Len(t, prov.s.Jobs(), 2)
+				require.Len(t, prov.artifacts, 2)
+				assert.Equal(t, "ghcr.io/acme/rules:v1", prov.artifacts[0].ID())
+				assert.Equal(t, "registry.example.com:5000/acme/other-rules:latest", prov.artifacts[1].ID())
+				assert.True(t, prov.artifacts[1].PlainHTTP)
+				assert.Equal(t, "/foo", prov.artifacts[1].RulesPathPrefix)
+				require.NotNil(t, prov.artifacts[1].Credentials)
+				assert.Equal(t, "foo", prov.artifacts[1].Credentials.Username)
+				assert.Equal(t, "bar", prov.artifacts[1].Credentials.Password)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			providerConf, err := testsupport.DecodeTestConfig(tc.conf)
+			require.NoError(t, err)
+
+			conf := &config.Configuration{
+				Rules: config.Rules{
+					Providers: config.RuleProviders{OCI: providerConf},
+				},
+			}
+
+			// WHEN
+			prov, err := newProvider(conf, mocks.NewRuleSetProcessorMock(t), log.Logger)
+
+			// THEN
+			tc.assert(t, err, prov)
+		})
+	}
+}
+
+func TestProviderLifecycle(t *testing.T) {
+	t.Parallel()
+
+	rulesV1 := []byte("version: \"1\"\nrules:\n- id: foo\n")
+	rulesV2 := []byte("version: \"1\"\nrules:\n- id: baz\n")
+	otherRules := []byte("version: \"1\"\nrules:\n- id: bar\n")
+
+	type testCase struct {
+		uc             string
+		watchInterval  string
+		setupRegistry  func(t *testing.T, reg *testRegistry)
+		setupProcessor func(t *testing.T, processor *mocks.RuleSetProcessorMock)
+		assert         func(t *testing.T, reg *testRegistry, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock)
+	}
+
+	for _, tc := range []testCase{
+		{
+			uc: "with not existing artifact",
+			setupRegistry: func(t *testing.T, _ *testRegistry) {
+				t.Helper()
+			},
+			assert: func(t *testing.T, _ *testRegistry, logs fmt.Stringer, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				messages := logs.String()
+				assert.Contains(t, messages, "Failed to fetch rule sets")
+				assert.NotContains(t, messages, "Rule sets synchronized")
+			},
+		},
+		{
+			uc: "with artifact without rule sets",
+			setupRegistry: func(t *testing.T, reg *testRegistry) {
+				t.Helper()
+
+				reg.push(t, "v1", nil, testLayer{title: "README.md", mediaType: "text/markdown", contents: []byte("# Rules")})
+			},
+			assert: func(t *testing.T, _ *testRegistry, logs fmt.Stringer, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				messages := logs.String()
+				assert.NotContains(t, messages, "error")
+				assert.Contains(t, messages, "Rule sets synchronized")
+			},
+		},
+		{
+			uc: "with rule set and without watch interval",
+			setupRegistry: func(t *testing.T, reg *testRegistry) {
+				t.Helper()
+
+				reg.push(t, "v1", nil, testLayer{title: "foo.yaml", mediaType: "application/yaml", contents: rulesV1})
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "captor1").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, reg *testRegistry, _ fmt.Stringer, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				ruleSet := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "captor1").Value()
+				assert.Equal(t, "oci:foo.yaml@"+reg.ref("v1"), ruleSet.Source)
+				assert.Equal(t, "1", ruleSet.Version)
+				require.Len(t, ruleSet.Rules, 1)
+				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
+			},
+		},
+		{
+			uc:            "rule set changes with watch interval",
+			watchInterval: "250ms",
+			setupRegistry: func(t *testing.T, reg *testRegistry) {
+				t.Helper()
+
+				reg.push(t, "v1", nil,
+					testLayer{title: "foo.yaml", mediaType: "application/yaml", contents: rulesV1},
+					testLayer{title: "bar.yaml", mediaType: "application/yaml", contents: otherRules},
+				)
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "created").Capture).
+					Return(nil).Twice()
+				processor.EXPECT().OnUpdated(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "updated").Capture).
+					Return(nil).Once()
+				processor.EXPECT().OnDeleted(mock.Anything).
+					Run(mock2.NewArgumentCaptor[*config2.RuleSet](&processor.Mock, "deleted").Capture).
+					Return(nil).Once()
+			},
+			assert: func(t *testing.T, reg *testRegistry, logs fmt.Stringer, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				// the tag is moved to an artifact with a modified rule set
+				reg.push(t, "v1", nil,
+					testLayer{title: "foo.yaml", mediaType: "application/yaml", contents: rulesV2},
+					testLayer{title: "bar.yaml", mediaType: "application/yaml", contents: otherRules},
+				)
+
+				time.Sleep(500 * time.Millisecond)
+
+				// the tag is moved to an artifact without the other rule set
+				reg.push(t, "v1", nil,
+					testLayer{title: "foo.yaml", mediaType: "application/yaml", contents: rulesV2},
+				)
+
+				time.Sleep(500 * time.Millisecond)
+
+				assert.Contains(t, logs.String(), "No updates received")
+
+				source := func(name string) string { return "oci:" + name + "@" + reg.ref("v1") }
+
+				created := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "created").Values()
+				require.Len(t, created, 2)
+				assert.Equal(t, source("bar.yaml"), created[0].Source)
+				assert.Equal(t, source("foo.yaml"), created[1].Source)
+
+				updated := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "updated").Values()
+				require.Len(t, updated, 1)
+				assert.Equal(t, source("foo.yaml"), updated[0].Source)
+				assert.Equal(t, "baz", updated[0].Rules[0].ID)
+
+				deleted := mock2.ArgumentCaptorFrom[*config2.RuleSet](&processor.Mock, "deleted").Values()
+				require.Len(t, deleted, 1)
+				assert.Equal(t, source("bar.yaml"), deleted[0].Source)
+			},
+		},
+		{
+			uc:            "rule sets are kept if the artifact becomes unavailable",
+			watchInterval: "250ms",
+			setupRegistry: func(t *testing.T, reg *testRegistry) {
+				t.Helper()
+
+				reg.push(t, "v1", nil, testLayer{title: "foo.yaml", mediaType: "application/yaml", contents: rulesV1})
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, reg *testRegistry, logs fmt.Stringer, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				reg.mutex.Lock()
+				delete(reg.manifests, "v1")
+				reg.mutex.Unlock()
+
+				time.Sleep(500 * time.Millisecond)
+
+				messages := logs.String()
+				assert.Contains(t, messages, "Rule sets synchronized")
+				assert.Contains(t, messages, "Failed to fetch rule sets")
+			},
+		},
+		{
+			uc:            "failing rule set is retried",
+			watchInterval: "250ms",
+			setupRegistry: func(t *testing.T, reg *testRegistry) {
+				t.Helper()
+
+				reg.push(t, "v1", nil, testLayer{title: "foo.yaml", mediaType: "application/yaml", contents: rulesV1})
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(testsupport.ErrTestPurpose).Once()
+				processor.EXPECT().OnCreated(mock.Anything).Return(nil).Once()
+			},
+			assert: func(t *testing.T, _ *testRegistry, logs fmt.Stringer, _ *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				time.Sleep(1500 * time.Millisecond)
+
+				messages := logs.String()
+				assert.Contains(t, messages, "Failed to apply rule set changes")
+				assert.Contains(t, messages, "Rule sets synchronized")
+				assert.Contains(t, messages, "No updates received")
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			reg := newTestRegistry(t)
+			tc.setupRegistry(t, reg)
+
+			setupProcessor := x.IfThenElse(
+				tc.setupProcessor != nil,
+				tc.setupProcessor,
+				func(t *testing.T, _ *mocks.RuleSetProcessorMock) { t.Helper() },
+			)
+
+			conf := fmt.Sprintf("artifacts:\n- ref: %s\n  plain_http: true\n", reg.ref("v1"))
+			if len(tc.watchInterval) != 0 {
+				conf += "watch_interval: " + tc.watchInterval + "\n"
+			}
+
+			providerConf, err := testsupport.DecodeTestConfig([]byte(conf))
+			require.NoError(t, err)
+
+			processor := mocks.NewRuleSetProcessorMock(t)
+			setupProcessor(t, processor)
+
+			logs := &strings.Builder{}
+			prov, err := newProvider(&config.Configuration{
+				Rules: config.Rules{Providers: config.RuleProviders{OCI: providerConf}},
+			}, processor, zerolog.New(logs))
+			require.NoError(t, err)
+
+			ctx := context.Background()
+
+			// WHEN
+			err = prov.Start(ctx)
+
+			// THEN
+			require.NoError(t, err)
+			tc.assert(t, reg, logs, processor)
+
+			require.NoError(t, prov.Stop(ctx))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	defaultTag             = "latest"
+	dockerHubRegistry      = "docker.io"
+	dockerHubRegistryHost  = "registry-1.docker.io"
+	dockerHubOfficialImage = "library/"
+)
+
+var (
+	ErrInvalidReference = errors.New("invalid artifact reference")
+
+	// patterns as defined by the OCI distribution specification
+	repositoryPattern = regexp.MustCompile(
+		`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagPattern    = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+	digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// reference is a parsed artifact reference in the form registry/repository[:tag][@digest].
+type reference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+func parseReference(value string) (*reference, error) {
+	var ref reference
+
+	remainder, digest, hasDigest := strings.Cut(value, "@")
+	if hasDigest {
+		if !digestPattern.MatchString(digest) {
+			return nil, errorchain.NewWithMessagef(ErrInvalidReference, "unsupported digest '%s'", digest)
+		}
+
+		ref.digest = digest
+	}
+
+	// a colon after the last slash separates the tag. Otherwise, it is part of the registry host
+	if idx := strings.LastIndex(remainder, ":"); idx > strings.LastIndex(remainder, "/") {
+		ref.tag = remainder[idx+1:]
+		remainder = remainder[:idx]
+
+		if !tagPattern.MatchString(ref.tag) {
+			return nil, errorchain.NewWithMessagef(ErrInvalidReference, "invalid tag '%s'", ref.tag)
+		}
+	}
+
+	registry, repository, found := strings.Cut(remainder, "/")
+	if !found || !(strings.ContainsAny(registry, ".:") || registry == "localhost") {
+		// no registry specified, thus docker hub
+		registry, repository = dockerHubRegistry, remainder
+	}
+
+	if registry == dockerHubRegistry && !strings.Contains(repository, "/") {
+		repository = dockerHubOfficialImage + repository
+	}
+
+	if !repositoryPattern.MatchString(repository) {
+		return nil, errorchain.NewWithMessagef(ErrInvalidReference, "invalid repository '%s'", repository)
+	}
+
+	if len(ref.tag) == 0 && len(ref.digest) == 0 {
+		ref.tag = defaultTag
+	}
+
+	ref.registry = registry
+	ref.repository = repository
+
+	return &ref, nil
+}
+
+// host returns the host of the registry the api requests should be sent to.
+func (r *reference) host() string {
+	if r.registry == dockerHubRegistry {
+		return dockerHubRegistryHost
+	}
+
+	return r.registry
+}
+
+// manifestReference returns the digest, if present and the tag otherwise.
+func (r *reference) manifestReference() string {
+	if len(r.digest) != 0 {
+		return r.digest
+	}
+
+	return r.tag
+}
+
+func (r *reference) String() string {
+	var builder strings.Builder
+
+	builder.WriteString(r.registry)
+	builder.WriteString("/")
+	builder.WriteString(r.repository)
+
+	if len(r.tag) != 0 {
+		builder.WriteString(":")
+		builder.WriteString(r.tag)
+	}
+
+	if len(r.digest) != 0 {
+		builder.WriteString("@")
+		builder.WriteString(r.digest)
+	}
+
+	return builder.String()
+}
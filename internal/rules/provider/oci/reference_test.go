@@ -0,0 +1,155 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	for _, tc := range []struct {
+		uc     string
+		ref    string
+		assert func(t *testing.T, err error, ref *reference)
+	}{
+		{
+			uc:  "registry, repository and tag",
+			ref: "ghcr.io/my-org/heimdall-rules:v1.0.0",
+			assert: func(t *testing.T, err error, ref *reference) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "ghcr.io", ref.registry)
+				assert.Equal(t, "ghcr.io", ref.host())
+				assert.Equal(t, "my-org/heimdall-rules", ref.repository)
+				assert.Equal(t, "v1.0.0", ref.tag)
+				assert.Empty(t, ref.digest)
+				assert.Equal(t, "v1.0.0", ref.manifestReference())
+				assert.Equal(t, "ghcr.io/my-org/heimdall-rules:v1.0.0", ref.String())
+			},
+		},
+		{
+			uc:  "registry with port and without tag",
+			ref: "localhost:5000/rules",
+			assert: func(t *testing.T, err error, ref *reference) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "localhost:5000", ref.registry)
+				assert.Equal(t, "rules", ref.repository)
+				assert.Equal(t, "latest", ref.tag)
+				assert.Equal(t, "localhost:5000/rules:latest", ref.String())
+			},
+		},
+		{
+			uc:  "digest",
+			ref: "registry.example.com/rules@" + digest,
+			assert: func(t *testing.T, err error, ref *reference) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "registry.example.com", ref.registry)
+				assert.Equal(t, "rules", ref.repository)
+				assert.Empty(t, ref.tag)
+				assert.Equal(t, digest, ref.digest)
+				assert.Equal(t, digest, ref.manifestReference())
+				assert.Equal(t, "registry.example.com/rules@"+digest, ref.String())
+			},
+		},
+		{
+			uc:  "tag and digest",
+			ref: "registry.example.com/rules:v1@" + digest,
+			assert: func(t *testing.T, err error, ref *reference) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "v1", ref.tag)
+				assert.Equal(t, digest, ref.manifestReference())
+			},
+		},
+		{
+			uc:  "docker hub official image",
+			ref: "rules:v1",
+			assert: func(t *testing.T, err error, ref *reference) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "docker.io", ref.registry)
+				assert.Equal(t, "registry-1.docker.io", ref.host())
+				assert.Equal(t, "library/rules", ref.repository)
+			},
+		},
+		{
+			uc:  "docker hub image",
+			ref: "my-org/rules",
+			assert: func(t *testing.T, err error, ref *reference) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "docker.io", ref.registry)
+				assert.Equal(t, "my-org/rules", ref.repository)
+				assert.Equal(t, "latest", ref.tag)
+			},
+		},
+		{
+			uc:  "unsupported digest",
+			ref: "registry.example.com/rules@sha512:abc",
+			assert: func(t *testing.T, err error, _ *reference) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidReference)
+				assert.Contains(t, err.Error(), "unsupported digest")
+			},
+		},
+		{
+			uc:  "invalid tag",
+			ref: "registry.example.com/rules:-v1",
+			assert: func(t *testing.T, err error, _ *reference) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidReference)
+				assert.Contains(t, err.Error(), "invalid tag")
+			},
+		},
+		{
+			uc:  "invalid repository",
+			ref: "registry.example.com/Rules",
+			assert: func(t *testing.T, err error, _ *reference) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidReference)
+				assert.Contains(t, err.Error(), "invalid repository")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			ref, err := parseReference(tc.ref)
+
+			// THEN
+			tc.assert(t, err, ref)
+		})
+	}
+}
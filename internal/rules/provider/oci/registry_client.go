@@ -0,0 +1,253 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+var ErrUnexpectedResponse = errors.New("unexpected response")
+
+type credentials struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// registryClient talks to the registry api as defined by the OCI distribution specification.
+// It authenticates, if requested by the registry, either using the configured credentials
+// directly (basic auth), or by exchanging them for a bearer token at the token endpoint
+// referenced by the registry.
+type registryClient struct {
+	baseURL    string
+	repository string
+	creds      *credentials
+	client     *http.Client
+
+	useBasicAuth bool
+	token        string
+}
+
+func newRegistryClient(ref *reference, plainHTTP bool, creds *credentials) *registryClient {
+	host := ref.host()
+
+	return &registryClient{
+		baseURL:    fmt.Sprintf("%s://%s", x.IfThenElse(plainHTTP, "http", "https"), host),
+		repository: ref.repository,
+		creds:      creds,
+		client: &http.Client{
+			Transport: otelhttp.NewTransport(
+				http.DefaultTransport,
+				otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+					return fmt.Sprintf("%s %s %s @%s", r.Proto, r.Method, r.URL.Path, host)
+				})),
+		},
+	}
+}
+
+// Do sends a request for the given path relative to the repository api endpoint and
+// returns the response, if its status code is 200 OK. The caller must close the body.
+func (c *registryClient) Do(ctx context.Context, method, path string, accept ...string) (*http.Response, error) {
+	resp, err := c.send(ctx, method, path, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err = c.authorize(ctx, challenge); err != nil {
+			return nil, err
+		}
+
+		if resp, err = c.send(ctx, method, path, accept); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+
+		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"unexpected response code %d for %s", resp.StatusCode, path).
+			CausedBy(ErrUnexpectedResponse)
+	}
+
+	return resp, nil
+}
+
+func (c *registryClient) send(ctx context.Context, method, path string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method,
+		fmt.Sprintf("%s/v2/%s/%s", c.baseURL, c.repository, path), nil)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed creating request").
+			CausedBy(err)
+	}
+
+	if len(accept) != 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+
+	switch {
+	case len(c.token) != 0:
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.useBasicAuth:
+		req.SetBasicAuth(c.creds.Username, c.creds.Password)
+	}
+
+	return c.exchange(req, "request to registry failed")
+}
+
+func (c *registryClient) authorize(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.creds == nil || c.useBasicAuth {
+			return errorchain.NewWithMessage(heimdall.ErrCommunication,
+				"registry requires authentication, but no valid credentials are configured")
+		}
+
+		c.useBasicAuth = true
+
+		return nil
+	case "bearer":
+		return c.fetchToken(ctx, params)
+	default:
+		return errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"registry requires authentication using unsupported '%s' scheme", scheme)
+	}
+}
+
+func (c *registryClient) fetchToken(ctx context.Context, params map[string]string) error {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || len(realm.Host) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrCommunication,
+			"registry requires bearer token authentication, but references an invalid token endpoint")
+	}
+
+	scope := params["scope"]
+	if len(scope) == 0 {
+		scope = fmt.Sprintf("repository:%s:pull", c.repository)
+	}
+
+	query := realm.Query()
+	query.Set("scope", scope)
+
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed creating token request").
+			CausedBy(err)
+	}
+
+	if c.creds != nil {
+		req.SetBasicAuth(c.creds.Username, c.creds.Password)
+	}
+
+	resp, err := c.exchange(req, "token request failed")
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"unexpected response code %d from token endpoint", resp.StatusCode).
+			CausedBy(ErrUnexpectedResponse)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrCommunication, "failed to decode token response").
+			CausedBy(err)
+	}
+
+	c.token = tokenResponse.Token
+	if len(c.token) == 0 {
+		c.token = tokenResponse.AccessToken
+	}
+
+	if len(c.token) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrCommunication, "token endpoint did not issue a token")
+	}
+
+	return nil
+}
+
+func (c *registryClient) exchange(req *http.Request, message string) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var clientErr *url.Error
+		if errors.As(err, &clientErr) && clientErr.Timeout() {
+			return nil, errorchain.NewWithMessage(heimdall.ErrCommunicationTimeout, message).CausedBy(err)
+		}
+
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication, message).CausedBy(err)
+	}
+
+	return resp, nil
+}
+
+// parseChallenge parses the value of a WWW-Authenticate header as defined in RFC 7235 into the
+// authentication scheme and its parameters. Only a single challenge is supported.
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+
+	for len(rest) != 0 {
+		var key, value string
+
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, ", "), "=")
+		if len(key) == 0 {
+			break
+		}
+
+		if strings.HasPrefix(rest, `"`) {
+			// quoted values may contain commas
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+
+	return scheme, params
+}
@@ -0,0 +1,423 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+const (
+	testRepository = "rules/heimdall"
+	testToken      = "t0k3n"
+)
+
+type testLayer struct {
+	title     string
+	mediaType string
+	contents  []byte
+}
+
+// testRegistry is a minimal in-process implementation of the pull part of the
+// OCI distribution api serving a single repository.
+type testRegistry struct {
+	*httptest.Server
+
+	// auth is either empty (anonymous access), "basic" or "bearer"
+	auth     string
+	username string
+	password string
+
+	mutex             sync.Mutex
+	manifests         map[string][]byte
+	blobs             map[string][]byte
+	manifestGets      int
+	tokenRequests     int
+	omitDigestHeader  bool
+	manifestMediaType string
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	t.Helper()
+
+	reg := &testRegistry{
+		manifests:         make(map[string][]byte),
+		blobs:             make(map[string][]byte),
+		manifestMediaType: mediaTypeOCIManifest,
+	}
+
+	reg.Server = httptest.NewServer(http.HandlerFunc(reg.serveHTTP))
+	t.Cleanup(reg.Close)
+
+	return reg
+}
+
+func (r *testRegistry) host() string {
+	srvURL, _ := url.Parse(r.URL)
+
+	return srvURL.Host
+}
+
+func (r *testRegistry) ref(tagOrDigest string) string {
+	separator := x.IfThenElse(strings.HasPrefix(tagOrDigest, "sha256:"), "@", ":")
+
+	return r.host() + "/" + testRepository + separator + tagOrDigest
+}
+
+// push stores the given layers as artifact and tags the manifest with the given tag.
+// It returns the digest of the manifest.
+func (r *testRegistry) push(t *testing.T, tag string, annotations map[string]string, layers ...testLayer) string {
+	t.Helper()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	mf := map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     r.manifestMediaType,
+		"artifactType":  "application/vnd.heimdall.rules",
+		"config": map[string]any{
+			"mediaType": "application/vnd.oci.empty.v1+json",
+			"digest":    digestOf([]byte("{}")),
+			"size":      2,
+		},
+		"annotations": annotations,
+	}
+
+	descriptors := make([]map[string]any, len(layers))
+
+	for idx, layer := range layers {
+		digest := digestOf(layer.contents)
+		r.blobs[digest] = layer.contents
+
+		descriptors[idx] = map[string]any{
+			"mediaType": layer.mediaType,
+			"digest":    digest,
+			"size":      len(layer.contents),
+		}
+
+		if len(layer.title) != 0 {
+			descriptors[idx]["annotations"] = map[string]string{annotationTitle: layer.title}
+		}
+	}
+
+	mf["layers"] = descriptors
+
+	raw, err := json.Marshal(mf)
+	require.NoError(t, err)
+
+	digest := digestOf(raw)
+	r.manifests[digest] = raw
+	r.manifests[tag] = raw
+
+	return digest
+}
+
+func (r *testRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if req.URL.Path == "/token" {
+		r.tokenRequests++
+
+		if user, pass, _ := req.BasicAuth(); user != r.username || pass != r.password {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if req.URL.Query().Get("scope") != "repository:"+testRepository+":pull" {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token": "` + testToken + `"}`))
+
+		return
+	}
+
+	if !r.authorized(w, req) {
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/"+testRepository+"/")
+
+	switch {
+	case strings.HasPrefix(path, "manifests/"):
+		raw, ok := r.manifests[strings.TrimPrefix(path, "manifests/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if !r.omitDigestHeader {
+			w.Header().Set("Docker-Content-Digest", digestOf(raw))
+		}
+
+		w.Header().Set("Content-Type", r.manifestMediaType)
+
+		if req.Method == http.MethodGet {
+			r.manifestGets++
+
+			_, _ = w.Write(raw)
+		}
+	case strings.HasPrefix(path, "blobs/"):
+		contents, ok := r.blobs[strings.TrimPrefix(path, "blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write(contents)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *testRegistry) authorized(w http.ResponseWriter, req *http.Request) bool {
+	switch r.auth {
+	case "basic":
+		if user, pass, ok := req.BasicAuth(); ok && user == r.username && pass == r.password {
+			return true
+		}
+
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+	case "bearer":
+		if req.Header.Get("Authorization") == "Bearer "+testToken {
+			return true
+		}
+
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+			`Bearer realm="%s/token",service="test-registry",scope="repository:%s:pull"`, r.URL, testRepository))
+	default:
+		return true
+	}
+
+	w.WriteHeader(http.StatusUnauthorized)
+
+	return false
+}
+
+func TestParseChallenge(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc        string
+		challenge string
+		scheme    string
+		params    map[string]string
+	}{
+		{uc: "empty challenge", params: map[string]string{}},
+		{uc: "scheme only", challenge: "Basic", scheme: "Basic", params: map[string]string{}},
+		{
+			uc:        "basic challenge",
+			challenge: `Basic realm="Registry Realm"`,
+			scheme:    "Basic",
+			params:    map[string]string{"realm": "Registry Realm"},
+		},
+		{
+			uc: "bearer challenge with quoted values containing commas",
+			challenge: `Bearer realm="https://auth.example.com/token",service="registry.example.com",` +
+				`scope="repository:foo/bar:pull,push"`,
+			scheme: "Bearer",
+			params: map[string]string{
+				"realm":   "https://auth.example.com/token",
+				"service": "registry.example.com",
+				"scope":   "repository:foo/bar:pull,push",
+			},
+		},
+		{
+			uc:        "challenge with unquoted values",
+			challenge: `Bearer Realm=https://auth.example.com/token, service=registry`,
+			scheme:    "Bearer",
+			params:    map[string]string{"realm": "https://auth.example.com/token", "service": "registry"},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			scheme, params := parseChallenge(tc.challenge)
+
+			// THEN
+			assert.Equal(t, tc.scheme, scheme)
+			assert.Equal(t, tc.params, params)
+		})
+	}
+}
+
+func TestRegistryClientDo(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		auth   string
+		creds  *credentials
+		path   string
+		assert func(t *testing.T, err error, resp *http.Response, reg *testRegistry)
+	}{
+		{
+			uc:   "anonymous access",
+			path: "manifests/v1",
+			assert: func(t *testing.T, err error, resp *http.Response, reg *testRegistry) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, 0, reg.tokenRequests)
+			},
+		},
+		{
+			uc:   "not existing manifest",
+			path: "manifests/v2",
+			assert: func(t *testing.T, err error, _ *http.Response, _ *testRegistry) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				require.ErrorIs(t, err, ErrUnexpectedResponse)
+				assert.Contains(t, err.Error(), "404")
+			},
+		},
+		{
+			uc:    "basic auth",
+			auth:  "basic",
+			creds: &credentials{Username: "foo", Password: "bar"},
+			path:  "manifests/v1",
+			assert: func(t *testing.T, err error, resp *http.Response, _ *testRegistry) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			},
+		},
+		{
+			uc:   "basic auth without credentials",
+			auth: "basic",
+			path: "manifests/v1",
+			assert: func(t *testing.T, err error, _ *http.Response, _ *testRegistry) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "no valid credentials")
+			},
+		},
+		{
+			uc:    "basic auth with wrong credentials",
+			auth:  "basic",
+			creds: &credentials{Username: "foo", Password: "baz"},
+			path:  "manifests/v1",
+			assert: func(t *testing.T, err error, _ *http.Response, _ *testRegistry) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "401")
+			},
+		},
+		{
+			uc:    "bearer token auth",
+			auth:  "bearer",
+			creds: &credentials{Username: "foo", Password: "bar"},
+			path:  "manifests/v1",
+			assert: func(t *testing.T, err error, resp *http.Response, reg *testRegistry) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, 1, reg.tokenRequests)
+			},
+		},
+		{
+			uc:    "bearer token auth with wrong credentials",
+			auth:  "bearer",
+			creds: &credentials{Username: "foo", Password: "baz"},
+			path:  "manifests/v1",
+			assert: func(t *testing.T, err error, _ *http.Response, _ *testRegistry) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "token endpoint")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			reg := newTestRegistry(t)
+			reg.auth = tc.auth
+			reg.username = "foo"
+			reg.password = "bar"
+			reg.push(t, "v1", nil, testLayer{title: "rules.yaml", mediaType: "application/yaml", contents: []byte("foo")})
+
+			ref, err := parseReference(reg.ref("v1"))
+			require.NoError(t, err)
+
+			client := newRegistryClient(ref, true, tc.creds)
+
+			// WHEN
+			resp, err := client.Do(context.Background(), http.MethodGet, tc.path, mediaTypeOCIManifest)
+			if err == nil {
+				defer resp.Body.Close()
+			}
+
+			// THEN
+			tc.assert(t, err, resp, reg)
+		})
+	}
+}
+
+func TestRegistryClientReusesToken(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	reg := newTestRegistry(t)
+	reg.auth = "bearer"
+	reg.push(t, "v1", nil)
+
+	ref, err := parseReference(reg.ref("v1"))
+	require.NoError(t, err)
+
+	client := newRegistryClient(ref, true, nil)
+
+	for i := 0; i < 3; i++ {
+		// WHEN
+		resp, err := client.Do(context.Background(), http.MethodHead, "manifests/v1")
+
+		// THEN
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, 1, reg.tokenRequests)
+}
@@ -0,0 +1,287 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+	annotationTitle   = "org.opencontainers.image.title"
+	annotationCreated = "org.opencontainers.image.created"
+
+	// maxManifestSize limits the size of manifests read from the registry
+	maxManifestSize = 4 * 1024 * 1024
+	// maxRuleSetSize limits the size of the rule sets read from the registry
+	maxRuleSetSize = 16 * 1024 * 1024
+)
+
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type manifest struct {
+	MediaType   string            `json:"mediaType"`
+	Layers      []descriptor      `json:"layers"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type ruleSetArtifact struct {
+	Ref             string       `mapstructure:"ref"`
+	RulesPathPrefix string       `mapstructure:"rule_path_match_prefix"`
+	PlainHTTP       bool         `mapstructure:"plain_http"`
+	Credentials     *credentials `mapstructure:"credentials"`
+
+	ref    *reference
+	client *registryClient
+}
+
+func (a *ruleSetArtifact) init() error {
+	if len(a.Ref) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration, "missing ref")
+	}
+
+	ref, err := parseReference(a.Ref)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to parse ref").CausedBy(err)
+	}
+
+	a.ref = ref
+	a.client = newRegistryClient(ref, a.PlainHTTP, a.Credentials)
+
+	return nil
+}
+
+func (a *ruleSetArtifact) ID() string { return a.ref.String() }
+
+// Resolve returns the digest of the manifest the configured reference points to. If the reference
+// contains a digest, that digest is returned without asking the registry.
+func (a *ruleSetArtifact) Resolve(ctx context.Context) (string, error) {
+	if len(a.ref.digest) != 0 {
+		return a.ref.digest, nil
+	}
+
+	resp, err := a.client.Do(ctx, http.MethodHead, "manifests/"+a.ref.tag,
+		mediaTypeOCIManifest, mediaTypeDockerManifest)
+	if err != nil {
+		return "", a.mapError(ctx, err)
+	}
+
+	resp.Body.Close()
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if len(digest) == 0 {
+		// the header is optional, so the manifest itself is required to calculate the digest
+		_, digest, err = a.readManifest(ctx, a.ref.tag)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if !digestPattern.MatchString(digest) {
+		return "", errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"registry responded with unsupported digest '%s'", digest)
+	}
+
+	return digest, nil
+}
+
+// ReadRuleSets reads all rule sets contained in the artifact with the manifest identified by the given
+// digest. Each layer having a yaml, json, tar+gzip or zip media type, or a respective file name in its
+// title annotation is considered to be a rule set or a bundle of rule sets. The returned rule sets are
+// indexed by the name of the layer, which is its title, or its digest, if the layer has no title, followed
+// by the ID of the rule set within the bundle, if any. Empty rule sets are ignored.
+func (a *ruleSetArtifact) ReadRuleSets(ctx context.Context, digest string) (map[string]*config.RuleSet, error) {
+	mf, _, err := a.readManifest(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	modTime := time.Now()
+	if created, err := time.Parse(time.RFC3339, mf.Annotations[annotationCreated]); err == nil {
+		modTime = created
+	}
+
+	ruleSets := make(map[string]*config.RuleSet)
+
+	for _, layer := range mf.Layers {
+		name := layer.Annotations[annotationTitle]
+		if len(name) == 0 {
+			name = layer.Digest
+		}
+
+		contentType := contentTypeOf(layer.MediaType, name)
+		if len(contentType) == 0 {
+			continue
+		}
+
+		layerRuleSets, err := a.readRuleSets(ctx, layer, name, contentType)
+		if err != nil {
+			if errors.Is(err, config.ErrEmptyRuleSet) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		src := fmt.Sprintf("oci:%s@%s", name, a.ID())
+
+		for _, ruleSet := range layerRuleSets {
+			ruleSet.ModTime = modTime
+			ruleSets[name+strings.TrimPrefix(ruleSet.Source, src)] = ruleSet
+		}
+	}
+
+	return ruleSets, nil
+}
+
+func (a *ruleSetArtifact) readManifest(ctx context.Context, reference string) (*manifest, string, error) {
+	resp, err := a.client.Do(ctx, http.MethodGet, "manifests/"+reference,
+		mediaTypeOCIManifest, mediaTypeDockerManifest)
+	if err != nil {
+		return nil, "", a.mapError(ctx, err)
+	}
+
+	defer resp.Body.Close()
+
+	contents, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, "", a.mapError(ctx,
+			errorchain.NewWithMessage(heimdall.ErrCommunication, "failed reading manifest").CausedBy(err))
+	}
+
+	digest := digestOf(contents)
+	if strings.HasPrefix(reference, "sha256:") && reference != digest {
+		return nil, "", errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"manifest %s has a different digest %s", reference, digest).CausedBy(ErrDigestMismatch)
+	}
+
+	var mf manifest
+	if err = json.Unmarshal(contents, &mf); err != nil {
+		return nil, "", errorchain.NewWithMessage(heimdall.ErrInternal, "failed to decode manifest").
+			CausedBy(err)
+	}
+
+	mediaType := mf.MediaType
+	if len(mediaType) == 0 {
+		mediaType = strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	}
+
+	if mediaType != mediaTypeOCIManifest && mediaType != mediaTypeDockerManifest {
+		return nil, "", errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"unsupported manifest media type '%s'", mediaType)
+	}
+
+	return &mf, digest, nil
+}
+
+func (a *ruleSetArtifact) readRuleSets(
+	ctx context.Context, layer descriptor, name, contentType string,
+) ([]*config.RuleSet, error) {
+	if layer.Size > maxRuleSetSize {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"rule set %s exceeds the maximum supported size", name)
+	}
+
+	resp, err := a.client.Do(ctx, http.MethodGet, "blobs/"+layer.Digest)
+	if err != nil {
+		return nil, a.mapError(ctx, err)
+	}
+
+	defer resp.Body.Close()
+
+	contents, err := io.ReadAll(io.LimitReader(resp.Body, maxRuleSetSize))
+	if err != nil {
+		return nil, a.mapError(ctx,
+			errorchain.NewWithMessagef(heimdall.ErrCommunication, "failed reading %s", name).CausedBy(err))
+	}
+
+	if digestOf(contents) != layer.Digest {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"contents of %s do not match the digest %s", name, layer.Digest).CausedBy(ErrDigestMismatch)
+	}
+
+	ruleSets, err := config.ParseRuleSets(contentType, fmt.Sprintf("oci:%s@%s", name, a.ID()), contents)
+	if err != nil {
+		if errors.Is(err, config.ErrEmptyRuleSet) {
+			return nil, err
+		}
+
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal, "failed to parse rule set from %s", name).
+			CausedBy(err)
+	}
+
+	for _, ruleSet := range ruleSets {
+		if err = ruleSet.VerifyPathPrefix(a.RulesPathPrefix); err != nil {
+			return nil, err
+		}
+	}
+
+	return ruleSets, nil
+}
+
+func (a *ruleSetArtifact) mapError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
+
+func digestOf(contents []byte) string {
+	md := sha256.Sum256(contents)
+
+	return "sha256:" + hex.EncodeToString(md[:])
+}
+
+func contentTypeOf(mediaType, name string) string {
+	mediaType = strings.ToLower(mediaType)
+
+	switch {
+	case strings.HasSuffix(mediaType, "yaml"):
+		return config.ContentTypeYAML
+	case strings.HasSuffix(mediaType, "json"):
+		return config.ContentTypeJSON
+	case strings.HasSuffix(mediaType, "gzip"):
+		return config.ContentTypeTarGz
+	case strings.HasSuffix(mediaType, "zip"):
+		return config.ContentTypeZip
+	}
+
+	return config.ContentTypeByExtension(name)
+}
@@ -0,0 +1,394 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func TestRuleSetArtifactInit(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		artifact ruleSetArtifact
+		assert   func(t *testing.T, err error, artifact *ruleSetArtifact)
+	}{
+		{
+			uc: "without ref",
+			assert: func(t *testing.T, err error, _ *ruleSetArtifact) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "missing ref")
+			},
+		},
+		{
+			uc:       "with invalid ref",
+			artifact: ruleSetArtifact{Ref: "registry.example.com/Foo"},
+			assert: func(t *testing.T, err error, _ *ruleSetArtifact) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				require.ErrorIs(t, err, ErrInvalidReference)
+			},
+		},
+		{
+			uc:       "with valid ref",
+			artifact: ruleSetArtifact{Ref: "registry.example.com/foo"},
+			assert: func(t *testing.T, err error, artifact *ruleSetArtifact) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "registry.example.com/foo:latest", artifact.ID())
+				require.NotNil(t, artifact.client)
+				assert.Equal(t, "https://registry.example.com", artifact.client.baseURL)
+			},
+		},
+		{
+			uc:       "with valid ref and plain http",
+			artifact: ruleSetArtifact{Ref: "registry.example.com/foo:bar", PlainHTTP: true},
+			assert: func(t *testing.T, err error, artifact *ruleSetArtifact) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "registry.example.com/foo:bar", artifact.ID())
+				assert.Equal(t, "http://registry.example.com", artifact.client.baseURL)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			err := tc.artifact.init()
+
+			// THEN
+			tc.assert(t, err, &tc.artifact)
+		})
+	}
+}
+
+func TestRuleSetArtifactResolve(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		setup  func(t *testing.T, reg *testRegistry) string
+		assert func(t *testing.T, err error, digest string, reg *testRegistry)
+	}{
+		{
+			uc: "not existing tag",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				return reg.ref("v1")
+			},
+			assert: func(t *testing.T, err error, _ string, _ *testRegistry) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "404")
+			},
+		},
+		{
+			uc: "tag resolved using the digest header",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				reg.push(t, "v1", nil)
+
+				return reg.ref("v1")
+			},
+			assert: func(t *testing.T, err error, digest string, reg *testRegistry) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, digestOf(reg.manifests["v1"]), digest)
+				assert.Equal(t, 0, reg.manifestGets)
+			},
+		},
+		{
+			uc: "tag resolved using the manifest",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				reg.omitDigestHeader = true
+				reg.push(t, "v1", nil)
+
+				return reg.ref("v1")
+			},
+			assert: func(t *testing.T, err error, digest string, reg *testRegistry) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, digestOf(reg.manifests["v1"]), digest)
+				assert.Equal(t, 1, reg.manifestGets)
+			},
+		},
+		{
+			uc: "digest reference",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				return reg.ref(digestOf([]byte("foo")))
+			},
+			assert: func(t *testing.T, err error, digest string, _ *testRegistry) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, digestOf([]byte("foo")), digest)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			reg := newTestRegistry(t)
+			artifact := &ruleSetArtifact{Ref: tc.setup(t, reg), PlainHTTP: true}
+			require.NoError(t, artifact.init())
+
+			// WHEN
+			digest, err := artifact.Resolve(context.Background())
+
+			// THEN
+			tc.assert(t, err, digest, reg)
+		})
+	}
+}
+
+func TestRuleSetArtifactReadRuleSets(t *testing.T) {
+	t.Parallel()
+
+	yamlRuleSet := []byte(`
+version: "1"
+name: test
+rules:
+- id: foo
+  match: http://<**>/foo/bar
+`)
+
+	jsonRuleSet := []byte(`{
+  "version": "1",
+  "name": "test2",
+  "rules": [{ "id": "bar", "match": "http://<**>/bar/foo" }]
+}`)
+
+	for _, tc := range []struct {
+		uc         string
+		pathPrefix string
+		setup      func(t *testing.T, reg *testRegistry) string
+		assert     func(t *testing.T, err error, ruleSets map[string]*config.RuleSet)
+	}{
+		{
+			uc: "not existing manifest",
+			setup: func(t *testing.T, _ *testRegistry) string {
+				t.Helper()
+
+				return digestOf([]byte("foo"))
+			},
+			assert: func(t *testing.T, err error, _ map[string]*config.RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "404")
+			},
+		},
+		{
+			uc: "unsupported manifest media type",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				reg.manifestMediaType = "application/vnd.oci.image.index.v1+json"
+
+				return reg.push(t, "v1", nil)
+			},
+			assert: func(t *testing.T, err error, _ map[string]*config.RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "unsupported manifest media type")
+			},
+		},
+		{
+			uc: "manifest not matching the digest",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				digest := reg.push(t, "v1", nil)
+				reg.manifests[digest] = append(reg.manifests[digest], ' ')
+
+				return digest
+			},
+			assert: func(t *testing.T, err error, _ map[string]*config.RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrDigestMismatch)
+			},
+		},
+		{
+			uc: "layer not matching its digest",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				digest := reg.push(t, "v1", nil,
+					testLayer{title: "rules.yaml", mediaType: "application/yaml", contents: yamlRuleSet})
+				reg.blobs[digestOf(yamlRuleSet)] = jsonRuleSet
+
+				return digest
+			},
+			assert: func(t *testing.T, err error, _ map[string]*config.RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrDigestMismatch)
+				assert.Contains(t, err.Error(), "rules.yaml")
+			},
+		},
+		{
+			uc: "invalid rule set",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				return reg.push(t, "v1", nil,
+					testLayer{title: "rules.yaml", mediaType: "application/yaml", contents: []byte("foo: [")})
+			},
+			assert: func(t *testing.T, err error, _ map[string]*config.RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to parse rule set from rules.yaml")
+			},
+		},
+		{
+			uc:         "rule set violating the path prefix",
+			pathPrefix: "/baz",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				return reg.push(t, "v1", nil,
+					testLayer{title: "rules.yaml", mediaType: "application/yaml", contents: yamlRuleSet})
+			},
+			assert: func(t *testing.T, err error, _ map[string]*config.RuleSet) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "path prefix")
+			},
+		},
+		{
+			uc: "artifact with multiple layers",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				return reg.push(t, "v1", map[string]string{annotationCreated: "2023-07-01T10:00:00Z"},
+					testLayer{title: "rules.yaml", mediaType: "application/vnd.oci.image.layer.v1.tar", contents: yamlRuleSet},
+					testLayer{mediaType: "application/json", contents: jsonRuleSet},
+					testLayer{title: "empty.yaml", mediaType: "application/yaml", contents: []byte{}},
+					testLayer{title: "README.md", mediaType: "text/markdown", contents: []byte("# Rules")},
+				)
+			},
+			assert: func(t *testing.T, err error, ruleSets map[string]*config.RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, ruleSets, 2)
+
+				ruleSet := ruleSets["rules.yaml#test"]
+				require.NotNil(t, ruleSet)
+				assert.Equal(t, "test", ruleSet.Name)
+				assert.Len(t, ruleSet.Rules, 1)
+				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
+				assert.Contains(t, ruleSet.Source, "oci:rules.yaml@")
+				assert.Contains(t, ruleSet.Source, testRepository+":v1")
+				assert.NotEmpty(t, ruleSet.Hash)
+				assert.Equal(t, time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC), ruleSet.ModTime)
+
+				ruleSet = ruleSets[digestOf(jsonRuleSet)+"#test2"]
+				require.NotNil(t, ruleSet)
+				assert.Equal(t, "test2", ruleSet.Name)
+				assert.Equal(t, "bar", ruleSet.Rules[0].ID)
+			},
+		},
+		{
+			uc: "artifact with rule set bundles",
+			setup: func(t *testing.T, reg *testRegistry) string {
+				t.Helper()
+
+				bundle := testsupport.CreateTarGzBundle(t, map[string]string{
+					"foo.yaml": string(yamlRuleSet),
+					"bar.json": string(jsonRuleSet),
+				})
+
+				return reg.push(t, "v1", nil,
+					testLayer{title: "bundle", mediaType: "application/vnd.oci.image.layer.v1.tar+gzip", contents: bundle},
+					testLayer{
+						title:     "stream.yaml",
+						mediaType: "application/yaml",
+						contents:  []byte(string(yamlRuleSet) + "---\n" + string(jsonRuleSet)),
+					},
+				)
+			},
+			assert: func(t *testing.T, err error, ruleSets map[string]*config.RuleSet) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, ruleSets, 4)
+
+				ruleSet := ruleSets["bundle#foo.yaml#test"]
+				require.NotNil(t, ruleSet)
+				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
+				assert.True(t, strings.HasPrefix(ruleSet.Source, "oci:bundle@"))
+				assert.True(t, strings.HasSuffix(ruleSet.Source, testRepository+":v1#foo.yaml#test"))
+
+				ruleSet = ruleSets["bundle#bar.json#test2"]
+				require.NotNil(t, ruleSet)
+				assert.Equal(t, "bar", ruleSet.Rules[0].ID)
+
+				ruleSet = ruleSets["stream.yaml#test"]
+				require.NotNil(t, ruleSet)
+				assert.Equal(t, "foo", ruleSet.Rules[0].ID)
+				assert.True(t, strings.HasSuffix(ruleSet.Source, testRepository+":v1#test"))
+
+				ruleSet = ruleSets["stream.yaml#test2"]
+				require.NotNil(t, ruleSet)
+				assert.Equal(t, "bar", ruleSet.Rules[0].ID)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			reg := newTestRegistry(t)
+			digest := tc.setup(t, reg)
+
+			artifact := &ruleSetArtifact{Ref: reg.ref("v1"), PlainHTTP: true, RulesPathPrefix: tc.pathPrefix}
+			require.NoError(t, artifact.init())
+
+			// WHEN
+			ruleSets, err := artifact.ReadRuleSets(context.Background(), digest)
+
+			// THEN
+			tc.assert(t, err, ruleSets)
+		})
+	}
+}
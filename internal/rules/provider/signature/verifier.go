@@ -0,0 +1,170 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"crypto/x509"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"golang.org/x/exp/slices"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	resultValid   = "valid"
+	resultInvalid = "invalid"
+	resultMissing = "missing"
+)
+
+var (
+	ErrMissingSignature = errors.New("missing rule set signature")
+	ErrInvalidSignature = errors.New("invalid rule set signature")
+)
+
+// Verifier verifies detached signatures of rule sets.
+type Verifier interface {
+	// Verify checks the given signature, a JWS in compact serialization with detached payload,
+	// to be a valid signature over the given rule set contents.
+	Verify(src string, contents, signature []byte) error
+}
+
+type verifier struct {
+	roots         *x509.CertPool
+	provider      string
+	verifications *prometheus.CounterVec
+	l             zerolog.Logger
+}
+
+func NewVerifier(
+	conf *Config, providerType string, reg prometheus.Registerer, logger zerolog.Logger,
+) (Verifier, error) {
+	if len(conf.TrustStore) == 0 {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"no trust_store configured for rule set signature verification in %s rule provider", providerType)
+	}
+
+	roots := x509.NewCertPool()
+	for _, cert := range conf.TrustStore {
+		roots.AddCert(cert)
+	}
+
+	return &verifier{
+		roots:         roots,
+		provider:      providerType,
+		verifications: newVerificationsCounter(reg),
+		l:             logger,
+	}, nil
+}
+
+func (v *verifier) Verify(src string, contents, signature []byte) error {
+	if len(signature) == 0 {
+		v.record(src, resultMissing, nil)
+
+		return errorchain.NewWithMessage(ErrMissingSignature, src)
+	}
+
+	if err := v.verify(contents, signature); err != nil {
+		v.record(src, resultInvalid, err)
+
+		return err
+	}
+
+	v.record(src, resultValid, nil)
+
+	return nil
+}
+
+func (v *verifier) verify(contents, signature []byte) error {
+	jws, err := jose.ParseDetached(string(signature), contents)
+	if err != nil {
+		return errorchain.NewWithMessage(ErrInvalidSignature, "failed to parse signature").CausedBy(err)
+	}
+
+	if len(jws.Signatures) != 1 {
+		return errorchain.NewWithMessage(ErrInvalidSignature, "expected exactly one signature")
+	}
+
+	chains, err := jws.Signatures[0].Protected.Certificates(x509.VerifyOptions{
+		Roots:     v.roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return errorchain.NewWithMessage(ErrInvalidSignature, "signer certificate is not trusted").CausedBy(err)
+	}
+
+	signer := chains[0][0]
+	if signer.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return errorchain.NewWithMessage(ErrInvalidSignature,
+			"signer certificate is not allowed to create digital signatures")
+	}
+
+	// certificates without any extended key usage pass the chain verification above. These
+	// are however not meant for signing rule sets
+	if !slices.Contains(signer.ExtKeyUsage, x509.ExtKeyUsageCodeSigning) {
+		return errorchain.NewWithMessage(ErrInvalidSignature,
+			"signer certificate is not allowed to sign code")
+	}
+
+	if err = jws.DetachedVerify(contents, signer.PublicKey); err != nil {
+		return errorchain.NewWithMessage(ErrInvalidSignature, "signature does not match").CausedBy(err)
+	}
+
+	return nil
+}
+
+func (v *verifier) record(src, result string, err error) {
+	v.verifications.WithLabelValues(v.provider, result).Inc()
+
+	if result == resultValid {
+		v.l.Debug().Str("_src", src).Msg("Rule set signature verified")
+
+		return
+	}
+
+	v.l.Warn().
+		Err(err).
+		Str("_src", src).
+		Str("_result", result).
+		Msg("Rule set rejected due to failed signature verification")
+}
+
+func newVerificationsCounter(reg prometheus.Registerer) *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rule_set_signature_verifications_total",
+			Help: "Number of rule set signature verifications, partitioned by rule provider and result",
+		},
+		[]string{"provider", "result"},
+	)
+
+	// the counter is shared by all rule providers
+	if err := reg.Register(counter); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector.(*prometheus.CounterVec) // nolint: forcetypeassert
+		}
+
+		panic(err)
+	}
+
+	return counter
+}
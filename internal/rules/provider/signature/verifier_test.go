@@ -0,0 +1,292 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func TestNewVerifier(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	reg := prometheus.NewRegistry()
+
+	// WHEN
+	_, err = NewVerifier(&Config{}, "test", reg, log.Logger)
+
+	// THEN
+	require.Error(t, err)
+	require.ErrorIs(t, err, heimdall.ErrConfiguration)
+	require.Contains(t, err.Error(), "no trust_store")
+
+	// WHEN
+	first, err := NewVerifier(&Config{TrustStore: truststore.TrustStore{rootCA.Certificate}}, "foo", reg, log.Logger)
+	require.NoError(t, err)
+
+	second, err := NewVerifier(&Config{TrustStore: truststore.TrustStore{rootCA.Certificate}}, "bar", reg, log.Logger)
+	require.NoError(t, err)
+
+	// THEN
+	assert.Same(t, first.(*verifier).verifications, second.(*verifier).verifications) // nolint: forcetypeassert
+}
+
+func TestVerifierVerify(t *testing.T) {
+	t.Parallel()
+
+	contents := []byte("version: 1\nrules: []\n")
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	otherCA, err := testsupport.NewRootCA("Other Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	intCAPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	intCACert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test Int CA"}),
+		testsupport.WithIsCA(),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&intCAPrivKey.PublicKey, x509.ECDSAWithSHA384))
+	require.NoError(t, err)
+
+	intCA := testsupport.NewCA(intCAPrivKey, intCACert)
+
+	signerPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	signerCert, err := intCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test Signer"}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&signerPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageCodeSigning))
+	require.NoError(t, err)
+
+	otherCert, err := otherCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Other Signer"}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&signerPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageCodeSigning))
+	require.NoError(t, err)
+
+	noDigSigCert, err := intCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test Encipherer"}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&signerPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageKeyEncipherment),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageCodeSigning))
+	require.NoError(t, err)
+
+	noCodeSigningCert, err := intCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test Signer without EKU"}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&signerPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature))
+	require.NoError(t, err)
+
+	serverAuthCert, err := intCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test Server"}),
+		testsupport.WithValidity(time.Now(), time.Hour*24),
+		testsupport.WithSubjectPubKey(&signerPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature),
+		testsupport.WithExtendedKeyUsage(x509.ExtKeyUsageServerAuth))
+	require.NoError(t, err)
+
+	sign := func(t *testing.T, data []byte, chain ...*x509.Certificate) []byte {
+		t.Helper()
+
+		sig, err := testsupport.SignDetached(data, jose.ES384, signerPrivKey, chain...)
+		require.NoError(t, err)
+
+		return []byte(sig)
+	}
+
+	for _, tc := range []struct {
+		uc        string
+		signature func(t *testing.T) []byte
+		result    string
+		assert    func(t *testing.T, err error)
+	}{
+		{
+			uc:        "missing signature",
+			signature: func(t *testing.T) []byte { t.Helper(); return nil },
+			result:    resultMissing,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMissingSignature)
+			},
+		},
+		{
+			uc:        "malformed signature",
+			signature: func(t *testing.T) []byte { t.Helper(); return []byte("foo.bar") },
+			result:    resultInvalid,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.Contains(t, err.Error(), "failed to parse")
+			},
+		},
+		{
+			uc: "signature without certificate chain",
+			signature: func(t *testing.T) []byte {
+				t.Helper()
+
+				return sign(t, contents)
+			},
+			result: resultInvalid,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.Contains(t, err.Error(), "not trusted")
+			},
+		},
+		{
+			uc: "signer certificate issued by an untrusted CA",
+			signature: func(t *testing.T) []byte {
+				t.Helper()
+
+				return sign(t, contents, otherCert)
+			},
+			result: resultInvalid,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.Contains(t, err.Error(), "not trusted")
+			},
+		},
+		{
+			uc: "signer certificate without digital signature key usage",
+			signature: func(t *testing.T) []byte {
+				t.Helper()
+
+				return sign(t, contents, noDigSigCert, intCACert)
+			},
+			result: resultInvalid,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.Contains(t, err.Error(), "not allowed")
+			},
+		},
+		{
+			uc: "signer certificate without code signing extended key usage",
+			signature: func(t *testing.T) []byte {
+				t.Helper()
+
+				return sign(t, contents, noCodeSigningCert, intCACert)
+			},
+			result: resultInvalid,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.Contains(t, err.Error(), "not allowed to sign")
+			},
+		},
+		{
+			uc: "signer certificate with other extended key usage only",
+			signature: func(t *testing.T) []byte {
+				t.Helper()
+
+				return sign(t, contents, serverAuthCert, intCACert)
+			},
+			result: resultInvalid,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.Contains(t, err.Error(), "not trusted")
+			},
+		},
+		{
+			uc: "signature over other contents",
+			signature: func(t *testing.T) []byte {
+				t.Helper()
+
+				return sign(t, []byte("version: 1\nrules: [foo]\n"), signerCert, intCACert)
+			},
+			result: resultInvalid,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.Contains(t, err.Error(), "does not match")
+			},
+		},
+		{
+			uc: "valid signature",
+			signature: func(t *testing.T) []byte {
+				t.Helper()
+
+				return sign(t, contents, signerCert, intCACert)
+			},
+			result: resultValid,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf := &Config{TrustStore: truststore.TrustStore{rootCA.Certificate}}
+
+			ver, err := NewVerifier(conf, "test", prometheus.NewRegistry(), log.Logger)
+			require.NoError(t, err)
+
+			// WHEN
+			err = ver.Verify("test:rules.yaml", contents, tc.signature(t))
+
+			// THEN
+			tc.assert(t, err)
+
+			counter := ver.(*verifier).verifications // nolint: forcetypeassert
+			assert.Equal(t, 1, testutil.CollectAndCount(counter))
+			assert.InDelta(t, 1, testutil.ToFloat64(counter.WithLabelValues("test", tc.result)), 0)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/rule"
+)
+
+type ruleSetState struct {
+	source string
+	hash   []byte
+}
+
+// Tracker keeps track of the rule sets made available by a single origin, like a git repository
+// or an oci artifact, and applies the differences between the known and the currently available
+// rule sets to the underlying rule.SetProcessor.
+type Tracker struct {
+	p        rule.SetProcessor
+	l        zerolog.Logger
+	known    map[string]ruleSetState
+	obsolete []string
+}
+
+func NewTracker(processor rule.SetProcessor, logger zerolog.Logger) *Tracker {
+	return &Tracker{
+		p:     processor,
+		l:     logger,
+		known: make(map[string]ruleSetState),
+	}
+}
+
+// Apply synchronizes the given rule sets, which are keyed by a name being stable across revisions,
+// with the rule sets known from previous invocations. New and changed rule sets are applied first.
+// Rule sets, which are not present any more, or which have been replaced by a rule set with a
+// different source, are deleted only afterwards. That way, a failing update never results in the
+// previously loaded rules being removed. If an error is returned, the state reflects the changes
+// applied so far, so that a subsequent invocation applies the remaining ones only. As the rules
+// of the rule sets to be deleted are still loaded while the new and changed rule sets are applied,
+// these are not considered while checking the latter for overlaps.
+func (t *Tracker) Apply(ruleSets map[string]*config.RuleSet) error {
+	obsolete := t.obsoleteSources(ruleSets)
+	for _, ruleSet := range ruleSets {
+		ruleSet.Obsoletes = obsolete
+	}
+
+	err := t.createOrUpdate(ruleSets)
+	if err == nil {
+		for _, name := range sortedKeys(t.known) {
+			if _, ok := ruleSets[name]; !ok {
+				t.obsolete = append(t.obsolete, t.known[name].source)
+
+				delete(t.known, name)
+			}
+		}
+	}
+
+	if delErr := t.deleteObsolete(); err == nil {
+		err = delErr
+	}
+
+	return err
+}
+
+func (t *Tracker) createOrUpdate(ruleSets map[string]*config.RuleSet) error {
+	for _, name := range sortedKeys(ruleSets) {
+		ruleSet := ruleSets[name]
+		known, isKnown := t.known[name]
+
+		var err error
+
+		switch {
+		case !isKnown || known.source != ruleSet.Source:
+			err = t.p.OnCreated(ruleSet)
+		case bytes.Equal(known.hash, ruleSet.Hash):
+			t.l.Debug().Str("_rule_set", ruleSet.Source).Msg("No updates received")
+
+			continue
+		default:
+			err = t.p.OnUpdated(ruleSet)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if isKnown && known.source != ruleSet.Source {
+			t.obsolete = append(t.obsolete, known.source)
+		}
+
+		t.known[name] = ruleSetState{source: ruleSet.Source, hash: ruleSet.Hash}
+	}
+
+	return nil
+}
+
+// obsoleteSources returns the sources of the known rule sets, which are either not present in the
+// given rule sets any more, or are replaced by a rule set with a different source, as well as the
+// sources still queued for deletion from previous invocations.
+func (t *Tracker) obsoleteSources(ruleSets map[string]*config.RuleSet) []string {
+	obsolete := slices.Clone(t.obsolete)
+
+	for _, name := range sortedKeys(t.known) {
+		if ruleSet, ok := ruleSets[name]; !ok || ruleSet.Source != t.known[name].source {
+			obsolete = append(obsolete, t.known[name].source)
+		}
+	}
+
+	return obsolete
+}
+
+func (t *Tracker) deleteObsolete() error {
+	for len(t.obsolete) != 0 {
+		if err := t.p.OnDeleted(&config.RuleSet{
+			MetaData: config.MetaData{
+				Source:  t.obsolete[0],
+				ModTime: time.Now(),
+			},
+		}); err != nil {
+			return err
+		}
+
+		t.obsolete = t.obsolete[1:]
+	}
+
+	return nil
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := maps.Keys(values)
+	slices.Sort(keys)
+
+	return keys
+}
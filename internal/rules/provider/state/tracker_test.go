@@ -0,0 +1,219 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+func newRuleSet(source, hash string) *config.RuleSet {
+	return &config.RuleSet{MetaData: config.MetaData{Source: source, Hash: []byte(hash)}}
+}
+
+func TestTrackerApply(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	for _, tc := range []struct {
+		uc             string
+		known          map[string]*config.RuleSet
+		ruleSets       map[string]*config.RuleSet
+		setupProcessor func(t *testing.T, processor *mocks.RuleSetProcessorMock)
+		assert         func(t *testing.T, err error, calls []string, tracker *Tracker)
+	}{
+		{
+			uc: "new, changed, unchanged and removed rule sets",
+			known: map[string]*config.RuleSet{
+				"a": newRuleSet("test:a", "1"),
+				"b": newRuleSet("test:b", "1"),
+				"c": newRuleSet("test:c", "1"),
+			},
+			ruleSets: map[string]*config.RuleSet{
+				"a": newRuleSet("test:a", "1"),
+				"b": newRuleSet("test:b", "2"),
+				"d": newRuleSet("test:d", "1"),
+			},
+			assert: func(t *testing.T, err error, calls []string, tracker *Tracker) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []string{"updated test:b", "created test:d", "deleted test:c"}, calls)
+				assert.Len(t, tracker.known, 3)
+				assert.Empty(t, tracker.obsolete)
+			},
+		},
+		{
+			uc:    "replaced rule set is deleted after its replacement has been created",
+			known: map[string]*config.RuleSet{"a": newRuleSet("test:a@1", "1")},
+			ruleSets: map[string]*config.RuleSet{
+				"a": newRuleSet("test:a@2", "1"),
+			},
+			assert: func(t *testing.T, err error, calls []string, tracker *Tracker) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []string{"created test:a@2", "deleted test:a@1"}, calls)
+				assert.Equal(t, "test:a@2", tracker.known["a"].source)
+			},
+		},
+		{
+			uc: "failing creation keeps rule sets, which are not present any more",
+			known: map[string]*config.RuleSet{
+				"a": newRuleSet("test:a", "1"),
+				"b": newRuleSet("test:b@1", "1"),
+			},
+			ruleSets: map[string]*config.RuleSet{
+				"b": newRuleSet("test:b@2", "1"),
+				"c": newRuleSet("test:c", "1"),
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.MatchedBy(func(rs *config.RuleSet) bool {
+					return rs.Source == "test:c"
+				})).Return(errTest).Once()
+			},
+			assert: func(t *testing.T, err error, calls []string, tracker *Tracker) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errTest)
+				assert.Equal(t, []string{"created test:b@2", "deleted test:b@1"}, calls)
+				assert.Len(t, tracker.known, 2)
+				assert.Contains(t, tracker.known, "a")
+				assert.Equal(t, "test:b@2", tracker.known["b"].source)
+			},
+		},
+		{
+			uc:    "failing replacement keeps the replaced rule set",
+			known: map[string]*config.RuleSet{"a": newRuleSet("test:a@1", "1")},
+			ruleSets: map[string]*config.RuleSet{
+				"a": newRuleSet("test:a@2", "2"),
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnCreated(mock.Anything).Return(errTest).Once()
+			},
+			assert: func(t *testing.T, err error, calls []string, tracker *Tracker) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errTest)
+				assert.Empty(t, calls)
+				assert.Equal(t, "test:a@1", tracker.known["a"].source)
+			},
+		},
+		{
+			uc: "sources of rule sets to be deleted are marked as obsoleted by applied rule sets",
+			known: map[string]*config.RuleSet{
+				"a": newRuleSet("test:a", "1"),
+				"b": newRuleSet("test:b@1", "1"),
+				"c": newRuleSet("test:c", "1"),
+			},
+			ruleSets: map[string]*config.RuleSet{
+				"b": newRuleSet("test:b@2", "1"),
+				"c": newRuleSet("test:c", "2"),
+				"d": newRuleSet("test:d", "1"),
+			},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				obsoleted := func(rs *config.RuleSet) bool {
+					return assert.ObjectsAreEqual([]string{"test:a", "test:b@1"}, rs.Obsoletes)
+				}
+
+				processor.EXPECT().OnCreated(mock.MatchedBy(obsoleted)).Return(nil).Twice()
+				processor.EXPECT().OnUpdated(mock.MatchedBy(obsoleted)).Return(nil).Once()
+			},
+			assert: func(t *testing.T, err error, calls []string, tracker *Tracker) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []string{"deleted test:b@1", "deleted test:a"}, calls)
+				assert.Empty(t, tracker.obsolete)
+			},
+		},
+		{
+			uc:       "failing deletion is retried on next invocation",
+			known:    map[string]*config.RuleSet{"a": newRuleSet("test:a", "1")},
+			ruleSets: map[string]*config.RuleSet{},
+			setupProcessor: func(t *testing.T, processor *mocks.RuleSetProcessorMock) {
+				t.Helper()
+
+				processor.EXPECT().OnDeleted(mock.Anything).Return(errTest).Once()
+			},
+			assert: func(t *testing.T, err error, calls []string, tracker *Tracker) {
+				t.Helper()
+
+				require.ErrorIs(t, err, errTest)
+				assert.Empty(t, calls)
+				assert.Empty(t, tracker.known)
+				assert.Equal(t, []string{"test:a"}, tracker.obsolete)
+
+				err = tracker.Apply(map[string]*config.RuleSet{})
+				require.NoError(t, err)
+				assert.Empty(t, tracker.obsolete)
+			},
+		},
+	} {
+		tc := tc
+
+		t.Run(tc.uc, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			var calls []string
+
+			setupProcessor := x.IfThenElse(tc.setupProcessor != nil,
+				tc.setupProcessor,
+				func(t *testing.T, _ *mocks.RuleSetProcessorMock) { t.Helper() })
+
+			processor := mocks.NewRuleSetProcessorMock(t)
+			tracker := NewTracker(processor, log.Logger)
+
+			for name, ruleSet := range tc.known {
+				tracker.known[name] = ruleSetState{source: ruleSet.Source, hash: ruleSet.Hash}
+			}
+
+			setupProcessor(t, processor)
+
+			record := func(op string) func(*config.RuleSet) {
+				return func(rs *config.RuleSet) { calls = append(calls, op+" "+rs.Source) }
+			}
+
+			processor.EXPECT().OnCreated(mock.Anything).Run(record("created")).Return(nil).Maybe()
+			processor.EXPECT().OnUpdated(mock.Anything).Run(record("updated")).Return(nil).Maybe()
+			processor.EXPECT().OnDeleted(mock.Anything).Run(record("deleted")).Return(nil).Maybe()
+
+			// WHEN
+			err := tracker.Apply(tc.ruleSets)
+
+			// THEN
+			tc.assert(t, err, calls, tracker)
+		})
+	}
+}
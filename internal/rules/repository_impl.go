@@ -3,10 +3,12 @@ package rules
 import (
 	"bytes"
 	"context"
-	"net/url"
 	"sync"
+	"sync/atomic"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
+	"golang.org/x/exp/slices"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/event"
@@ -19,45 +21,76 @@ import (
 func newRepository(
 	queue event.RuleSetChangedEventQueue,
 	ruleFactory rule.Factory,
+	reg prometheus.Registerer,
 	logger zerolog.Logger,
 ) *repository {
-	return &repository{
+	repo := &repository{
 		dr: x.IfThenElseExec(ruleFactory.HasDefaultRule(),
 			func() rule.Rule { return ruleFactory.DefaultRule() },
 			func() rule.Rule { return nil }),
-		logger: logger,
-		queue:  queue,
-		quit:   make(chan bool),
+		logger:    logger,
+		pending:   newRuleIndex(),
+		decisions: newShadowDecisionsCounter(reg),
+		queue:     queue,
+		quit:      make(chan bool),
 	}
+
+	repo.index.Store(repo.pending)
+
+	return repo
 }
 
 type repository struct {
-	dr     rule.Rule
-	logger zerolog.Logger
-
-	rules []rule.Rule
-	mutex sync.RWMutex
+	dr        rule.Rule
+	logger    zerolog.Logger
+	decisions *prometheus.CounterVec
+
+	// rules and pending are only used while modifying the repository and are guarded by
+	// the mutex. index holds the last published version of pending and is used for
+	// lookups without any locking.
+	rules   []rule.Rule
+	pending *ruleIndex
+	index   atomic.Pointer[ruleIndex]
+	mutex   sync.Mutex
 
 	queue event.RuleSetChangedEventQueue
 	quit  chan bool
 }
 
-func (r *repository) FindRule(requestURL *url.URL) (rule.Rule, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+func (r *repository) FindRule(req *heimdall.Request) (rule.Rule, error) {
+	var shadow *ruleImpl
 
-	for _, rul := range r.rules {
-		if rul.MatchesURL(requestURL) {
-			return rul, nil
+	for _, rul := range r.index.Load().candidates(req.URL.String()) {
+		if !rul.Matches(req) {
+			continue
+		}
+
+		// rules in shadow mode don't hide the rule, which would have been used otherwise
+		if impl, ok := rul.(*ruleImpl); ok && impl.shadow {
+			shadow = x.IfThenElse(shadow == nil, impl, shadow)
+
+			continue
 		}
+
+		return r.withShadow(shadow, rul), nil
 	}
 
+	// a shadow rule never handles a request on its own. Without a rule it could shadow,
+	// the request is treated as if the shadow rule did not exist.
 	if r.dr != nil {
-		return r.dr, nil
+		return r.withShadow(shadow, r.dr), nil
 	}
 
 	return nil, errorchain.NewWithMessagef(heimdall.ErrNoRuleFound,
-		"no applicable rule found for %s", requestURL.String())
+		"no applicable rule found for %s", req.URL.String())
+}
+
+func (r *repository) withShadow(shadow *ruleImpl, enforced rule.Rule) rule.Rule {
+	if shadow == nil {
+		return enforced
+	}
+
+	return &shadowedRule{shadow: shadow, enforced: enforced, decisions: r.decisions}
 }
 
 func (r *repository) Start(_ context.Context) error {
@@ -75,6 +108,13 @@ func (r *repository) Stop(_ context.Context) error {
 
 	close(r.quit)
 
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, rul := range r.rules {
+		stopHealthChecks(rul)
+	}
+
 	return nil
 }
 
@@ -111,6 +151,8 @@ func (r *repository) addRuleSet(srcID string, rules []rule.Rule) {
 
 	// add them
 	r.addRules(rules)
+
+	r.publish()
 }
 
 func (r *repository) updateRuleSet(srcID string, rules []rule.Rule) {
@@ -185,6 +227,8 @@ func (r *repository) updateRuleSet(srcID string, rules []rule.Rule) {
 
 		// add new rules
 		r.addRules(newRules)
+
+		r.publish()
 	}()
 }
 
@@ -199,58 +243,59 @@ func (r *repository) deleteRuleSet(srcID string) {
 
 	// remove them
 	r.removeRules(applicable)
+
+	r.publish()
 }
 
+func (r *repository) publish() { r.index.Store(r.pending) }
+
 func (r *repository) addRules(rules []rule.Rule) {
 	for _, rul := range rules {
 		r.rules = append(r.rules, rul)
+		r.pending = r.pending.add(rul)
+
+		startHealthChecks(rul)
 
 		r.logger.Debug().Str("_src", rul.SrcID()).Str("_id", rul.ID()).Msg("Rule added")
 	}
 }
 
 func (r *repository) removeRules(rules []rule.Rule) {
-	// find all indexes for affected rules
-	var idxs []int
+	// keep all rules, which should not be removed, in place
+	remaining := r.rules[:0]
 
-	for idx, rul := range r.rules {
-		for _, tbd := range rules {
-			if rul.ID() == tbd.ID() {
-				idxs = append(idxs, idx)
+	for _, rul := range r.rules {
+		if !slices.ContainsFunc(rules, func(tbd rule.Rule) bool { return sameRule(rul, tbd) }) {
+			remaining = append(remaining, rul)
 
-				r.logger.Debug().Str("_src", rul.SrcID()).Str("_id", rul.ID()).Msg("Rule removed")
-			}
+			continue
 		}
-	}
 
-	// if all rules should be dropped, just create a new slice
-	if len(idxs) == len(r.rules) {
-		r.rules = nil
+		r.pending = r.pending.remove(rul)
 
-		return
-	}
+		stopHealthChecks(rul)
 
-	// move the elements from the end of the rules slice to the found positions
-	// and set the corresponding "emptied" values to nil
-	for i, idx := range idxs {
-		tailIdx := len(r.rules) - (1 + i)
-
-		r.rules[idx] = r.rules[tailIdx]
+		r.logger.Debug().Str("_src", rul.SrcID()).Str("_id", rul.ID()).Msg("Rule removed")
+	}
 
-		// the below re-slice preserves the capacity of the slice.
-		// this is required to avoid memory leaks
-		r.rules[tailIdx] = nil
+	// set the "emptied" values to nil. This is required to avoid memory leaks
+	// as the re-slice below preserves the capacity of the slice.
+	for idx := len(remaining); idx < len(r.rules); idx++ {
+		r.rules[idx] = nil
 	}
 
-	// re-slice
-	r.rules = r.rules[:len(r.rules)-len(idxs)]
+	r.rules = remaining
 }
 
 func (r *repository) replaceRules(rules []rule.Rule) {
 	for _, updated := range rules {
 		for idx, existing := range r.rules {
-			if existing.ID() == updated.ID() {
+			if sameRule(existing, updated) {
 				r.rules[idx] = updated
+				r.pending = r.pending.replace(existing, updated)
+
+				stopHealthChecks(existing)
+				startHealthChecks(updated)
 
 				r.logger.Debug().
 					Str("_src", existing.SrcID()).
@@ -262,3 +307,21 @@ func (r *repository) replaceRules(rules []rule.Rule) {
 		}
 	}
 }
+
+// sameRule reports whether both rules are the same rule, which is the case if these have the same
+// id and stem from the same source. Rule IDs are unique within a rule set only.
+func sameRule(first, second rule.Rule) bool {
+	return first.SrcID() == second.SrcID() && first.ID() == second.ID()
+}
+
+func startHealthChecks(rul rule.Rule) {
+	if impl, ok := rul.(*ruleImpl); ok {
+		impl.startHealthChecks()
+	}
+}
+
+func stopHealthChecks(rul rule.Rule) {
+	if impl, ok := rul.(*ruleImpl); ok {
+		impl.stopHealthChecks()
+	}
+}
@@ -22,14 +22,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	config2 "github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
 	"github.com/dadrus/heimdall/internal/rules/event"
 	"github.com/dadrus/heimdall/internal/rules/patternmatcher"
+	"github.com/dadrus/heimdall/internal/rules/provider/state"
 	"github.com/dadrus/heimdall/internal/rules/rule"
 	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
 	"github.com/dadrus/heimdall/internal/x"
@@ -39,7 +44,7 @@ func TestRepositoryAddAndRemoveRulesFromSameRuleSet(t *testing.T) {
 	t.Parallel()
 
 	// GIVEN
-	repo := newRepository(nil, &ruleFactory{}, *zerolog.Ctx(context.Background()))
+	repo := newRepository(nil, &ruleFactory{}, prometheus.NewRegistry(), *zerolog.Ctx(context.Background()))
 
 	// WHEN
 	repo.addRuleSet("bar", []rule.Rule{
@@ -111,7 +116,7 @@ func TestRepositoryFindRule(t *testing.T) {
 			addRules: func(t *testing.T, repo *repository) {
 				t.Helper()
 
-				repo.rules = append(repo.rules,
+				repo.addRules([]rule.Rule{
 					&ruleImpl{
 						id:    "test1",
 						srcID: "bar",
@@ -132,7 +137,9 @@ func TestRepositoryFindRule(t *testing.T) {
 							return matcher
 						}(),
 					},
-				)
+				})
+
+				repo.publish()
 			},
 			assert: func(t *testing.T, err error, rul rule.Rule) {
 				t.Helper()
@@ -146,6 +153,235 @@ func TestRepositoryFindRule(t *testing.T) {
 				require.Equal(t, "baz", impl.srcID)
 			},
 		},
+		{
+			uc:         "most specific matching rule wins independent of its insertion order",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/v1/users"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				newRule := func(id, pattern string) rule.Rule {
+					matcher, err := patternmatcher.NewPatternMatcher("glob", pattern)
+					require.NoError(t, err)
+
+					return &ruleImpl{
+						id:         id,
+						srcID:      "bar",
+						urlMatcher: matcher,
+						urlPrefix:  patternmatcher.StaticPrefix("glob", pattern),
+					}
+				}
+
+				repo.addRules([]rule.Rule{
+					newRule("test1", "http://foo.bar/api/v2/<**>"),
+					newRule("test2", "<{http,https}>://foo.bar/api/<**>"),
+					newRule("test3", "http://foo.bar/api/v1/<**>"),
+					newRule("test4", "http://foo.bar/<**>"),
+				})
+
+				repo.publish()
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "test3", rul.ID())
+			},
+		},
+		{
+			uc:         "matching rule with the highest priority wins independent of its specificity",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/v1/users"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				newRule := func(id, pattern string, priority int) rule.Rule {
+					matcher, err := patternmatcher.NewPatternMatcher("glob", pattern)
+					require.NoError(t, err)
+
+					return &ruleImpl{
+						id:         id,
+						srcID:      "bar",
+						urlMatcher: matcher,
+						urlPrefix:  patternmatcher.StaticPrefix("glob", pattern),
+						priority:   priority,
+					}
+				}
+
+				repo.addRules([]rule.Rule{
+					newRule("test1", "http://foo.bar/api/v2/<**>", 0),
+					newRule("test2", "<{http,https}>://foo.bar/api/<**>", 10),
+					newRule("test3", "http://foo.bar/api/v1/<**>", 0),
+					newRule("test4", "http://foo.bar/<**>", 20),
+				})
+
+				repo.publish()
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "test4", rul.ID())
+			},
+		},
+		{
+			uc:         "matching shadow rule is returned together with the rule it shadows",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/v1/users"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				newRule := func(id, pattern string, shadow bool) rule.Rule {
+					matcher, err := patternmatcher.NewPatternMatcher("glob", pattern)
+					require.NoError(t, err)
+
+					return &ruleImpl{
+						id:         id,
+						srcID:      "bar",
+						urlMatcher: matcher,
+						urlPrefix:  patternmatcher.StaticPrefix("glob", pattern),
+						shadow:     shadow,
+					}
+				}
+
+				repo.addRules([]rule.Rule{
+					newRule("test1", "http://foo.bar/<**>", false),
+					newRule("test2", "http://foo.bar/api/<**>", true),
+					newRule("test3", "http://foo.bar/api/v1/<**>", true),
+				})
+
+				repo.publish()
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				shadowed, ok := rul.(*shadowedRule)
+				require.True(t, ok)
+				assert.Equal(t, "test3", shadowed.shadow.ID())
+				require.NotNil(t, shadowed.enforced)
+				assert.Equal(t, "test1", shadowed.enforced.ID())
+				assert.Equal(t, "test1", rul.ID())
+			},
+		},
+		{
+			uc:         "matching shadow rule without any other matching rule and without default rule",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/v1/users"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				matcher, err := patternmatcher.NewPatternMatcher("glob", "http://foo.bar/api/<**>")
+				require.NoError(t, err)
+
+				repo.addRules([]rule.Rule{
+					&ruleImpl{
+						id:         "test1",
+						srcID:      "bar",
+						urlMatcher: matcher,
+						urlPrefix:  "http://foo.bar/api/",
+						shadow:     true,
+					},
+				})
+
+				repo.publish()
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrNoRuleFound)
+				assert.Nil(t, rul)
+			},
+		},
+		{
+			uc:         "matching shadow rule without any other matching rule, but with default rule",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/v1/users"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(true)
+				factory.EXPECT().DefaultRule().Return(&ruleImpl{id: "default", isDefault: true})
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				matcher, err := patternmatcher.NewPatternMatcher("glob", "http://foo.bar/api/<**>")
+				require.NoError(t, err)
+
+				repo.addRules([]rule.Rule{
+					&ruleImpl{
+						id:         "test1",
+						srcID:      "bar",
+						urlMatcher: matcher,
+						urlPrefix:  "http://foo.bar/api/",
+						shadow:     true,
+					},
+				})
+
+				repo.publish()
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				shadowed, ok := rul.(*shadowedRule)
+				require.True(t, ok)
+				assert.Equal(t, "test1", shadowed.shadow.ID())
+				require.NotNil(t, shadowed.enforced)
+				assert.Equal(t, "default", shadowed.enforced.ID())
+			},
+		},
+		{
+			uc:         "no rule matches although their prefixes do",
+			requestURL: &url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/v1/users"},
+			configureFactory: func(t *testing.T, factory *mocks.FactoryMock) {
+				t.Helper()
+
+				factory.EXPECT().HasDefaultRule().Return(false)
+			},
+			addRules: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				matcher, err := patternmatcher.NewPatternMatcher("glob", "http://foo.bar/api/v1/<*>/groups")
+				require.NoError(t, err)
+
+				repo.addRules([]rule.Rule{
+					&ruleImpl{
+						id:         "test1",
+						srcID:      "bar",
+						urlMatcher: matcher,
+						urlPrefix:  "http://foo.bar/api/v1/",
+					},
+				})
+
+				repo.publish()
+			},
+			assert: func(t *testing.T, err error, rul rule.Rule) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrNoRuleFound)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -156,12 +392,12 @@ func TestRepositoryFindRule(t *testing.T) {
 			factory := mocks.NewFactoryMock(t)
 			tc.configureFactory(t, factory)
 
-			repo := newRepository(nil, factory, *zerolog.Ctx(context.Background()))
+			repo := newRepository(nil, factory, prometheus.NewRegistry(), *zerolog.Ctx(context.Background()))
 
 			addRules(t, repo)
 
 			// WHEN
-			rul, err := repo.FindRule(tc.requestURL)
+			rul, err := repo.FindRule(&heimdall.Request{URL: &heimdall.URL{URL: *tc.requestURL}})
 
 			// THEN
 			tc.assert(t, err, rul)
@@ -173,7 +409,7 @@ func TestRepositoryAddAndRemoveRulesFromDifferentRuleSets(t *testing.T) {
 	t.Parallel()
 
 	// GIVEN
-	repo := newRepository(nil, &ruleFactory{}, *zerolog.Ctx(context.Background()))
+	repo := newRepository(nil, &ruleFactory{}, prometheus.NewRegistry(), *zerolog.Ctx(context.Background()))
 
 	// WHEN
 	repo.addRules([]rule.Rule{
@@ -214,6 +450,53 @@ func TestRepositoryAddAndRemoveRulesFromDifferentRuleSets(t *testing.T) {
 	assert.Len(t, repo.rules, 0)
 }
 
+func TestRepositoryManagesHealthChecksOfRules(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	newRule := func(hash string) *ruleImpl {
+		upstream, err := newBackend(&config.Upstream{
+			Targets:     []config.UpstreamTarget{{URL: "http://127.0.0.1:1"}},
+			HealthCheck: &config.HealthCheck{Active: &config.ActiveHealthCheck{Interval: time.Hour}},
+		}, nil, log.Logger)
+		require.NoError(t, err)
+
+		return &ruleImpl{id: "1", srcID: "foo", hash: []byte(hash), backend: upstream}
+	}
+
+	isRunning := func(rul *ruleImpl) bool {
+		prober := rul.backend.(*backend).prober //nolint: forcetypeassert
+
+		prober.mutex.Lock()
+		defer prober.mutex.Unlock()
+
+		return prober.cancel != nil
+	}
+
+	repo := newRepository(nil, &ruleFactory{}, prometheus.NewRegistry(), *zerolog.Ctx(context.Background()))
+	initial := newRule("1")
+	updated := newRule("2")
+
+	// WHEN
+	repo.addRuleSet("foo", []rule.Rule{initial})
+
+	// THEN
+	assert.True(t, isRunning(initial))
+
+	// WHEN
+	repo.updateRuleSet("foo", []rule.Rule{updated})
+
+	// THEN
+	assert.False(t, isRunning(initial))
+	assert.True(t, isRunning(updated))
+
+	// WHEN
+	repo.deleteRuleSet("foo")
+
+	// THEN
+	assert.False(t, isRunning(updated))
+}
+
 func TestRepositoryRuleSetLifecycleManagement(t *testing.T) {
 	t.Parallel()
 
@@ -294,6 +577,40 @@ func TestRepositoryRuleSetLifecycleManagement(t *testing.T) {
 				assert.Equal(t, &ruleImpl{id: "rule:bar", srcID: "test1"}, repo.rules[0])
 			},
 		},
+		{
+			uc: "rule set replaced by a rule set from another source having rules with the same ids",
+			events: []event.RuleSetChanged{
+				{
+					Source:     "test1",
+					ChangeType: event.Create,
+					Rules: []rule.Rule{
+						&ruleImpl{id: "rule:foo", srcID: "test1"},
+						&ruleImpl{id: "rule:bar", srcID: "test1"},
+					},
+				},
+				{
+					Source:     "test2",
+					ChangeType: event.Create,
+					Rules: []rule.Rule{
+						&ruleImpl{id: "rule:foo", srcID: "test2"},
+						&ruleImpl{id: "rule:bar", srcID: "test2"},
+					},
+				},
+				{
+					Source:     "test1",
+					ChangeType: event.Remove,
+				},
+			},
+			assert: func(t *testing.T, repo *repository) {
+				t.Helper()
+
+				require.Len(t, repo.rules, 2)
+				assert.ElementsMatch(t, repo.rules, []rule.Rule{
+					&ruleImpl{id: "rule:foo", srcID: "test2"},
+					&ruleImpl{id: "rule:bar", srcID: "test2"},
+				})
+			},
+		},
 		{
 			uc: "multiple rule sets created and one updated",
 			events: []event.RuleSetChanged{
@@ -341,7 +658,7 @@ func TestRepositoryRuleSetLifecycleManagement(t *testing.T) {
 			queue := make(event.RuleSetChangedEventQueue, 10)
 			defer close(queue)
 
-			repo := newRepository(queue, &ruleFactory{}, log.Logger)
+			repo := newRepository(queue, &ruleFactory{}, prometheus.NewRegistry(), log.Logger)
 			require.NoError(t, repo.Start(ctx))
 
 			// nolint: errcheck
@@ -359,3 +676,82 @@ func TestRepositoryRuleSetLifecycleManagement(t *testing.T) {
 		})
 	}
 }
+
+func TestRepositoryKeepsRulesOfRenamedRuleSet(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	newRule := func(t *testing.T, id, srcID string) *ruleImpl {
+		t.Helper()
+
+		matcher, err := patternmatcher.NewPatternMatcher("glob", "http://foo.bar/<**>")
+		require.NoError(t, err)
+
+		return &ruleImpl{
+			id:         id,
+			srcID:      srcID,
+			urlMatcher: matcher,
+			urlPrefix:  "http://foo.bar/",
+			match:      config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"},
+			methods:    []string{"GET"},
+		}
+	}
+
+	factory := mocks.NewFactoryMock(t)
+	factory.EXPECT().HasDefaultRule().Return(false)
+	factory.EXPECT().CreateRule(config.CurrentRuleSetVersion, mock.Anything, mock.Anything).
+		RunAndReturn(func(_, srcID string, rc config.Rule) (rule.Rule, error) {
+			return newRule(t, rc.ID, srcID), nil
+		})
+
+	queue := make(event.RuleSetChangedEventQueue, 10)
+	repo := newRepository(queue, factory, prometheus.NewRegistry(), log.Logger)
+	processor := NewRuleSetProcessor(queue, factory,
+		&config2.Configuration{Rules: config2.Rules{FailOnOverlap: true}}, log.Logger)
+	tracker := state.NewTracker(processor, log.Logger)
+
+	newRuleSet := func(source string) *config.RuleSet {
+		return &config.RuleSet{
+			MetaData: config.MetaData{Source: source, Hash: []byte("1")},
+			Version:  config.CurrentRuleSetVersion,
+			Name:     "test",
+			Rules:    []config.Rule{{ID: "foo"}},
+		}
+	}
+
+	apply := func(ruleSets map[string]*config.RuleSet) error {
+		err := tracker.Apply(ruleSets)
+
+		for len(queue) != 0 {
+			evt := <-queue
+
+			switch evt.ChangeType {
+			case event.Create:
+				repo.addRuleSet(evt.Source, evt.Rules)
+			case event.Update:
+				repo.updateRuleSet(evt.Source, evt.Rules)
+			case event.Remove:
+				repo.deleteRuleSet(evt.Source)
+			}
+		}
+
+		return err
+	}
+
+	require.NoError(t, apply(map[string]*config.RuleSet{"a.yaml": newRuleSet("git:a.yaml")}))
+
+	// WHEN
+	err := apply(map[string]*config.RuleSet{"b.yaml": newRuleSet("git:b.yaml")})
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, repo.rules, 1)
+	assert.Equal(t, "foo", repo.rules[0].ID())
+	assert.Equal(t, "git:b.yaml", repo.rules[0].SrcID())
+
+	rul, err := repo.FindRule(&heimdall.Request{URL: &heimdall.URL{URL: url.URL{
+		Scheme: "http", Host: "foo.bar", Path: "/baz",
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, "git:b.yaml", rul.SrcID())
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"net/http"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/errorhandlers/matcher"
+	"github.com/dadrus/heimdall/internal/rules/patternmatcher"
+)
+
+type requestMatcher interface {
+	Matches(req *heimdall.Request) bool
+}
+
+// compositeRequestMatcher matches a request only if all of its matchers do.
+type compositeRequestMatcher []requestMatcher
+
+func (m compositeRequestMatcher) Matches(req *heimdall.Request) bool {
+	for _, rm := range m {
+		if !rm.Matches(req) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type hostMatcher struct {
+	pm patternmatcher.PatternMatcher
+}
+
+func (m *hostMatcher) Matches(req *heimdall.Request) bool { return m.pm.Match(req.URL.Host) }
+
+type headerMatcher struct {
+	name     string
+	patterns []patternmatcher.PatternMatcher
+}
+
+func (m *headerMatcher) Matches(req *heimdall.Request) bool {
+	return matchesAnyPattern(m.patterns, req.Header(m.name))
+}
+
+type queryParamMatcher struct {
+	name     string
+	patterns []patternmatcher.PatternMatcher
+}
+
+func (m *queryParamMatcher) Matches(req *heimdall.Request) bool {
+	return matchesAnyPattern(m.patterns, req.URL.Query()[m.name]...)
+}
+
+// clientIPMatcher matches the address of the peer heimdall is directly communicating with. The
+// addresses from the X-Forwarded-For header are not considered, as the client can set any of them.
+type clientIPMatcher struct {
+	cm *matcher.CIDRMatcher
+}
+
+func (m *clientIPMatcher) Matches(req *heimdall.Request) bool { return m.cm.Match(req.PeerAddress()) }
+
+func matchesAnyPattern(patterns []patternmatcher.PatternMatcher, values ...string) bool {
+	for _, value := range values {
+		for _, pattern := range patterns {
+			if pattern.Match(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func newRequestMatcher(conf config.Matcher) (compositeRequestMatcher, error) {
+	var matchers compositeRequestMatcher
+
+	if len(conf.Host) != 0 {
+		pm, err := patternmatcher.NewPatternMatcher(conf.Strategy, conf.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, &hostMatcher{pm: pm})
+	}
+
+	for name, values := range conf.Headers {
+		patterns, err := newPatternMatchers(conf.Strategy, values)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, &headerMatcher{name: http.CanonicalHeaderKey(name), patterns: patterns})
+	}
+
+	for name, values := range conf.QueryParams {
+		patterns, err := newPatternMatchers(conf.Strategy, values)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, &queryParamMatcher{name: name, patterns: patterns})
+	}
+
+	if len(conf.ClientCIDRs) != 0 {
+		cm, err := matcher.NewCIDRMatcher(conf.ClientCIDRs)
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, &clientIPMatcher{cm: cm})
+	}
+
+	return matchers, nil
+}
+
+func newPatternMatchers(strategy string, values []string) ([]patternmatcher.PatternMatcher, error) {
+	patterns := make([]patternmatcher.PatternMatcher, len(values))
+
+	for idx, value := range values {
+		pm, err := patternmatcher.NewPatternMatcher(strategy, value)
+		if err != nil {
+			return nil, err
+		}
+
+		patterns[idx] = pm
+	}
+
+	return patterns, nil
+}
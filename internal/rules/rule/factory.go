@@ -16,7 +16,11 @@
 
 package rule
 
-import "github.com/dadrus/heimdall/internal/rules/config"
+import (
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
 
 //go:generate mockery --name Factory --structname FactoryMock
 
@@ -25,3 +29,34 @@ type Factory interface {
 	DefaultRule() Rule
 	HasDefaultRule() bool
 }
+
+// CreateRules creates the rules of the given rule set by making use of the given factory. Rules
+// not defining a mode on their own are created with the mode of the rule set. This is the single
+// place defining how rule sets are turned into rules, so that validating a rule set, e.g. by an
+// admission controller, applies exactly the same checks as loading it.
+func CreateRules(factory Factory, ruleSet *config.RuleSet) ([]Rule, error) {
+	ruleConfigs, err := ruleSet.ExpandRules()
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed expanding rules").
+			CausedBy(err)
+	}
+
+	rules := make([]Rule, len(ruleConfigs))
+
+	for idx, rc := range ruleConfigs {
+		if len(rc.Mode) == 0 {
+			// the mode of the rule set applies to all rules not defining one on their own
+			rc.Mode = ruleSet.Mode
+		}
+
+		rul, err := factory.CreateRule(ruleSet.Version, ruleSet.Source, rc)
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed loading rule ID=%s", rc.ID).CausedBy(err)
+		}
+
+		rules[idx] = rul
+	}
+
+	return rules, nil
+}
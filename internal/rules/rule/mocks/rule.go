@@ -6,7 +6,7 @@ import (
 	heimdall "github.com/dadrus/heimdall/internal/heimdall"
 	mock "github.com/stretchr/testify/mock"
 
-	url "net/url"
+	rule "github.com/dadrus/heimdall/internal/rules/rule"
 )
 
 // RuleMock is an autogenerated mock type for the Rule type
@@ -23,19 +23,19 @@ func (_m *RuleMock) EXPECT() *RuleMock_Expecter {
 }
 
 // Execute provides a mock function with given fields: _a0
-func (_m *RuleMock) Execute(_a0 heimdall.Context) (*url.URL, error) {
+func (_m *RuleMock) Execute(_a0 heimdall.Context) (rule.Backend, error) {
 	ret := _m.Called(_a0)
 
-	var r0 *url.URL
+	var r0 rule.Backend
 	var r1 error
-	if rf, ok := ret.Get(0).(func(heimdall.Context) (*url.URL, error)); ok {
+	if rf, ok := ret.Get(0).(func(heimdall.Context) (rule.Backend, error)); ok {
 		return rf(_a0)
 	}
-	if rf, ok := ret.Get(0).(func(heimdall.Context) *url.URL); ok {
+	if rf, ok := ret.Get(0).(func(heimdall.Context) rule.Backend); ok {
 		r0 = rf(_a0)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*url.URL)
+			r0 = ret.Get(0).(rule.Backend)
 		}
 	}
 
@@ -66,12 +66,12 @@ func (_c *RuleMock_Execute_Call) Run(run func(_a0 heimdall.Context)) *RuleMock_E
 	return _c
 }
 
-func (_c *RuleMock_Execute_Call) Return(_a0 *url.URL, _a1 error) *RuleMock_Execute_Call {
+func (_c *RuleMock_Execute_Call) Return(_a0 rule.Backend, _a1 error) *RuleMock_Execute_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *RuleMock_Execute_Call) RunAndReturn(run func(heimdall.Context) (*url.URL, error)) *RuleMock_Execute_Call {
+func (_c *RuleMock_Execute_Call) RunAndReturn(run func(heimdall.Context) (rule.Backend, error)) *RuleMock_Execute_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -117,12 +117,12 @@ func (_c *RuleMock_ID_Call) RunAndReturn(run func() string) *RuleMock_ID_Call {
 	return _c
 }
 
-// MatchesMethod provides a mock function with given fields: _a0
-func (_m *RuleMock) MatchesMethod(_a0 string) bool {
+// Matches provides a mock function with given fields: _a0
+func (_m *RuleMock) Matches(_a0 *heimdall.Request) bool {
 	ret := _m.Called(_a0)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(string) bool); ok {
+	if rf, ok := ret.Get(0).(func(*heimdall.Request) bool); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Get(0).(bool)
@@ -131,40 +131,40 @@ func (_m *RuleMock) MatchesMethod(_a0 string) bool {
 	return r0
 }
 
-// RuleMock_MatchesMethod_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MatchesMethod'
-type RuleMock_MatchesMethod_Call struct {
+// RuleMock_Matches_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Matches'
+type RuleMock_Matches_Call struct {
 	*mock.Call
 }
 
-// MatchesMethod is a helper method to define mock.On call
-//   - _a0 string
-func (_e *RuleMock_Expecter) MatchesMethod(_a0 interface{}) *RuleMock_MatchesMethod_Call {
-	return &RuleMock_MatchesMethod_Call{Call: _e.mock.On("MatchesMethod", _a0)}
+// Matches is a helper method to define mock.On call
+//   - _a0 *heimdall.Request
+func (_e *RuleMock_Expecter) Matches(_a0 interface{}) *RuleMock_Matches_Call {
+	return &RuleMock_Matches_Call{Call: _e.mock.On("Matches", _a0)}
 }
 
-func (_c *RuleMock_MatchesMethod_Call) Run(run func(_a0 string)) *RuleMock_MatchesMethod_Call {
+func (_c *RuleMock_Matches_Call) Run(run func(_a0 *heimdall.Request)) *RuleMock_Matches_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(*heimdall.Request))
 	})
 	return _c
 }
 
-func (_c *RuleMock_MatchesMethod_Call) Return(_a0 bool) *RuleMock_MatchesMethod_Call {
+func (_c *RuleMock_Matches_Call) Return(_a0 bool) *RuleMock_Matches_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *RuleMock_MatchesMethod_Call) RunAndReturn(run func(string) bool) *RuleMock_MatchesMethod_Call {
+func (_c *RuleMock_Matches_Call) RunAndReturn(run func(*heimdall.Request) bool) *RuleMock_Matches_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// MatchesURL provides a mock function with given fields: _a0
-func (_m *RuleMock) MatchesURL(_a0 *url.URL) bool {
+// MatchesMethod provides a mock function with given fields: _a0
+func (_m *RuleMock) MatchesMethod(_a0 string) bool {
 	ret := _m.Called(_a0)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(*url.URL) bool); ok {
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
 		r0 = rf(_a0)
 	} else {
 		r0 = ret.Get(0).(bool)
@@ -173,30 +173,30 @@ func (_m *RuleMock) MatchesURL(_a0 *url.URL) bool {
 	return r0
 }
 
-// RuleMock_MatchesURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MatchesURL'
-type RuleMock_MatchesURL_Call struct {
+// RuleMock_MatchesMethod_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MatchesMethod'
+type RuleMock_MatchesMethod_Call struct {
 	*mock.Call
 }
 
-// MatchesURL is a helper method to define mock.On call
-//   - _a0 *url.URL
-func (_e *RuleMock_Expecter) MatchesURL(_a0 interface{}) *RuleMock_MatchesURL_Call {
-	return &RuleMock_MatchesURL_Call{Call: _e.mock.On("MatchesURL", _a0)}
+// MatchesMethod is a helper method to define mock.On call
+//   - _a0 string
+func (_e *RuleMock_Expecter) MatchesMethod(_a0 interface{}) *RuleMock_MatchesMethod_Call {
+	return &RuleMock_MatchesMethod_Call{Call: _e.mock.On("MatchesMethod", _a0)}
 }
 
-func (_c *RuleMock_MatchesURL_Call) Run(run func(_a0 *url.URL)) *RuleMock_MatchesURL_Call {
+func (_c *RuleMock_MatchesMethod_Call) Run(run func(_a0 string)) *RuleMock_MatchesMethod_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*url.URL))
+		run(args[0].(string))
 	})
 	return _c
 }
 
-func (_c *RuleMock_MatchesURL_Call) Return(_a0 bool) *RuleMock_MatchesURL_Call {
+func (_c *RuleMock_MatchesMethod_Call) Return(_a0 bool) *RuleMock_MatchesMethod_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *RuleMock_MatchesURL_Call) RunAndReturn(run func(*url.URL) bool) *RuleMock_MatchesURL_Call {
+func (_c *RuleMock_MatchesMethod_Call) RunAndReturn(run func(string) bool) *RuleMock_MatchesMethod_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -17,11 +17,11 @@
 package rule
 
 import (
-	"net/url"
+	"github.com/dadrus/heimdall/internal/heimdall"
 )
 
 //go:generate mockery --name Repository --structname RepositoryMock
 
 type Repository interface {
-	FindRule(*url.URL) (Rule, error)
+	FindRule(*heimdall.Request) (Rule, error)
 }
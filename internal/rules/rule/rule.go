@@ -17,8 +17,6 @@
 package rule
 
 import (
-	"net/url"
-
 	"github.com/dadrus/heimdall/internal/heimdall"
 )
 
@@ -27,7 +25,7 @@ import (
 type Rule interface {
 	ID() string
 	SrcID() string
-	Execute(heimdall.Context) (*url.URL, error)
-	MatchesURL(*url.URL) bool
+	Execute(heimdall.Context) (Backend, error)
+	Matches(*heimdall.Request) bool
 	MatchesMethod(string) bool
 }
@@ -4,7 +4,6 @@ import (
 	"crypto"
 	"errors"
 	"fmt"
-	"net/url"
 
 	"github.com/goccy/go-json"
 	"github.com/rs/zerolog"
@@ -146,14 +145,22 @@ func (f *ruleFactory) CreateRule(version, srcID string, ruleConfig config2.Rule)
 			ruleConfig.RuleMatcher.Strategy, ruleConfig.ID, srcID).CausedBy(err)
 	}
 
-	var upstreamURL *url.URL
+	reqMatcher, err := newRequestMatcher(ruleConfig.RuleMatcher)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"bad request matching conditions defined for rule ID=%s from %s",
+			ruleConfig.ID, srcID).CausedBy(err)
+	}
 
-	if len(ruleConfig.Upstream) != 0 {
-		upstreamURL, err = url.Parse(ruleConfig.Upstream)
-		if err != nil {
-			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
-				"bad upstream URL defined for rule ID=%s from %s", ruleConfig.ID, srcID).CausedBy(err)
-		}
+	if ruleConfig.Mode != "" && ruleConfig.Mode != config2.RuleModeEnforce && ruleConfig.Mode != config2.RuleModeShadow {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported mode '%s' defined for rule ID=%s from %s", ruleConfig.Mode, ruleConfig.ID, srcID)
+	}
+
+	upstream, canaries, err := f.createBackends(ruleConfig)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"bad upstream defined for rule ID=%s from %s", ruleConfig.ID, srcID).CausedBy(err)
 	}
 
 	authenticators, subHandlers, unifiers, err := f.createExecutePipeline(version, ruleConfig.Execute)
@@ -198,20 +205,71 @@ func (f *ruleFactory) CreateRule(version, srcID string, ruleConfig config2.Rule)
 	}
 
 	return &ruleImpl{
-		id:          ruleConfig.ID,
-		urlMatcher:  matcher,
-		upstreamURL: upstreamURL,
-		methods:     methods,
-		srcID:       srcID,
-		isDefault:   false,
-		hash:        hash,
-		sc:          authenticators,
-		sh:          subHandlers,
-		un:          unifiers,
-		eh:          errorHandlers,
+		id:         ruleConfig.ID,
+		urlMatcher: matcher,
+		urlPrefix:  patternmatcher.StaticPrefix(ruleConfig.RuleMatcher.Strategy, ruleConfig.RuleMatcher.URL),
+		reqMatcher: reqMatcher,
+		match:      ruleConfig.RuleMatcher,
+		priority:   ruleConfig.Priority,
+		shadow:     ruleConfig.Mode == config2.RuleModeShadow,
+		backend:    upstream,
+		canaries:   canaries,
+		methods:    methods,
+		srcID:      srcID,
+		isDefault:  false,
+		hash:       hash,
+		sc:         authenticators,
+		sh:         subHandlers,
+		un:         unifiers,
+		eh:         errorHandlers,
 	}, nil
 }
 
+func (f *ruleFactory) createBackends(ruleConfig config2.Rule) (rule.Backend, []*canary, error) {
+	if ruleConfig.Upstream == nil {
+		if ruleConfig.UpstreamRewrite != nil || len(ruleConfig.Canaries) != 0 {
+			return nil, nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"upstream rewrite or canaries defined without upstream")
+		}
+
+		return nil, nil, nil
+	}
+
+	upstream, err := newBackend(ruleConfig.Upstream, ruleConfig.UpstreamRewrite, f.logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	canaries := make([]*canary, len(ruleConfig.Canaries))
+	names := make(map[string]bool, len(ruleConfig.Canaries))
+
+	for idx, conf := range ruleConfig.Canaries {
+		if names[conf.Name] {
+			return nil, nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"canary %s defined multiple times", conf.Name)
+		}
+
+		names[conf.Name] = true
+
+		if conf.Upstream == nil {
+			return nil, nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"no upstream defined for canary %s", conf.Name)
+		}
+
+		bknd, err := newBackend(conf.Upstream, ruleConfig.UpstreamRewrite, f.logger)
+		if err != nil {
+			return nil, nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"bad upstream defined for canary %s", conf.Name).CausedBy(err)
+		}
+
+		if canaries[idx], err = newCanary(conf, bknd); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return upstream, canaries, nil
+}
+
 func (f *ruleFactory) createHash(ruleConfig config2.Rule) ([]byte, error) {
 	rawRuleConfig, err := json.Marshal(ruleConfig)
 	if err != nil {
@@ -555,7 +555,7 @@ func TestRuleFactoryCreateRule(t *testing.T) {
 			config: config2.Rule{
 				ID:          "foobar",
 				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
-				Upstream:    "http://[::1]:namedport",
+				Upstream:    &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://[::1]:namedport"}}},
 			},
 			assert: func(t *testing.T, err error, rul *ruleImpl) {
 				t.Helper()
@@ -745,6 +745,217 @@ func TestRuleFactoryCreateRule(t *testing.T) {
 				assert.Len(t, rul.eh, 0)
 			},
 		},
+		{
+			uc: "with unsupported mode",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				Mode:        "foo",
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported mode")
+			},
+		},
+		{
+			uc: "with upstream rewrite, but without upstream",
+			config: config2.Rule{
+				ID:              "foobar",
+				RuleMatcher:     config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				UpstreamRewrite: &config2.UpstreamRewrite{StripPathPrefix: "/foo"},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "without upstream")
+			},
+		},
+		{
+			uc: "with bad upstream rewrite",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				Upstream:    &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar.foo"}}},
+				UpstreamRewrite: &config2.UpstreamRewrite{
+					PathRegex: &config2.PathRegex{Pattern: "(foo", Replacement: "bar"},
+				},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "bad path regex")
+			},
+		},
+		{
+			uc: "with canaries, but without upstream",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				Canaries: []config2.Canary{
+					{Name: "foo", Percentage: 10, Upstream: &config2.Upstream{
+						Targets: []config2.UpstreamTarget{{URL: "http://baz.foo"}},
+					}},
+				},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "without upstream")
+			},
+		},
+		{
+			uc: "with canary without upstream",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				Upstream:    &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar.foo"}}},
+				Canaries:    []config2.Canary{{Name: "foo", Percentage: 10}},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no upstream defined for canary foo")
+			},
+		},
+		{
+			uc: "with canary having bad upstream",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				Upstream:    &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar.foo"}}},
+				Canaries: []config2.Canary{
+					{Name: "foo", Percentage: 10, Upstream: &config2.Upstream{
+						Targets: []config2.UpstreamTarget{{URL: "://baz.foo"}},
+					}},
+				},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "bad upstream defined for canary foo")
+			},
+		},
+		{
+			uc: "with canaries having the same name",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				Upstream:    &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar.foo"}}},
+				Canaries: []config2.Canary{
+					{Name: "foo", Percentage: 10, Upstream: &config2.Upstream{
+						Targets: []config2.UpstreamTarget{{URL: "http://baz.foo"}},
+					}},
+					{Name: "foo", Percentage: 20, Upstream: &config2.Upstream{
+						Targets: []config2.UpstreamTarget{{URL: "http://zab.foo"}},
+					}},
+				},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "canary foo defined multiple times")
+			},
+		},
+		{
+			uc: "with canary having bad percentage",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				Upstream:    &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar.foo"}}},
+				Canaries: []config2.Canary{
+					{Name: "foo", Percentage: 120, Upstream: &config2.Upstream{
+						Targets: []config2.UpstreamTarget{{URL: "http://baz.foo"}},
+					}},
+				},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "between 0 and 100")
+			},
+		},
+		{
+			uc: "with default rule and canaries",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				Upstream:    &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar.foo"}}},
+				Canaries: []config2.Canary{
+					{Name: "foo", Percentage: 10, Upstream: &config2.Upstream{
+						Targets: []config2.UpstreamTarget{{URL: "http://baz.foo"}},
+					}},
+					{Name: "bar", If: "Request.Method == 'GET'", Upstream: &config2.Upstream{
+						Targets: []config2.UpstreamTarget{{URL: "http://zab.foo"}},
+					}},
+				},
+			},
+			defaultRule: &ruleImpl{
+				methods: []string{"FOO"},
+				sc:      compositeSubjectCreator{&mocks.SubjectCreatorMock{}},
+				sh:      compositeSubjectHandler{&mocks.SubjectHandlerMock{}},
+				un:      compositeSubjectHandler{&mocks.SubjectHandlerMock{}},
+				eh:      compositeErrorHandler{&mocks.ErrorHandlerMock{}},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, rul)
+
+				require.Len(t, rul.canaries, 2)
+				assert.Equal(t, "foo", rul.canaries[0].name)
+				assert.Equal(t, 10, rul.canaries[0].percentage)
+				assert.Nil(t, rul.canaries[0].condition)
+				assert.Equal(t, "http://baz.foo", rul.canaries[0].backend.targets[0].url.String())
+				assert.Equal(t, "bar", rul.canaries[1].name)
+				assert.Equal(t, 0, rul.canaries[1].percentage)
+				assert.NotNil(t, rul.canaries[1].condition)
+				assert.Equal(t, "http://zab.foo", rul.canaries[1].backend.targets[0].url.String())
+			},
+		},
+		{
+			uc: "with default rule, priority and shadow mode",
+			config: config2.Rule{
+				ID:          "foobar",
+				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
+				Priority:    10,
+				Mode:        config2.RuleModeShadow,
+			},
+			defaultRule: &ruleImpl{
+				methods: []string{"FOO"},
+				sc:      compositeSubjectCreator{&mocks.SubjectCreatorMock{}},
+				sh:      compositeSubjectHandler{&mocks.SubjectHandlerMock{}},
+				un:      compositeSubjectHandler{&mocks.SubjectHandlerMock{}},
+				eh:      compositeErrorHandler{&mocks.ErrorHandlerMock{}},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, rul)
+
+				assert.Equal(t, "foobar", rul.id)
+				assert.Equal(t, 10, rul.priority)
+				assert.True(t, rul.shadow)
+			},
+		},
 		{
 			uc: "with default rule and with id and url only",
 			config: config2.Rule{
@@ -780,7 +991,7 @@ func TestRuleFactoryCreateRule(t *testing.T) {
 			config: config2.Rule{
 				ID:          "foobar",
 				RuleMatcher: config2.Matcher{URL: "http://foo.bar", Strategy: "glob"},
-				Upstream:    "http://bar.foo",
+				Upstream:    &config2.Upstream{Targets: []config2.UpstreamTarget{{URL: "http://bar.foo"}}},
 				Execute: []config.MechanismConfig{
 					{"authenticator": "foo"},
 					{"contextualizer": "bar"},
@@ -824,7 +1035,9 @@ func TestRuleFactoryCreateRule(t *testing.T) {
 				assert.Equal(t, "foobar", rul.id)
 				assert.NotNil(t, rul.urlMatcher)
 				assert.ElementsMatch(t, rul.methods, []string{"BAR", "BAZ"})
-				assert.Equal(t, "http://bar.foo", rul.upstreamURL.String())
+				require.IsType(t, &backend{}, rul.backend)
+				require.Len(t, rul.backend.(*backend).targets, 1)
+				assert.Equal(t, "http://bar.foo", rul.backend.(*backend).targets[0].url.String())
 
 				// nil checks above mean the responses from the mockHandlerFactory are used
 				// and not the values from the default rule
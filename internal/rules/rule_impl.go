@@ -18,29 +18,41 @@ package rules
 
 import (
 	"net/url"
+	"reflect"
 
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slices"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/config"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
 	"github.com/dadrus/heimdall/internal/rules/patternmatcher"
+	"github.com/dadrus/heimdall/internal/rules/rule"
 )
 
 type ruleImpl struct {
-	id          string
-	urlMatcher  patternmatcher.PatternMatcher
-	upstreamURL *url.URL
-	methods     []string
-	srcID       string
-	isDefault   bool
-	hash        []byte
-	sc          compositeSubjectCreator
-	sh          compositeSubjectHandler
-	un          compositeSubjectHandler
-	eh          compositeErrorHandler
+	id         string
+	urlMatcher patternmatcher.PatternMatcher
+	urlPrefix  string
+	reqMatcher compositeRequestMatcher
+	match      config.Matcher
+	priority   int
+	shadow     bool
+	backend    rule.Backend
+	canaries   []*canary
+	methods    []string
+	srcID      string
+	isDefault  bool
+	hash       []byte
+	sc         compositeSubjectCreator
+	sh         compositeSubjectHandler
+	un         compositeSubjectHandler
+	eh         compositeErrorHandler
 }
 
-func (r *ruleImpl) Execute(ctx heimdall.Context) (*url.URL, error) {
+func (r *ruleImpl) Execute(ctx heimdall.Context) (rule.Backend, error) {
 	logger := zerolog.Ctx(ctx.AppContext())
 
 	if r.isDefault {
@@ -49,6 +61,16 @@ func (r *ruleImpl) Execute(ctx heimdall.Context) (*url.URL, error) {
 		logger.Debug().Str("_src", r.srcID).Str("_id", r.id).Msg("Executing rule")
 	}
 
+	var captures map[string]string
+
+	if r.urlMatcher != nil {
+		// make the values of the named parameters available to the mechanisms. These are set on
+		// a copy of the request only, as other rules, like a shadow rule, might be executed for
+		// the same request as well.
+		captures = r.urlMatcher.Captures(ctx.Request().URL.String())
+		ctx = withCaptures(ctx, captures)
+	}
+
 	// authenticators
 	sub, err := r.sc.Execute(ctx)
 	if err != nil {
@@ -71,15 +93,129 @@ func (r *ruleImpl) Execute(ctx heimdall.Context) (*url.URL, error) {
 		return nil, err
 	}
 
-	return r.upstreamURL, nil
+	upstream := r.selectBackend(ctx, sub)
+	if upstream == nil || r.urlMatcher == nil {
+		return upstream, nil
+	}
+
+	return &capturingBackend{Backend: upstream, captures: captures}, nil
 }
 
-func (r *ruleImpl) MatchesURL(requestURL *url.URL) bool {
-	return r.urlMatcher.Match(requestURL.String())
+// selectBackend returns the backend of the first canary applicable to the given subject, or the
+// regular one, if there is no such canary.
+func (r *ruleImpl) selectBackend(ctx heimdall.Context, sub *subject.Subject) rule.Backend {
+	logger := zerolog.Ctx(ctx.AppContext())
+
+	for _, cnr := range r.canaries {
+		applies, err := cnr.applies(ctx, r.id, sub)
+		if err != nil {
+			logger.Warn().Err(err).Str("_canary", cnr.name).Msg("Failed evaluating canary condition")
+
+			continue
+		}
+
+		if applies {
+			logger.Debug().Str("_canary", cnr.name).Msg("Forwarding request to canary upstream")
+
+			trace.SpanFromContext(ctx.AppContext()).
+				SetAttributes(attribute.String("heimdall.upstream.canary", cnr.name))
+
+			return cnr.backend
+		}
+	}
+
+	return r.backend
+}
+
+func (r *ruleImpl) Matches(req *heimdall.Request) bool {
+	return r.urlMatcher.Match(req.URL.String()) && r.reqMatcher.Matches(req)
 }
 
 func (r *ruleImpl) MatchesMethod(method string) bool { return slices.Contains(r.methods, method) }
 
 func (r *ruleImpl) ID() string { return r.id }
 
+// startHealthChecks starts the active health checks of the upstream targets, if configured. These
+// run as long as the rule is part of the repository.
+func (r *ruleImpl) startHealthChecks() {
+	if bknd, ok := r.backend.(*backend); ok {
+		bknd.startHealthChecks()
+	}
+
+	for _, cnr := range r.canaries {
+		cnr.backend.startHealthChecks()
+	}
+}
+
+func (r *ruleImpl) stopHealthChecks() {
+	if bknd, ok := r.backend.(*backend); ok {
+		bknd.stopHealthChecks()
+	}
+
+	for _, cnr := range r.canaries {
+		cnr.backend.stopHealthChecks()
+	}
+}
+
 func (r *ruleImpl) SrcID() string { return r.srcID }
+
+// overlaps returns true if both rules may match the same request without any of them taking
+// precedence over the other one. Which of these rules is used in such cases depends on the order
+// they have been loaded in. The check is a heuristic: url patterns are considered overlapping if
+// they are equal, or if one of them matches the other one taken literally.
+func (r *ruleImpl) overlaps(other *ruleImpl) bool {
+	if r.priority != other.priority ||
+		len(r.urlPrefix) != len(other.urlPrefix) ||
+		len(r.reqMatcher) != len(other.reqMatcher) {
+		return false
+	}
+
+	if !slices.ContainsFunc(r.methods, other.MatchesMethod) || !r.hasSameConditionsAs(other) {
+		return false
+	}
+
+	return r.match.URL == other.match.URL ||
+		r.urlMatcher.Match(other.match.URL) ||
+		other.urlMatcher.Match(r.match.URL)
+}
+
+func (r *ruleImpl) hasSameConditionsAs(other *ruleImpl) bool {
+	return r.match.Host == other.match.Host &&
+		reflect.DeepEqual(r.match.Headers, other.match.Headers) &&
+		reflect.DeepEqual(r.match.QueryParams, other.match.QueryParams) &&
+		reflect.DeepEqual(r.match.ClientCIDRs, other.match.ClientCIDRs)
+}
+
+// capturesContext makes the request with the captured values of the named parameters available
+// to the mechanisms of a rule.
+type capturesContext struct {
+	heimdall.Context
+
+	req *heimdall.Request
+}
+
+func withCaptures(ctx heimdall.Context, captures map[string]string) heimdall.Context {
+	req := *ctx.Request()
+	reqURL := *req.URL
+	reqURL.Captures = captures
+	req.URL = &reqURL
+
+	return &capturesContext{Context: ctx, req: &req}
+}
+
+func (c *capturesContext) Request() *heimdall.Request { return c.req }
+
+// capturingBackend makes the captured values of the named parameters available while creating
+// the upstream url, e.g. for templated paths.
+type capturingBackend struct {
+	rule.Backend
+
+	captures map[string]string
+}
+
+func (b *capturingBackend) CreateURL(value *heimdall.URL) (*url.URL, error) {
+	reqURL := *value
+	reqURL.Captures = b.captures
+
+	return b.Backend.CreateURL(&reqURL)
+}
@@ -18,16 +18,23 @@ package rules
 
 import (
 	"context"
+	"net/http"
 	"net/url"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/dadrus/heimdall/internal/heimdall"
 	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/config"
 	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
 	"github.com/dadrus/heimdall/internal/rules/mocks"
 	"github.com/dadrus/heimdall/internal/rules/patternmatcher"
+	"github.com/dadrus/heimdall/internal/rules/rule"
+	rulemocks "github.com/dadrus/heimdall/internal/rules/rule/mocks"
 	"github.com/dadrus/heimdall/internal/x/testsupport"
 )
 
@@ -123,7 +130,7 @@ func TestRuleMatchURL(t *testing.T) {
 			rul := &ruleImpl{urlMatcher: tc.matcher(t)}
 
 			// WHEN
-			matched := rul.MatchesURL(tc.toBeMatched)
+			matched := rul.Matches(&heimdall.Request{URL: &heimdall.URL{URL: *tc.toBeMatched}})
 
 			// THEN
 			tc.assert(t, matched)
@@ -131,12 +138,271 @@ func TestRuleMatchURL(t *testing.T) {
 	}
 }
 
+func TestRuleMatchRequest(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc           string
+		matcher      config.Matcher
+		url          string
+		peerAddress  string
+		forwardedFor []string
+		headers      map[string]string
+		matched      bool
+	}{
+		{
+			uc:      "url only",
+			matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"},
+			url:     "http://foo.bar/baz",
+			matched: true,
+		},
+		{
+			uc:      "matching host",
+			matcher: config.Matcher{URL: "<**>", Strategy: "glob", Host: "<*>.bar"},
+			url:     "http://foo.bar/baz",
+			matched: true,
+		},
+		{
+			uc:      "not matching host",
+			matcher: config.Matcher{URL: "<**>", Strategy: "glob", Host: "<*>.baz"},
+			url:     "http://foo.bar/baz",
+			matched: false,
+		},
+		{
+			uc: "matching header",
+			matcher: config.Matcher{
+				URL: "<**>", Strategy: "glob",
+				Headers: map[string][]string{"accept": {"text/html", "application/grpc<*>"}},
+			},
+			url:     "http://foo.bar/baz",
+			headers: map[string]string{"Accept": "application/grpc+proto"},
+			matched: true,
+		},
+		{
+			uc: "not matching header",
+			matcher: config.Matcher{
+				URL: "<**>", Strategy: "glob",
+				Headers: map[string][]string{"Accept": {"application/grpc<*>"}},
+			},
+			url:     "http://foo.bar/baz",
+			headers: map[string]string{"Accept": "application/json"},
+			matched: false,
+		},
+		{
+			uc: "matching query parameter",
+			matcher: config.Matcher{
+				URL: "<.*>", Strategy: "regex",
+				QueryParams: map[string][]string{"version": {"<v[12]>"}},
+			},
+			url:     "http://foo.bar/baz?version=v2",
+			matched: true,
+		},
+		{
+			uc: "missing query parameter",
+			matcher: config.Matcher{
+				URL: "<.*>", Strategy: "regex",
+				QueryParams: map[string][]string{"version": {"<v[12]>"}},
+			},
+			url:     "http://foo.bar/baz",
+			matched: false,
+		},
+		{
+			uc: "matching client ip",
+			matcher: config.Matcher{
+				URL: "<**>", Strategy: "glob", ClientCIDRs: []string{"10.0.0.0/8", "192.168.1.0/24"},
+			},
+			url:         "http://foo.bar/baz",
+			peerAddress: "192.168.1.10",
+			matched:     true,
+		},
+		{
+			uc: "not matching client ip",
+			matcher: config.Matcher{
+				URL: "<**>", Strategy: "glob", ClientCIDRs: []string{"10.0.0.0/8"},
+			},
+			url:         "http://foo.bar/baz",
+			peerAddress: "192.168.1.10",
+			matched:     false,
+		},
+		{
+			uc: "spoofed X-Forwarded-For entry does not match",
+			matcher: config.Matcher{
+				URL: "<**>", Strategy: "glob", ClientCIDRs: []string{"10.0.0.0/8"},
+			},
+			url:          "http://foo.bar/baz",
+			peerAddress:  "192.168.1.10",
+			forwardedFor: []string{"10.0.0.1", "192.168.1.10"},
+			matched:      false,
+		},
+		{
+			uc: "all conditions must match",
+			matcher: config.Matcher{
+				URL: "<**>", Strategy: "glob", Host: "foo.bar", ClientCIDRs: []string{"10.0.0.0/8"},
+			},
+			url:         "http://foo.bar/baz",
+			peerAddress: "192.168.1.10",
+			matched:     false,
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			urlMatcher, err := patternmatcher.NewPatternMatcher(tc.matcher.Strategy, tc.matcher.URL)
+			require.NoError(t, err)
+
+			reqMatcher, err := newRequestMatcher(tc.matcher)
+			require.NoError(t, err)
+
+			reqURL, err := url.Parse(tc.url)
+			require.NoError(t, err)
+
+			reqf := heimdallmocks.NewRequestFunctionsMock(t)
+			reqf.EXPECT().Header(mock.Anything).RunAndReturn(func(name string) string {
+				return tc.headers[name]
+			}).Maybe()
+			reqf.EXPECT().PeerAddress().Return(tc.peerAddress).Maybe()
+
+			rul := &ruleImpl{urlMatcher: urlMatcher, reqMatcher: reqMatcher}
+
+			// WHEN
+			matched := rul.Matches(&heimdall.Request{
+				RequestFunctions: reqf,
+				URL:              &heimdall.URL{URL: *reqURL},
+				ClientIP:         tc.forwardedFor,
+			})
+
+			// THEN
+			assert.Equal(t, tc.matched, matched)
+		})
+	}
+}
+
+func TestRuleOverlaps(t *testing.T) {
+	t.Parallel()
+
+	type ruleDef struct {
+		matcher  config.Matcher
+		methods  []string
+		priority int
+	}
+
+	newRule := func(t *testing.T, def ruleDef) *ruleImpl {
+		t.Helper()
+
+		urlMatcher, err := patternmatcher.NewPatternMatcher(def.matcher.Strategy, def.matcher.URL)
+		require.NoError(t, err)
+
+		reqMatcher, err := newRequestMatcher(def.matcher)
+		require.NoError(t, err)
+
+		return &ruleImpl{
+			urlMatcher: urlMatcher,
+			urlPrefix:  patternmatcher.StaticPrefix(def.matcher.Strategy, def.matcher.URL),
+			reqMatcher: reqMatcher,
+			match:      def.matcher,
+			methods:    def.methods,
+			priority:   def.priority,
+		}
+	}
+
+	for _, tc := range []struct {
+		uc       string
+		first    ruleDef
+		second   ruleDef
+		overlaps bool
+	}{
+		{
+			uc:       "same patterns",
+			first:    ruleDef{matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"}, methods: []string{"GET"}},
+			second:   ruleDef{matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"}, methods: []string{"GET"}},
+			overlaps: true,
+		},
+		{
+			uc:    "one pattern matching the other one",
+			first: ruleDef{matcher: config.Matcher{URL: "http://foo.bar/<*>", Strategy: "glob"}, methods: []string{"GET"}},
+			second: ruleDef{
+				matcher: config.Matcher{URL: "http://foo.bar/<{a,b}*>", Strategy: "glob"}, methods: []string{"GET"},
+			},
+			overlaps: true,
+		},
+		{
+			uc:       "not matching patterns with the same prefix length",
+			first:    ruleDef{matcher: config.Matcher{URL: "http://foo.bar/api", Strategy: "glob"}, methods: []string{"GET"}},
+			second:   ruleDef{matcher: config.Matcher{URL: "http://foo.baz/api", Strategy: "glob"}, methods: []string{"GET"}},
+			overlaps: false,
+		},
+		{
+			uc:    "different prefix length",
+			first: ruleDef{matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"}, methods: []string{"GET"}},
+			second: ruleDef{
+				matcher: config.Matcher{URL: "http://foo.bar/api/<**>", Strategy: "glob"}, methods: []string{"GET"},
+			},
+			overlaps: false,
+		},
+		{
+			uc:    "different priorities",
+			first: ruleDef{matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"}, methods: []string{"GET"}},
+			second: ruleDef{
+				matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"}, methods: []string{"GET"}, priority: 1,
+			},
+			overlaps: false,
+		},
+		{
+			uc:       "different methods",
+			first:    ruleDef{matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"}, methods: []string{"GET"}},
+			second:   ruleDef{matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"}, methods: []string{"POST"}},
+			overlaps: false,
+		},
+		{
+			uc: "same further conditions",
+			first: ruleDef{
+				matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob", Host: "foo.bar"},
+				methods: []string{"GET"},
+			},
+			second: ruleDef{
+				matcher: config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob", Host: "foo.bar"},
+				methods: []string{"GET", "POST"},
+			},
+			overlaps: true,
+		},
+		{
+			uc: "different further conditions",
+			first: ruleDef{
+				matcher: config.Matcher{
+					URL: "http://foo.bar/<**>", Strategy: "glob", Headers: map[string][]string{"Accept": {"text/html"}},
+				},
+				methods: []string{"GET"},
+			},
+			second: ruleDef{
+				matcher: config.Matcher{
+					URL: "http://foo.bar/<**>", Strategy: "glob", Headers: map[string][]string{"Accept": {"text/xml"}},
+				},
+				methods: []string{"GET"},
+			},
+			overlaps: false,
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			first := newRule(t, tc.first)
+			second := newRule(t, tc.second)
+
+			// WHEN
+			overlaps1 := first.overlaps(second)
+			overlaps2 := second.overlaps(first)
+
+			// THEN
+			assert.Equal(t, tc.overlaps, overlaps1)
+			assert.Equal(t, tc.overlaps, overlaps2)
+		})
+	}
+}
+
 func TestRuleExecute(t *testing.T) {
 	t.Parallel()
 
 	for _, tc := range []struct {
 		uc             string
-		upstreamURL    *url.URL
+		backend        rule.Backend
 		configureMocks func(
 			t *testing.T,
 			ctx *heimdallmocks.ContextMock,
@@ -145,11 +411,11 @@ func TestRuleExecute(t *testing.T) {
 			unifier *mocks.SubjectHandlerMock,
 			errHandler *mocks.ErrorHandlerMock,
 		)
-		assert func(t *testing.T, err error, upstreamURL *url.URL)
+		assert func(t *testing.T, err error, upstream rule.Backend)
 	}{
 		{
-			uc:          "authenticator fails, but error handler succeeds",
-			upstreamURL: &url.URL{Scheme: "http", Host: "test.local", Path: "foo"},
+			uc:      "authenticator fails, but error handler succeeds",
+			backend: &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "test.local"}}}},
 			configureMocks: func(t *testing.T, ctx *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
 				authorizer *mocks.SubjectHandlerMock, unifier *mocks.SubjectHandlerMock,
 				errHandler *mocks.ErrorHandlerMock,
@@ -160,16 +426,16 @@ func TestRuleExecute(t *testing.T) {
 				authenticator.EXPECT().IsFallbackOnErrorAllowed().Return(false)
 				errHandler.EXPECT().Execute(ctx, testsupport.ErrTestPurpose).Return(true, nil)
 			},
-			assert: func(t *testing.T, err error, upstreamURL *url.URL) {
+			assert: func(t *testing.T, err error, upstream rule.Backend) {
 				t.Helper()
 
 				require.NoError(t, err)
-				assert.Nil(t, upstreamURL)
+				assert.Nil(t, upstream)
 			},
 		},
 		{
-			uc:          "authenticator fails, and error handler fails",
-			upstreamURL: &url.URL{Scheme: "http", Host: "test.local", Path: "foo"},
+			uc:      "authenticator fails, and error handler fails",
+			backend: &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "test.local"}}}},
 			configureMocks: func(t *testing.T, ctx *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
 				authorizer *mocks.SubjectHandlerMock, unifier *mocks.SubjectHandlerMock,
 				errHandler *mocks.ErrorHandlerMock,
@@ -180,17 +446,17 @@ func TestRuleExecute(t *testing.T) {
 				authenticator.EXPECT().IsFallbackOnErrorAllowed().Return(false)
 				errHandler.EXPECT().Execute(ctx, testsupport.ErrTestPurpose).Return(true, testsupport.ErrTestPurpose2)
 			},
-			assert: func(t *testing.T, err error, upstreamURL *url.URL) {
+			assert: func(t *testing.T, err error, upstream rule.Backend) {
 				t.Helper()
 
 				require.Error(t, err)
 				assert.ErrorIs(t, err, testsupport.ErrTestPurpose2)
-				assert.Nil(t, upstreamURL)
+				assert.Nil(t, upstream)
 			},
 		},
 		{
-			uc:          "authenticator succeeds, authorizer fails, but error handler succeeds",
-			upstreamURL: &url.URL{Scheme: "http", Host: "test.local", Path: "foo"},
+			uc:      "authenticator succeeds, authorizer fails, but error handler succeeds",
+			backend: &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "test.local"}}}},
 			configureMocks: func(t *testing.T, ctx *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
 				authorizer *mocks.SubjectHandlerMock, unifier *mocks.SubjectHandlerMock,
 				errHandler *mocks.ErrorHandlerMock,
@@ -204,16 +470,16 @@ func TestRuleExecute(t *testing.T) {
 				authorizer.EXPECT().ContinueOnError().Return(false)
 				errHandler.EXPECT().Execute(ctx, testsupport.ErrTestPurpose).Return(true, nil)
 			},
-			assert: func(t *testing.T, err error, upstreamURL *url.URL) {
+			assert: func(t *testing.T, err error, upstream rule.Backend) {
 				t.Helper()
 
 				require.NoError(t, err)
-				assert.Nil(t, upstreamURL)
+				assert.Nil(t, upstream)
 			},
 		},
 		{
-			uc:          "authenticator succeeds, authorizer fails and error handler fails",
-			upstreamURL: &url.URL{Scheme: "http", Host: "test.local", Path: "foo"},
+			uc:      "authenticator succeeds, authorizer fails and error handler fails",
+			backend: &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "test.local"}}}},
 			configureMocks: func(t *testing.T, ctx *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
 				authorizer *mocks.SubjectHandlerMock, unifier *mocks.SubjectHandlerMock,
 				errHandler *mocks.ErrorHandlerMock,
@@ -227,17 +493,17 @@ func TestRuleExecute(t *testing.T) {
 				authorizer.EXPECT().ContinueOnError().Return(false)
 				errHandler.EXPECT().Execute(ctx, testsupport.ErrTestPurpose).Return(true, testsupport.ErrTestPurpose2)
 			},
-			assert: func(t *testing.T, err error, upstreamURL *url.URL) {
+			assert: func(t *testing.T, err error, upstream rule.Backend) {
 				t.Helper()
 
 				require.Error(t, err)
 				assert.ErrorIs(t, err, testsupport.ErrTestPurpose2)
-				assert.Nil(t, upstreamURL)
+				assert.Nil(t, upstream)
 			},
 		},
 		{
-			uc:          "authenticator succeeds, authorizer succeeds, unifier fails, but error handler succeeds",
-			upstreamURL: &url.URL{Scheme: "http", Host: "test.local", Path: "foo"},
+			uc:      "authenticator succeeds, authorizer succeeds, unifier fails, but error handler succeeds",
+			backend: &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "test.local"}}}},
 			configureMocks: func(t *testing.T, ctx *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
 				authorizer *mocks.SubjectHandlerMock, unifier *mocks.SubjectHandlerMock,
 				errHandler *mocks.ErrorHandlerMock,
@@ -252,16 +518,16 @@ func TestRuleExecute(t *testing.T) {
 				unifier.EXPECT().ContinueOnError().Return(false)
 				errHandler.EXPECT().Execute(ctx, testsupport.ErrTestPurpose).Return(true, nil)
 			},
-			assert: func(t *testing.T, err error, upstreamURL *url.URL) {
+			assert: func(t *testing.T, err error, upstream rule.Backend) {
 				t.Helper()
 
 				require.NoError(t, err)
-				assert.Nil(t, upstreamURL)
+				assert.Nil(t, upstream)
 			},
 		},
 		{
-			uc:          "authenticator succeeds, authorizer succeeds, unifier fails and error handler fails",
-			upstreamURL: &url.URL{Scheme: "http", Host: "test.local", Path: "foo"},
+			uc:      "authenticator succeeds, authorizer succeeds, unifier fails and error handler fails",
+			backend: &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "test.local"}}}},
 			configureMocks: func(t *testing.T, ctx *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
 				authorizer *mocks.SubjectHandlerMock, unifier *mocks.SubjectHandlerMock,
 				errHandler *mocks.ErrorHandlerMock,
@@ -276,17 +542,17 @@ func TestRuleExecute(t *testing.T) {
 				unifier.EXPECT().ContinueOnError().Return(false)
 				errHandler.EXPECT().Execute(ctx, testsupport.ErrTestPurpose).Return(true, testsupport.ErrTestPurpose2)
 			},
-			assert: func(t *testing.T, err error, upstreamURL *url.URL) {
+			assert: func(t *testing.T, err error, upstream rule.Backend) {
 				t.Helper()
 
 				require.Error(t, err)
 				assert.ErrorIs(t, err, testsupport.ErrTestPurpose2)
-				assert.Nil(t, upstreamURL)
+				assert.Nil(t, upstream)
 			},
 		},
 		{
-			uc:          "all handler succeed",
-			upstreamURL: &url.URL{Scheme: "http", Host: "test.local", Path: "foo"},
+			uc:      "all handler succeed",
+			backend: &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "test.local"}}}},
 			configureMocks: func(t *testing.T, ctx *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
 				authorizer *mocks.SubjectHandlerMock, unifier *mocks.SubjectHandlerMock,
 				errHandler *mocks.ErrorHandlerMock,
@@ -299,11 +565,11 @@ func TestRuleExecute(t *testing.T) {
 				authorizer.EXPECT().Execute(ctx, sub).Return(nil)
 				unifier.EXPECT().Execute(ctx, sub).Return(nil)
 			},
-			assert: func(t *testing.T, err error, upstreamURL *url.URL) {
+			assert: func(t *testing.T, err error, upstream rule.Backend) {
 				t.Helper()
 
 				require.NoError(t, err)
-				assert.Equal(t, &url.URL{Scheme: "http", Host: "test.local", Path: "foo"}, upstreamURL)
+				assert.Equal(t, &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "test.local"}}}}, upstream)
 			},
 		},
 	} {
@@ -318,20 +584,170 @@ func TestRuleExecute(t *testing.T) {
 			errHandler := mocks.NewErrorHandlerMock(t)
 
 			rul := &ruleImpl{
-				upstreamURL: tc.upstreamURL,
-				sc:          compositeSubjectCreator{authenticator},
-				sh:          compositeSubjectHandler{authorizer},
-				un:          compositeSubjectHandler{unifier},
-				eh:          compositeErrorHandler{errHandler},
+				backend: tc.backend,
+				sc:      compositeSubjectCreator{authenticator},
+				sh:      compositeSubjectHandler{authorizer},
+				un:      compositeSubjectHandler{unifier},
+				eh:      compositeErrorHandler{errHandler},
 			}
 
 			tc.configureMocks(t, ctx, authenticator, authorizer, unifier, errHandler)
 
 			// WHEN
-			upstreamURL, err := rul.Execute(ctx)
+			upstream, err := rul.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, upstream)
+		})
+	}
+}
+
+func TestRuleExecuteMakesCapturesAvailable(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	matcher, err := patternmatcher.NewPatternMatcher("glob", "http://foo.bar/api/:tenant/users/:id")
+	require.NoError(t, err)
+
+	req := &heimdall.Request{
+		URL: &heimdall.URL{URL: url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/acme/users/42"}},
+	}
+	sub := &subject.Subject{ID: "Foo"}
+	captures := map[string]string{"tenant": "acme", "id": "42"}
+
+	ctx := heimdallmocks.NewContextMock(t)
+	ctx.EXPECT().AppContext().Return(context.Background())
+	ctx.EXPECT().Request().Return(req)
+
+	authenticator := mocks.NewSubjectCreatorMock(t)
+	authenticator.EXPECT().Execute(mock.Anything).Return(sub, nil)
+
+	unifier := mocks.NewSubjectHandlerMock(t)
+	unifier.EXPECT().Execute(mock.Anything, sub).Run(func(ctx heimdall.Context, _ *subject.Subject) {
+		// THEN
+		assert.Equal(t, captures, ctx.Request().URL.Captures)
+	}).Return(nil)
+
+	bknd := rulemocks.NewBackendMock(t)
+	bknd.EXPECT().CreateURL(mock.MatchedBy(func(value *heimdall.URL) bool {
+		return value.Path == "/api/acme/users/42" && reflect.DeepEqual(captures, value.Captures)
+	})).Return(&url.URL{Scheme: "http", Host: "bar.foo"}, nil)
+
+	rul := &ruleImpl{
+		urlMatcher: matcher,
+		backend:    bknd,
+		sc:         compositeSubjectCreator{authenticator},
+		un:         compositeSubjectHandler{unifier},
+	}
+
+	// WHEN
+	upstream, err := rul.Execute(ctx)
+
+	// THEN
+	require.NoError(t, err)
+
+	// the shared request is not modified
+	assert.Nil(t, req.URL.Captures)
+
+	// but the captures are available to the upstream
+	_, err = upstream.CreateURL(req.URL)
+	require.NoError(t, err)
+}
+
+func TestRuleExecuteSelectsBackend(t *testing.T) {
+	t.Parallel()
+
+	regular := &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "regular.local"}}}}
+	canary1 := &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "canary1.local"}}}}
+	canary2 := &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "canary2.local"}}}}
+
+	for _, tc := range []struct {
+		uc       string
+		method   string
+		canaries func(t *testing.T) []*canary
+		expected *backend
+	}{
+		{
+			uc:       "without canaries",
+			method:   http.MethodGet,
+			canaries: func(t *testing.T) []*canary { t.Helper(); return nil },
+			expected: regular,
+		},
+		{
+			uc:     "no canary applies",
+			method: http.MethodPost,
+			canaries: func(t *testing.T) []*canary {
+				t.Helper()
+
+				cnr, err := newCanary(config.Canary{Name: "foo", If: "Request.Method == 'GET'"}, canary1)
+				require.NoError(t, err)
+
+				return []*canary{cnr}
+			},
+			expected: regular,
+		},
+		{
+			uc:     "first applicable canary wins",
+			method: http.MethodGet,
+			canaries: func(t *testing.T) []*canary {
+				t.Helper()
+
+				cnr1, err := newCanary(config.Canary{Name: "foo", If: "Request.Method == 'POST'"}, canary1)
+				require.NoError(t, err)
+
+				cnr2, err := newCanary(config.Canary{Name: "bar", If: "Request.Method == 'GET'"}, canary2)
+				require.NoError(t, err)
+
+				cnr3, err := newCanary(config.Canary{Name: "baz", Percentage: 100}, canary1)
+				require.NoError(t, err)
+
+				return []*canary{cnr1, cnr2, cnr3}
+			},
+			expected: canary2,
+		},
+		{
+			uc:     "canary with failing condition is skipped",
+			method: http.MethodGet,
+			canaries: func(t *testing.T) []*canary {
+				t.Helper()
+
+				cnr1, err := newCanary(config.Canary{Name: "foo", If: "Subject.Attributes.missing == 'bar'"}, canary1)
+				require.NoError(t, err)
+
+				cnr2, err := newCanary(config.Canary{Name: "bar", Percentage: 100}, canary2)
+				require.NoError(t, err)
+
+				return []*canary{cnr1, cnr2}
+			},
+			expected: canary2,
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			sub := &subject.Subject{ID: "Foo", Attributes: map[string]any{}}
+
+			ctx := heimdallmocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background())
+			ctx.EXPECT().Request().Return(&heimdall.Request{
+				Method: tc.method,
+				URL:    &heimdall.URL{URL: url.URL{Scheme: "http", Host: "foo.bar", Path: "/test"}},
+			}).Maybe()
+
+			authenticator := mocks.NewSubjectCreatorMock(t)
+			authenticator.EXPECT().Execute(ctx).Return(sub, nil)
+
+			rul := &ruleImpl{
+				backend:  regular,
+				canaries: tc.canaries(t),
+				sc:       compositeSubjectCreator{authenticator},
+			}
+
+			// WHEN
+			upstream, err := rul.Execute(ctx)
 
 			// THEN
-			tc.assert(t, err, upstreamURL)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, upstream)
 		})
 	}
 }
@@ -0,0 +1,263 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/dadrus/heimdall/internal/rules/rule"
+)
+
+// ruleIndex is an immutable radix tree over the static prefixes of the url patterns of the
+// loaded rules. Each modification results in a new index, sharing all untouched nodes with
+// the previous one. That way a published index can be used by any number of readers without
+// any locking, while the next version is being built.
+type ruleIndex struct {
+	root *indexNode
+	seq  uint64
+}
+
+type indexEntry struct {
+	seq          uint64
+	priority     int
+	prefixLength int
+	conditions   int
+	rule         rule.Rule
+}
+
+type indexNode struct {
+	label    string
+	children []*indexNode
+	entries  []indexEntry
+}
+
+func newRuleIndex() *ruleIndex { return &ruleIndex{root: &indexNode{}} }
+
+// add returns a new index containing the given rule in addition to all already indexed ones.
+func (idx *ruleIndex) add(rul rule.Rule) *ruleIndex {
+	seq := idx.seq + 1
+
+	return &ruleIndex{
+		root: idx.root.insert(indexKey(rul), newIndexEntry(seq, rul)),
+		seq:  seq,
+	}
+}
+
+// remove returns a new index without the given rule.
+func (idx *ruleIndex) remove(rul rule.Rule) *ruleIndex {
+	root, _ := idx.root.remove(indexKey(rul), rul)
+
+	return &ruleIndex{root: root, seq: idx.seq}
+}
+
+// replace returns a new index with the existing rule replaced by the updated one. The
+// updated rule keeps the position of the existing one in the insertion order.
+func (idx *ruleIndex) replace(existing, updated rule.Rule) *ruleIndex {
+	root, removed := idx.root.remove(indexKey(existing), existing)
+	seq, lastSeq := removed.seq, idx.seq
+
+	if removed.rule == nil {
+		lastSeq++
+		seq = lastSeq
+	}
+
+	return &ruleIndex{
+		root: root.insert(indexKey(updated), newIndexEntry(seq, updated)),
+		seq:  lastSeq,
+	}
+}
+
+// candidates returns all rules, the url patterns of which have a static prefix matching
+// the given value. The rules are ordered by their precedence (see indexEntry.precedes).
+func (idx *ruleIndex) candidates(value string) []rule.Rule {
+	var entries []indexEntry
+
+	node := idx.root
+
+	for {
+		entries = append(entries, node.entries...)
+
+		if len(value) == 0 {
+			break
+		}
+
+		pos, found := node.childIndex(value[0])
+		if !found || !strings.HasPrefix(value, node.children[pos].label) {
+			break
+		}
+
+		node = node.children[pos]
+		value = value[len(node.label):]
+	}
+
+	slices.SortFunc(entries, indexEntry.precedes)
+
+	rules := make([]rule.Rule, len(entries))
+	for i, entry := range entries {
+		rules[i] = entry.rule
+	}
+
+	return rules
+}
+
+func (n *indexNode) clone() *indexNode {
+	return &indexNode{
+		label:    n.label,
+		children: slices.Clone(n.children),
+		entries:  slices.Clone(n.entries),
+	}
+}
+
+func (n *indexNode) isEmpty() bool { return len(n.entries) == 0 && len(n.children) == 0 }
+
+func (n *indexNode) childIndex(char byte) (int, bool) {
+	pos := sort.Search(len(n.children), func(i int) bool { return n.children[i].label[0] >= char })
+
+	return pos, pos < len(n.children) && n.children[pos].label[0] == char
+}
+
+func (n *indexNode) insert(key string, entry indexEntry) *indexNode {
+	cpy := n.clone()
+
+	if len(key) == 0 {
+		pos := sort.Search(len(cpy.entries), func(i int) bool { return entry.precedes(cpy.entries[i]) })
+		cpy.entries = slices.Insert(cpy.entries, pos, entry)
+
+		return cpy
+	}
+
+	pos, found := cpy.childIndex(key[0])
+	if !found {
+		cpy.children = slices.Insert(cpy.children, pos, &indexNode{label: key, entries: []indexEntry{entry}})
+
+		return cpy
+	}
+
+	child := cpy.children[pos]
+	common := commonPrefixLength(key, child.label)
+
+	if common == len(child.label) {
+		cpy.children[pos] = child.insert(key[common:], entry)
+
+		return cpy
+	}
+
+	// the key diverges within the label of the child. So the child has to be split
+	suffix := child.clone()
+	suffix.label = child.label[common:]
+
+	split := &indexNode{label: child.label[:common], children: []*indexNode{suffix}}
+	cpy.children[pos] = split.insert(key[common:], entry)
+
+	return cpy
+}
+
+// remove returns a copy of the node without the entry for the given rule. Rule ids are unique
+// within a rule set only, so entries are matched on the source and the id of their rules.
+func (n *indexNode) remove(key string, rul rule.Rule) (*indexNode, indexEntry) {
+	if len(key) == 0 {
+		pos := slices.IndexFunc(n.entries, func(entry indexEntry) bool { return sameRule(entry.rule, rul) })
+		if pos < 0 {
+			return n, indexEntry{}
+		}
+
+		removed := n.entries[pos]
+		cpy := n.clone()
+		cpy.entries = slices.Delete(cpy.entries, pos, pos+1)
+
+		return cpy, removed
+	}
+
+	pos, found := n.childIndex(key[0])
+	if !found || !strings.HasPrefix(key, n.children[pos].label) {
+		return n, indexEntry{}
+	}
+
+	child := n.children[pos]
+
+	updated, removed := child.remove(key[len(child.label):], rul)
+	if removed.rule == nil {
+		return n, removed
+	}
+
+	cpy := n.clone()
+
+	switch {
+	case updated.isEmpty():
+		cpy.children = slices.Delete(cpy.children, pos, pos+1)
+	case len(updated.entries) == 0 && len(updated.children) == 1:
+		// merge the child with its only remaining child to keep the tree compact
+		merged := updated.children[0].clone()
+		merged.label = updated.label + merged.label
+		cpy.children[pos] = merged
+	default:
+		cpy.children[pos] = updated
+	}
+
+	return cpy, removed
+}
+
+func commonPrefixLength(first, second string) int {
+	length := 0
+
+	for length < len(first) && length < len(second) && first[length] == second[length] {
+		length++
+	}
+
+	return length
+}
+
+func newIndexEntry(seq uint64, rul rule.Rule) indexEntry {
+	entry := indexEntry{seq: seq, rule: rul}
+
+	if impl, ok := rul.(*ruleImpl); ok {
+		entry.priority = impl.priority
+		entry.prefixLength = len(impl.urlPrefix)
+		entry.conditions = len(impl.reqMatcher)
+	}
+
+	return entry
+}
+
+// precedes returns true if the rule of the entry must be matched before the rule of the other
+// entry. Rules with higher priority come first. Rules with the same priority are ordered by their
+// specificity, which is defined by the length of the static prefix of their url patterns and the
+// number of further matching conditions. Only if these are equal as well, the rule added first
+// comes first.
+func (e indexEntry) precedes(other indexEntry) bool {
+	switch {
+	case e.priority != other.priority:
+		return e.priority > other.priority
+	case e.prefixLength != other.prefixLength:
+		return e.prefixLength > other.prefixLength
+	case e.conditions != other.conditions:
+		return e.conditions > other.conditions
+	default:
+		return e.seq < other.seq
+	}
+}
+
+func indexKey(rul rule.Rule) string {
+	if impl, ok := rul.(*ruleImpl); ok {
+		return impl.urlPrefix
+	}
+
+	return ""
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dadrus/heimdall/internal/rules/rule"
+)
+
+func ruleIDs(rules []rule.Rule) []string {
+	ids := make([]string, len(rules))
+	for i, rul := range rules {
+		ids[i] = rul.ID()
+	}
+
+	return ids
+}
+
+func TestRuleIndexCandidates(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	idx := newRuleIndex().
+		add(&ruleImpl{id: "1", urlPrefix: "http://foo.bar/api/v1/"}).
+		add(&ruleImpl{id: "2", urlPrefix: ""}).
+		add(&ruleImpl{id: "3", urlPrefix: "http://foo.bar/api/"}).
+		add(&ruleImpl{id: "4", urlPrefix: "http://foo.bar/api/v2/"}).
+		add(&ruleImpl{id: "5", urlPrefix: "http://foo.baz/"}).
+		add(&ruleImpl{id: "6", urlPrefix: "http://foo.bar/api/v1/"})
+
+	for _, tc := range []struct {
+		uc    string
+		value string
+		ids   []string
+	}{
+		{uc: "only rules without prefix", value: "https://foo.bar/api/v1/users", ids: []string{"2"}},
+		{uc: "multiple levels", value: "http://foo.bar/api/v1/users", ids: []string{"1", "6", "3", "2"}},
+		{uc: "sibling of split node", value: "http://foo.bar/api/v2/users", ids: []string{"4", "3", "2"}},
+		{uc: "partially matching label", value: "http://foo.bar/ap", ids: []string{"2"}},
+		{uc: "other branch", value: "http://foo.baz/", ids: []string{"5", "2"}},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			candidates := idx.candidates(tc.value)
+
+			// THEN
+			assert.Equal(t, tc.ids, ruleIDs(candidates))
+		})
+	}
+}
+
+func TestRuleIndexCandidatesPrecedence(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	idx := newRuleIndex().
+		add(&ruleImpl{id: "1", urlPrefix: "http://foo.bar/"}).
+		add(&ruleImpl{id: "2", urlPrefix: "http://foo.bar/api/"}).
+		add(&ruleImpl{id: "3", urlPrefix: "http://foo.bar/api/", reqMatcher: compositeRequestMatcher{&hostMatcher{}}}).
+		add(&ruleImpl{id: "4", urlPrefix: "", priority: 10}).
+		add(&ruleImpl{id: "5", urlPrefix: "http://foo.bar/", priority: 10}).
+		add(&ruleImpl{id: "6", urlPrefix: "http://foo.bar/api/", priority: -1}).
+		add(&ruleImpl{id: "7", urlPrefix: "http://foo.bar/api/"})
+
+	// WHEN
+	candidates := idx.candidates("http://foo.bar/api/users")
+
+	// THEN
+	assert.Equal(t, []string{"5", "4", "3", "2", "7", "1", "6"}, ruleIDs(candidates))
+}
+
+func TestRuleIndexModificationsDoNotAffectPreviousVersions(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	rul1 := &ruleImpl{id: "1", urlPrefix: "http://foo.bar/api/v1/"}
+	rul2 := &ruleImpl{id: "2", urlPrefix: "http://foo.bar/api/v2/"}
+	rul3 := &ruleImpl{id: "3", urlPrefix: "http://foo.bar/"}
+
+	idx1 := newRuleIndex().add(rul1).add(rul2).add(rul3)
+
+	// WHEN
+	idx2 := idx1.remove(rul2)
+
+	// THEN
+	assert.Equal(t, []string{"2", "3"}, ruleIDs(idx1.candidates("http://foo.bar/api/v2/foo")))
+	assert.Equal(t, []string{"3"}, ruleIDs(idx2.candidates("http://foo.bar/api/v2/foo")))
+	assert.Equal(t, []string{"1", "3"}, ruleIDs(idx2.candidates("http://foo.bar/api/v1/foo")))
+
+	// WHEN
+	idx3 := idx2.remove(rul1).remove(rul3)
+
+	// THEN
+	assert.True(t, idx3.root.isEmpty())
+	assert.Equal(t, []string{"1", "3"}, ruleIDs(idx2.candidates("http://foo.bar/api/v1/foo")))
+}
+
+func TestRuleIndexReplaceKeepsMatchingOrder(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	idx := newRuleIndex().
+		add(&ruleImpl{id: "1", urlPrefix: "http://foo.bar/api/"}).
+		add(&ruleImpl{id: "2", urlPrefix: "http://foo.bar/"})
+
+	// WHEN
+	idx = idx.replace(
+		&ruleImpl{id: "1", urlPrefix: "http://foo.bar/api/"},
+		&ruleImpl{id: "1", urlPrefix: "http://foo.bar/"},
+	).add(&ruleImpl{id: "3", urlPrefix: "http://foo.bar/"})
+
+	// THEN
+	assert.Equal(t, []string{"1", "2", "3"}, ruleIDs(idx.candidates("http://foo.bar/api/foo")))
+	assert.Equal(t, "http://foo.bar/", idx.candidates("http://foo.bar/")[0].(*ruleImpl).urlPrefix) //nolint:forcetypeassert
+}
+
+func TestRuleIndexDistinguishesRulesWithSameIDFromDifferentSources(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	rulA := &ruleImpl{id: "r1", srcID: "A", urlPrefix: "http://foo/"}
+	rulB := &ruleImpl{id: "r1", srcID: "B", urlPrefix: "http://foo/"}
+
+	idx := newRuleIndex().add(rulA).add(rulB)
+
+	// WHEN
+	removed := idx.remove(rulB)
+	replaced := idx.replace(rulB, &ruleImpl{id: "r1", srcID: "B", urlPrefix: "http://foo/", priority: 1})
+
+	// THEN
+	candidates := removed.candidates("http://foo/bar")
+	assert.Len(t, candidates, 1)
+	assert.Same(t, rulA, candidates[0])
+
+	candidates = replaced.candidates("http://foo/bar")
+	assert.Len(t, candidates, 2)
+	assert.Equal(t, "B", candidates[0].SrcID())
+	assert.NotSame(t, rulB, candidates[0])
+	assert.Same(t, rulA, candidates[1])
+}
@@ -2,31 +2,44 @@ package rules
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/rs/zerolog"
+	"golang.org/x/exp/slices"
 
-	"github.com/dadrus/heimdall/internal/heimdall"
+	config2 "github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/rules/config"
 	"github.com/dadrus/heimdall/internal/rules/event"
 	"github.com/dadrus/heimdall/internal/rules/rule"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
-var ErrUnsupportedRuleSetVersion = errors.New("unsupported rule set version")
+var (
+	ErrUnsupportedRuleSetVersion = errors.New("unsupported rule set version")
+	ErrOverlappingRules          = errors.New("overlapping rules")
+)
 
 type ruleSetProcessor struct {
 	q event.RuleSetChangedEventQueue
 	f rule.Factory
 	l zerolog.Logger
+
+	failOnOverlap bool
+
+	// the rules of all known rule sets by their source. Used to detect overlapping rules.
+	rules map[string][]rule.Rule
+	mutex sync.Mutex
 }
 
 func NewRuleSetProcessor(
-	queue event.RuleSetChangedEventQueue, factory rule.Factory, logger zerolog.Logger,
+	queue event.RuleSetChangedEventQueue, factory rule.Factory, conf *config2.Configuration, logger zerolog.Logger,
 ) rule.SetProcessor {
 	return &ruleSetProcessor{
-		q: queue,
-		f: factory,
-		l: logger,
+		q:             queue,
+		f:             factory,
+		l:             logger,
+		failOnOverlap: conf.Rules.FailOnOverlap,
+		rules:         make(map[string][]rule.Rule),
 	}
 }
 
@@ -34,19 +47,60 @@ func (p *ruleSetProcessor) isVersionSupported(version string) bool {
 	return version == config.CurrentRuleSetVersion
 }
 
-func (p *ruleSetProcessor) loadRules(ruleSet *config.RuleSet) ([]rule.Rule, error) {
-	rules := make([]rule.Rule, len(ruleSet.Rules))
+// registerRules remembers the given rules as the ones of the source of the given rule set, after
+// having checked them for overlaps with the rules from all other sources, except the ones, which
+// are obsoleted by the rule set. Overlaps are reported as warnings, or as errors if configured, in
+// which case the rules are not registered.
+func (p *ruleSetProcessor) registerRules(ruleSet *config.RuleSet, rules []rule.Rule) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 
-	for idx, rc := range ruleSet.Rules {
-		rul, err := p.f.CreateRule(ruleSet.Version, ruleSet.Source, rc)
-		if err != nil {
-			return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed loading rule").CausedBy(err)
+	srcID := ruleSet.Source
+
+	for otherSrcID, others := range p.rules {
+		if otherSrcID == srcID || slices.Contains(ruleSet.Obsoletes, otherSrcID) {
+			continue
 		}
 
-		rules[idx] = rul
+		for _, rul := range rules {
+			for _, other := range others {
+				if !rulesOverlap(rul, other) {
+					continue
+				}
+
+				if p.failOnOverlap {
+					return errorchain.NewWithMessagef(ErrOverlappingRules,
+						"rule ID=%s from %s overlaps with rule ID=%s from %s",
+						rul.ID(), srcID, other.ID(), otherSrcID)
+				}
+
+				p.l.Warn().
+					Str("_src", srcID).
+					Str("_id", rul.ID()).
+					Str("_overlapping_src", otherSrcID).
+					Str("_overlapping_id", other.ID()).
+					Msg("Rule overlaps with a rule from another rule set. Consider setting a priority")
+			}
+		}
 	}
 
-	return rules, nil
+	p.rules[srcID] = rules
+
+	return nil
+}
+
+func rulesOverlap(first, second rule.Rule) bool {
+	firstImpl, ok := first.(*ruleImpl)
+	if !ok {
+		return false
+	}
+
+	secondImpl, ok := second.(*ruleImpl)
+	if !ok {
+		return false
+	}
+
+	return firstImpl.overlaps(secondImpl)
 }
 
 func (p *ruleSetProcessor) OnCreated(ruleSet *config.RuleSet) error {
@@ -54,13 +108,18 @@ func (p *ruleSetProcessor) OnCreated(ruleSet *config.RuleSet) error {
 		return errorchain.NewWithMessage(ErrUnsupportedRuleSetVersion, ruleSet.Version)
 	}
 
-	rules, err := p.loadRules(ruleSet)
+	rules, err := rule.CreateRules(p.f, ruleSet)
 	if err != nil {
 		return err
 	}
 
+	if err = p.registerRules(ruleSet, rules); err != nil {
+		return err
+	}
+
 	evt := event.RuleSetChanged{
 		Source:     ruleSet.Source,
+		Revision:   ruleSet.Revision,
 		Name:       ruleSet.Name,
 		Rules:      rules,
 		ChangeType: event.Create,
@@ -76,13 +135,18 @@ func (p *ruleSetProcessor) OnUpdated(ruleSet *config.RuleSet) error {
 		return errorchain.NewWithMessage(ErrUnsupportedRuleSetVersion, ruleSet.Version)
 	}
 
-	rules, err := p.loadRules(ruleSet)
+	rules, err := rule.CreateRules(p.f, ruleSet)
 	if err != nil {
 		return err
 	}
 
+	if err = p.registerRules(ruleSet, rules); err != nil {
+		return err
+	}
+
 	evt := event.RuleSetChanged{
 		Source:     ruleSet.Source,
+		Revision:   ruleSet.Revision,
 		Name:       ruleSet.Name,
 		Rules:      rules,
 		ChangeType: event.Update,
@@ -94,6 +158,10 @@ func (p *ruleSetProcessor) OnUpdated(ruleSet *config.RuleSet) error {
 }
 
 func (p *ruleSetProcessor) OnDeleted(ruleSet *config.RuleSet) error {
+	p.mutex.Lock()
+	delete(p.rules, ruleSet.Source)
+	p.mutex.Unlock()
+
 	evt := event.RuleSetChanged{
 		Source:     ruleSet.Source,
 		Name:       ruleSet.Name,
@@ -106,9 +174,14 @@ func (p *ruleSetProcessor) OnDeleted(ruleSet *config.RuleSet) error {
 }
 
 func (p *ruleSetProcessor) sendEvent(evt event.RuleSetChanged) {
-	p.l.Info().
+	logEvent := p.l.Info().
 		Str("_src", evt.Source).
-		Str("_type", evt.ChangeType.String()).
-		Msg("Rule set changed")
+		Str("_type", evt.ChangeType.String())
+
+	if len(evt.Revision) != 0 {
+		logEvent = logEvent.Str("_revision", evt.Revision)
+	}
+
+	logEvent.Msg("Rule set changed")
 	p.q <- evt
 }
@@ -8,8 +8,11 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	config2 "github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/rules/config"
 	"github.com/dadrus/heimdall/internal/rules/event"
+	"github.com/dadrus/heimdall/internal/rules/patternmatcher"
 	"github.com/dadrus/heimdall/internal/rules/rule/mocks"
 	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/testsupport"
@@ -34,6 +37,21 @@ func TestRuleSetProcessorOnCreated(t *testing.T) {
 				assert.ErrorIs(t, err, ErrUnsupportedRuleSetVersion)
 			},
 		},
+		{
+			uc: "error while expanding templates",
+			ruleset: &config.RuleSet{
+				Version: config.CurrentRuleSetVersion,
+				Rules:   []config.Rule{{ID: "foo", Extends: "bar"}},
+			},
+			assert: func(t *testing.T, err error, queue event.RuleSetChangedEventQueue) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unknown template")
+				assert.Empty(t, queue)
+			},
+		},
 		{
 			uc:      "error while loading rule set",
 			ruleset: &config.RuleSet{Version: config.CurrentRuleSetVersion, Rules: []config.Rule{{ID: "foo"}}},
@@ -54,7 +72,7 @@ func TestRuleSetProcessorOnCreated(t *testing.T) {
 		{
 			uc: "successful",
 			ruleset: &config.RuleSet{
-				MetaData: config.MetaData{Source: "test"},
+				MetaData: config.MetaData{Source: "test", Revision: "4b825dc"},
 				Version:  config.CurrentRuleSetVersion,
 				Name:     "foobar",
 				Rules:    []config.Rule{{ID: "foo"}},
@@ -74,6 +92,7 @@ func TestRuleSetProcessorOnCreated(t *testing.T) {
 				require.Len(t, evt.Rules, 1)
 				assert.Equal(t, event.Create, evt.ChangeType)
 				assert.Equal(t, "test", evt.Source)
+				assert.Equal(t, "4b825dc", evt.Revision)
 				assert.Equal(t, "foobar", evt.Name)
 
 				assert.Equal(t, &mocks.RuleMock{}, evt.Rules[0])
@@ -91,7 +110,7 @@ func TestRuleSetProcessorOnCreated(t *testing.T) {
 			factory := mocks.NewFactoryMock(t)
 			configureFactory(t, factory)
 
-			processor := NewRuleSetProcessor(queue, factory, log.Logger)
+			processor := NewRuleSetProcessor(queue, factory, &config2.Configuration{}, log.Logger)
 
 			// WHEN
 			err := processor.OnCreated(tc.ruleset)
@@ -179,7 +198,7 @@ func TestRuleSetProcessorOnUpdated(t *testing.T) {
 			factory := mocks.NewFactoryMock(t)
 			configureFactory(t, factory)
 
-			processor := NewRuleSetProcessor(queue, factory, log.Logger)
+			processor := NewRuleSetProcessor(queue, factory, &config2.Configuration{}, log.Logger)
 
 			// WHEN
 			err := processor.OnUpdated(tc.ruleset)
@@ -221,7 +240,7 @@ func TestRuleSetProcessorOnDeleted(t *testing.T) {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEM
 			queue := make(event.RuleSetChangedEventQueue, 10)
-			processor := NewRuleSetProcessor(queue, mocks.NewFactoryMock(t), log.Logger)
+			processor := NewRuleSetProcessor(queue, mocks.NewFactoryMock(t), &config2.Configuration{}, log.Logger)
 
 			// WHEN
 			err := processor.OnDeleted(tc.ruleset)
@@ -231,3 +250,150 @@ func TestRuleSetProcessorOnDeleted(t *testing.T) {
 		})
 	}
 }
+
+func TestRuleSetProcessorOverlappingRules(t *testing.T) {
+	t.Parallel()
+
+	newRule := func(t *testing.T, id, srcID string) *ruleImpl {
+		t.Helper()
+
+		matcher, err := patternmatcher.NewPatternMatcher("glob", "http://foo.bar/<**>")
+		require.NoError(t, err)
+
+		return &ruleImpl{
+			id:         id,
+			srcID:      srcID,
+			urlMatcher: matcher,
+			urlPrefix:  "http://foo.bar/",
+			match:      config.Matcher{URL: "http://foo.bar/<**>", Strategy: "glob"},
+			methods:    []string{"GET"},
+		}
+	}
+
+	for _, tc := range []struct {
+		uc            string
+		failOnOverlap bool
+		obsoletes     []string
+		assert        func(t *testing.T, err error, queue event.RuleSetChangedEventQueue)
+	}{
+		{
+			uc: "overlaps are reported as warnings",
+			assert: func(t *testing.T, err error, queue event.RuleSetChangedEventQueue) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, queue, 2)
+			},
+		},
+		{
+			uc:            "overlaps are reported as errors",
+			failOnOverlap: true,
+			assert: func(t *testing.T, err error, queue event.RuleSetChangedEventQueue) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, ErrOverlappingRules)
+				assert.Contains(t, err.Error(), "rule ID=bar from test2 overlaps with rule ID=foo from test1")
+				require.Len(t, queue, 1)
+			},
+		},
+		{
+			uc:            "overlaps with rules from obsoleted rule sets are ignored",
+			failOnOverlap: true,
+			obsoletes:     []string{"test1"},
+			assert: func(t *testing.T, err error, queue event.RuleSetChangedEventQueue) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, queue, 2)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			queue := make(event.RuleSetChangedEventQueue, 10)
+
+			factory := mocks.NewFactoryMock(t)
+			factory.EXPECT().CreateRule(config.CurrentRuleSetVersion, "test1", mock.Anything).
+				Return(newRule(t, "foo", "test1"), nil)
+			factory.EXPECT().CreateRule(config.CurrentRuleSetVersion, "test2", mock.Anything).
+				Return(newRule(t, "bar", "test2"), nil)
+
+			processor := NewRuleSetProcessor(queue, factory,
+				&config2.Configuration{Rules: config2.Rules{FailOnOverlap: tc.failOnOverlap}}, log.Logger)
+
+			err := processor.OnCreated(&config.RuleSet{
+				MetaData: config.MetaData{Source: "test1"},
+				Version:  config.CurrentRuleSetVersion,
+				Rules:    []config.Rule{{ID: "foo"}},
+			})
+			require.NoError(t, err)
+
+			// WHEN
+			err = processor.OnCreated(&config.RuleSet{
+				MetaData: config.MetaData{Source: "test2", Obsoletes: tc.obsoletes},
+				Version:  config.CurrentRuleSetVersion,
+				Rules:    []config.Rule{{ID: "bar"}},
+			})
+
+			// THEN
+			tc.assert(t, err, queue)
+		})
+	}
+}
+
+func TestRuleSetProcessorForgetsRulesOfDeletedRuleSets(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	queue := make(event.RuleSetChangedEventQueue, 10)
+	factory := mocks.NewFactoryMock(t)
+	factory.EXPECT().CreateRule(config.CurrentRuleSetVersion, "test", mock.Anything).
+		Return(&mocks.RuleMock{}, nil)
+
+	processor := NewRuleSetProcessor(queue, factory, &config2.Configuration{}, log.Logger)
+	ruleSet := &config.RuleSet{
+		MetaData: config.MetaData{Source: "test"},
+		Version:  config.CurrentRuleSetVersion,
+		Rules:    []config.Rule{{ID: "foo"}},
+	}
+
+	require.NoError(t, processor.OnCreated(ruleSet))
+
+	// WHEN
+	err := processor.OnDeleted(ruleSet)
+
+	// THEN
+	require.NoError(t, err)
+
+	impl := processor.(*ruleSetProcessor) // nolint: forcetypeassert
+	assert.Empty(t, impl.rules)
+}
+
+func TestRuleSetProcessorAppliesRuleSetModeToRules(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	queue := make(event.RuleSetChangedEventQueue, 10)
+	factory := mocks.NewFactoryMock(t)
+	factory.EXPECT().CreateRule(config.CurrentRuleSetVersion, "test",
+		mock.MatchedBy(func(rc config.Rule) bool { return rc.ID == "foo" && rc.Mode == config.RuleModeShadow })).
+		Return(&mocks.RuleMock{}, nil)
+	factory.EXPECT().CreateRule(config.CurrentRuleSetVersion, "test",
+		mock.MatchedBy(func(rc config.Rule) bool { return rc.ID == "bar" && rc.Mode == config.RuleModeEnforce })).
+		Return(&mocks.RuleMock{}, nil)
+
+	processor := NewRuleSetProcessor(queue, factory, &config2.Configuration{}, log.Logger)
+
+	// WHEN
+	err := processor.OnCreated(&config.RuleSet{
+		MetaData: config.MetaData{Source: "test"},
+		Version:  config.CurrentRuleSetVersion,
+		Mode:     config.RuleModeShadow,
+		Rules:    []config.Rule{{ID: "foo"}, {ID: "bar", Mode: config.RuleModeEnforce}},
+	})
+
+	// THEN
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/rules/rule"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+const (
+	decisionAllow = "allow"
+	decisionDeny  = "deny"
+)
+
+// shadowedRule executes a rule operated in shadow mode together with the rule, which would have
+// been used if the shadow rule did not exist. Only the decision of the latter is enforced.
+type shadowedRule struct {
+	shadow    *ruleImpl
+	enforced  rule.Rule
+	decisions *prometheus.CounterVec
+}
+
+func (r *shadowedRule) ID() string { return r.enforced.ID() }
+
+func (r *shadowedRule) SrcID() string { return r.enforced.SrcID() }
+
+func (r *shadowedRule) Matches(req *heimdall.Request) bool { return r.shadow.Matches(req) }
+
+func (r *shadowedRule) MatchesMethod(method string) bool { return r.enforced.MatchesMethod(method) }
+
+func (r *shadowedRule) Execute(ctx heimdall.Context) (rule.Backend, error) {
+	var shadowDecision string
+
+	// the shadow rule is not applicable to requests with methods it does not match. There is
+	// no decision to compare with the enforced one in that case.
+	applicable := r.shadow.MatchesMethod(ctx.Request().Method)
+
+	if applicable {
+		shadowCtx := &decisionContext{Context: ctx, dryRun: true}
+		_, shadowErr := r.shadow.Execute(shadowCtx)
+		shadowDecision = shadowCtx.decision(shadowErr)
+	}
+
+	enforcedCtx := &decisionContext{Context: ctx}
+	upstream, err := r.enforced.Execute(enforcedCtx)
+
+	if applicable {
+		r.record(ctx, shadowDecision, enforcedCtx.decision(err))
+	}
+
+	return upstream, err
+}
+
+func (r *shadowedRule) record(ctx heimdall.Context, shadowDecision, enforcedDecision string) {
+	enforcedID := r.enforced.ID()
+	differs := shadowDecision != enforcedDecision
+
+	trace.SpanFromContext(ctx.AppContext()).SetAttributes(
+		attribute.String("heimdall.shadow_rule.id", r.shadow.id),
+		attribute.String("heimdall.shadow_rule.src", r.shadow.srcID),
+		attribute.String("heimdall.shadow_rule.decision", shadowDecision),
+		attribute.String("heimdall.enforced_rule.id", enforcedID),
+		attribute.String("heimdall.enforced_rule.decision", enforcedDecision),
+		attribute.Bool("heimdall.shadow_rule.decision_differs", differs),
+	)
+
+	if r.decisions != nil {
+		r.decisions.WithLabelValues(r.shadow.srcID, r.shadow.id, shadowDecision, enforcedDecision).Inc()
+	}
+
+	zerolog.Ctx(ctx.AppContext()).
+		WithLevel(x.IfThenElse(differs, zerolog.WarnLevel, zerolog.DebugLevel)).
+		Str("_src", r.shadow.srcID).
+		Str("_id", r.shadow.id).
+		Str("_shadow_decision", shadowDecision).
+		Str("_enforced_rule_id", enforcedID).
+		Str("_enforced_decision", enforcedDecision).
+		Msg(x.IfThenElse(differs,
+			"Decision of shadow rule differs from the enforced one",
+			"Decision of shadow rule matches the enforced one"))
+}
+
+// decisionContext records the error set by the error handlers of a pipeline, as this, and not
+// the error returned by the pipeline, is the actual decision if the error has been handled. In dry
+// run mode it additionally prevents the pipeline from affecting the request.
+type decisionContext struct {
+	heimdall.Context
+
+	dryRun bool
+	err    error
+}
+
+func (c *decisionContext) AddHeaderForUpstream(name, value string) {
+	if !c.dryRun {
+		c.Context.AddHeaderForUpstream(name, value)
+	}
+}
+
+func (c *decisionContext) AddCookieForUpstream(name, value string) {
+	if !c.dryRun {
+		c.Context.AddCookieForUpstream(name, value)
+	}
+}
+
+func (c *decisionContext) SetPipelineError(err error) {
+	c.err = err
+
+	if !c.dryRun {
+		c.Context.SetPipelineError(err)
+	}
+}
+
+func (c *decisionContext) decision(err error) string {
+	return x.IfThenElse(err == nil && c.err == nil, decisionAllow, decisionDeny)
+}
+
+func newShadowDecisionsCounter(reg prometheus.Registerer) *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shadow_rule_decisions_total",
+			Help: "Number of decisions made by rules in shadow mode, partitioned by the enforced decision",
+		},
+		[]string{"src", "rule_id", "shadow_decision", "enforced_decision"},
+	)
+
+	reg.MustRegister(counter)
+
+	return counter
+}
@@ -0,0 +1,272 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/rules/mechanisms/subject"
+	"github.com/dadrus/heimdall/internal/rules/mocks"
+	"github.com/dadrus/heimdall/internal/rules/patternmatcher"
+	"github.com/dadrus/heimdall/internal/rules/rule"
+	rulemocks "github.com/dadrus/heimdall/internal/rules/rule/mocks"
+	"github.com/dadrus/heimdall/internal/x/testsupport"
+)
+
+func TestShadowedRuleExecute(t *testing.T) {
+	t.Parallel()
+
+	shadowUpstream := &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "shadow.local"}}}}
+	enforcedUpstream := &backend{targets: []*upstreamTarget{{url: &url.URL{Scheme: "http", Host: "enforced.local"}}}}
+
+	for _, tc := range []struct {
+		uc             string
+		shadowMethods  []string
+		configureMocks func(
+			t *testing.T,
+			ctx *heimdallmocks.ContextMock,
+			authenticator *mocks.SubjectCreatorMock,
+			unifier *mocks.SubjectHandlerMock,
+			errHandler *mocks.ErrorHandlerMock,
+			enforced *rulemocks.RuleMock,
+		)
+		assert func(t *testing.T, err error, upstream rule.Backend, decisions *prometheus.CounterVec)
+	}{
+		{
+			uc:            "shadow and enforced rule allow the request",
+			shadowMethods: []string{http.MethodGet},
+			configureMocks: func(t *testing.T, _ *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
+				unifier *mocks.SubjectHandlerMock, _ *mocks.ErrorHandlerMock, enforced *rulemocks.RuleMock,
+			) {
+				t.Helper()
+
+				sub := &subject.Subject{ID: "foo"}
+
+				authenticator.EXPECT().Execute(mock.Anything).Return(sub, nil)
+				unifier.EXPECT().Execute(mock.Anything, sub).
+					Run(func(ctx heimdall.Context, _ *subject.Subject) {
+						// must not reach the actual context
+						ctx.AddHeaderForUpstream("X-Foo", "bar")
+						ctx.AddCookieForUpstream("foo", "bar")
+					}).
+					Return(nil)
+				enforced.EXPECT().Execute(mock.Anything).Return(enforcedUpstream, nil)
+			},
+			assert: func(t *testing.T, err error, upstream rule.Backend, decisions *prometheus.CounterVec) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, enforcedUpstream, upstream)
+				assert.Equal(t, 1.0,
+					testutil.ToFloat64(decisions.WithLabelValues("test", "shadow", decisionAllow, decisionAllow)))
+			},
+		},
+		{
+			uc:            "shadow rule denies the request, enforced one allows it",
+			shadowMethods: []string{http.MethodGet},
+			configureMocks: func(t *testing.T, _ *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
+				_ *mocks.SubjectHandlerMock, errHandler *mocks.ErrorHandlerMock, enforced *rulemocks.RuleMock,
+			) {
+				t.Helper()
+
+				authenticator.EXPECT().Execute(mock.Anything).Return(nil, testsupport.ErrTestPurpose)
+				authenticator.EXPECT().IsFallbackOnErrorAllowed().Return(false)
+				errHandler.EXPECT().Execute(mock.Anything, testsupport.ErrTestPurpose).
+					Run(func(ctx heimdall.Context, err error) {
+						// must not reach the actual context
+						ctx.SetPipelineError(err)
+					}).
+					Return(true, nil)
+				enforced.EXPECT().Execute(mock.Anything).Return(enforcedUpstream, nil)
+			},
+			assert: func(t *testing.T, err error, upstream rule.Backend, decisions *prometheus.CounterVec) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, enforcedUpstream, upstream)
+				assert.Equal(t, 1.0,
+					testutil.ToFloat64(decisions.WithLabelValues("test", "shadow", decisionDeny, decisionAllow)))
+			},
+		},
+		{
+			uc:            "shadow rule allows the request, enforced one denies it",
+			shadowMethods: []string{http.MethodGet},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.ContextMock, authenticator *mocks.SubjectCreatorMock,
+				unifier *mocks.SubjectHandlerMock, _ *mocks.ErrorHandlerMock, enforced *rulemocks.RuleMock,
+			) {
+				t.Helper()
+
+				sub := &subject.Subject{ID: "foo"}
+
+				authenticator.EXPECT().Execute(mock.Anything).Return(sub, nil)
+				unifier.EXPECT().Execute(mock.Anything, sub).Return(nil)
+				enforced.EXPECT().Execute(mock.Anything).
+					Run(func(ctx heimdall.Context) { ctx.SetPipelineError(testsupport.ErrTestPurpose) }).
+					Return(nil, nil)
+				ctx.EXPECT().SetPipelineError(testsupport.ErrTestPurpose)
+			},
+			assert: func(t *testing.T, err error, upstream rule.Backend, decisions *prometheus.CounterVec) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, upstream)
+				assert.Equal(t, 1.0,
+					testutil.ToFloat64(decisions.WithLabelValues("test", "shadow", decisionAllow, decisionDeny)))
+			},
+		},
+		{
+			uc:            "shadow rule does not match the method",
+			shadowMethods: []string{http.MethodPost},
+			configureMocks: func(t *testing.T, _ *heimdallmocks.ContextMock, _ *mocks.SubjectCreatorMock,
+				_ *mocks.SubjectHandlerMock, _ *mocks.ErrorHandlerMock, enforced *rulemocks.RuleMock,
+			) {
+				t.Helper()
+
+				enforced.EXPECT().Execute(mock.Anything).Return(nil, testsupport.ErrTestPurpose)
+			},
+			assert: func(t *testing.T, err error, upstream rule.Backend, decisions *prometheus.CounterVec) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, testsupport.ErrTestPurpose)
+				assert.Nil(t, upstream)
+
+				// the shadow rule is not applicable, so there is no decision to record
+				assert.Equal(t, 0, testutil.CollectAndCount(decisions))
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := heimdallmocks.NewContextMock(t)
+			ctx.EXPECT().AppContext().Return(context.Background()).Maybe()
+			ctx.EXPECT().Request().Return(&heimdall.Request{
+				Method: http.MethodGet,
+				URL:    &heimdall.URL{URL: url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz"}},
+			})
+
+			authenticator := mocks.NewSubjectCreatorMock(t)
+			unifier := mocks.NewSubjectHandlerMock(t)
+			errHandler := mocks.NewErrorHandlerMock(t)
+			enforced := rulemocks.NewRuleMock(t)
+
+			tc.configureMocks(t, ctx, authenticator, unifier, errHandler, enforced)
+
+			rul := &shadowedRule{
+				shadow: &ruleImpl{
+					id:      "shadow",
+					srcID:   "test",
+					shadow:  true,
+					methods: tc.shadowMethods,
+					backend: shadowUpstream,
+					sc:      compositeSubjectCreator{authenticator},
+					un:      compositeSubjectHandler{unifier},
+					eh:      compositeErrorHandler{errHandler},
+				},
+				enforced:  enforced,
+				decisions: newShadowDecisionsCounter(prometheus.NewRegistry()),
+			}
+
+			enforced.EXPECT().ID().Return("enforced").Maybe()
+
+			// WHEN
+			upstream, err := rul.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, upstream, rul.decisions)
+		})
+	}
+}
+
+func TestShadowedRuleDoesNotLeakCapturesToEnforcedRule(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	newRule := func(t *testing.T, id, pattern string, unifier *mocks.SubjectHandlerMock) *ruleImpl {
+		t.Helper()
+
+		var matcher patternmatcher.PatternMatcher
+
+		if len(pattern) != 0 {
+			var err error
+
+			matcher, err = patternmatcher.NewPatternMatcher("glob", pattern)
+			require.NoError(t, err)
+		}
+
+		sub := &subject.Subject{ID: "foo"}
+
+		authenticator := mocks.NewSubjectCreatorMock(t)
+		authenticator.EXPECT().Execute(mock.Anything).Return(sub, nil)
+
+		return &ruleImpl{
+			id:         id,
+			srcID:      "test",
+			urlMatcher: matcher,
+			methods:    []string{http.MethodGet},
+			sc:         compositeSubjectCreator{authenticator},
+			un:         compositeSubjectHandler{unifier},
+		}
+	}
+
+	req := &heimdall.Request{
+		Method: http.MethodGet,
+		URL:    &heimdall.URL{URL: url.URL{Scheme: "http", Host: "foo.bar", Path: "/api/acme/users/42"}},
+	}
+
+	ctx := heimdallmocks.NewContextMock(t)
+	ctx.EXPECT().AppContext().Return(context.Background())
+	ctx.EXPECT().Request().Return(req)
+
+	shadowUnifier := mocks.NewSubjectHandlerMock(t)
+	shadowUnifier.EXPECT().Execute(mock.Anything, mock.Anything).
+		Run(func(ctx heimdall.Context, _ *subject.Subject) {
+			assert.Equal(t, map[string]string{"tenant": "acme", "id": "42"}, ctx.Request().URL.Captures)
+		}).
+		Return(nil)
+
+	enforcedUnifier := mocks.NewSubjectHandlerMock(t)
+	enforcedUnifier.EXPECT().Execute(mock.Anything, mock.Anything).
+		Run(func(ctx heimdall.Context, _ *subject.Subject) {
+			assert.Empty(t, ctx.Request().URL.Captures)
+		}).
+		Return(nil)
+
+	rul := &shadowedRule{
+		shadow: newRule(t, "shadow", "http://foo.bar/api/:tenant/users/:id", shadowUnifier),
+		// like the default rule, which does not have a url matcher
+		enforced: newRule(t, "enforced", "", enforcedUnifier),
+	}
+
+	// WHEN
+	_, err := rul.Execute(ctx)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Nil(t, req.URL.Captures)
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rules
+
+import (
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+type upstreamTarget struct {
+	url    *url.URL
+	weight int
+
+	// inFlight is the number of requests currently forwarded to the target
+	inFlight atomic.Int64
+	// failures is the number of consecutive failed requests, recorded by the passive health check
+	failures atomic.Int64
+	// ejectedUntil is the time in unix nanos, the target is ejected by the passive health check until
+	ejectedUntil atomic.Int64
+	// unhealthy is set by the active health check
+	unhealthy atomic.Bool
+	// currentWeight is used by the weighted load balancer and guarded by it
+	currentWeight int
+}
+
+func (t *upstreamTarget) isAvailable(now time.Time) bool {
+	return !t.unhealthy.Load() && now.UnixNano() >= t.ejectedUntil.Load()
+}
+
+func (t *upstreamTarget) is(value *url.URL) bool {
+	return t.url.Scheme == value.Scheme && t.url.Host == value.Host
+}
+
+// recordOutcome updates the passive health state of the target and returns true if the target
+// has been ejected due to the given failure.
+func (t *upstreamTarget) recordOutcome(success bool, maxFailures int64, ejectionTime time.Duration) bool {
+	if success {
+		t.failures.Store(0)
+
+		return false
+	}
+
+	if t.failures.Add(1) < maxFailures {
+		return false
+	}
+
+	t.failures.Store(0)
+	t.ejectedUntil.Store(time.Now().Add(ejectionTime).UnixNano())
+
+	return true
+}
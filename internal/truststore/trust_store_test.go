@@ -69,3 +69,13 @@ func TestNewKeyStoreFromPEMBytes(t *testing.T) {
 	assert.Equal(t, intCA1Cert, ts[0])
 	assert.Equal(t, rootCA1.Certificate, ts[1])
 }
+
+func TestNewTrustStoreFromPEMBytesWithoutPEMData(t *testing.T) {
+	// WHEN
+	ts, err := NewTrustStoreFromPEMBytes([]byte("foobar"), true)
+
+	// THEN
+	require.Error(t, err)
+	require.ErrorIs(t, err, pemx.ErrNoPEMData)
+	assert.Empty(t, ts)
+}
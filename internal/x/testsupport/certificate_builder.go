@@ -24,6 +24,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"math/big"
+	"net/url"
 	"time"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
@@ -98,6 +99,24 @@ func WithExtendedKeyUsage(usage x509.ExtKeyUsage) CertificateBuilderOption {
 	}
 }
 
+func WithDNSNames(names ...string) CertificateBuilderOption {
+	return func(builder *CertificateBuilder) {
+		builder.tmpl.DNSNames = append(builder.tmpl.DNSNames, names...)
+	}
+}
+
+func WithEmailAddresses(addresses ...string) CertificateBuilderOption {
+	return func(builder *CertificateBuilder) {
+		builder.tmpl.EmailAddresses = append(builder.tmpl.EmailAddresses, addresses...)
+	}
+}
+
+func WithURIs(uris ...*url.URL) CertificateBuilderOption {
+	return func(builder *CertificateBuilder) {
+		builder.tmpl.URIs = append(builder.tmpl.URIs, uris...)
+	}
+}
+
 func WithIsCA() CertificateBuilderOption {
 	return func(builder *CertificateBuilder) {
 		builder.tmpl.IsCA = true
@@ -0,0 +1,51 @@
+// Copyright 2022 Dimitrij Drus <dadrus@gmx.de>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package testsupport
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+
+	"gopkg.in/square/go-jose.v2"
+)
+
+// SignDetached creates a JWS in compact serialization with detached payload over the given contents.
+// The given certificate chain is included in the x5c header.
+func SignDetached(contents []byte, alg jose.SignatureAlgorithm, key any, chain ...*x509.Certificate) (string, error) {
+	opts := &jose.SignerOptions{}
+
+	if len(chain) != 0 {
+		x5c := make([]string, len(chain))
+		for idx, cert := range chain {
+			x5c[idx] = base64.StdEncoding.EncodeToString(cert.Raw)
+		}
+
+		opts.WithHeader("x5c", x5c)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+	if err != nil {
+		return "", err
+	}
+
+	jws, err := signer.Sign(contents)
+	if err != nil {
+		return "", err
+	}
+
+	return jws.DetachedCompactSerialize()
+}